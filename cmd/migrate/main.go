@@ -5,8 +5,11 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"gorectus/internal/schema"
+
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
@@ -36,6 +39,9 @@ func main() {
 		drop  = flag.Bool("drop", false, "Drop everything in database")
 		reset = flag.Bool("reset", false, "Drop and recreate database")
 		hash  = flag.String("hash", "", "Generate bcrypt hash for password")
+
+		snapshot = flag.String("snapshot", "", "Export a schema snapshot to FILE (.json, .yaml or - for stdout)")
+		format   = flag.String("format", "", "Snapshot format: json or yaml (default: from file extension)")
 	)
 	flag.Parse()
 
@@ -57,6 +63,12 @@ func main() {
 	}
 	defer db.Close()
 
+	// Export the schema snapshot without touching migrations
+	if *snapshot != "" {
+		exportSnapshot(db, *snapshot, *format)
+		return
+	}
+
 	// Create migration instance
 	m, err := createMigrator(db)
 	if err != nil {
@@ -269,6 +281,48 @@ func generatePasswordHash(password string) {
 	logrus.Info("Hash generated and verified successfully")
 }
 
+func exportSnapshot(db *sql.DB, path, format string) {
+	if format == "" {
+		format = snapshotFormatForPath(path)
+	}
+
+	snapshot, err := schema.Load(db)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load schema snapshot")
+	}
+
+	document, err := schema.Marshal(snapshot, format)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to encode schema snapshot")
+	}
+
+	if path == "-" {
+		os.Stdout.Write(document)
+		return
+	}
+
+	if err := os.WriteFile(path, document, 0644); err != nil {
+		logrus.WithError(err).Fatal("Failed to write schema snapshot")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"file":        path,
+		"collections": len(snapshot.Collections),
+		"fields":      len(snapshot.Fields),
+		"permissions": len(snapshot.Permissions),
+	}).Info("Schema snapshot exported successfully")
+}
+
+// snapshotFormatForPath picks the snapshot encoding from the file extension
+func snapshotFormatForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return schema.FormatYAML
+	default:
+		return schema.FormatJSON
+	}
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -290,12 +344,15 @@ func printUsage() {
 	fmt.Println("  -drop            Drop all tables")
 	fmt.Println("  -reset           Drop and recreate database")
 	fmt.Println("  -hash PASSWORD   Generate bcrypt hash for password")
+	fmt.Println("  -snapshot FILE   Export collections, fields and permissions to FILE")
+	fmt.Println("  -format F        Snapshot format (json or yaml, used with -snapshot)")
 	fmt.Println("")
 	fmt.Println("Examples:")
 	fmt.Println("  go run cmd/migrate/main.go -up")
 	fmt.Println("  go run cmd/migrate/main.go -down -steps 1")
 	fmt.Println("  go run cmd/migrate/main.go -hash 'mypassword'")
 	fmt.Println("  go run cmd/migrate/main.go -reset")
+	fmt.Println("  go run cmd/migrate/main.go -snapshot schema.yaml")
 	fmt.Println("")
 	fmt.Println("Without flags, shows current migration status.")
 }
@@ -56,3 +56,10 @@ func TestGetEnvOrDefault(t *testing.T) {
 func TestMigrationPathConstants(t *testing.T) {
 	assert.Equal(t, "file://migrations", migrationsPath)
 }
+
+func TestSnapshotFormatForPath(t *testing.T) {
+	assert.Equal(t, "yaml", snapshotFormatForPath("schema.yaml"))
+	assert.Equal(t, "yaml", snapshotFormatForPath("schema.YML"))
+	assert.Equal(t, "json", snapshotFormatForPath("schema.json"))
+	assert.Equal(t, "json", snapshotFormatForPath("-"))
+}
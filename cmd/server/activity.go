@@ -0,0 +1,45 @@
+package main
+
+import (
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Activity actions recorded by the server itself
+const (
+	activityActionIPDenied = "ip_denied"
+)
+
+// ActivityEntry represents a row to be written to the activity log
+type ActivityEntry struct {
+	Action     string
+	UserID     string
+	Collection string
+	Item       string
+	Comment    string
+}
+
+// recordActivity writes an entry to the activity log, taking the client IP,
+// user agent and origin from the request. Failures are logged but never
+// interrupt the request.
+func recordActivity(db *sql.DB, c *gin.Context, entry ActivityEntry) {
+	_, err := db.Exec(`
+		INSERT INTO activity (action, user_id, ip, user_agent, collection, item, comment, origin)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, entry.Action, nullableString(entry.UserID), c.ClientIP(), c.Request.UserAgent(),
+		nullableString(entry.Collection), nullableString(entry.Item),
+		nullableString(entry.Comment), nullableString(c.GetHeader("Origin")))
+	if err != nil {
+		logrus.WithError(err).WithField("action", entry.Action).Warn("Failed to record activity")
+	}
+}
+
+// nullableString maps empty strings to NULL
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/hooks"
+)
+
+// Values of the archived query parameter
+const (
+	archivedExclude = "false"
+	archivedOnly    = "true"
+	archivedAll     = "all"
+)
+
+// archives reports whether deleting an item of the collection archives it
+func (col *ItemCollection) archives() bool {
+	return col != nil && col.ArchiveField != nil && *col.ArchiveField != "" && col.ArchiveValue != nil
+}
+
+// archiveFilter returns the filter selecting items for an archived mode, or
+// nil when every item matches. Items with no value in the archive field are
+// not archived.
+func (col *ItemCollection) archiveFilter(mode string) map[string]interface{} {
+	if !col.archives() || mode == archivedAll {
+		return nil
+	}
+
+	field := *col.ArchiveField
+	if mode == archivedOnly {
+		return map[string]interface{}{field: map[string]interface{}{"_eq": *col.ArchiveValue}}
+	}
+	return map[string]interface{}{"_or": []interface{}{
+		map[string]interface{}{field: map[string]interface{}{"_null": true}},
+		map[string]interface{}{field: map[string]interface{}{"_neq": *col.ArchiveValue}},
+	}}
+}
+
+// archivedMode reads the archived query parameter. Without it archived items
+// are left out, unless the collection's archive_app_filter is turned off.
+func archivedMode(c *gin.Context, collection *ItemCollection) (string, bool) {
+	mode := c.Query("archived")
+	switch mode {
+	case "":
+		if collection.ArchiveAppFilter {
+			return archivedExclude, true
+		}
+		return archivedAll, true
+	case archivedExclude, archivedOnly, archivedAll:
+		return mode, true
+	default:
+		return "", false
+	}
+}
+
+// combineFilters requires both filters to match
+func combineFilters(a, b map[string]interface{}) map[string]interface{} {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	return map[string]interface{}{"_and": []interface{}{a, b}}
+}
+
+// setArchiveValue stores value in the archive field of an item
+func (h *ItemsHandler) setArchiveValue(collection *ItemCollection, itemID string, value *string) error {
+	touch := ""
+	if collection.HasUpdatedAt {
+		touch = ", updated_at = CURRENT_TIMESTAMP"
+	}
+	query := fmt.Sprintf(`UPDATE "%s" SET "%s" = $1%s WHERE id = $2`,
+		collection.Collection, *collection.ArchiveField, touch)
+
+	var arg interface{}
+	if value != nil {
+		arg = *value
+	}
+
+	_, err := h.db.Exec(query, arg, itemID)
+	return err
+}
+
+// UnarchiveItem restores an archived item
+//
+//	@Summary		Unarchive an item
+//	@Description	Set the archive field of an item back to the collection's unarchive value
+//	@Tags			items
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			collection	path		string		true	"Collection name"
+//	@Param			id			path		string		true	"Item ID"
+//	@Success		200			{object}	ItemModel	"Unarchived item"
+//	@Failure		400			{object}	ErrorResponse	"Collection has no archive field"
+//	@Failure		401			{object}	ErrorResponse	"Unauthorized"
+//	@Failure		404			{object}	ErrorResponse	"Item not found"
+//	@Failure		500			{object}	ErrorResponse	"Internal server error"
+//	@Router			/items/{collection}/{id}/unarchive [post]
+func (h *ItemsHandler) unarchiveItem(c *gin.Context) {
+	// Only admins can update items
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	collectionName := c.Param("collection")
+	itemID := c.Param("id")
+
+	collection, ok := h.resolveCollection(c, collectionName)
+	if !ok {
+		return
+	}
+
+	if !collection.archives() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Collection has no archive field"})
+		return
+	}
+
+	if _, err := h.getItemByID(collectionName, itemID); err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while checking item")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	event := requestEvent(c, scopeItems, collectionName, hooks.ActionUpdate, nil, itemID)
+	if !runFilterHooks(c, h.events, &event) {
+		return
+	}
+
+	if err := h.setArchiveValue(collection, itemID, collection.UnarchiveValue); err != nil {
+		logrus.WithError(err).Error("Database error while unarchiving item")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	item, err := h.getItemByID(collectionName, itemID)
+	if err != nil {
+		logrus.WithError(err).Error("Error fetching unarchived item")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	fields, err := h.getFieldsByCollection(collectionName)
+	if err != nil {
+		logrus.WithError(err).Error("Error getting collection fields")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"collection": collectionName,
+		"item_id":    itemID,
+	}).Info("Item unarchived successfully")
+	emitActionHooks(c, h.events, scopeItems, collectionName, hooks.ActionUpdate, eventItem(fields, item), itemID)
+
+	c.JSON(http.StatusOK, gin.H{"data": outputItem(c, fields, item)})
+}
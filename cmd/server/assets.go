@@ -0,0 +1,466 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/imaging"
+)
+
+// Asset transformation modes of settings.storage_asset_transform
+const (
+	assetTransformAll     = "all"
+	assetTransformNone    = "none"
+	assetTransformPresets = "presets"
+)
+
+// Limits keeping transformations from exhausting memory
+const (
+	maxTransformDimension = 6000
+	maxTransformSource    = 50_000_000 // pixels
+)
+
+// assetTransformParams are the query parameters requesting a transformation
+var assetTransformParams = []string{"key", "width", "height", "fit", "format", "quality", "withoutEnlargement"}
+
+// AssetPreset is an entry of settings.storage_asset_presets
+type AssetPreset struct {
+	Key                string `json:"key"`
+	Fit                string `json:"fit,omitempty"`
+	Width              int    `json:"width,omitempty"`
+	Height             int    `json:"height,omitempty"`
+	Quality            int    `json:"quality,omitempty"`
+	Format             string `json:"format,omitempty"`
+	WithoutEnlargement bool   `json:"withoutEnlargement,omitempty"`
+}
+
+// options converts a preset into transformation options
+func (p AssetPreset) options() imaging.Options {
+	return imaging.Options{
+		Width:              p.Width,
+		Height:             p.Height,
+		Fit:                p.Fit,
+		Format:             p.Format,
+		Quality:            p.Quality,
+		WithoutEnlargement: p.WithoutEnlargement,
+	}
+}
+
+// resolveAssetTransform returns the transformation requested by the query,
+// or nil when none is. The storage_asset_transform setting decides whether
+// custom transformations, only presets or nothing is allowed.
+func (h *FilesHandler) resolveAssetTransform(c *gin.Context) (*imaging.Options, bool) {
+	requested := false
+	for _, param := range assetTransformParams {
+		if _, ok := c.GetQuery(param); ok {
+			requested = true
+			break
+		}
+	}
+	if !requested {
+		return nil, true
+	}
+
+	var mode sql.NullString
+	var presetsJSON []byte
+	err := h.db.QueryRow(`SELECT storage_asset_transform, storage_asset_presets FROM settings LIMIT 1`).Scan(&mode, &presetsJSON)
+	if err != nil && err != sql.ErrNoRows {
+		logrus.WithError(err).Error("Database error while loading asset settings")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	if !mode.Valid || mode.String == "" {
+		mode.String = assetTransformAll
+	}
+
+	if mode.String == assetTransformNone {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Asset transformations are disabled"})
+		return nil, false
+	}
+
+	if key := c.Query("key"); key != "" {
+		if hasCustomTransform(c) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Asset presets can't be combined with other transformations"})
+			return nil, false
+		}
+
+		var presets []AssetPreset
+		if len(presetsJSON) > 0 {
+			if err := json.Unmarshal(presetsJSON, &presets); err != nil {
+				logrus.WithError(err).Error("Invalid storage_asset_presets setting")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid asset presets"})
+				return nil, false
+			}
+		}
+		for _, preset := range presets {
+			if preset.Key == key {
+				opts := preset.options()
+				if err := validateAssetTransform(&opts); err != nil {
+					logrus.WithError(err).WithField("key", key).Error("Invalid asset preset")
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid asset preset"})
+					return nil, false
+				}
+				return &opts, true
+			}
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown asset preset '" + key + "'"})
+		return nil, false
+	}
+
+	if mode.String == assetTransformPresets {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only asset presets are allowed"})
+		return nil, false
+	}
+
+	opts, err := parseAssetTransform(c)
+	if err == nil {
+		err = validateAssetTransform(&opts)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transformation: " + err.Error()})
+		return nil, false
+	}
+	return &opts, true
+}
+
+// hasCustomTransform reports whether the query has transformation parameters
+// other than a preset key
+func hasCustomTransform(c *gin.Context) bool {
+	for _, param := range assetTransformParams[1:] {
+		if _, ok := c.GetQuery(param); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAssetTransform reads a custom transformation from the query
+func parseAssetTransform(c *gin.Context) (imaging.Options, error) {
+	opts := imaging.Options{
+		Fit:                c.Query("fit"),
+		Format:             c.Query("format"),
+		WithoutEnlargement: c.Query("withoutEnlargement") == "true",
+	}
+
+	for param, target := range map[string]*int{"width": &opts.Width, "height": &opts.Height, "quality": &opts.Quality} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return opts, fmt.Errorf("%s must be a positive integer", param)
+		}
+		*target = n
+	}
+	return opts, nil
+}
+
+// validateAssetTransform checks a transformation and normalizes its format
+func validateAssetTransform(opts *imaging.Options) error {
+	if opts.Width < 0 || opts.Width > maxTransformDimension {
+		return fmt.Errorf("width must be between 1 and %d", maxTransformDimension)
+	}
+	if opts.Height < 0 || opts.Height > maxTransformDimension {
+		return fmt.Errorf("height must be between 1 and %d", maxTransformDimension)
+	}
+	if opts.Fit != "" && !imaging.IsFit(opts.Fit) {
+		return errors.New("fit must be one of cover, contain, inside or outside")
+	}
+	if opts.Quality < 0 || opts.Quality > 100 {
+		return errors.New("quality must be between 1 and 100")
+	}
+	if opts.Format != "" {
+		format, ok := imaging.FormatOf(opts.Format)
+		if !ok || strings.Contains(opts.Format, "/") {
+			return errors.New("format must be one of jpg or png")
+		}
+		opts.Format = format
+	}
+	return nil
+}
+
+// serveTransformedAsset sends a transformed variant of an image, generating
+// and caching it on the first request
+func (h *FilesHandler) serveTransformedAsset(c *gin.Context, file Item, opts imaging.Options) {
+	contentType, _ := file["type"].(string)
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	sourceFormat, ok := imaging.FormatOf(mediaType)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File can't be transformed"})
+		return
+	}
+	if opts.Format == "" {
+		opts.Format = sourceFormat
+	}
+
+	// Variants are keyed by the file contents, so replaced contents never
+	// serve a stale variant
+	fileID := fmt.Sprint(file["id"])
+	checksum := fmt.Sprint(file["checksum"])
+	variant := assetVariantKey(checksum, opts)
+	etag := `"` + checksum + "-" + variant[:16] + `"`
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	data, err := h.assets.get(fileID, variant, opts.Format)
+	if err != nil {
+		data, err = h.transformAsset(c, file, opts)
+		if err != nil {
+			return
+		}
+		if err := h.assets.put(fileID, variant, opts.Format, data); err != nil {
+			logrus.WithError(err).WithField("file_id", fileID).Warn("Failed to cache transformed asset")
+		}
+	}
+
+	filename := fmt.Sprint(file["filename_download"])
+	filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + "." + opts.Format
+	outputType := imaging.ContentType(opts.Format)
+	c.Header("Content-Disposition", mime.FormatMediaType(assetDisposition(c, outputType),
+		map[string]string{"filename": filename}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("ETag", etag)
+	c.Data(http.StatusOK, outputType, data)
+}
+
+// transformAsset reads an image from storage and transforms it, responding
+// itself when it fails.
+func (h *FilesHandler) transformAsset(c *gin.Context, file Item, opts imaging.Options) ([]byte, error) {
+	driver, ok := h.fileDriver(c, file)
+	if !ok {
+		return nil, errors.New("unknown storage location")
+	}
+
+	filenameDisk := fmt.Sprint(file["filename_disk"])
+	reader, err := driver.Open(c.Request.Context(), filenameDisk, 0, -1)
+	if err != nil {
+		logrus.WithError(err).WithField("filename_disk", filenameDisk).Error("Failed to open stored file")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return nil, err
+	}
+	source, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to read stored file")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return nil, err
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(source), maxTransformSource)
+	if errors.Is(err, imaging.ErrTooLarge) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Image too large to transform"})
+		return nil, err
+	} else if err != nil {
+		logrus.WithError(err).WithField("file_id", file["id"]).Warn("Failed to decode image")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File can't be transformed"})
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := imaging.Encode(&out, imaging.Transform(img, opts), opts.Format, opts.Quality); err != nil {
+		logrus.WithError(err).Error("Failed to encode transformed image")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transform file"})
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// assetVariantKey identifies a transformation of a file's contents
+func assetVariantKey(checksum string, opts imaging.Options) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d|%s|%s|%d|%t", checksum,
+		opts.Width, opts.Height, opts.Fit, opts.Format, opts.Quality, opts.WithoutEnlargement)))
+	return hex.EncodeToString(sum[:])
+}
+
+// defaultAssetCacheMaxSize bounds the disk space used by transformed variants
+// when ASSETS_CACHE_MAX_SIZE isn't set
+const defaultAssetCacheMaxSize = 512 << 20
+
+// assetCache keeps transformed variants on disk, in a directory per file.
+// Once the variants take more than maxSize bytes, the least recently used
+// ones are evicted.
+type assetCache struct {
+	root    string
+	maxSize int64
+
+	mu   sync.Mutex
+	size int64
+}
+
+// assetCacheEntry is a cached variant on disk
+type assetCacheEntry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// newAssetCache creates a cache under root, creating the directory if needed
+// and accounting for the variants already there
+func newAssetCache(root string, maxSize int64) (*assetCache, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("creating asset cache: %w", err)
+	}
+
+	a := &assetCache{root: root, maxSize: maxSize}
+	entries, err := a.entries(root)
+	if err != nil {
+		return nil, fmt.Errorf("reading asset cache: %w", err)
+	}
+	for _, entry := range entries {
+		a.size += entry.size
+	}
+	return a, nil
+}
+
+func (a *assetCache) path(fileID, variant, format string) string {
+	return filepath.Join(a.root, filepath.Base(fileID), variant+"."+format)
+}
+
+// get returns a cached variant, marking it as recently used
+func (a *assetCache) get(fileID, variant, format string) ([]byte, error) {
+	path := a.path(fileID, variant, format)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return data, nil
+}
+
+// put stores a variant, writing a temporary file first so concurrent
+// requests never read a partial variant
+func (a *assetCache) put(fileID, variant, format string, data []byte) error {
+	path := a.path(fileID, variant, format)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".variant-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var replaced int64
+	if info, err := os.Stat(path); err == nil {
+		replaced = info.Size()
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	a.size += int64(len(data)) - replaced
+	if a.size > a.maxSize {
+		return a.evict()
+	}
+	return nil
+}
+
+// evict removes the least recently used variants until the cache is down to
+// three quarters of its maximum size, so a full cache doesn't evict on every
+// put. The caller holds a.mu.
+func (a *assetCache) evict() error {
+	entries, err := a.entries(a.root)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+
+	a.size = 0
+	for _, entry := range entries {
+		a.size += entry.size
+	}
+	for _, entry := range entries {
+		if a.size <= a.maxSize/4*3 {
+			break
+		}
+		if err := os.Remove(entry.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		a.size -= entry.size
+	}
+	return nil
+}
+
+// purge removes the cached variants of a file
+func (a *assetCache) purge(fileID string) error {
+	dir := filepath.Join(a.root, filepath.Base(fileID))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries, err := a.entries(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		a.size -= entry.size
+	}
+	return nil
+}
+
+// entries lists the variants cached under dir, skipping temporary files
+func (a *assetCache) entries(dir string) ([]assetCacheEntry, error) {
+	var entries []assetCacheEntry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		entries = append(entries, assetCacheEntry{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	return entries, err
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gorectus/internal/imaging"
+)
+
+// putTestImage stores a 40x20 PNG as f1.png
+func (suite *FileHandlersTestSuite) putTestImage() {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			img.Set(x, y, color.RGBA{0, 128, 255, 255})
+		}
+	}
+	var data bytes.Buffer
+	require.NoError(suite.T(), png.Encode(&data, img))
+	require.NoError(suite.T(), suite.storage.Put(context.Background(), "f1.png", &data))
+}
+
+func (suite *FileHandlersTestSuite) expectImageFile() {
+	suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WithArgs("f1").WillReturnRows(
+		fileRows().AddRow("f1", "local", "f1.png", "photo.png", "Photo", nil, "image/png", int64(100), "abc", nil, nil, nil, nil))
+}
+
+func (suite *FileHandlersTestSuite) expectAssetSettings(mode string, presets interface{}) {
+	suite.mock.ExpectQuery(`SELECT storage_asset_transform, storage_asset_presets FROM settings`).
+		WillReturnRows(sqlmock.NewRows([]string{"storage_asset_transform", "storage_asset_presets"}).AddRow(mode, presets))
+}
+
+func (suite *FileHandlersTestSuite) getAsset(url string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", url, nil)
+	w := httptest.NewRecorder()
+	suite.router("user-1", false).ServeHTTP(w, req)
+	return w
+}
+
+func (suite *FileHandlersTestSuite) TestGetAsset_Transform() {
+	suite.putTestImage()
+	suite.expectImageFile()
+	suite.expectAssetSettings("all", nil)
+
+	w := suite.getAsset("/api/v1/assets/f1?width=10&format=jpg&quality=70")
+
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "image/jpeg", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), "inline; filename=photo.jpg", w.Header().Get("Content-Disposition"))
+	config, format, err := image.DecodeConfig(w.Body)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "jpeg", format)
+	assert.Equal(suite.T(), 10, config.Width)
+	assert.Equal(suite.T(), 5, config.Height)
+
+	// The variant is cached, so the second request doesn't read the original
+	require.NoError(suite.T(), suite.storage.Delete(context.Background(), "f1.png"))
+	suite.expectImageFile()
+	suite.expectAssetSettings("all", nil)
+
+	cached := suite.getAsset("/api/v1/assets/f1?width=10&format=jpg&quality=70")
+
+	require.Equal(suite.T(), http.StatusOK, cached.Code)
+	assert.Equal(suite.T(), w.Header().Get("ETag"), cached.Header().Get("ETag"))
+}
+
+func (suite *FileHandlersTestSuite) TestGetAsset_Preset() {
+	suite.putTestImage()
+	suite.expectImageFile()
+	suite.expectAssetSettings("presets", []byte(`[{"key":"thumb","width":8,"height":8,"fit":"cover","format":"jpg"}]`))
+
+	w := suite.getAsset("/api/v1/assets/f1?key=thumb")
+
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "image/jpeg", w.Header().Get("Content-Type"))
+	config, err := jpeg.DecodeConfig(w.Body)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 8, config.Width)
+}
+
+// testWebP is a transparent 40x20 lossless WebP
+const testWebP = "RIFF\x1a\x00\x00\x00WEBPVP8L\r\x00\x00\x00/'\xc0\x04\x10\a\x10\x11\x11\x88\x88\xfe\a\x00"
+
+func (suite *FileHandlersTestSuite) TestGetAsset_WebPSource() {
+	require.NoError(suite.T(), suite.storage.Put(context.Background(), "f1.webp", strings.NewReader(testWebP)))
+	suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WithArgs("f1").WillReturnRows(
+		fileRows().AddRow("f1", "local", "f1.webp", "photo.webp", "Photo", nil, "image/webp", int64(100), "abc", nil, nil, nil, nil))
+	suite.expectAssetSettings("all", nil)
+
+	w := suite.getAsset("/api/v1/assets/f1?width=10")
+
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), "nosniff", w.Header().Get("X-Content-Type-Options"))
+	config, err := png.DecodeConfig(w.Body)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 10, config.Width)
+	assert.Equal(suite.T(), 5, config.Height)
+}
+
+func (suite *FileHandlersTestSuite) TestGetAsset_TransformModes() {
+	tests := []struct {
+		name    string
+		mode    string
+		presets interface{}
+		url     string
+		status  int
+	}{
+		{"none", "none", nil, "/api/v1/assets/f1?width=10", http.StatusForbidden},
+		{"presets only", "presets", nil, "/api/v1/assets/f1?width=10", http.StatusForbidden},
+		{"unknown preset", "all", []byte(`[]`), "/api/v1/assets/f1?key=thumb", http.StatusBadRequest},
+		{"preset with parameters", "all", nil, "/api/v1/assets/f1?key=thumb&width=10", http.StatusBadRequest},
+		{"invalid fit", "all", nil, "/api/v1/assets/f1?width=10&fit=stretch", http.StatusBadRequest},
+		{"too wide", "all", nil, "/api/v1/assets/f1?width=100000", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.expectImageFile()
+			suite.expectAssetSettings(tt.mode, tt.presets)
+
+			w := suite.getAsset(tt.url)
+
+			assert.Equal(suite.T(), tt.status, w.Code, w.Body.String())
+		})
+	}
+}
+
+func (suite *FileHandlersTestSuite) TestGetAsset_TransformNonImage() {
+	suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WithArgs("f1").WillReturnRows(
+		fileRows().AddRow("f1", "local", "f1.txt", "notes.txt", "Notes", nil, "text/plain", int64(12), "abc", nil, nil, nil, nil))
+	suite.expectAssetSettings("all", nil)
+
+	w := suite.getAsset("/api/v1/assets/f1?width=10")
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func TestValidateAssetTransform(t *testing.T) {
+	opts := imaging.Options{Width: 100, Format: "jpeg", Fit: imaging.FitInside}
+	require.NoError(t, validateAssetTransform(&opts))
+	assert.Equal(t, imaging.FormatJPEG, opts.Format)
+
+	for _, invalid := range []imaging.Options{
+		{Width: maxTransformDimension + 1},
+		{Height: -1},
+		{Fit: "fill"},
+		{Quality: 101},
+		{Format: "gif"},
+		{Format: "image/png"},
+	} {
+		assert.Error(t, validateAssetTransform(&invalid), "%+v", invalid)
+	}
+}
+
+func TestAssetCache(t *testing.T) {
+	cache, err := newAssetCache(t.TempDir(), defaultAssetCacheMaxSize)
+	require.NoError(t, err)
+
+	_, err = cache.get("f1", "v1", "png")
+	assert.Error(t, err)
+
+	require.NoError(t, cache.put("f1", "v1", "png", []byte("data")))
+	data, err := cache.get("f1", "v1", "png")
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+
+	require.NoError(t, cache.purge("f1"))
+	_, err = cache.get("f1", "v1", "png")
+	assert.Error(t, err)
+	assert.Zero(t, cache.size)
+}
+
+func TestAssetCache_Evicts(t *testing.T) {
+	root := t.TempDir()
+	cache, err := newAssetCache(root, 100)
+	require.NoError(t, err)
+
+	// The third 40 byte variant goes over the limit, which evicts the older
+	// ones until the cache is down to three quarters of it
+	old := time.Now().Add(-time.Hour)
+	for i, variant := range []string{"v1", "v2"} {
+		require.NoError(t, cache.put("f1", variant, "png", bytes.Repeat([]byte("x"), 40)))
+		modTime := old.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, os.Chtimes(cache.path("f1", variant, "png"), modTime, modTime))
+	}
+	require.NoError(t, cache.put("f2", "v3", "png", bytes.Repeat([]byte("x"), 40)))
+
+	_, err = cache.get("f1", "v1", "png")
+	assert.Error(t, err)
+	_, err = cache.get("f1", "v2", "png")
+	assert.Error(t, err)
+	_, err = cache.get("f2", "v3", "png")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(40), cache.size)
+
+	// A new cache accounts for the variants already on disk
+	reopened, err := newAssetCache(root, 100)
+	require.NoError(t, err)
+	assert.Equal(t, int64(40), reopened.size)
+	_, err = os.Stat(filepath.Join(root, "f2", "v3.png"))
+	assert.NoError(t, err)
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"database/sql"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,13 +12,15 @@ import (
 
 // AuthHandler handles authentication-related routes
 type AuthHandler struct {
-	db *sql.DB
+	db             *sql.DB
+	authMiddleware gin.HandlerFunc
 }
 
 // NewAuthHandler creates a new authentication handler
 func NewAuthHandler(server ServerInterface) *AuthHandler {
 	return &AuthHandler{
-		db: server.GetDB(),
+		db:             server.GetDB(),
+		authMiddleware: server.AuthMiddleware(),
 	}
 }
 
@@ -34,9 +35,9 @@ func (h *AuthHandler) SetupRoutes(v1 *gin.RouterGroup) {
 	auth := v1.Group("/auth")
 	{
 		auth.POST("/login", h.login)
-		auth.POST("/logout", h.authMiddleware(), h.logout)
-		auth.POST("/refresh", h.authMiddleware(), h.refresh)
-		auth.GET("/me", h.authMiddleware(), h.getCurrentUser)
+		auth.POST("/logout", h.authMiddleware, h.logout)
+		auth.POST("/refresh", h.authMiddleware, h.refresh)
+		auth.GET("/me", h.authMiddleware, h.getCurrentUser)
 	}
 }
 
@@ -48,39 +49,6 @@ func (h *AuthHandler) optionsHandler(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
-// JWT Middleware for protected routes
-func (h *AuthHandler) authMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
-			return
-		}
-
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Bearer token required"})
-			c.Abort()
-			return
-		}
-
-		claims, err := validateJWT(tokenString)
-		if err != nil {
-			logrus.WithError(err).Warn("Invalid JWT token")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
-
-		// Add user info to context
-		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
-		c.Set("user_role", claims.Role)
-		c.Next()
-	}
-}
-
 // Login authenticates a user and returns a JWT token
 //
 //	@Summary		User login
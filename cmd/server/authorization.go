@@ -0,0 +1,190 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// administratorRoleID is the seeded role with full admin access
+const administratorRoleID = "550e8400-e29b-41d4-a716-446655440000"
+
+// roleAccessCacheTTL bounds how long a loaded role is reused before it is read again
+const roleAccessCacheTTL = 30 * time.Second
+
+// RoleAccess holds the access rules of the role attached to a request
+type RoleAccess struct {
+	ID          string
+	Name        string
+	IPAccess    []string
+	AdminAccess bool
+	AppAccess   bool
+}
+
+// roleAccessCache keeps recently loaded roles in memory so the auth middleware
+// does not hit the database on every request. A nil cache disables caching.
+type roleAccessCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]roleAccessCacheEntry
+}
+
+type roleAccessCacheEntry struct {
+	role      RoleAccess
+	expiresAt time.Time
+}
+
+// newRoleAccessCache creates an empty cache whose entries expire after ttl
+func newRoleAccessCache(ttl time.Duration) *roleAccessCache {
+	return &roleAccessCache{
+		ttl:     ttl,
+		entries: make(map[string]roleAccessCacheEntry),
+	}
+}
+
+func (rc *roleAccessCache) get(key string) (*RoleAccess, bool) {
+	if rc == nil {
+		return nil, false
+	}
+
+	rc.mu.RLock()
+	entry, ok := rc.entries[key]
+	rc.mu.RUnlock()
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+
+	role := entry.role
+	return &role, true
+}
+
+func (rc *roleAccessCache) set(key string, role *RoleAccess) {
+	if rc == nil {
+		return
+	}
+
+	rc.mu.Lock()
+	rc.entries[key] = roleAccessCacheEntry{role: *role, expiresAt: time.Now().Add(rc.ttl)}
+	rc.mu.Unlock()
+}
+
+// clear drops every cached role, forcing the next request to reload them
+func (rc *roleAccessCache) clear() {
+	if rc == nil {
+		return
+	}
+
+	rc.mu.Lock()
+	rc.entries = make(map[string]roleAccessCacheEntry)
+	rc.mu.Unlock()
+}
+
+// loadUserRoleAccess loads the access rules of the role assigned to a user
+func (s *Server) loadUserRoleAccess(userID string) (*RoleAccess, error) {
+	cacheKey := "user:" + userID
+	if role, ok := s.roleCache.get(cacheKey); ok {
+		return role, nil
+	}
+
+	var role RoleAccess
+	var ipAccess pq.StringArray
+
+	err := s.db.QueryRow(`
+		SELECT r.id, r.name, r.ip_access, r.admin_access, r.app_access
+		FROM users u
+		JOIN roles r ON u.role_id = r.id
+		WHERE u.id = $1
+	`, userID).Scan(&role.ID, &role.Name, &ipAccess, &role.AdminAccess, &role.AppAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	role.IPAccess = []string(ipAccess)
+	s.roleCache.set(cacheKey, &role)
+	return &role, nil
+}
+
+// loadRoleAccess loads the access rules of a role by ID
+func (s *Server) loadRoleAccess(roleID string) (*RoleAccess, error) {
+	cacheKey := "role:" + roleID
+	if role, ok := s.roleCache.get(cacheKey); ok {
+		return role, nil
+	}
+
+	var role RoleAccess
+	var ipAccess pq.StringArray
+
+	err := s.db.QueryRow(`
+		SELECT id, name, ip_access, admin_access, app_access
+		FROM roles
+		WHERE id = $1
+	`, roleID).Scan(&role.ID, &role.Name, &ipAccess, &role.AdminAccess, &role.AppAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	role.IPAccess = []string(ipAccess)
+	s.roleCache.set(cacheKey, &role)
+	return &role, nil
+}
+
+// setRoleContext stores the role of the current request in the gin context
+func setRoleContext(c *gin.Context, role *RoleAccess) {
+	c.Set("role_id", role.ID)
+	c.Set("user_role", role.Name)
+	c.Set("admin_access", role.AdminAccess)
+	c.Set("app_access", role.AppAccess)
+}
+
+// isAdmin reports whether the requesting user's role has admin access
+func isAdmin(c *gin.Context) bool {
+	return c.GetBool("admin_access")
+}
+
+// hasAppAccess reports whether the requesting user's role may use the admin app.
+// Admin access implies app access.
+func hasAppAccess(c *gin.Context) bool {
+	return c.GetBool("admin_access") || c.GetBool("app_access")
+}
+
+// isAdminOrSelf reports whether the requesting user is an admin or the target user
+func isAdminOrSelf(c *gin.Context, targetUserID string) bool {
+	if isAdmin(c) {
+		return true
+	}
+
+	currentUserID := c.GetString("user_id")
+	return currentUserID != "" && currentUserID == targetUserID
+}
+
+// requireAdmin rejects requests whose role does not have admin access
+func requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAdmin(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireAppAccess rejects requests whose role does not have app access
+func requireAppAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasAppAccess(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "App access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// isSystemRole reports whether a role is one of the seeded roles that must not be deleted
+func isSystemRole(role *Role) bool {
+	return role.ID == administratorRoleID || role.ID == publicRoleID
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleAccessCache(t *testing.T) {
+	cache := newRoleAccessCache(time.Minute)
+
+	_, ok := cache.get("user:1")
+	assert.False(t, ok)
+
+	cache.set("user:1", &RoleAccess{ID: "role-1", AdminAccess: true})
+	role, ok := cache.get("user:1")
+	require.True(t, ok)
+	assert.Equal(t, "role-1", role.ID)
+	assert.True(t, role.AdminAccess)
+
+	cache.clear()
+	_, ok = cache.get("user:1")
+	assert.False(t, ok)
+
+	expired := newRoleAccessCache(-time.Second)
+	expired.set("user:1", &RoleAccess{ID: "role-1"})
+	_, ok = expired.get("user:1")
+	assert.False(t, ok)
+
+	// A nil cache never stores anything
+	var disabled *roleAccessCache
+	disabled.set("user:1", &RoleAccess{ID: "role-1"})
+	_, ok = disabled.get("user:1")
+	assert.False(t, ok)
+	disabled.clear()
+}
+
+func TestAuthMiddlewareRoleFlags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logrus.SetLevel(logrus.PanicLevel)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// The token still carries the old role name; the flags come from the database
+	token, err := generateJWT("user-1", "owner@example.com", "Administrator")
+	require.NoError(t, err)
+
+	router := gin.New()
+	server := &Server{db: db, router: router, roleCache: newRoleAccessCache(time.Minute)}
+
+	// Only one lookup is expected: the second request is served from the cache
+	mock.ExpectQuery("SELECT r.id, r.name, r.ip_access, r.admin_access, r.app_access FROM users u").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "ip_access", "admin_access", "app_access"}).
+			AddRow("role-1", "Site Owner", nil, true, true))
+
+	router.GET("/admin", server.authMiddleware(), requireAdmin(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"role":       c.GetString("user_role"),
+			"role_id":    c.GetString("role_id"),
+			"app_access": hasAppAccess(c),
+		})
+	})
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"role":"Site Owner"`)
+		assert.Contains(t, w.Body.String(), `"role_id":"role-1"`)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRequireAdminAndAppAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		adminAccess bool
+		appAccess   bool
+		adminCode   int
+		appCode     int
+	}{
+		{"Admin role", true, false, http.StatusOK, http.StatusOK},
+		{"App role", false, true, http.StatusForbidden, http.StatusOK},
+		{"API-only role", false, false, http.StatusForbidden, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("admin_access", tt.adminAccess)
+				c.Set("app_access", tt.appAccess)
+				c.Next()
+			})
+			ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+			router.GET("/admin", requireAdmin(), ok)
+			router.GET("/app", requireAppAccess(), ok)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/admin", nil))
+			assert.Equal(t, tt.adminCode, w.Code)
+
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/app", nil))
+			assert.Equal(t, tt.appCode, w.Code)
+		})
+	}
+}
+
+func TestIsAdminOrSelf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("user_id", "user-1")
+	c.Set("admin_access", false)
+	assert.True(t, isAdminOrSelf(c, "user-1"))
+	assert.False(t, isAdminOrSelf(c, "user-2"))
+
+	c.Set("admin_access", true)
+	assert.True(t, isAdminOrSelf(c, "user-2"))
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,13 +12,20 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/hooks"
+	"gorectus/internal/schema"
 )
 
+// systemCollections are the tables backing the API itself
+var systemCollections = []string{"users", "roles", "permissions", "collections", "fields", "sessions", "activity", "revisions", "settings", "files", "folders", "webhooks", "webhook_deliveries", "flows", "operations", "flow_runs", "jobs", "job_runs"}
+
 // CollectionsHandler handles collection-related routes
 type CollectionsHandler struct {
 	db             *sql.DB
 	authMiddleware gin.HandlerFunc
 	optionsHandler gin.HandlerFunc
+	events         *hooks.Bus
 }
 
 // NewCollectionsHandler creates a new collections handler
@@ -26,6 +34,7 @@ func NewCollectionsHandler(server ServerInterface) *CollectionsHandler {
 		db:             server.GetDB(),
 		authMiddleware: server.AuthMiddleware(),
 		optionsHandler: server.OptionsHandler(),
+		events:         server.Events(),
 	}
 }
 
@@ -34,6 +43,7 @@ func (h *CollectionsHandler) SetupRoutes(v1 *gin.RouterGroup) {
 	// CORS preflight OPTIONS for collections endpoints
 	v1.OPTIONS("/collections", h.optionsHandler)
 	v1.OPTIONS("/collections/:collection", h.optionsHandler)
+	v1.OPTIONS("/collections/adopt", h.optionsHandler)
 
 	// Collections routes (protected)
 	collections := v1.Group("/collections")
@@ -41,6 +51,7 @@ func (h *CollectionsHandler) SetupRoutes(v1 *gin.RouterGroup) {
 	{
 		collections.GET("", h.getCollections)
 		collections.POST("", h.createCollection)
+		collections.POST("/adopt", h.adoptCollection)
 		collections.GET("/:collection", h.getCollection)
 		collections.PATCH("/:collection", h.updateCollection)
 		collections.DELETE("/:collection", h.deleteCollection)
@@ -145,12 +156,6 @@ type UpdateCollectionRequest struct {
 	Versioning            *bool       `json:"versioning"`
 }
 
-// isAdmin checks if the requesting user is an admin
-func (h *CollectionsHandler) isAdmin(c *gin.Context) bool {
-	currentUserRole := c.GetString("user_role")
-	return currentUserRole == "Administrator"
-}
-
 // Collections handlers implementations
 // GetCollections retrieves all collections
 //
@@ -269,11 +274,15 @@ func (h *CollectionsHandler) getCollections(c *gin.Context) {
 //	@Router			/collections [post]
 func (h *CollectionsHandler) createCollection(c *gin.Context) {
 	// Only admins can create collections
-	if !h.isAdmin(c) {
+	if !isAdmin(c) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
 		return
 	}
 
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeCollections, scopeCollections, hooks.ActionCreate, nil)) {
+		return
+	}
+
 	var req CreateCollectionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logrus.WithError(err).Error("Invalid create collection request payload")
@@ -303,168 +312,189 @@ func (h *CollectionsHandler) createCollection(c *gin.Context) {
 	}
 	defer tx.Rollback()
 
-	// Set defaults
-	hidden := false
-	singleton := false
-	archiveAppFilter := true
-	accountability := "all"
-	collapse := "open"
-	versioning := false
+	// Insert collection metadata and create the data table
+	if err = insertCollection(tx, &req); err != nil {
+		logrus.WithError(err).Error("Database error while creating collection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
 
-	if req.Hidden != nil {
-		hidden = *req.Hidden
+	if err = createCollectionTable(tx, req.Collection); err != nil {
+		logrus.WithError(err).Error("Failed to create collection table")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create collection table"})
+		return
 	}
-	if req.Singleton != nil {
-		singleton = *req.Singleton
+
+	// Add fields if provided
+	for i := range req.Fields {
+		if err = insertFieldMetadata(tx, req.Collection, &req.Fields[i]); err != nil {
+			logrus.WithError(err).Error("Database error while creating field")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
 	}
-	if req.ArchiveAppFilter != nil {
-		archiveAppFilter = *req.ArchiveAppFilter
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		logrus.WithError(err).Error("Failed to commit transaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
 	}
-	if req.Accountability != nil {
-		accountability = *req.Accountability
+
+	// Return the created collection
+	collection, err := h.getCollectionByName(req.Collection)
+	if err != nil {
+		logrus.WithError(err).Error("Error fetching created collection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
 	}
-	if req.Collapse != nil {
-		collapse = *req.Collapse
+
+	logrus.WithField("collection", req.Collection).Info("Collection created successfully")
+	emitActionHooks(c, h.events, scopeCollections, scopeCollections, hooks.ActionCreate, collection, req.Collection)
+	c.JSON(http.StatusCreated, gin.H{"data": collection})
+}
+
+// adoptCollection registers an existing database table as a collection
+//
+//	@Summary		Adopt an existing table
+//	@Description	Register an existing table as a collection, building field definitions from its columns. The table and its data are left untouched. Fields in the request override the generated field metadata by name
+//	@Tags			collections
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			collection	body		CreateCollectionRequest	true	"Table name and collection options"
+//	@Success		201			{object}	CollectionModel			"Adopted collection with its fields"
+//	@Failure		400			{object}	ErrorResponse			"Invalid table or missing id primary key"
+//	@Failure		401			{object}	ErrorResponse			"Unauthorized"
+//	@Failure		403			{object}	ErrorResponse			"Admin access required"
+//	@Failure		404			{object}	ErrorResponse			"Table not found"
+//	@Failure		409			{object}	ErrorResponse			"Collection already exists"
+//	@Failure		500			{object}	ErrorResponse			"Internal server error"
+//	@Router			/collections/adopt [post]
+func (h *CollectionsHandler) adoptCollection(c *gin.Context) {
+	// Only admins can adopt tables
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
 	}
-	if req.Versioning != nil {
-		versioning = *req.Versioning
+
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeCollections, scopeCollections, hooks.ActionCreate, nil)) {
+		return
 	}
 
-	// Convert JSON fields to bytes or NULL
-	var translationsData, itemDuplicationFieldsData interface{}
-	if req.Translations != nil {
-		translationsBytes, _ := json.Marshal(req.Translations)
-		translationsData = translationsBytes
-	} else {
-		translationsData = nil
+	var req CreateCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid adopt collection request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
 	}
-	if req.ItemDuplicationFields != nil {
-		itemDuplicationFieldsBytes, _ := json.Marshal(req.ItemDuplicationFields)
-		itemDuplicationFieldsData = itemDuplicationFieldsBytes
-	} else {
-		itemDuplicationFieldsData = nil
+
+	if !isValidFieldName(req.Collection) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid table name"})
+		return
+	}
+	if isSystemCollection(req.Collection) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot adopt system table"})
+		return
 	}
 
-	// Insert collection
-	_, err = tx.Exec(`
-		INSERT INTO collections (
-			collection, icon, note, display_template, hidden, singleton,
-			translations, archive_field, archive_app_filter, archive_value,
-			unarchive_value, sort_field, accountability, color, 
-			item_duplication_fields, sort, "group", collapse, preview_url, versioning
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
-	`, req.Collection, req.Icon, req.Note, req.DisplayTemplate, hidden, singleton,
-		translationsData, req.ArchiveField, archiveAppFilter, req.ArchiveValue,
-		req.UnarchiveValue, req.SortField, accountability, req.Color,
-		itemDuplicationFieldsData, req.Sort, req.Group, collapse, req.PreviewURL, versioning)
+	// Check if collection already exists
+	var exists bool
+	err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM collections WHERE collection = $1)", req.Collection).Scan(&exists)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while checking collection existence")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if exists {
+		c.JSON(http.StatusConflict, gin.H{"error": "Collection already exists"})
+		return
+	}
 
+	// Check that the table exists
+	err = h.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = current_schema() AND table_name = $1 AND table_type = 'BASE TABLE'
+		)
+	`, req.Collection).Scan(&exists)
 	if err != nil {
-		logrus.WithError(err).Error("Database error while creating collection")
+		logrus.WithError(err).Error("Database error while checking table existence")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Table not found"})
+		return
+	}
 
-	// Create the actual data table for this collection
-	createTableSQL := `CREATE TABLE IF NOT EXISTS "` + req.Collection + `" (
-		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	)`
+	columns, err := schema.LoadColumns(h.db, []string{req.Collection})
+	if err != nil {
+		logrus.WithError(err).Error("Database error while introspecting table")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	tableColumns := columns[req.Collection]
 
-	_, err = tx.Exec(createTableSQL)
+	// The items API addresses rows by id; created_at and updated_at are
+	// maintained when the table has them
+	if !hasIDPrimaryKey(tableColumns) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Table must have an id primary key column"})
+		return
+	}
+
+	fields, unknown := adoptedFields(tableColumns, req.Fields)
+	if unknown != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Field '%s' does not exist in table", unknown)})
+		return
+	}
+
+	// Start transaction
+	tx, err := h.db.Begin()
 	if err != nil {
-		logrus.WithError(err).Error("Failed to create collection table")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create collection table"})
+		logrus.WithError(err).Error("Failed to begin transaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
+	defer tx.Rollback()
 
-	// Add fields if provided
-	if len(req.Fields) > 0 {
-		for _, field := range req.Fields {
-			// Handle JSON fields properly - use nil for NULL instead of empty byte slices
-			var optionsData, displayOptionsData, translationsData, conditionsData, validationData interface{}
-
-			if field.Options != nil {
-				optionsBytes, _ := json.Marshal(field.Options)
-				optionsData = optionsBytes
-			} else {
-				optionsData = nil
-			}
-			if field.DisplayOptions != nil {
-				displayOptionsBytes, _ := json.Marshal(field.DisplayOptions)
-				displayOptionsData = displayOptionsBytes
-			} else {
-				displayOptionsData = nil
-			}
-			if field.Translations != nil {
-				translationsBytes, _ := json.Marshal(field.Translations)
-				translationsData = translationsBytes
-			} else {
-				translationsData = nil
-			}
-			if field.Conditions != nil {
-				conditionsBytes, _ := json.Marshal(field.Conditions)
-				conditionsData = conditionsBytes
-			} else {
-				conditionsData = nil
-			}
-			if field.Validation != nil {
-				validationBytes, _ := json.Marshal(field.Validation)
-				validationData = validationBytes
-			} else {
-				validationData = nil
-			}
-
-			// Set field defaults
-			width := "full"
-			if field.Width != "" {
-				width = field.Width
-			}
-
-			// Convert special array to PostgreSQL array format
-			var special interface{}
-			if len(field.Special) > 0 {
-				special = pq.Array(field.Special)
-			} else {
-				special = pq.Array([]string{})
-			}
-
-			_, err = tx.Exec(`
-				INSERT INTO fields (
-					collection, field, special, interface, options, display, 
-					display_options, readonly, hidden, sort, width, translations,
-					note, conditions, required, "group", validation, validation_message
-				) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
-			`, req.Collection, field.Field, special, field.Interface, optionsData,
-				field.Display, displayOptionsData, field.Readonly, field.Hidden,
-				field.Sort, width, translationsData, field.Note, conditionsData,
-				field.Required, field.Group, validationData, field.ValidationMessage)
-
-			if err != nil {
-				logrus.WithError(err).Error("Database error while creating field")
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
-				return
-			}
+	if err = insertCollection(tx, &req); err != nil {
+		logrus.WithError(err).Error("Database error while adopting collection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	for i := range fields {
+		if err = insertFieldMetadata(tx, req.Collection, &fields[i]); err != nil {
+			logrus.WithError(err).Error("Database error while creating field")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
 		}
 	}
 
-	// Commit transaction
 	if err = tx.Commit(); err != nil {
 		logrus.WithError(err).Error("Failed to commit transaction")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
 
-	// Return the created collection
 	collection, err := h.getCollectionByName(req.Collection)
 	if err != nil {
-		logrus.WithError(err).Error("Error fetching created collection")
+		logrus.WithError(err).Error("Error fetching adopted collection")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
 
-	logrus.WithField("collection", req.Collection).Info("Collection created successfully")
-	c.JSON(http.StatusCreated, gin.H{"data": collection})
+	logrus.WithFields(logrus.Fields{
+		"collection": req.Collection,
+		"fields":     len(fields),
+	}).Info("Table adopted as collection")
+	emitActionHooks(c, h.events, scopeCollections, scopeCollections, hooks.ActionCreate, collection, req.Collection)
+
+	c.JSON(http.StatusCreated, gin.H{"data": map[string]interface{}{
+		"collection": collection,
+		"fields":     fields,
+	}})
 }
 
 // GetCollection retrieves a specific collection by name
@@ -529,7 +559,7 @@ func (h *CollectionsHandler) getCollection(c *gin.Context) {
 //	@Router			/collections/{collection} [patch]
 func (h *CollectionsHandler) updateCollection(c *gin.Context) {
 	// Only admins can update collections
-	if !h.isAdmin(c) {
+	if !isAdmin(c) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
 		return
 	}
@@ -547,6 +577,10 @@ func (h *CollectionsHandler) updateCollection(c *gin.Context) {
 		return
 	}
 
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeCollections, scopeCollections, hooks.ActionUpdate, nil, collectionName)) {
+		return
+	}
+
 	var req UpdateCollectionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logrus.WithError(err).Error("Invalid update collection request payload")
@@ -684,18 +718,22 @@ func (h *CollectionsHandler) updateCollection(c *gin.Context) {
 	}
 
 	logrus.WithField("collection", collectionName).Info("Collection updated successfully")
+	emitActionHooks(c, h.events, scopeCollections, scopeCollections, hooks.ActionUpdate, collection, collectionName)
 	c.JSON(http.StatusOK, gin.H{"data": collection})
 }
 
-// deleteCollection deletes a collection by name
+// deleteCollection deletes a collection by name. The data table is only
+// dropped with ?drop=true, so deleting an adopted collection never destroys a
+// table the API didn't create unless asked to.
 //
 //	@Summary		Delete a collection by name
-//	@Description	Delete a specific collection by its name
+//	@Description	Delete a specific collection by its name, dropping its table with drop=true
 //	@Tags			collections
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			collection	path		string	true	"Collection name"
+//	@Param			drop		query		bool	false	"Drop the collection's table"
 //	@Success		200			{object}	SuccessMessage	"Collection deleted successfully"
 //	@Failure		401			{object}	ErrorResponse	"Unauthorized"
 //	@Failure		403			{object}	ErrorResponse	"Forbidden"
@@ -705,7 +743,7 @@ func (h *CollectionsHandler) updateCollection(c *gin.Context) {
 //	@Router			/collections/{collection} [delete]
 func (h *CollectionsHandler) deleteCollection(c *gin.Context) {
 	// Only admins can delete collections
-	if !h.isAdmin(c) {
+	if !isAdmin(c) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
 		return
 	}
@@ -724,12 +762,14 @@ func (h *CollectionsHandler) deleteCollection(c *gin.Context) {
 	}
 
 	// Prevent deletion of system collections if any
-	systemCollections := []string{"users", "roles", "permissions", "collections", "fields", "sessions", "activity", "revisions", "settings"}
-	for _, sysCol := range systemCollections {
-		if collectionName == sysCol {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot delete system collection"})
-			return
-		}
+	if isSystemCollection(collectionName) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot delete system collection"})
+		return
+	}
+
+	event := requestEvent(c, scopeCollections, scopeCollections, hooks.ActionDelete, nil, collectionName)
+	if !runFilterHooks(c, h.events, &event) {
+		return
 	}
 
 	// Start transaction
@@ -757,13 +797,17 @@ func (h *CollectionsHandler) deleteCollection(c *gin.Context) {
 		return
 	}
 
-	// Drop the actual data table (be careful with this)
-	dropTableSQL := `DROP TABLE IF EXISTS "` + collectionName + `" CASCADE`
-	_, err = tx.Exec(dropTableSQL)
-	if err != nil {
-		logrus.WithError(err).Error("Failed to drop collection table")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to drop collection table"})
-		return
+	// Drop the actual data table only when asked; otherwise the collection is
+	// just unregistered and its table and data are kept
+	drop := c.Query("drop") == "true"
+	if drop {
+		dropTableSQL := `DROP TABLE IF EXISTS "` + collectionName + `" CASCADE`
+		_, err = tx.Exec(dropTableSQL)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to drop collection table")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to drop collection table"})
+			return
+		}
 	}
 
 	// Commit transaction
@@ -773,7 +817,11 @@ func (h *CollectionsHandler) deleteCollection(c *gin.Context) {
 		return
 	}
 
-	logrus.WithField("collection", collectionName).Info("Collection deleted successfully")
+	logrus.WithFields(logrus.Fields{
+		"collection": collectionName,
+		"dropped":    drop,
+	}).Info("Collection deleted successfully")
+	emitActionHooks(c, h.events, scopeCollections, scopeCollections, hooks.ActionDelete, nil, collectionName)
 	c.JSON(http.StatusOK, gin.H{"message": "Collection deleted successfully"})
 }
 
@@ -878,3 +926,187 @@ func (h *CollectionsHandler) getFieldsByCollection(collectionName string) ([]Fie
 
 	return fields, nil
 }
+
+// insertCollection inserts a collection row, applying the defaults for unset options
+func insertCollection(tx *sql.Tx, req *CreateCollectionRequest) error {
+	// Set defaults
+	hidden := false
+	singleton := false
+	archiveAppFilter := true
+	accountability := "all"
+	collapse := "open"
+	versioning := false
+
+	if req.Hidden != nil {
+		hidden = *req.Hidden
+	}
+	if req.Singleton != nil {
+		singleton = *req.Singleton
+	}
+	if req.ArchiveAppFilter != nil {
+		archiveAppFilter = *req.ArchiveAppFilter
+	}
+	if req.Accountability != nil {
+		accountability = *req.Accountability
+	}
+	if req.Collapse != nil {
+		collapse = *req.Collapse
+	}
+	if req.Versioning != nil {
+		versioning = *req.Versioning
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO collections (
+			collection, icon, note, display_template, hidden, singleton,
+			translations, archive_field, archive_app_filter, archive_value,
+			unarchive_value, sort_field, accountability, color, 
+			item_duplication_fields, sort, "group", collapse, preview_url, versioning
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+	`, req.Collection, req.Icon, req.Note, req.DisplayTemplate, hidden, singleton,
+		jsonOrNull(req.Translations), req.ArchiveField, archiveAppFilter, req.ArchiveValue,
+		req.UnarchiveValue, req.SortField, accountability, req.Color,
+		jsonOrNull(req.ItemDuplicationFields), req.Sort, req.Group, collapse, req.PreviewURL, versioning)
+	return err
+}
+
+// createCollectionTable creates the data table for a collection with the default columns
+func createCollectionTable(tx *sql.Tx, collectionName string) error {
+	createTableSQL := `CREATE TABLE IF NOT EXISTS "` + collectionName + `" (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	_, err := tx.Exec(createTableSQL)
+	return err
+}
+
+// insertFieldMetadata inserts a row into the fields table for a collection field
+func insertFieldMetadata(tx *sql.Tx, collectionName string, field *Field) error {
+	// Set field defaults
+	width := "full"
+	if field.Width != "" {
+		width = field.Width
+	}
+
+	// Convert special array to PostgreSQL array format
+	special := field.Special
+	if special == nil {
+		special = []string{}
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO fields (
+			collection, field, special, interface, options, display, 
+			display_options, readonly, hidden, sort, width, translations,
+			note, conditions, required, "group", validation, validation_message
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	`, collectionName, field.Field, pq.Array(special), field.Interface, jsonOrNull(field.Options),
+		field.Display, jsonOrNull(field.DisplayOptions), field.Readonly, field.Hidden,
+		field.Sort, width, jsonOrNull(field.Translations), field.Note, jsonOrNull(field.Conditions),
+		field.Required, field.Group, jsonOrNull(field.Validation), field.ValidationMessage)
+	return err
+}
+
+// jsonOrNull marshals a value for a JSONB column, using NULL for nil values
+func jsonOrNull(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	data, _ := json.Marshal(value)
+	return data
+}
+
+// isSystemCollection reports whether a name belongs to a system table
+func isSystemCollection(name string) bool {
+	for _, sysCol := range systemCollections {
+		if name == sysCol {
+			return true
+		}
+	}
+	return false
+}
+
+// hasIDPrimaryKey reports whether a table's primary key is its id column
+func hasIDPrimaryKey(columns []schema.TableColumn) bool {
+	for _, column := range columns {
+		if column.Name == "id" {
+			return column.IsPrimaryKey != nil && *column.IsPrimaryKey
+		}
+	}
+	return false
+}
+
+// adoptedFields builds field definitions for the columns of an adopted table.
+// Overrides replace the generated definition of the column with the same name;
+// the name of an override without a matching column is returned as unknown.
+func adoptedFields(columns []schema.TableColumn, overrides []Field) (fields []Field, unknown string) {
+	byName := make(map[string]*Field, len(overrides))
+	for i := range overrides {
+		byName[overrides[i].Field] = &overrides[i]
+	}
+
+	fields = make([]Field, 0, len(columns))
+	for i, column := range columns {
+		if override, ok := byName[column.Name]; ok {
+			fields = append(fields, *override)
+			delete(byName, column.Name)
+			continue
+		}
+		fields = append(fields, fieldFromColumn(column, i+1))
+	}
+
+	for _, override := range overrides {
+		if _, ok := byName[override.Field]; ok {
+			return nil, override.Field
+		}
+	}
+
+	return fields, ""
+}
+
+// fieldFromColumn infers field metadata from an introspected column
+func fieldFromColumn(column schema.TableColumn, sort int) Field {
+	field := Field{
+		Field:   column.Name,
+		Special: []string{},
+		Sort:    &sort,
+		Width:   "full",
+	}
+
+	interfaceType := "input"
+	switch column.DataType {
+	case "text":
+		interfaceType = "input-multiline"
+	case "boolean":
+		interfaceType = "boolean"
+	case "date", "time", "timestamp":
+		interfaceType = "datetime"
+	case "json":
+		interfaceType = "input-code"
+	}
+
+	switch {
+	case column.IsPrimaryKey != nil && *column.IsPrimaryKey:
+		if column.DataType == "uuid" {
+			field.Special = []string{"uuid"}
+		}
+		field.Readonly = true
+		field.Hidden = true
+	case schema.IsSystemColumn(column.Name):
+		field.Readonly = true
+		field.Hidden = true
+	case column.ForeignTable != nil:
+		interfaceType = "select-dropdown-m2o"
+		field.Special = []string{"m2o"}
+	}
+	field.Interface = &interfaceType
+
+	// Columns without a default must be provided on insert
+	if column.IsNullable != nil && !*column.IsNullable && !column.HasDefault && !field.Readonly {
+		field.Required = true
+	}
+
+	return field
+}
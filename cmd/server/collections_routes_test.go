@@ -15,6 +15,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+
+	"gorectus/internal/hooks"
 )
 
 // Test suite for collection handlers
@@ -75,10 +77,22 @@ func (m *mockCollectionServerInterface) AuthMiddleware() gin.HandlerFunc {
 		c.Set("user_id", "550e8400-e29b-41d4-a716-446655440000")
 		c.Set("user_email", "admin@example.com")
 		c.Set("user_role", "Administrator")
+		c.Set("admin_access", true)
+		c.Set("app_access", true)
 		c.Next()
 	}
 }
 
+func (m *mockCollectionServerInterface) OptionalAuthMiddleware() gin.HandlerFunc {
+	return m.AuthMiddleware()
+}
+
+func (m *mockCollectionServerInterface) InvalidateRoleAccess() {}
+
+func (m *mockCollectionServerInterface) Webhooks() *webhookDispatcher { return nil }
+
+func (m *mockCollectionServerInterface) Events() *hooks.Bus { return nil }
+
 func (m *mockCollectionServerInterface) OptionsHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
@@ -98,6 +112,8 @@ func (suite *CollectionHandlersTestSuite) createAuthenticatedRequest(method, url
 		c.Set("user_id", userID)
 		c.Set("user_email", "test@example.com")
 		c.Set("user_role", role)
+		c.Set("admin_access", role == "Administrator")
+		c.Set("app_access", true)
 		c.Next()
 	}
 
@@ -380,7 +396,7 @@ func (suite *CollectionHandlersTestSuite) TestDeleteCollection_Success() {
 	suite.mock.ExpectExec("DROP TABLE IF EXISTS").WillReturnResult(sqlmock.NewResult(0, 0))
 	suite.mock.ExpectCommit()
 
-	req, router := suite.createAuthenticatedRequest("DELETE", "/api/v1/collections/test_collection", nil, "test-user", "Administrator")
+	req, router := suite.createAuthenticatedRequest("DELETE", "/api/v1/collections/test_collection?drop=true", nil, "test-user", "Administrator")
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
@@ -393,6 +409,35 @@ func (suite *CollectionHandlersTestSuite) TestDeleteCollection_Success() {
 	assert.Equal(suite.T(), "Collection deleted successfully", response["message"])
 }
 
+// Without drop=true the collection is unregistered and its table kept, which
+// is what deleting an adopted collection needs
+func (suite *CollectionHandlersTestSuite) TestDeleteCollection_KeepsTable() {
+	suite.mock.ExpectQuery("SELECT collection, icon, note").WillReturnRows(sqlmock.NewRows([]string{
+		"collection", "icon", "note", "display_template", "hidden", "singleton",
+		"translations", "archive_field", "archive_app_filter", "archive_value",
+		"unarchive_value", "sort_field", "accountability", "color",
+		"item_duplication_fields", "sort", "group", "collapse", "preview_url",
+		"versioning", "created_at", "updated_at",
+	}).AddRow(
+		"legacy_orders", nil, nil, nil, false, false,
+		nil, nil, true, nil, nil, nil, "all", nil,
+		nil, nil, nil, "open", nil, false,
+		time.Now(), time.Now(),
+	))
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("DELETE FROM fields WHERE collection").WillReturnResult(sqlmock.NewResult(0, 3))
+	suite.mock.ExpectExec("DELETE FROM collections WHERE collection").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	req, router := suite.createAuthenticatedRequest("DELETE", "/api/v1/collections/legacy_orders", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
 func (suite *CollectionHandlersTestSuite) TestDeleteCollection_SystemCollection() {
 	// Mock fetching system collection
 	rows := sqlmock.NewRows([]string{
@@ -422,6 +467,185 @@ func (suite *CollectionHandlersTestSuite) TestDeleteCollection_SystemCollection(
 	assert.Equal(suite.T(), "Cannot delete system collection", response["error"])
 }
 
+// expectLegacyTableColumns mocks the introspection of a legacy orders table
+func (suite *CollectionHandlersTestSuite) expectLegacyTableColumns(columns *sqlmock.Rows) {
+	suite.mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM collections").
+		WithArgs("legacy_orders").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	suite.mock.ExpectQuery("SELECT 1 FROM information_schema.tables").
+		WithArgs("legacy_orders").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	suite.mock.ExpectQuery("FROM information_schema.columns").WillReturnRows(columns)
+	suite.mock.ExpectQuery("FROM information_schema.table_constraints").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "constraint_type", "foreign_table", "foreign_column"}).
+			AddRow("legacy_orders", "id", "PRIMARY KEY", nil, nil).
+			AddRow("legacy_orders", "customer_id", "FOREIGN KEY", "customers", "id"))
+	suite.mock.ExpectQuery("FROM pg_index").
+		WillReturnRows(sqlmock.NewRows([]string{"relname", "attname"}))
+}
+
+func legacyTableColumns() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"table_name", "column_name", "data_type", "character_maximum_length", "is_nullable", "column_default"}).
+		AddRow("legacy_orders", "id", "integer", nil, "NO", "nextval('legacy_orders_id_seq'::regclass)").
+		AddRow("legacy_orders", "reference", "character varying", 40, "NO", nil).
+		AddRow("legacy_orders", "customer_id", "uuid", nil, "YES", nil).
+		AddRow("legacy_orders", "notes", "text", nil, "YES", nil).
+		AddRow("legacy_orders", "created_at", "timestamp without time zone", nil, "YES", "CURRENT_TIMESTAMP").
+		AddRow("legacy_orders", "updated_at", "timestamp without time zone", nil, "YES", "CURRENT_TIMESTAMP")
+}
+
+func (suite *CollectionHandlersTestSuite) TestAdoptCollection_Success() {
+	suite.expectLegacyTableColumns(legacyTableColumns())
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("INSERT INTO collections").WillReturnResult(sqlmock.NewResult(1, 1))
+	for i := 0; i < 6; i++ {
+		suite.mock.ExpectExec("INSERT INTO fields").WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	suite.mock.ExpectCommit()
+
+	rows := sqlmock.NewRows([]string{
+		"collection", "icon", "note", "display_template", "hidden", "singleton",
+		"translations", "archive_field", "archive_app_filter", "archive_value",
+		"unarchive_value", "sort_field", "accountability", "color",
+		"item_duplication_fields", "sort", "group", "collapse", "preview_url",
+		"versioning", "created_at", "updated_at",
+	}).AddRow(
+		"legacy_orders", nil, nil, nil, false, false,
+		nil, nil, true, nil, nil, nil, "all", nil,
+		nil, nil, nil, "open", nil, false,
+		time.Now(), time.Now(),
+	)
+	suite.mock.ExpectQuery("SELECT collection, icon, note").WillReturnRows(rows)
+
+	body := map[string]interface{}{
+		"collection": "legacy_orders",
+		"fields":     []map[string]interface{}{{"field": "notes", "interface": "input-rich-text-md"}},
+	}
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/collections/adopt", body, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var response struct {
+		Data struct {
+			Fields []Field `json:"fields"`
+		} `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	fields := response.Data.Fields
+	require.Len(suite.T(), fields, 6)
+
+	// The serial primary key is read-only and not required
+	assert.Equal(suite.T(), "id", fields[0].Field)
+	assert.True(suite.T(), fields[0].Readonly)
+	assert.False(suite.T(), fields[0].Required)
+
+	assert.Equal(suite.T(), "input", *fields[1].Interface)
+	assert.True(suite.T(), fields[1].Required)
+
+	assert.Equal(suite.T(), "select-dropdown-m2o", *fields[2].Interface)
+	assert.Equal(suite.T(), []string{"m2o"}, fields[2].Special)
+
+	// The override replaces the generated definition
+	assert.Equal(suite.T(), "input-rich-text-md", *fields[3].Interface)
+
+	assert.True(suite.T(), fields[4].Hidden)
+}
+
+func (suite *CollectionHandlersTestSuite) TestAdoptCollection_TableNotFound() {
+	suite.mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM collections").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	suite.mock.ExpectQuery("SELECT 1 FROM information_schema.tables").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	body := CreateCollectionRequest{Collection: "legacy_orders"}
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/collections/adopt", body, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *CollectionHandlersTestSuite) TestAdoptCollection_OnlyPrimaryKey() {
+	// Tables without the timestamp columns are adopted as they are
+	suite.expectLegacyTableColumns(
+		sqlmock.NewRows([]string{"table_name", "column_name", "data_type", "character_maximum_length", "is_nullable", "column_default"}).
+			AddRow("legacy_orders", "id", "integer", nil, "NO", "nextval('legacy_orders_id_seq'::regclass)"))
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("INSERT INTO collections").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec("INSERT INTO fields").WithArgs("legacy_orders", "id", sqlmock.AnyArg(), sqlmock.AnyArg(),
+		sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+		sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+		sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectCommit()
+	suite.mock.ExpectQuery("SELECT collection, icon, note").WillReturnRows(sqlmock.NewRows([]string{
+		"collection", "icon", "note", "display_template", "hidden", "singleton",
+		"translations", "archive_field", "archive_app_filter", "archive_value",
+		"unarchive_value", "sort_field", "accountability", "color",
+		"item_duplication_fields", "sort", "group", "collapse", "preview_url",
+		"versioning", "created_at", "updated_at",
+	}).AddRow(
+		"legacy_orders", nil, nil, nil, false, false,
+		nil, nil, true, nil, nil, nil, "all", nil,
+		nil, nil, nil, "open", nil, false,
+		time.Now(), time.Now(),
+	))
+
+	body := CreateCollectionRequest{Collection: "legacy_orders"}
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/collections/adopt", body, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+}
+
+func (suite *CollectionHandlersTestSuite) TestAdoptCollection_MissingPrimaryKey() {
+	suite.expectLegacyTableColumns(
+		sqlmock.NewRows([]string{"table_name", "column_name", "data_type", "character_maximum_length", "is_nullable", "column_default"}).
+			AddRow("legacy_orders", "reference", "character varying", 40, "NO", nil))
+
+	body := CreateCollectionRequest{Collection: "legacy_orders"}
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/collections/adopt", body, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "id primary key")
+}
+
+func (suite *CollectionHandlersTestSuite) TestAdoptCollection_UnknownField() {
+	suite.expectLegacyTableColumns(legacyTableColumns())
+
+	body := CreateCollectionRequest{
+		Collection: "legacy_orders",
+		Fields:     []Field{{Field: "total"}},
+	}
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/collections/adopt", body, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Field 'total' does not exist in table")
+}
+
+func (suite *CollectionHandlersTestSuite) TestAdoptCollection_SystemTable() {
+	body := CreateCollectionRequest{Collection: "users"}
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/collections/adopt", body, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
 // Run the test suite
 func TestCollectionHandlersTestSuite(t *testing.T) {
 	suite.Run(t, new(CollectionHandlersTestSuite))
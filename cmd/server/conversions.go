@@ -0,0 +1,254 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/lib/pq"
+
+	"gorectus/internal/schema"
+)
+
+// errUnsupportedConversion is returned for type pairs without a conversion
+var errUnsupportedConversion = errors.New("unsupported type conversion")
+
+// Value ranges of the integer column types
+var integerRanges = map[string][2]string{
+	"integer": {"-2147483648", "2147483647"},
+	"bigint":  {"-9223372036854775808", "9223372036854775807"},
+}
+
+// Patterns used to find text values that cannot be cast to the target type
+const (
+	integerPattern   = `^[-+]?[0-9]+$`
+	decimalPattern   = `^[-+]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][-+]?[0-9]+)?$`
+	uuidPattern      = `^\{?[0-9a-f]{8}-?[0-9a-f]{4}-?[0-9a-f]{4}-?[0-9a-f]{4}-?[0-9a-f]{12}\}?$`
+	datePattern      = `^[0-9]{4}-[0-9]{2}-[0-9]{2}$`
+	timePattern      = `^[0-9]{2}:[0-9]{2}(:[0-9]{2}(\.[0-9]+)?)?$`
+	timestampPattern = `^[0-9]{4}-[0-9]{2}-[0-9]{2}([ T][0-9]{2}:[0-9]{2}(:[0-9]{2}(\.[0-9]+)?)?)?(Z|[-+][0-9]{2}(:?[0-9]{2})?)?$`
+	booleanLiterals  = `('t', 'true', 'y', 'yes', 'on', '1', 'f', 'false', 'n', 'no', 'off', '0')`
+)
+
+// TypeConversion describes how a column is converted to a new data type
+type TypeConversion struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Using is the USING expression of the ALTER COLUMN TYPE statement; empty
+	// when Postgres converts the values on its own
+	Using string `json:"using,omitempty"`
+	// Lossy conversions succeed but discard precision, such as decimals
+	// rounded to integers or timestamps truncated to dates
+	Lossy bool `json:"lossy"`
+	// FailingRows counts the rows whose value cannot be converted
+	FailingRows int `json:"failing_rows"`
+
+	// failCheck selects the non-null values that cannot be converted
+	failCheck string
+}
+
+// isConversionError reports whether a statement failed because a value could
+// not be converted, such as a date out of range
+func isConversionError(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Class() == "22"
+}
+
+// rowQuerier is implemented by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// planConversion plans the conversion of an existing column to the data type
+// of target and counts the rows that would fail it. It returns nil when the
+// target does not change the data type, sql.ErrNoRows when the column does not
+// exist and errUnsupportedConversion for type pairs that cannot be converted.
+func (h *FieldsHandler) planConversion(q rowQuerier, collectionName, fieldName string, target *FieldSchema) (*TypeConversion, error) {
+	if target == nil || target.DataType == "" {
+		return nil, nil
+	}
+
+	var dataType string
+	var maxLength sql.NullInt64
+	err := q.QueryRow(`
+		SELECT data_type, character_maximum_length
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2
+	`, collectionName, fieldName).Scan(&dataType, &maxLength)
+	if err != nil {
+		return nil, err
+	}
+
+	var fromLength *int
+	if maxLength.Valid {
+		length := int(maxLength.Int64)
+		fromLength = &length
+	}
+
+	conversion, err := planTypeConversion(fieldName, schema.NormalizeDataType(dataType), fromLength,
+		target.DataType, target.MaxLength, h.mapDataTypeToSQL(target.DataType, target.MaxLength))
+	if err != nil || conversion.failCheck == "" {
+		return conversion, err
+	}
+
+	countQuery := `SELECT COUNT(*) FROM "` + collectionName + `" WHERE "` + fieldName +
+		`" IS NOT NULL AND (` + conversion.failCheck + `)`
+	if err := q.QueryRow(countQuery).Scan(&conversion.FailingRows); err != nil {
+		return nil, err
+	}
+
+	return conversion, nil
+}
+
+// planTypeConversion builds the USING expression and failure check for
+// converting column from one data type to another. sqlType is the Postgres
+// type of the target.
+func planTypeConversion(column, from string, fromLength *int, to string, toLength *int, sqlType string) (*TypeConversion, error) {
+	from = schema.CanonicalDataType(from)
+	to = schema.CanonicalDataType(to)
+	if _, known := knownDataTypes[to]; !known {
+		to = "text"
+	}
+
+	conversion := &TypeConversion{
+		From: describeDataType(from, fromLength),
+		To:   describeDataType(to, toLength),
+	}
+
+	if from == to && to != "string" {
+		return conversion, nil
+	}
+
+	quoted := `"` + column + `"`
+	text := quoted + "::text"
+	if from == "json" {
+		// Unwrap JSON strings instead of keeping their quotes
+		text = quoted + " #>> '{}'"
+	}
+	trimmed := "btrim(" + text + ")"
+	parsable := isTextType(from) || from == "json"
+
+	switch to {
+	case "string", "text":
+		if !isTextType(from) {
+			conversion.Using = text
+		}
+		if to == "string" && (from != "string" || varcharLength(fromLength) > varcharLength(toLength)) {
+			conversion.failCheck = "length(" + text + ") > " + strconv.Itoa(varcharLength(toLength))
+		}
+
+	case "integer", "bigint":
+		bounds := integerRanges[to]
+		switch {
+		case from == "integer" || from == "bigint":
+			if from == "bigint" && to == "integer" {
+				conversion.failCheck = quoted + " NOT BETWEEN " + bounds[0] + " AND " + bounds[1]
+			}
+		case from == "decimal":
+			conversion.Using = "round(" + quoted + ")"
+			conversion.Lossy = true
+			conversion.failCheck = "round(" + quoted + ") NOT BETWEEN " + bounds[0] + " AND " + bounds[1]
+		case from == "boolean":
+			conversion.Using = "CASE WHEN " + quoted + " THEN 1 ELSE 0 END"
+		case parsable:
+			conversion.Using = trimmed + "::" + sqlType
+			conversion.failCheck = "CASE WHEN " + trimmed + " ~ '" + integerPattern + "' THEN " +
+				trimmed + "::numeric NOT BETWEEN " + bounds[0] + " AND " + bounds[1] + " ELSE true END"
+		default:
+			return nil, unsupportedConversion(from, to)
+		}
+
+	case "decimal":
+		switch {
+		case from == "integer" || from == "bigint":
+		case from == "boolean":
+			conversion.Using = "CASE WHEN " + quoted + " THEN 1 ELSE 0 END"
+		case parsable:
+			conversion.Using = trimmed + "::" + sqlType
+			conversion.failCheck = trimmed + " !~ '" + decimalPattern + "'"
+		default:
+			return nil, unsupportedConversion(from, to)
+		}
+
+	case "boolean":
+		switch {
+		case from == "integer" || from == "bigint" || from == "decimal":
+			conversion.Using = quoted + " <> 0"
+		case parsable:
+			conversion.Using = "lower(" + trimmed + ")::boolean"
+			conversion.failCheck = "lower(" + trimmed + ") NOT IN " + booleanLiterals
+		default:
+			return nil, unsupportedConversion(from, to)
+		}
+
+	case "uuid":
+		if !parsable {
+			return nil, unsupportedConversion(from, to)
+		}
+		conversion.Using = trimmed + "::uuid"
+		conversion.failCheck = trimmed + " !~* '" + uuidPattern + "'"
+
+	case "json":
+		if isTextType(from) {
+			// Text holds JSON documents; blank values become null
+			conversion.Using = "NULLIF(" + trimmed + ", '')::jsonb"
+			conversion.failCheck = trimmed + " <> '' AND NOT can_cast_to(" + trimmed + ", 'jsonb')"
+		} else {
+			conversion.Using = "to_jsonb(" + quoted + ")"
+		}
+
+	case "date", "time", "timestamp":
+		switch {
+		case from == "date" && to == "timestamp":
+		case from == "timestamp":
+			conversion.Using = quoted + "::" + sqlType
+			conversion.Lossy = true
+		case parsable:
+			conversion.Using = trimmed + "::" + sqlType
+			// The pattern keeps out words like 'now'; the cast check catches
+			// out of range values like 2024-13-45
+			conversion.failCheck = trimmed + " !~ '" + temporalPatterns[to] + "' OR NOT can_cast_to(" +
+				trimmed + ", '" + sqlType + "')"
+		default:
+			return nil, unsupportedConversion(from, to)
+		}
+	}
+
+	return conversion, nil
+}
+
+// knownDataTypes are the canonical data types the planner understands
+var knownDataTypes = map[string]struct{}{
+	"string": {}, "text": {}, "integer": {}, "bigint": {}, "decimal": {}, "boolean": {},
+	"date": {}, "time": {}, "timestamp": {}, "uuid": {}, "json": {},
+}
+
+var temporalPatterns = map[string]string{
+	"date":      datePattern,
+	"time":      timePattern,
+	"timestamp": timestampPattern,
+}
+
+func isTextType(dataType string) bool {
+	return dataType == "string" || dataType == "text"
+}
+
+// varcharLength returns the effective VARCHAR length, which defaults to 255
+func varcharLength(maxLength *int) int {
+	if maxLength != nil && *maxLength > 0 {
+		return *maxLength
+	}
+	return 255
+}
+
+func describeDataType(dataType string, maxLength *int) string {
+	if dataType == "string" {
+		return dataType + "(" + strconv.Itoa(varcharLength(maxLength)) + ")"
+	}
+	return dataType
+}
+
+func unsupportedConversion(from, to string) error {
+	return fmt.Errorf("%w from %s to %s", errUnsupportedConversion, from, to)
+}
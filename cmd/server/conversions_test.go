@@ -0,0 +1,139 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanTypeConversion(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      string
+		to        string
+		sqlType   string
+		using     string
+		failCheck string
+		lossy     bool
+	}{
+		{
+			name:      "text to integer",
+			from:      "string",
+			to:        "integer",
+			sqlType:   "INTEGER",
+			using:     `btrim("views"::text)::INTEGER`,
+			failCheck: `CASE WHEN btrim("views"::text) ~ '^[-+]?[0-9]+$' THEN btrim("views"::text)::numeric NOT BETWEEN -2147483648 AND 2147483647 ELSE true END`,
+		},
+		{
+			name:      "varchar to jsonb parses documents",
+			from:      "string",
+			to:        "json",
+			sqlType:   "JSONB",
+			using:     `NULLIF(btrim("views"::text), '')::jsonb`,
+			failCheck: `btrim("views"::text) <> '' AND NOT can_cast_to(btrim("views"::text), 'jsonb')`,
+		},
+		{
+			name:    "integer to jsonb",
+			from:    "integer",
+			to:      "json",
+			sqlType: "JSONB",
+			using:   `to_jsonb("views")`,
+		},
+		{
+			name:      "jsonb to integer unwraps strings",
+			from:      "json",
+			to:        "bigint",
+			sqlType:   "BIGINT",
+			using:     `btrim("views" #>> '{}')::BIGINT`,
+			failCheck: `CASE WHEN btrim("views" #>> '{}') ~ '^[-+]?[0-9]+$' THEN btrim("views" #>> '{}')::numeric NOT BETWEEN -9223372036854775808 AND 9223372036854775807 ELSE true END`,
+		},
+		{
+			name:      "decimal to integer rounds",
+			from:      "decimal",
+			to:        "integer",
+			sqlType:   "INTEGER",
+			using:     `round("views")`,
+			failCheck: `round("views") NOT BETWEEN -2147483648 AND 2147483647`,
+			lossy:     true,
+		},
+		{
+			name:    "integer to bigint needs no expression",
+			from:    "integer",
+			to:      "bigint",
+			sqlType: "BIGINT",
+		},
+		{
+			name:    "integer to boolean",
+			from:    "integer",
+			to:      "boolean",
+			sqlType: "BOOLEAN",
+			using:   `"views" <> 0`,
+		},
+		{
+			name:      "text to boolean",
+			from:      "text",
+			to:        "bool",
+			sqlType:   "BOOLEAN",
+			using:     `lower(btrim("views"::text))::boolean`,
+			failCheck: `lower(btrim("views"::text)) NOT IN ('t', 'true', 'y', 'yes', 'on', '1', 'f', 'false', 'n', 'no', 'off', '0')`,
+		},
+		{
+			name:      "integer to varchar checks length",
+			from:      "integer",
+			to:        "string",
+			sqlType:   "VARCHAR(255)",
+			using:     `"views"::text`,
+			failCheck: `length("views"::text) > 255`,
+		},
+		{
+			name:    "timestamp to date",
+			from:    "timestamp",
+			to:      "date",
+			sqlType: "DATE",
+			using:   `"views"::DATE`,
+			lossy:   true,
+		},
+		{
+			name:      "text to timestamp",
+			from:      "text",
+			to:        "datetime",
+			sqlType:   "TIMESTAMP",
+			using:     `btrim("views"::text)::TIMESTAMP`,
+			failCheck: `btrim("views"::text) !~ '` + timestampPattern + `' OR NOT can_cast_to(btrim("views"::text), 'TIMESTAMP')`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conversion, err := planTypeConversion("views", tt.from, nil, tt.to, nil, tt.sqlType)
+			require.NoError(t, err)
+			assert.Equal(t, tt.using, conversion.Using)
+			assert.Equal(t, tt.failCheck, conversion.failCheck)
+			assert.Equal(t, tt.lossy, conversion.Lossy)
+		})
+	}
+}
+
+func TestPlanTypeConversion_VarcharLength(t *testing.T) {
+	conversion, err := planTypeConversion("title", "string", intPtr(255), "string", intPtr(100), "VARCHAR(100)")
+	require.NoError(t, err)
+	assert.Equal(t, "string(255)", conversion.From)
+	assert.Equal(t, "string(100)", conversion.To)
+	assert.Empty(t, conversion.Using)
+	assert.Equal(t, `length("title"::text) > 100`, conversion.failCheck)
+
+	// Widening never fails
+	conversion, err = planTypeConversion("title", "string", intPtr(100), "text", nil, "TEXT")
+	require.NoError(t, err)
+	assert.Empty(t, conversion.failCheck)
+}
+
+func TestPlanTypeConversion_Unsupported(t *testing.T) {
+	_, err := planTypeConversion("flag", "boolean", nil, "uuid", nil, "UUID")
+	assert.ErrorIs(t, err, errUnsupportedConversion)
+	assert.ErrorContains(t, err, "from boolean to uuid")
+
+	_, err = planTypeConversion("created", "integer", nil, "date", nil, "DATE")
+	assert.ErrorIs(t, err, errUnsupportedConversion)
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"database/sql"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
@@ -15,6 +17,8 @@ type DashboardHandler struct {
 	db             *sql.DB
 	authMiddleware gin.HandlerFunc
 	optionsHandler gin.HandlerFunc
+	// items renders the $display of the items activity refers to
+	items *ItemsHandler
 }
 
 // NewDashboardHandler creates a new dashboard handler
@@ -23,6 +27,7 @@ func NewDashboardHandler(server ServerInterface) *DashboardHandler {
 		db:             server.GetDB(),
 		authMiddleware: server.AuthMiddleware(),
 		optionsHandler: server.OptionsHandler(),
+		items:          NewItemsHandler(server),
 	}
 }
 
@@ -35,9 +40,9 @@ func (h *DashboardHandler) SetupRoutes(v1 *gin.RouterGroup) {
 	v1.OPTIONS("/dashboard/users", h.optionsHandler)
 	v1.OPTIONS("/dashboard/collections", h.optionsHandler)
 
-	// Dashboard routes (protected)
+	// Dashboard routes (protected, admin app only)
 	dashboard := v1.Group("/dashboard")
-	dashboard.Use(h.authMiddleware)
+	dashboard.Use(h.authMiddleware, requireAppAccess())
 	{
 		dashboard.GET("", h.getDashboardOverview)
 		dashboard.GET("/stats", h.getSystemStats)
@@ -90,6 +95,8 @@ type ActivityItem struct {
 	Comment    *string   `json:"comment"`
 	Timestamp  time.Time `json:"timestamp"`
 	IP         *string   `json:"ip"`
+	// Display is the item's display template, filled in with display=true
+	Display *string `json:"$display,omitempty"`
 }
 
 type UserSummary struct {
@@ -120,12 +127,6 @@ type SystemHealth struct {
 	LastBackup        *time.Time `json:"last_backup"`
 }
 
-// isAdmin checks if the requesting user is an admin
-func (h *DashboardHandler) isAdmin(c *gin.Context) bool {
-	currentUserRole := c.GetString("user_role")
-	return currentUserRole == "Administrator"
-}
-
 // GetDashboardOverview retrieves comprehensive dashboard overview data
 //
 //	@Summary		Get dashboard overview
@@ -149,7 +150,7 @@ func (h *DashboardHandler) getDashboardOverview(c *gin.Context) {
 
 	// Only fetch user insights for admins
 	var userInsights *UserInsights
-	if h.isAdmin(c) {
+	if isAdmin(c) {
 		insights, err := h.getUserInsightsData()
 		if err != nil {
 			logrus.WithError(err).Error("Error fetching user insights")
@@ -218,6 +219,7 @@ func (h *DashboardHandler) getSystemStats(c *gin.Context) {
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			limit	query		int						false	"Maximum number of activity items to return (1-100, default: 20)"
+//	@Param			display	query		bool					false	"Add the $display title of the item each entry refers to"
 //	@Success		200		{object}	map[string][]ActivityItem	"Recent activity data"
 //	@Failure		401		{object}	map[string]string			"Unauthorized"
 //	@Failure		500		{object}	map[string]string			"Internal server error"
@@ -238,6 +240,14 @@ func (h *DashboardHandler) getRecentActivity(c *gin.Context) {
 		return
 	}
 
+	if c.Query("display") == "true" {
+		if err := h.addActivityDisplay(c, activity); err != nil {
+			logrus.WithError(err).Error("Error rendering activity display templates")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"data": activity})
 }
 
@@ -256,7 +266,7 @@ func (h *DashboardHandler) getRecentActivity(c *gin.Context) {
 //	@Router			/dashboard/users [get]
 func (h *DashboardHandler) getUserInsights(c *gin.Context) {
 	// Check if user is admin
-	if !h.isAdmin(c) {
+	if !isAdmin(c) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
 		return
 	}
@@ -567,6 +577,99 @@ func (h *DashboardHandler) getRecentActivityData(limit int) ([]ActivityItem, err
 	return activities, nil
 }
 
+// addActivityDisplay sets $display on the entries that refer to an item of a
+// collection, rendered as the items endpoints render it. The items of each
+// collection are loaded in one query; entries whose collection or item no
+// longer exists are left without one.
+func (h *DashboardHandler) addActivityDisplay(c *gin.Context, activity []ActivityItem) error {
+	entries := make(map[string][]int)
+	var collections []string
+	for i, entry := range activity {
+		if entry.Collection == nil || entry.Item == nil {
+			continue
+		}
+		if _, ok := entries[*entry.Collection]; !ok {
+			collections = append(collections, *entry.Collection)
+		}
+		entries[*entry.Collection] = append(entries[*entry.Collection], i)
+	}
+
+	for _, name := range collections {
+		collection, err := h.items.getItemCollection(name)
+		if err == sql.ErrNoRows {
+			// System tables and dropped collections have no display template
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		seen := make(map[string]bool)
+		var ids []string
+		for _, i := range entries[name] {
+			if id := *activity[i].Item; !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+
+		items, err := h.loadActivityItems(name, ids)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			continue
+		}
+
+		fields, err := h.items.getFieldsByCollection(name)
+		if err != nil {
+			return err
+		}
+		for i := range items {
+			items[i] = outputItem(c, fields, items[i])
+		}
+		if err := h.items.addDisplayValues(c, collection, items); err != nil {
+			return err
+		}
+
+		byID := make(map[string]string, len(items))
+		for _, item := range items {
+			byID[fmt.Sprint(item["id"])] = item[displayKey].(string)
+		}
+		for _, i := range entries[name] {
+			if display, ok := byID[*activity[i].Item]; ok {
+				activity[i].Display = &display
+			}
+		}
+	}
+
+	return nil
+}
+
+// loadActivityItems loads the items of a collection with the given IDs. The
+// IDs take the type of the id column, so its index is used.
+func (h *DashboardHandler) loadActivityItems(collection string, ids []string) ([]Item, error) {
+	rows, err := h.db.Query(fmt.Sprintf(`SELECT * FROM "%s" WHERE id = ANY($1)`, collection), pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	for rows.Next() {
+		item, err := scanItemRow(rows, columns)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
 func (h *DashboardHandler) getSystemHealthData() SystemHealth {
 	health := SystemHealth{
 		DatabaseConnected: true, // If we reach here, DB is connected
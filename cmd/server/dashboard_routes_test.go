@@ -11,10 +11,13 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+
+	"gorectus/internal/hooks"
 )
 
 type DashboardHandlersTestSuite struct {
@@ -44,10 +47,22 @@ func (m *mockDashboardServerInterface) AuthMiddleware() gin.HandlerFunc {
 		c.Set("user_id", "550e8400-e29b-41d4-a716-446655440000")
 		c.Set("user_email", "admin@example.com")
 		c.Set("user_role", "Administrator")
+		c.Set("admin_access", true)
+		c.Set("app_access", true)
 		c.Next()
 	}
 }
 
+func (m *mockDashboardServerInterface) OptionalAuthMiddleware() gin.HandlerFunc {
+	return m.AuthMiddleware()
+}
+
+func (m *mockDashboardServerInterface) InvalidateRoleAccess() {}
+
+func (m *mockDashboardServerInterface) Webhooks() *webhookDispatcher { return nil }
+
+func (m *mockDashboardServerInterface) Events() *hooks.Bus { return nil }
+
 func (m *mockDashboardServerInterface) OptionsHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
@@ -97,6 +112,8 @@ func (suite *DashboardHandlersTestSuite) createAuthenticatedRequest(method, url
 		c.Set("user_id", userID)
 		c.Set("user_email", "test@example.com")
 		c.Set("user_role", role)
+		c.Set("admin_access", role == "Administrator")
+		c.Set("app_access", true)
 		c.Next()
 	}
 
@@ -334,6 +351,48 @@ func (suite *DashboardHandlersTestSuite) TestGetRecentActivity_WithLimit() {
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
 }
 
+// Test GetRecentActivity with the $display of the items entries refer to
+func (suite *DashboardHandlersTestSuite) TestGetRecentActivity_WithDisplay() {
+	suite.mock.ExpectQuery("SELECT a.id, a.action, a.user_id, u.first_name, u.last_name,.*FROM activity a.*LEFT JOIN users u ON a.user_id = u.id.*ORDER BY a.timestamp DESC.*LIMIT \\$1").
+		WithArgs(20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "action", "user_id", "first_name", "last_name", "collection", "item", "comment", "timestamp", "ip"}).
+			AddRow("activity-1", "create", "user-1", "John", "Doe", "posts", "post-1", nil, time.Now(), "127.0.0.1").
+			AddRow("activity-2", "update", "user-1", "John", "Doe", "posts", "post-1", nil, time.Now(), "127.0.0.1").
+			AddRow("activity-3", "delete", "user-1", "John", "Doe", "posts", "post-2", nil, time.Now(), "127.0.0.1").
+			AddRow("activity-4", "update", "user-1", "John", "Doe", "users", "user-2", nil, time.Now(), "127.0.0.1").
+			AddRow("activity-5", "login", "user-1", "John", "Doe", nil, nil, nil, time.Now(), "127.0.0.1"))
+
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WithArgs("posts").
+		WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, "{{title}}", true, true))
+	// Each item is loaded once, and deleted ones are missing
+	suite.mock.ExpectQuery(`SELECT \* FROM "posts" WHERE id = ANY\(\$1\)`).
+		WithArgs(pq.Array([]string{"post-1", "post-2"})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow("post-1", "Hello"))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows().
+		AddRow("title", false, nil, nil, false, false, nil, nil, "character varying", 255, "YES"))
+	// System tables have no display template
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WithArgs("users").
+		WillReturnError(sql.ErrNoRows)
+
+	req, router := suite.createAuthenticatedRequest("GET", "/api/v1/dashboard/activity?display=true", nil, "admin-id", "Administrator")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+
+	var response struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	suite.Require().Len(response.Data, 5)
+	assert.Equal(suite.T(), "Hello", response.Data[0]["$display"])
+	assert.Equal(suite.T(), "Hello", response.Data[1]["$display"])
+	for _, entry := range response.Data[2:] {
+		assert.NotContains(suite.T(), entry, "$display")
+	}
+}
+
 // Test GetUserInsights endpoint
 func (suite *DashboardHandlersTestSuite) TestGetUserInsights_AsAdmin() {
 	// Mock user insights queries
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/schema"
+)
+
+// displayKey is the item key the rendered display template is returned under
+const displayKey = "$display"
+
+// displayPlaceholder matches {{field}} and {{relation.field}} in display templates
+var displayPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+(?:\.[A-Za-z0-9_]+)*)\s*\}\}`)
+
+// displaySystemTables are the tables outside of collections that display
+// templates may follow relations to, with the columns they may show
+var displaySystemTables = map[string][]string{
+	"users": {"id", "first_name", "last_name", "email"},
+	"files": {"id", "title", "filename_download", "type"},
+}
+
+// templatePaths returns the field paths a display template refers to
+func templatePaths(template string) [][]string {
+	var paths [][]string
+	for _, match := range displayPlaceholder.FindAllStringSubmatch(template, -1) {
+		paths = append(paths, strings.Split(match[1], "."))
+	}
+	return paths
+}
+
+// renderDisplayTemplate fills in the placeholders of a display template.
+// Relations must already be expanded into nested items; missing values render
+// as an empty string.
+func renderDisplayTemplate(template string, item Item) string {
+	return displayPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		path := strings.Split(displayPlaceholder.FindStringSubmatch(placeholder)[1], ".")
+
+		var value interface{} = item
+		for _, segment := range path {
+			nested, ok := value.(Item)
+			if !ok {
+				return ""
+			}
+			value = nested[segment]
+		}
+		return displayValue(value)
+	})
+}
+
+// displayValue formats a single value for a display template
+func displayValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case Item:
+		// A relation without a field, such as {{author}}
+		return displayValue(v["id"])
+	case map[string]interface{}, []interface{}:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// applyDisplay adds $display to items when the request asks for it with
+// display=true.
+func (h *ItemsHandler) applyDisplay(c *gin.Context, collection *ItemCollection, items []Item) bool {
+	if c.Query("display") != "true" {
+		return true
+	}
+
+	if err := h.addDisplayValues(c, collection, items); err != nil {
+		logrus.WithError(err).Error("Error rendering display template")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+	return true
+}
+
+// addDisplayValues sets $display on each item from the collection's display
+// template, or the item ID when it has none. Many-to-one relations are
+// followed for authenticated requests only, since related collections aren't
+// covered by the Public role's permission.
+func (h *ItemsHandler) addDisplayValues(c *gin.Context, collection *ItemCollection, items []Item) error {
+	if collection.DisplayTemplate == nil || *collection.DisplayTemplate == "" {
+		for _, item := range items {
+			if item != nil {
+				item[displayKey] = displayValue(item["id"])
+			}
+		}
+		return nil
+	}
+
+	template := *collection.DisplayTemplate
+	contexts := items
+	if !c.GetBool("is_public") {
+		var err error
+		contexts, err = h.expandRelations(collection.Collection, items, templatePaths(template))
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, item := range items {
+		if item != nil {
+			item[displayKey] = renderDisplayTemplate(template, contexts[i])
+		}
+	}
+	return nil
+}
+
+// expandRelations returns copies of items in which the many-to-one fields
+// that paths follow are replaced by the related items, loaded in one query per
+// relation and expanded recursively for longer paths
+func (h *ItemsHandler) expandRelations(table string, items []Item, paths [][]string) ([]Item, error) {
+	relations := make(map[string][][]string)
+	for _, path := range paths {
+		if len(path) > 1 {
+			relations[path[0]] = append(relations[path[0]], path[1:])
+		}
+	}
+	if len(relations) == 0 {
+		return items, nil
+	}
+
+	foreignKeys, err := schema.ForeignKeys(h.db, table)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(relations))
+	for field := range relations {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	expanded := make([]Item, len(items))
+	for i, item := range items {
+		if item == nil {
+			continue
+		}
+		expanded[i] = make(Item, len(item))
+		for key, value := range item {
+			expanded[i][key] = value
+		}
+	}
+
+	for _, field := range fields {
+		key, ok := foreignKeys[field]
+		if !ok {
+			continue
+		}
+
+		related, err := h.loadRelatedItems(key, items, field)
+		if err != nil {
+			return nil, err
+		}
+		if len(related) == 0 {
+			continue
+		}
+
+		rows := make([]Item, 0, len(related))
+		for _, row := range related {
+			rows = append(rows, row)
+		}
+		rows, err = h.expandRelations(key.Table, rows, relations[field])
+		if err != nil {
+			return nil, err
+		}
+
+		byKey := make(map[string]Item, len(rows))
+		for _, row := range rows {
+			byKey[fmt.Sprint(row[key.Column])] = row
+		}
+		for _, item := range expanded {
+			if item == nil || item[field] == nil {
+				continue
+			}
+			if row, ok := byKey[fmt.Sprint(item[field])]; ok {
+				item[field] = row
+			}
+		}
+	}
+
+	return expanded, nil
+}
+
+// loadRelatedItems loads the rows a many-to-one field of items points to.
+// Only collections and the displayable system tables are followed, and their
+// hidden and hashed fields are left out.
+func (h *ItemsHandler) loadRelatedItems(key schema.ForeignKey, items []Item, field string) (map[string]Item, error) {
+	allowedColumns, isSystemTable := displaySystemTables[key.Table]
+	var relatedFields []FieldInfo
+	if !isSystemTable {
+		// Tables outside of collections are never shown
+		if _, err := h.getItemCollection(key.Table); err == sql.ErrNoRows {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		var err error
+		if relatedFields, err = h.getFieldsByCollection(key.Table); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := make(map[string]bool)
+	var values []string
+	for _, item := range items {
+		if item == nil || item[field] == nil {
+			continue
+		}
+		value := fmt.Sprint(item[field])
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	// The values are cast to the column's type rather than the column to
+	// text, so its index can be used
+	rows, err := h.db.Query(fmt.Sprintf(`SELECT * FROM "%s" WHERE "%s" = ANY($1::"%s"[])`, key.Table, key.Column, key.Type),
+		pq.Array(values))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	related := make(map[string]Item)
+	for rows.Next() {
+		row, err := scanItemRow(rows, columns)
+		if err != nil {
+			return nil, err
+		}
+
+		if isSystemTable {
+			allowed := make(Item, len(allowedColumns))
+			for _, column := range allowedColumns {
+				allowed[column] = row[column]
+			}
+			allowed[key.Column] = row[key.Column]
+			row = allowed
+		} else {
+			row = hideFields(relatedFields, redactHashes(relatedFields, row))
+		}
+		related[fmt.Sprint(row[key.Column])] = row
+	}
+
+	return related, rows.Err()
+}
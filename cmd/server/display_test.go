@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplatePaths(t *testing.T) {
+	paths := templatePaths("{{first_name}} {{ last_name }} ({{author.company.name}})")
+
+	assert.Equal(t, [][]string{{"first_name"}, {"last_name"}, {"author", "company", "name"}}, paths)
+	assert.Empty(t, templatePaths("No placeholders"))
+}
+
+func TestRenderDisplayTemplate(t *testing.T) {
+	item := Item{
+		"first_name": "Ada",
+		"last_name":  "Lovelace",
+		"age":        float64(36),
+		"author":     Item{"id": "u1", "name": "Charles"},
+		"editor":     "u2",
+	}
+
+	assert.Equal(t, "Ada Lovelace", renderDisplayTemplate("{{first_name}} {{last_name}}", item))
+	assert.Equal(t, "36 by Charles", renderDisplayTemplate("{{age}} by {{author.name}}", item))
+	assert.Equal(t, "u1", renderDisplayTemplate("{{author}}", item))
+	// Relations that weren't expanded and missing fields render as nothing
+	assert.Equal(t, "[]", renderDisplayTemplate("[{{editor.name}}{{missing}}]", item))
+}
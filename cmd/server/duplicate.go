@@ -0,0 +1,415 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/hooks"
+	"gorectus/internal/schema"
+)
+
+// duplicationPlan lists what item_duplication_fields copies: fields of the
+// item itself, and fields of related collections whose rows point to the item
+type duplicationPlan struct {
+	fields []string
+	// related maps a collection to its copied fields; "*" copies every field
+	related map[string][]string
+}
+
+// copies reports whether the plan copies a field of the item itself
+func (p duplicationPlan) copies(field string) bool {
+	for _, name := range p.fields {
+		if name == field || name == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDuplicationFields splits item_duplication_fields entries such as
+// "title" and "comments.text" or "comments.*" into a duplication plan
+func parseDuplicationFields(entries []string) duplicationPlan {
+	plan := duplicationPlan{related: make(map[string][]string)}
+	for _, entry := range entries {
+		collection, field, nested := strings.Cut(entry, ".")
+		if !nested {
+			plan.fields = append(plan.fields, entry)
+			continue
+		}
+		plan.related[collection] = append(plan.related[collection], field)
+	}
+	return plan
+}
+
+// copyFields copies the listed fields of source, or all of them for "*".
+// The id, timestamps and the given extra columns are never copied.
+func copyFields(source Item, fields []string, exclude ...string) Item {
+	copied := make(Item)
+	for _, field := range fields {
+		if field == "*" {
+			for key, value := range source {
+				copied[key] = value
+			}
+			continue
+		}
+		if value, ok := source[field]; ok {
+			copied[field] = value
+		}
+	}
+
+	for _, column := range schema.SystemColumns {
+		delete(copied, column)
+	}
+	for _, column := range exclude {
+		delete(copied, column)
+	}
+
+	// Values are copied in their JSON form, as a client would send them, so
+	// copies are checked like created items
+	encoded, err := json.Marshal(copied)
+	if err != nil {
+		return copied
+	}
+	var decoded Item
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return copied
+	}
+	return decoded
+}
+
+// DuplicateItem copies an item
+//
+//	@Summary		Duplicate an item
+//	@Description	Create a copy of an item with the fields listed in the collection's item_duplication_fields, including related items listed as "collection.field"
+//	@Tags			items
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			collection	path		string		true	"Collection name"
+//	@Param			id			path		string		true	"Item ID"
+//	@Success		201			{object}	ItemModel	"Created copy"
+//	@Failure		400			{object}	ErrorResponse	"Invalid duplication fields or singleton collection"
+//	@Failure		401			{object}	ErrorResponse	"Unauthorized"
+//	@Failure		404			{object}	ErrorResponse	"Item not found"
+//	@Failure		500			{object}	ErrorResponse	"Internal server error"
+//	@Router			/items/{collection}/{id}/duplicate [post]
+func (h *ItemsHandler) duplicateItem(c *gin.Context) {
+	// Only admins can create items
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	collectionName := c.Param("collection")
+	itemID := c.Param("id")
+
+	collection, ok := h.resolveCollection(c, collectionName)
+	if !ok {
+		return
+	}
+
+	if collection.Singleton {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Singleton items can't be duplicated"})
+		return
+	}
+
+	source, err := h.getItemByID(collectionName, itemID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while fetching item")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	fields, err := h.getFieldsByCollection(collectionName)
+	if err != nil {
+		logrus.WithError(err).Error("Error getting collection fields")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	plan := parseDuplicationFields(collection.DuplicationFields)
+
+	// Related collections must point to this one through exactly one column
+	relatedNames := make([]string, 0, len(plan.related))
+	for name := range plan.related {
+		relatedNames = append(relatedNames, name)
+	}
+	sort.Strings(relatedNames)
+
+	parentColumns := make(map[string]string, len(relatedNames))
+	relatedCollections := make(map[string]*ItemCollection, len(relatedNames))
+	for _, name := range relatedNames {
+		related, err := h.getItemCollection(name)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Related collection '%s' does not exist", name)})
+			return
+		} else if err != nil {
+			logrus.WithError(err).Error("Database error while checking related collection")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		relatedCollections[name] = related
+
+		columns, err := schema.ReferencingColumns(h.db, name, collectionName)
+		if err != nil {
+			logrus.WithError(err).Error("Database error while loading relations")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if len(columns) != 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Collection '%s' must reference '%s' through exactly one column", name, collectionName),
+			})
+			return
+		}
+		parentColumns[name] = columns[0]
+	}
+
+	// The junction rows of listed files and translations fields are copied
+	// like related items, in full
+	for _, field := range fields {
+		if !hasJunction(field.Special) || !plan.copies(field.Field) {
+			continue
+		}
+		junction := junctionCollection(collectionName, field.Field)
+		if _, listed := plan.related[junction]; listed {
+			continue
+		}
+		related, err := h.getItemCollection(junction)
+		if err != nil {
+			logrus.WithError(err).WithField("junction", junction).Error("Database error while loading junction collection")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		relatedNames = append(relatedNames, junction)
+		relatedCollections[junction] = related
+		parentColumns[junction] = junctionParentField(collectionName)
+		plan.related[junction] = []string{"*"}
+	}
+
+	unique, err := schema.UniqueColumns(h.db, append([]string{collectionName}, relatedNames...))
+	if err != nil {
+		logrus.WithError(err).Error("Database error while loading unique columns")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	exclude := generatedColumns(fields, unique[collectionName])
+	if collection.SortField != nil {
+		exclude = append(exclude, *collection.SortField)
+	}
+
+	data := copyFields(source, plan.fields, exclude...)
+	event := requestEvent(c, scopeItems, collectionName, hooks.ActionCreate, data)
+	if !runFilterHooks(c, h.events, &event) {
+		return
+	}
+	data = event.Payload
+	fields, writeErr, err := prepareCopy(fields, data, userID)
+	if writeErr != nil {
+		c.JSON(http.StatusBadRequest, writeErr.response())
+		return
+	} else if err != nil {
+		logrus.WithError(err).Error("Error applying special field behaviors")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process item"})
+		return
+	}
+
+	// Related copies go through the same checks before anything is written.
+	// Their parent column points to the source item until the copy exists.
+	relatedCopies := make(map[string][]Item, len(relatedNames))
+	for _, name := range relatedNames {
+		parentColumn := parentColumns[name]
+		relatedFields, err := h.getFieldsByCollection(name)
+		if err != nil {
+			logrus.WithError(err).WithField("related_collection", name).Error("Error getting related collection fields")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		rows, err := h.relatedRows(relatedCollections[name], parentColumn, itemID)
+		if err != nil {
+			logrus.WithError(err).WithField("related_collection", name).Error("Database error while fetching related items")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		relatedExclude := append(generatedColumns(relatedFields, unique[name]), parentColumn)
+		for _, row := range rows {
+			copied := copyFields(row, plan.related[name], relatedExclude...)
+			copied[parentColumn] = itemID
+			if _, writeErr, err := prepareCopy(relatedFields, copied, userID); writeErr != nil {
+				response := writeErr.response()
+				response["collection"] = name
+				c.JSON(http.StatusBadRequest, response)
+				return
+			} else if err != nil {
+				logrus.WithError(err).Error("Error applying special field behaviors")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process item"})
+				return
+			}
+			relatedCopies[name] = append(relatedCopies[name], copied)
+		}
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to start transaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	newID, err := insertItemRow(tx, collectionName, data)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while duplicating item")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	for _, name := range relatedNames {
+		for _, copied := range relatedCopies[name] {
+			copied[parentColumns[name]] = newID
+			if _, err := insertItemRow(tx, name, copied); err != nil {
+				logrus.WithError(err).WithField("related_collection", name).Error("Database error while duplicating related items")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+				return
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logrus.WithError(err).Error("Failed to commit duplicate")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	item, err := h.getItemByID(collectionName, newID)
+	if err != nil {
+		logrus.WithError(err).Error("Error fetching duplicated item")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"collection": collectionName,
+		"item_id":    itemID,
+		"copy_id":    newID,
+	}).Info("Item duplicated successfully")
+	emitActionHooks(c, h.events, scopeItems, collectionName, hooks.ActionCreate, eventItem(fields, item), newID)
+
+	c.JSON(http.StatusCreated, gin.H{"data": outputItem(c, fields, item)})
+}
+
+// generatedColumns lists the fields a copy must not take from its source:
+// unique columns, which would conflict, and fields whose special hooks fill
+// them in on create, such as uuid and date-created
+func generatedColumns(fields []FieldInfo, unique []string) []string {
+	columns := append([]string(nil), unique...)
+	for _, field := range fields {
+		if specialProvidesValue(field) {
+			columns = append(columns, field.Field)
+		}
+	}
+	return columns
+}
+
+// prepareCopy runs the checks and special hooks of an item create on a copy,
+// which is changed in place. Copied hashes are kept as they are rather than
+// hashed again. It returns the fields with their conditions resolved.
+func prepareCopy(fields []FieldInfo, data Item, userID string) ([]FieldInfo, *itemWriteError, error) {
+	resolved, writeErr := checkItemWrite(fields, data, nil)
+	if writeErr != nil {
+		return nil, writeErr, nil
+	}
+
+	hashes := make(Item)
+	for _, field := range resolved {
+		if value, ok := data[field.Field]; ok && hasSpecial(field.Special, specialHash) {
+			hashes[field.Field] = value
+			delete(data, field.Field)
+		}
+	}
+	if err := applySpecials(resolved, data, actionCreate, userID); err != nil {
+		return nil, nil, err
+	}
+	for field, value := range hashes {
+		data[field] = value
+	}
+	return resolved, nil, nil
+}
+
+// relatedRows returns the rows of a related collection that point to the
+// source item, in creation order
+func (h *ItemsHandler) relatedRows(collection *ItemCollection, parentColumn, sourceID string) ([]Item, error) {
+	rows, err := h.db.Query(fmt.Sprintf(`SELECT * FROM "%s" WHERE "%s" = $1 ORDER BY %s`,
+		collection.Collection, parentColumn, collection.creationOrder()), sourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var related []Item
+	for rows.Next() {
+		item, err := scanItemRow(rows, columns)
+		if err != nil {
+			return nil, err
+		}
+		related = append(related, item)
+	}
+	return related, rows.Err()
+}
+
+// insertItemRow inserts data as a new row and returns its ID
+func insertItemRow(tx *sql.Tx, collectionName string, data Item) (string, error) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	query := fmt.Sprintf(`INSERT INTO "%s" DEFAULT VALUES RETURNING id`, collectionName)
+	values := make([]interface{}, 0, len(keys))
+	if len(keys) > 0 {
+		columns := make([]string, 0, len(keys))
+		placeholders := make([]string, 0, len(keys))
+		for i, key := range keys {
+			columns = append(columns, fmt.Sprintf(`"%s"`, key))
+			placeholders = append(placeholders, fmt.Sprintf("$%d", i+1))
+			values = append(values, columnValue(data[key]))
+		}
+		query = fmt.Sprintf(`INSERT INTO "%s" (%s) VALUES (%s) RETURNING id`,
+			collectionName, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	}
+
+	var id string
+	err := tx.QueryRow(query, values...).Scan(&id)
+	return id, err
+}
+
+// columnValue converts an item value to a database value. Objects and arrays
+// are stored as JSON.
+func columnValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}, []interface{}:
+		jsonBytes, _ := json.Marshal(v)
+		return jsonBytes
+	default:
+		return v
+	}
+}
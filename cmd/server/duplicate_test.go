@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDuplicationFields(t *testing.T) {
+	plan := parseDuplicationFields([]string{"title", "comments.*", "tags", "comments.body"})
+
+	assert.Equal(t, []string{"title", "tags"}, plan.fields)
+	assert.Equal(t, map[string][]string{"comments": {"*", "body"}}, plan.related)
+}
+
+func TestCopyFields(t *testing.T) {
+	source := Item{"id": "1", "created_at": "2024-01-01", "title": "Hello", "sort": 3, "body": "Text"}
+
+	assert.Equal(t, Item{"title": "Hello"}, copyFields(source, []string{"title", "missing", "id"}))
+	assert.Equal(t, Item{"title": "Hello", "body": "Text"}, copyFields(source, []string{"*"}, "sort"))
+	assert.Empty(t, copyFields(source, nil))
+
+	// Values are copied as a client would send them
+	assert.Equal(t, Item{"views": float64(3)}, copyFields(Item{"views": int64(3)}, []string{"views"}))
+}
+
+func TestGeneratedColumns(t *testing.T) {
+	fields := []FieldInfo{
+		{Field: "title"},
+		{Field: "key", Special: []string{specialUUID}},
+		{Field: "owner", Special: []string{specialUserCreated}},
+		{Field: "pin", Special: []string{specialHash}},
+	}
+
+	assert.Equal(t, []string{"slug", "key", "owner"}, generatedColumns(fields, []string{"slug"}))
+}
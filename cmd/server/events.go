@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/hooks"
+)
+
+// Scopes of the hook events emitted by the handlers. Items events carry the
+// item's collection; the other scopes use the scope as collection.
+const (
+	scopeItems       = "items"
+	scopeCollections = "collections"
+	scopeFields      = "fields"
+	scopeUsers       = "users"
+	scopeRoles       = "roles"
+	scopeFiles       = "files"
+	scopeFolders     = "folders"
+	scopeSettings    = "settings"
+	scopeWebhooks    = "webhooks"
+)
+
+// requestEvent creates the hook event of a change made by the current request
+func requestEvent(c *gin.Context, scope, collection, action string, payload map[string]interface{}, keys ...string) hooks.Event {
+	event := hooks.NewEvent(scope, collection, action, payload, keys...)
+	event.Accountability = requestAccountability(c)
+	return event
+}
+
+// requestAccountability identifies the user making the current request
+func requestAccountability(c *gin.Context) hooks.Accountability {
+	return hooks.Accountability{
+		User:  c.GetString("user_id"),
+		Role:  c.GetString("role_id"),
+		Admin: isAdmin(c),
+	}
+}
+
+// runFilterHooks runs the filter hooks of an event, writing the error
+// response when one rejects the change
+func runFilterHooks(c *gin.Context, events *hooks.Bus, event *hooks.Event) bool {
+	err := events.RunFilters(c.Request.Context(), event)
+	if err == nil {
+		return true
+	}
+	if reject, ok := hooks.IsReject(err); ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": reject.Message})
+		return false
+	}
+	logrus.WithError(err).WithField("event", event.Name).Error("Filter hook failed")
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Hook failed"})
+	return false
+}
+
+// filterRequestBody runs the filter hooks of an event on the JSON object in
+// the request body and replaces the body with the filtered payload, so the
+// handler binds and validates what the filters left. Bodies that aren't
+// JSON objects are left for the handler to reject.
+func filterRequestBody(c *gin.Context, events *hooks.Bus, event hooks.Event) bool {
+	if !events.HasFilters(event.Name) || c.Request.Body == nil {
+		return true
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return false
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil || payload == nil {
+		return true
+	}
+	event.Payload = payload
+	if !runFilterHooks(c, events, &event) {
+		return false
+	}
+
+	filtered, err := json.Marshal(event.Payload)
+	if err != nil {
+		logrus.WithError(err).WithField("event", event.Name).Error("Filter hook left an invalid payload")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Hook failed"})
+		return false
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(filtered))
+	return true
+}
+
+// emitActionHooks runs the action hooks of a committed change. The record
+// written, if any, becomes the event payload.
+func emitActionHooks(c *gin.Context, events *hooks.Bus, scope, collection, action string, record interface{}, keys ...string) {
+	if events == nil {
+		return
+	}
+	events.Emit(requestEvent(c, scope, collection, action, eventPayload(record), keys...))
+}
+
+// eventPayload converts a record to an event payload
+func eventPayload(record interface{}) map[string]interface{} {
+	switch value := record.(type) {
+	case nil:
+		return nil
+	case Item:
+		return value
+	case map[string]interface{}:
+		return value
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to convert record to event payload")
+		return nil
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil
+	}
+	return payload
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gorectus/internal/hooks"
+)
+
+// serveWithHooks serves a request to the item routes as an admin, running
+// the hooks registered on events
+func serveWithHooks(t *testing.T, db *sql.DB, events *hooks.Bus, method, url, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	logrus.SetLevel(logrus.PanicLevel)
+	router := gin.New()
+	NewItemsHandler(&mockItemServerInterface{db: db, events: events}).SetupRoutes(router.Group("/api/v1"))
+
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestItemHooks_FilterAndAction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	events := hooks.New()
+	events.Filter("items.create", func(ctx context.Context, event *hooks.Event) error {
+		event.Payload["title"] = strings.ToUpper(event.Payload["title"].(string))
+		return nil
+	})
+	var created hooks.Event
+	events.Action("items.*", func(ctx context.Context, event hooks.Event) {
+		created = event
+	})
+
+	mock.ExpectQuery("FROM collections WHERE collection").
+		WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows().
+		AddRow("title", true, nil, nil, false, false, nil, nil, "character varying", 255, "NO"))
+	// The filtered payload is validated and written
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "articles"`).WithArgs("HELLO").WillReturnRows(
+		sqlmock.NewRows([]string{"id"}).AddRow("item-1"))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT \* FROM "articles"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "title"}).AddRow("item-1", "HELLO"))
+
+	w := serveWithHooks(t, db, events, "POST", "/api/v1/items/articles", `{"title":"hello"}`)
+	events.Wait()
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, "items.create", created.Name)
+	assert.Equal(t, "articles", created.Collection)
+	assert.Equal(t, []string{"item-1"}, created.Keys)
+	assert.Equal(t, "HELLO", created.Payload["title"])
+	assert.Equal(t, "550e8400-e29b-41d4-a716-446655440000", created.Accountability.User)
+	assert.True(t, created.Accountability.Admin)
+}
+
+func TestItemHooks_FilterRejects(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	events := hooks.New()
+	events.Filter("items.delete", func(ctx context.Context, event *hooks.Event) error {
+		return hooks.Reject("%s items are kept", event.Collection)
+	})
+	events.Action("*", func(ctx context.Context, event hooks.Event) {
+		t.Error("action ran for a rejected change")
+	})
+
+	mock.ExpectQuery("FROM collections WHERE collection").
+		WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	mock.ExpectQuery(`SELECT \* FROM "articles"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "title"}).AddRow("item-1", "Hello"))
+
+	w := serveWithHooks(t, db, events, "DELETE", "/api/v1/items/articles/item-1", "")
+	events.Wait()
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "articles items are kept")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestItemHooks_FilterError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	events := hooks.New()
+	events.Filter("items.update", func(ctx context.Context, event *hooks.Event) error {
+		return assert.AnError
+	})
+
+	mock.ExpectQuery("FROM collections WHERE collection").
+		WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	mock.ExpectQuery(`SELECT \* FROM "articles"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "title"}).AddRow("item-1", "Hello"))
+
+	w := serveWithHooks(t, db, events, "PATCH", "/api/v1/items/articles/item-1", `{"title":"Hi"}`)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Archiving runs items.update actions with the archived item
+func TestItemHooks_ArchiveAction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	events := hooks.New()
+	var archived hooks.Event
+	events.Action("items.update", func(ctx context.Context, event hooks.Event) {
+		archived = event
+	})
+
+	mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(archiveCollectionRow())
+	mock.ExpectQuery(`SELECT \* FROM "articles"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "title", "status"}).AddRow("item-1", "Hello", "published"))
+	mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows().
+		AddRow("notes", false, nil, nil, false, true, nil, nil, "text", nil, "YES"))
+	mock.ExpectExec(`UPDATE "articles" SET "status" = \$1`).WithArgs("archived", "item-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "articles"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "title", "status", "notes"}).AddRow("item-1", "Hello", "archived", "internal"))
+
+	w := serveWithHooks(t, db, events, "DELETE", "/api/v1/items/articles/item-1", "")
+	events.Wait()
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []string{"item-1"}, archived.Keys)
+	assert.Equal(t, "archived", archived.Payload["status"])
+	assert.NotContains(t, archived.Payload, "notes", "hidden fields are left out")
+}
+
+func TestFolderHooks_FilterAndAction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	events := hooks.New()
+	events.Filter("folders.create", func(ctx context.Context, event *hooks.Event) error {
+		event.Payload["name"] = strings.ToUpper(event.Payload["name"].(string))
+		return nil
+	})
+	var created hooks.Event
+	events.Action("folders.*", func(ctx context.Context, event hooks.Event) {
+		created = event
+	})
+
+	mock.ExpectQuery(`INSERT INTO folders`).WithArgs("LOGOS", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testFolderID))
+	now := time.Now()
+	mock.ExpectQuery(`FROM folders WHERE id = \$1`).WithArgs(testFolderID).
+		WillReturnRows(folderRows().AddRow(testFolderID, "LOGOS", nil, now, now))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewFoldersHandler(&mockItemServerInterface{db: db, events: events}, nil).SetupRoutes(router.Group("/api/v1"))
+	req := httptest.NewRequest("POST", "/api/v1/folders", strings.NewReader(`{"name":"logos"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	events.Wait()
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, "folders.create", created.Name)
+	assert.Equal(t, "folders", created.Collection)
+	assert.Equal(t, []string{testFolderID}, created.Keys)
+	assert.Equal(t, "LOGOS", created.Payload["name"])
+}
+
+func TestFolderHooks_FilterRejects(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	events := hooks.New()
+	events.Filter("*.delete", func(ctx context.Context, event *hooks.Event) error {
+		return hooks.Reject("%s are kept", event.Collection)
+	})
+
+	now := time.Now()
+	mock.ExpectQuery(`FROM folders WHERE id = \$1`).WithArgs(testFolderID).
+		WillReturnRows(folderRows().AddRow(testFolderID, "Logos", nil, now, now))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewFoldersHandler(&mockItemServerInterface{db: db, events: events}, nil).SetupRoutes(router.Group("/api/v1"))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/v1/folders/"+testFolderID, nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "folders are kept")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Webhook events leave out headers, which are delivered to other webhooks
+func TestWebhookHooks_DeleteAction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	events := hooks.New()
+	var deleted hooks.Event
+	events.Action("webhooks.delete", func(ctx context.Context, event hooks.Event) {
+		deleted = event
+	})
+
+	now := time.Now()
+	mock.ExpectQuery(`FROM webhooks WHERE id = \$1`).WithArgs(testWebhookID).
+		WillReturnRows(webhookRows().AddRow(testWebhookID, "Indexer", "https://hooks.example.com/in", "POST", "active",
+			"{articles}", "{create}", true, []byte(`{"Authorization":"Bearer abc"}`), now, now))
+	mock.ExpectExec(`DELETE FROM webhooks WHERE id = \$1`).WithArgs(testWebhookID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewWebhooksHandler(&mockItemServerInterface{db: db, events: events}).SetupRoutes(router.Group("/api/v1"))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/v1/webhooks/"+testWebhookID, nil))
+	events.Wait()
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []string{testWebhookID}, deleted.Keys)
+	assert.Equal(t, "Indexer", deleted.Payload["name"])
+	assert.NotContains(t, deleted.Payload, "headers")
+}
+
+func TestEventPayload(t *testing.T) {
+	assert.Nil(t, eventPayload(nil))
+	assert.Equal(t, map[string]interface{}{"title": "Hi"}, eventPayload(Item{"title": "Hi"}))
+	payload := eventPayload(&Folder{ID: "f-1", Name: "Logos"})
+	assert.Equal(t, "Logos", payload["name"])
+	assert.Nil(t, payload["parent"])
+}
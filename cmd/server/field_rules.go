@@ -0,0 +1,131 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/schema"
+)
+
+// FieldCondition overrides a field's flags while its rule matches the item.
+// Conditions are stored in fields.conditions and evaluated in order, so a
+// later matching condition wins.
+//
+//	[{"name": "Published", "rule": {"status": {"_eq": "published"}}, "required": true, "readonly": true}]
+type FieldCondition struct {
+	Name     string                 `json:"name"`
+	Rule     map[string]interface{} `json:"rule"`
+	Readonly *bool                  `json:"readonly"`
+	Required *bool                  `json:"required"`
+	Hidden   *bool                  `json:"hidden"`
+}
+
+// resolveFieldConditions returns a copy of fields with the flags of their
+// matching conditions applied for the given item
+func resolveFieldConditions(fields []FieldInfo, item Item) []FieldInfo {
+	resolved := make([]FieldInfo, len(fields))
+	for i, field := range fields {
+		for _, condition := range field.Conditions {
+			matches, err := matchesFilter(condition.Rule, item)
+			if err != nil {
+				logrus.WithError(err).WithFields(logrus.Fields{
+					"field":     field.Field,
+					"condition": condition.Name,
+				}).Warn("Ignoring invalid field condition")
+				continue
+			}
+			if !matches {
+				continue
+			}
+			if condition.Readonly != nil {
+				field.Readonly = *condition.Readonly
+			}
+			if condition.Required != nil {
+				field.Required = *condition.Required
+			}
+			if condition.Hidden != nil {
+				field.Hidden = *condition.Hidden
+			}
+		}
+		resolved[i] = field
+	}
+	return resolved
+}
+
+// readonlyViolations reports the readonly fields present in data
+func readonlyViolations(fields []FieldInfo, data Item) []ValidationError {
+	var violations []ValidationError
+	for _, field := range fields {
+		if _, present := data[field.Field]; present && field.Readonly {
+			violations = append(violations, ValidationError{
+				Field:   field.Field,
+				Message: "Field '" + field.Field + "' is read-only",
+			})
+		}
+	}
+	return violations
+}
+
+// hideFields removes the fields that are hidden for this item, after applying
+// conditions. The default collection columns are always kept so items can
+// still be addressed.
+func hideFields(fields []FieldInfo, item Item) Item {
+	if item == nil {
+		return nil
+	}
+	for _, field := range resolveFieldConditions(fields, item) {
+		if field.Hidden && !schema.IsSystemColumn(field.Field) {
+			delete(item, field.Field)
+		}
+	}
+	return item
+}
+
+// showHidden reports whether hidden fields were requested by someone allowed to see them
+func showHidden(c *gin.Context) bool {
+	return c.Query("show_hidden") == "true" && isAdmin(c)
+}
+
+// outputItem prepares an item for a response. Hashed values are never
+// returned and hidden fields only when an admin asks for them.
+func outputItem(c *gin.Context, fields []FieldInfo, item Item) Item {
+	item = redactHashes(fields, item)
+	if showHidden(c) {
+		return item
+	}
+	return hideFields(fields, item)
+}
+
+// eventItem prepares a copy of an item for an event payload, which reaches
+// webhooks and flows: hashed values and hidden fields are never included
+func eventItem(fields []FieldInfo, item Item) Item {
+	if item == nil {
+		return nil
+	}
+	payload := make(Item, len(item))
+	for key, value := range item {
+		payload[key] = value
+	}
+	return hideFields(fields, redactHashes(fields, payload))
+}
+
+// queryableField returns whether list queries may filter or sort by a field.
+// It needs a column, and values that aren't returned must not be probed
+// through it: hashes, hidden fields and fields the permission doesn't allow.
+func queryableField(c *gin.Context, fields []FieldInfo, permission *ItemPermission) func(string) bool {
+	return func(name string) bool {
+		if !permission.allowsField(name) {
+			return false
+		}
+		if schema.IsSystemColumn(name) {
+			return true
+		}
+		for _, field := range fields {
+			if field.Field == name {
+				return field.Schema != nil && !hasSpecial(field.Special, specialHash) &&
+					(!field.Hidden || showHidden(c))
+			}
+		}
+		return false
+	}
+}
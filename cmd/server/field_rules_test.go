@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveFieldConditions(t *testing.T) {
+	fields := []FieldInfo{
+		{Field: "title"},
+		{Field: "slug", Conditions: []FieldCondition{
+			{Name: "Published", Rule: map[string]interface{}{"status": map[string]interface{}{"_eq": "published"}},
+				Required: boolPtr(true), Readonly: boolPtr(true)},
+			{Name: "Locked", Rule: map[string]interface{}{"locked": map[string]interface{}{"_eq": true}},
+				Readonly: boolPtr(false)},
+			{Name: "Broken", Rule: map[string]interface{}{"status": map[string]interface{}{"_regex": "^p"}},
+				Hidden: boolPtr(true)},
+		}},
+	}
+
+	draft := resolveFieldConditions(fields, Item{"status": "draft"})
+	assert.False(t, draft[1].Required)
+	assert.False(t, draft[1].Readonly)
+
+	published := resolveFieldConditions(fields, Item{"status": "published"})
+	assert.True(t, published[1].Required)
+	assert.True(t, published[1].Readonly)
+	assert.False(t, published[1].Hidden, "invalid rules never match")
+
+	// Later conditions win
+	unlocked := resolveFieldConditions(fields, Item{"status": "published", "locked": true})
+	assert.True(t, unlocked[1].Required)
+	assert.False(t, unlocked[1].Readonly)
+
+	// The stored flags are left untouched
+	assert.False(t, fields[1].Required)
+}
+
+func TestReadonlyViolations(t *testing.T) {
+	fields := []FieldInfo{{Field: "slug", Readonly: true}, {Field: "title"}}
+
+	assert.Empty(t, readonlyViolations(fields, Item{"title": "Hello"}))
+
+	violations := readonlyViolations(fields, Item{"title": "Hello", "slug": nil})
+	if assert.Len(t, violations, 1) {
+		assert.Equal(t, "slug", violations[0].Field)
+		assert.Equal(t, "Field 'slug' is read-only", violations[0].Message)
+	}
+}
+
+func TestHideFields_KeepsSystemColumns(t *testing.T) {
+	fields := []FieldInfo{{Field: "id", Hidden: true}, {Field: "created_at", Hidden: true}, {Field: "notes", Hidden: true}}
+
+	item := hideFields(fields, Item{"id": "1", "created_at": "2024-01-01", "notes": "secret", "title": "Hello"})
+	assert.Equal(t, Item{"id": "1", "created_at": "2024-01-01", "title": "Hello"}, item)
+	assert.Nil(t, hideFields(fields, nil))
+}
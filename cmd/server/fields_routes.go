@@ -3,6 +3,8 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,6 +13,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/hooks"
 )
 
 // FieldsHandler handles field-related routes
@@ -18,6 +22,7 @@ type FieldsHandler struct {
 	db             *sql.DB
 	authMiddleware gin.HandlerFunc
 	optionsHandler gin.HandlerFunc
+	events         *hooks.Bus
 }
 
 // NewFieldsHandler creates a new fields handler
@@ -26,6 +31,7 @@ func NewFieldsHandler(server ServerInterface) *FieldsHandler {
 		db:             server.GetDB(),
 		authMiddleware: server.AuthMiddleware(),
 		optionsHandler: server.OptionsHandler(),
+		events:         server.Events(),
 	}
 }
 
@@ -35,6 +41,7 @@ func (h *FieldsHandler) SetupRoutes(v1 *gin.RouterGroup) {
 	v1.OPTIONS("/fields", h.optionsHandler)
 	v1.OPTIONS("/fields/:collection", h.optionsHandler)
 	v1.OPTIONS("/fields/:collection/:field", h.optionsHandler)
+	v1.OPTIONS("/fields/:collection/:field/conversion", h.optionsHandler)
 
 	// Fields routes (protected)
 	fields := v1.Group("/fields")
@@ -49,6 +56,9 @@ func (h *FieldsHandler) SetupRoutes(v1 *gin.RouterGroup) {
 		fields.POST("/:collection", h.createField)
 		fields.PATCH("/:collection/:field", h.updateField)
 		fields.DELETE("/:collection/:field", h.deleteField)
+
+		// Preview a data type change without applying it
+		fields.GET("/:collection/:field/conversion", h.previewConversion)
 	}
 }
 
@@ -129,6 +139,7 @@ type FieldSchema struct {
 	IsPrimaryKey  *bool       `json:"is_primary_key"` // Whether column is primary key
 	ForeignTable  *string     `json:"foreign_table"`  // For foreign key relationships
 	ForeignColumn *string     `json:"foreign_column"` // For foreign key relationships
+	OnDelete      *string     `json:"on_delete"`      // For file fields: restrict (default) or unlink
 }
 
 // FieldsListResponse represents a paginated list of fields
@@ -141,12 +152,6 @@ type FieldsListResponse struct {
 	} `json:"meta"`
 }
 
-// isAdmin checks if the requesting user is an admin
-func (h *FieldsHandler) isAdmin(c *gin.Context) bool {
-	currentUserRole := c.GetString("user_role")
-	return currentUserRole == "Administrator"
-}
-
 // getFields returns all fields in the system with optional filtering
 //
 //	@Summary		Get all fields
@@ -339,7 +344,7 @@ func (h *FieldsHandler) getField(c *gin.Context) {
 //	@Router			/fields/{collection} [post]
 func (h *FieldsHandler) createField(c *gin.Context) {
 	// Only admins can create fields
-	if !h.isAdmin(c) {
+	if !isAdmin(c) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
 		return
 	}
@@ -359,6 +364,10 @@ func (h *FieldsHandler) createField(c *gin.Context) {
 		return
 	}
 
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeFields, scopeFields, hooks.ActionCreate, nil)) {
+		return
+	}
+
 	var req CreateFieldRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logrus.WithError(err).Error("Invalid create field request payload")
@@ -386,6 +395,37 @@ func (h *FieldsHandler) createField(c *gin.Context) {
 		return
 	}
 
+	if req.Schema != nil && !isScalarDefault(req.Schema.DefaultValue) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "default_value must be a string, number, boolean or null"})
+		return
+	}
+
+	// File interfaces imply their special
+	req.Special = fileFieldSpecial(req.Interface, req.Special)
+	fileOnDeleteAction, err := fileOnDelete(req.Schema)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Translations and files fields set up a junction collection named after them
+	translations := hasSpecial(req.Special, specialTranslations)
+	files := hasSpecial(req.Special, specialFiles)
+	if translations || files {
+		junction := junctionCollection(collectionName, req.Field)
+		var junctionExists bool
+		err = h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM collections WHERE collection = $1)", junction).Scan(&junctionExists)
+		if err != nil {
+			logrus.WithError(err).Error("Database error while checking collection existence")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if junctionExists {
+			c.JSON(http.StatusConflict, gin.H{"error": "Collection '" + junction + "' already exists"})
+			return
+		}
+	}
+
 	// Start transaction
 	tx, err := h.db.Begin()
 	if err != nil {
@@ -464,8 +504,30 @@ func (h *FieldsHandler) createField(c *gin.Context) {
 		return
 	}
 
-	// Create database column if schema is provided and field is not virtual
-	if req.Schema != nil && !isVirtualField(req.Interface) {
+	// Create the translations or files junction, the column of a file field,
+	// or the database column if schema is provided and the field is not virtual
+	if translations {
+		err = createTranslationsCollection(tx, collectionName, req.Field)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to create translations collection")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create translations collection"})
+			return
+		}
+	} else if files {
+		err = createFilesCollection(tx, collectionName, req.Field, fileOnDeleteAction)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to create files collection")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create files collection"})
+			return
+		}
+	} else if hasSpecial(req.Special, specialFile) {
+		err = createFileColumn(tx, collectionName, req.Field, fileOnDeleteAction)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to create database column")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create database column"})
+			return
+		}
+	} else if req.Schema != nil && !isVirtualField(req.Interface) {
 		err = h.createDatabaseColumn(tx, collectionName, req.Field, req.Schema)
 		if err != nil {
 			logrus.WithError(err).Error("Failed to create database column")
@@ -493,6 +555,7 @@ func (h *FieldsHandler) createField(c *gin.Context) {
 		"collection": collectionName,
 		"field":      req.Field,
 	}).Info("Field created successfully")
+	emitActionHooks(c, h.events, scopeFields, scopeFields, hooks.ActionCreate, field, collectionName+"."+req.Field)
 	c.JSON(http.StatusCreated, gin.H{"data": field})
 }
 
@@ -516,7 +579,7 @@ func (h *FieldsHandler) createField(c *gin.Context) {
 //	@Router			/fields/{collection}/{field} [patch]
 func (h *FieldsHandler) updateField(c *gin.Context) {
 	// Only admins can update fields
-	if !h.isAdmin(c) {
+	if !isAdmin(c) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
 		return
 	}
@@ -525,7 +588,7 @@ func (h *FieldsHandler) updateField(c *gin.Context) {
 	fieldName := c.Param("field")
 
 	// Check if field exists
-	_, err := h.getFieldByName(collectionName, fieldName)
+	existing, err := h.getFieldByName(collectionName, fieldName)
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Field not found"})
 		return
@@ -535,6 +598,10 @@ func (h *FieldsHandler) updateField(c *gin.Context) {
 		return
 	}
 
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeFields, scopeFields, hooks.ActionUpdate, nil, collectionName+"."+fieldName)) {
+		return
+	}
+
 	var req UpdateFieldRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logrus.WithError(err).Error("Invalid update field request payload")
@@ -646,6 +713,10 @@ func (h *FieldsHandler) updateField(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
 		return
 	}
+	if req.Schema != nil && !isScalarDefault(req.Schema.DefaultValue) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "default_value must be a string, number, boolean or null"})
+		return
+	}
 
 	// Update field metadata if there are changes
 	if len(updateFields) > 0 {
@@ -664,9 +735,37 @@ func (h *FieldsHandler) updateField(c *gin.Context) {
 	}
 
 	// Update database column if schema changes are provided
-	if req.Schema != nil && !isVirtualField(req.Interface) {
-		err = h.alterDatabaseColumn(tx, collectionName, fieldName, req.Schema)
-		if err != nil {
+	if req.Schema != nil && !isVirtualField(req.Interface) && !isVirtualField(existing.Interface) {
+		conversion, err := h.planConversion(tx, collectionName, fieldName, req.Schema)
+		if errors.Is(err, errUnsupportedConversion) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot convert field: " + err.Error()})
+			return
+		} else if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Field has no database column"})
+			return
+		} else if err != nil {
+			logrus.WithError(err).Error("Database error while planning type conversion")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		// Refuse conversions that would fail half way through the table
+		if conversion != nil && conversion.FailingRows > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":      fmt.Sprintf("%d rows cannot be converted to %s", conversion.FailingRows, conversion.To),
+				"conversion": conversion,
+			})
+			return
+		}
+
+		err = h.alterDatabaseColumn(tx, collectionName, fieldName, req.Schema, conversion)
+		if conversion != nil && isConversionError(err) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":      "Values cannot be converted to " + conversion.To + ": " + err.Error(),
+				"conversion": conversion,
+			})
+			return
+		} else if err != nil {
 			logrus.WithError(err).Error("Failed to alter database column")
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to alter database column"})
 			return
@@ -692,9 +791,78 @@ func (h *FieldsHandler) updateField(c *gin.Context) {
 		"collection": collectionName,
 		"field":      fieldName,
 	}).Info("Field updated successfully")
+	emitActionHooks(c, h.events, scopeFields, scopeFields, hooks.ActionUpdate, field, collectionName+"."+fieldName)
 	c.JSON(http.StatusOK, gin.H{"data": field})
 }
 
+// previewConversion reports how a field's column would be converted to a new data type
+//
+//	@Summary		Preview a field type conversion
+//	@Description	Plan the conversion of a field's column to another data type and count the rows that would fail it, without changing anything
+//	@Tags			fields
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			collection	path		string	true	"Collection name"
+//	@Param			field		path		string	true	"Field name"
+//	@Param			data_type	query		string	true	"Target data type"
+//	@Param			max_length	query		int		false	"Target length for string fields"
+//	@Success		200			{object}	map[string]TypeConversion	"Conversion plan"
+//	@Failure		400			{object}	ErrorResponse	"Bad request (missing data type or unsupported conversion)"
+//	@Failure		401			{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403			{object}	ErrorResponse	"Forbidden (admin access required)"
+//	@Failure		404			{object}	ErrorResponse	"Field not found"
+//	@Failure		500			{object}	ErrorResponse	"Internal server error"
+//	@Router			/fields/{collection}/{field}/conversion [get]
+func (h *FieldsHandler) previewConversion(c *gin.Context) {
+	// Only admins can change field types
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	collectionName := c.Param("collection")
+	fieldName := c.Param("field")
+
+	target := &FieldSchema{DataType: c.Query("data_type")}
+	if target.DataType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "data_type is required"})
+		return
+	}
+	if lengthStr := c.Query("max_length"); lengthStr != "" {
+		length, err := strconv.Atoi(lengthStr)
+		if err != nil || length <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid max_length"})
+			return
+		}
+		target.MaxLength = &length
+	}
+
+	if _, err := h.getFieldByName(collectionName, fieldName); err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Field not found"})
+		return
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while fetching field")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	conversion, err := h.planConversion(h.db, collectionName, fieldName, target)
+	if errors.Is(err, errUnsupportedConversion) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot convert field: " + err.Error()})
+		return
+	} else if err == sql.ErrNoRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Field has no database column"})
+		return
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while planning type conversion")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": conversion})
+}
+
 // deleteField deletes a field from a collection
 //
 //	@Summary		Delete a field
@@ -714,7 +882,7 @@ func (h *FieldsHandler) updateField(c *gin.Context) {
 //	@Router			/fields/{collection}/{field} [delete]
 func (h *FieldsHandler) deleteField(c *gin.Context) {
 	// Only admins can delete fields
-	if !h.isAdmin(c) {
+	if !isAdmin(c) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
 		return
 	}
@@ -742,6 +910,11 @@ func (h *FieldsHandler) deleteField(c *gin.Context) {
 		}
 	}
 
+	event := requestEvent(c, scopeFields, scopeFields, hooks.ActionDelete, nil, collectionName+"."+fieldName)
+	if !runFilterHooks(c, h.events, &event) {
+		return
+	}
+
 	// Start transaction
 	tx, err := h.db.Begin()
 	if err != nil {
@@ -759,8 +932,15 @@ func (h *FieldsHandler) deleteField(c *gin.Context) {
 		return
 	}
 
-	// Drop database column if it's not a virtual field
-	if !isVirtualField(field.Interface) {
+	// Drop the translations or files junction, or the database column if it's not a virtual field
+	if hasJunction(field.Special) {
+		err = dropJunctionCollection(tx, collectionName, fieldName)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to drop junction collection")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to drop junction collection"})
+			return
+		}
+	} else if !isVirtualField(field.Interface) {
 		err = h.dropDatabaseColumn(tx, collectionName, fieldName)
 		if err != nil {
 			logrus.WithError(err).Error("Failed to drop database column")
@@ -780,6 +960,7 @@ func (h *FieldsHandler) deleteField(c *gin.Context) {
 		"collection": collectionName,
 		"field":      fieldName,
 	}).Info("Field deleted successfully")
+	emitActionHooks(c, h.events, scopeFields, scopeFields, hooks.ActionDelete, nil, collectionName+"."+fieldName)
 	c.JSON(http.StatusOK, gin.H{"message": "Field deleted successfully"})
 }
 
@@ -961,14 +1142,18 @@ func (h *FieldsHandler) createDatabaseColumn(tx *sql.Tx, collectionName, fieldNa
 	return nil
 }
 
-// alterDatabaseColumn alters an existing column in the collection table
-func (h *FieldsHandler) alterDatabaseColumn(tx *sql.Tx, collectionName, fieldName string, schema *FieldSchema) error {
-	// Note: PostgreSQL has limitations on altering columns.
-	// This is a simplified implementation - in production, you might need more sophisticated logic
-
+// alterDatabaseColumn alters an existing column in the collection table.
+// When the type change needs a USING expression the column default is dropped
+// first, since it may not survive the conversion; schema.DefaultValue sets a new one.
+func (h *FieldsHandler) alterDatabaseColumn(tx *sql.Tx, collectionName, fieldName string, schema *FieldSchema, conversion *TypeConversion) error {
 	if schema.DataType != "" {
 		alterSQL := `ALTER TABLE "` + collectionName + `" ALTER COLUMN "` + fieldName +
 			`" TYPE ` + h.mapDataTypeToSQL(schema.DataType, schema.MaxLength)
+		if conversion != nil && conversion.Using != "" {
+			alterSQL = `ALTER TABLE "` + collectionName + `" ALTER COLUMN "` + fieldName + `" DROP DEFAULT, ` +
+				`ALTER COLUMN "` + fieldName + `" TYPE ` + h.mapDataTypeToSQL(schema.DataType, schema.MaxLength) +
+				` USING ` + conversion.Using
+		}
 		_, err := tx.Exec(alterSQL)
 		if err != nil {
 			return err
@@ -1055,7 +1240,17 @@ func (h *FieldsHandler) mapDataTypeToSQL(dataType string, maxLength *int) string
 	}
 }
 
-// formatDefaultValue formats a default value for SQL
+// isScalarDefault reports whether a decoded JSON value can be a column default
+func isScalarDefault(value interface{}) bool {
+	switch value.(type) {
+	case nil, string, bool, float64:
+		return true
+	}
+	return false
+}
+
+// formatDefaultValue formats a default value for SQL. Values that aren't
+// scalars (see isScalarDefault) are written as their string form.
 func (h *FieldsHandler) formatDefaultValue(value interface{}) string {
 	switch v := value.(type) {
 	case string:
@@ -1067,7 +1262,9 @@ func (h *FieldsHandler) formatDefaultValue(value interface{}) string {
 		return "false"
 	case nil:
 		return "NULL"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
 	default:
-		return strconv.Itoa(int(v.(float64)))
+		return "'" + strings.ReplaceAll(fmt.Sprint(v), "'", "''") + "'"
 	}
 }
@@ -142,6 +142,219 @@ func (suite *FieldHandlersTestSuite) TestCreateField() {
 		assert.Equal(suite.T(), "input", data["interface"])
 	})
 
+	suite.Run("Translations", func() {
+		suite.mock.ExpectQuery("SELECT EXISTS").
+			WithArgs("articles").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		suite.mock.ExpectQuery("SELECT EXISTS").
+			WithArgs("articles", "translations").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		suite.mock.ExpectQuery("SELECT EXISTS").
+			WithArgs("articles_translations").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectExec("INSERT INTO fields").WillReturnResult(sqlmock.NewResult(1, 1))
+		suite.mock.ExpectQuery("SELECT data_type FROM information_schema.columns").
+			WithArgs("articles").
+			WillReturnRows(sqlmock.NewRows([]string{"data_type"}).AddRow("uuid"))
+		suite.mock.ExpectExec("INSERT INTO collections").WillReturnResult(sqlmock.NewResult(1, 1))
+		suite.mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "articles_translations"`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		suite.mock.ExpectExec(`ALTER TABLE "articles_translations"\s+ADD COLUMN "articles_id" uuid NOT NULL REFERENCES "articles"`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		suite.mock.ExpectExec("INSERT INTO fields").
+			WithArgs("articles_translations", "articles_id", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg(), false, true, sqlmock.AnyArg(), "full",
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), true, sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		suite.mock.ExpectExec("INSERT INTO fields").
+			WithArgs("articles_translations", "language", sqlmock.AnyArg(), "input", sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg(), false, false, sqlmock.AnyArg(), "full",
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), true, sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		suite.mock.ExpectCommit()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "collection", "field", "special", "interface", "options", "display",
+			"display_options", "readonly", "hidden", "sort", "width", "translations",
+			"note", "conditions", "required", "group", "validation", "validation_message",
+			"created_at", "updated_at",
+		}).AddRow(
+			"field-id", "articles", "translations", pq.StringArray{"translations"}, "translations", nil, nil,
+			nil, false, false, nil, "full", nil, nil, nil, false, nil, nil, nil,
+			testTime, testTime,
+		)
+		suite.mock.ExpectQuery("SELECT id, collection, field").
+			WithArgs("articles", "translations").
+			WillReturnRows(rows)
+
+		body, _ := json.Marshal(CreateFieldRequest{
+			Field:     "translations",
+			Special:   []string{"translations"},
+			Interface: stringPtr("translations"),
+		})
+		req, _ := http.NewRequest("POST", "/api/v1/fields/articles", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = addMockAuthContext(req, "admin", "Administrator")
+
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	})
+
+	suite.Run("TranslationsCollectionExists", func() {
+		suite.mock.ExpectQuery("SELECT EXISTS").
+			WithArgs("articles").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		suite.mock.ExpectQuery("SELECT EXISTS").
+			WithArgs("articles", "translations").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		suite.mock.ExpectQuery("SELECT EXISTS").
+			WithArgs("articles_translations").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		body, _ := json.Marshal(CreateFieldRequest{Field: "translations", Special: []string{"translations"}})
+		req, _ := http.NewRequest("POST", "/api/v1/fields/articles", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = addMockAuthContext(req, "admin", "Administrator")
+
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	})
+
+	suite.Run("FileField", func() {
+		suite.mock.ExpectQuery("SELECT EXISTS").
+			WithArgs("articles").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		suite.mock.ExpectQuery("SELECT EXISTS").
+			WithArgs("articles", "cover").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectExec("INSERT INTO fields").
+			WithArgs("articles", "cover", pq.Array([]string{"file"}), "file-image", sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg(), false, false, sqlmock.AnyArg(), "full",
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), false, sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		suite.mock.ExpectExec(`ALTER TABLE "articles" ADD COLUMN "cover" UUID\s+CONSTRAINT "fk_articles_cover" REFERENCES files \(id\) ON DELETE SET NULL`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		suite.mock.ExpectCommit()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "collection", "field", "special", "interface", "options", "display",
+			"display_options", "readonly", "hidden", "sort", "width", "translations",
+			"note", "conditions", "required", "group", "validation", "validation_message",
+			"created_at", "updated_at",
+		}).AddRow(
+			"field-id", "articles", "cover", pq.StringArray{"file"}, "file-image", nil, nil,
+			nil, false, false, nil, "full", nil, nil, nil, false, nil, nil, nil,
+			testTime, testTime,
+		)
+		suite.mock.ExpectQuery("SELECT id, collection, field").
+			WithArgs("articles", "cover").
+			WillReturnRows(rows)
+
+		body, _ := json.Marshal(CreateFieldRequest{
+			Field:     "cover",
+			Interface: stringPtr("file-image"),
+			Schema:    &FieldSchema{OnDelete: stringPtr("unlink")},
+		})
+		req, _ := http.NewRequest("POST", "/api/v1/fields/articles", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = addMockAuthContext(req, "admin", "Administrator")
+
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	})
+
+	suite.Run("FilesField", func() {
+		suite.mock.ExpectQuery("SELECT EXISTS").
+			WithArgs("articles").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		suite.mock.ExpectQuery("SELECT EXISTS").
+			WithArgs("articles", "gallery").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		suite.mock.ExpectQuery("SELECT EXISTS").
+			WithArgs("articles_gallery").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectExec("INSERT INTO fields").WillReturnResult(sqlmock.NewResult(1, 1))
+		suite.mock.ExpectQuery("SELECT data_type FROM information_schema.columns").
+			WithArgs("articles").
+			WillReturnRows(sqlmock.NewRows([]string{"data_type"}).AddRow("uuid"))
+		suite.mock.ExpectExec("INSERT INTO collections").WillReturnResult(sqlmock.NewResult(1, 1))
+		suite.mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "articles_gallery"`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		suite.mock.ExpectExec(`ALTER TABLE "articles_gallery"\s+ADD COLUMN "articles_id" uuid NOT NULL REFERENCES "articles" \(id\) ON DELETE CASCADE,\s+ADD COLUMN "files_id" UUID NOT NULL REFERENCES files \(id\) ON DELETE RESTRICT`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		for _, field := range []string{"articles_id", "files_id", "sort"} {
+			suite.mock.ExpectExec("INSERT INTO fields").
+				WithArgs("articles_gallery", field, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+					sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "full",
+					sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+					sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+		}
+		suite.mock.ExpectCommit()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "collection", "field", "special", "interface", "options", "display",
+			"display_options", "readonly", "hidden", "sort", "width", "translations",
+			"note", "conditions", "required", "group", "validation", "validation_message",
+			"created_at", "updated_at",
+		}).AddRow(
+			"field-id", "articles", "gallery", pq.StringArray{"files"}, "files", nil, nil,
+			nil, false, false, nil, "full", nil, nil, nil, false, nil, nil, nil,
+			testTime, testTime,
+		)
+		suite.mock.ExpectQuery("SELECT id, collection, field").
+			WithArgs("articles", "gallery").
+			WillReturnRows(rows)
+
+		body, _ := json.Marshal(CreateFieldRequest{Field: "gallery", Interface: stringPtr("files")})
+		req, _ := http.NewRequest("POST", "/api/v1/fields/articles", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = addMockAuthContext(req, "admin", "Administrator")
+
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	})
+
+	suite.Run("InvalidFileOnDelete", func() {
+		suite.mock.ExpectQuery("SELECT EXISTS").
+			WithArgs("articles").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		suite.mock.ExpectQuery("SELECT EXISTS").
+			WithArgs("articles", "cover").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		body, _ := json.Marshal(CreateFieldRequest{
+			Field:     "cover",
+			Interface: stringPtr("file"),
+			Schema:    &FieldSchema{OnDelete: stringPtr("cascade")},
+		})
+		req, _ := http.NewRequest("POST", "/api/v1/fields/articles", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = addMockAuthContext(req, "admin", "Administrator")
+
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	})
+
 	suite.Run("DuplicateField", func() {
 		// Mock collection existence check
 		suite.mock.ExpectQuery("SELECT EXISTS").
@@ -370,6 +583,43 @@ func (suite *FieldHandlersTestSuite) TestDeleteField() {
 		assert.Equal(suite.T(), http.StatusOK, w.Code)
 	})
 
+	suite.Run("Translations", func() {
+		rows := sqlmock.NewRows([]string{
+			"id", "collection", "field", "special", "interface", "options", "display",
+			"display_options", "readonly", "hidden", "sort", "width", "translations",
+			"note", "conditions", "required", "group", "validation", "validation_message",
+			"created_at", "updated_at",
+		}).AddRow(
+			"field-id", "articles", "translations", pq.StringArray{"translations"}, "translations", nil, nil,
+			nil, false, false, 1, "full", nil, nil, nil, false, nil, nil, nil,
+			testTime, testTime,
+		)
+		suite.mock.ExpectQuery("SELECT id, collection, field").
+			WithArgs("articles", "translations").
+			WillReturnRows(rows)
+
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectExec("DELETE FROM fields").
+			WithArgs("articles", "translations").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		suite.mock.ExpectExec("DELETE FROM fields").
+			WithArgs("articles_translations").
+			WillReturnResult(sqlmock.NewResult(2, 2))
+		suite.mock.ExpectExec("DELETE FROM collections").
+			WithArgs("articles_translations").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		suite.mock.ExpectExec(`DROP TABLE IF EXISTS "articles_translations"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		suite.mock.ExpectCommit()
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/fields/articles/translations", nil)
+		req = addMockAuthContext(req, "admin", "Administrator")
+
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+	})
+
 	suite.Run("SystemField", func() {
 		// Mock field retrieval for system field check
 		rows := sqlmock.NewRows([]string{
@@ -397,6 +647,140 @@ func (suite *FieldHandlersTestSuite) TestDeleteField() {
 	})
 }
 
+func (suite *FieldHandlersTestSuite) expectFieldLookup(fieldName, interfaceType string) {
+	rows := sqlmock.NewRows([]string{
+		"id", "collection", "field", "special", "interface", "options", "display",
+		"display_options", "readonly", "hidden", "sort", "width", "translations",
+		"note", "conditions", "required", "group", "validation", "validation_message",
+		"created_at", "updated_at",
+	}).AddRow(
+		"field-id", "test_collection", fieldName, pq.StringArray{}, interfaceType, nil, "raw",
+		nil, false, false, 1, "full", nil, nil, nil, false, nil, nil, nil,
+		testTime, testTime,
+	)
+
+	suite.mock.ExpectQuery("SELECT id, collection, field").
+		WithArgs("test_collection", fieldName).
+		WillReturnRows(rows)
+}
+
+func (suite *FieldHandlersTestSuite) TestPreviewConversion() {
+	suite.Run("Success", func() {
+		suite.expectFieldLookup("views", "input")
+		suite.mock.ExpectQuery("SELECT data_type, character_maximum_length FROM information_schema.columns").
+			WithArgs("test_collection", "views").
+			WillReturnRows(sqlmock.NewRows([]string{"data_type", "character_maximum_length"}).AddRow("character varying", 255))
+		suite.mock.ExpectQuery(`SELECT COUNT\(\*\) FROM "test_collection" WHERE "views" IS NOT NULL`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+		req, _ := http.NewRequest("GET", "/api/v1/fields/test_collection/views/conversion?data_type=integer", nil)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+		var response struct {
+			Data TypeConversion `json:"data"`
+		}
+		require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(suite.T(), "string(255)", response.Data.From)
+		assert.Equal(suite.T(), "integer", response.Data.To)
+		assert.Equal(suite.T(), `btrim("views"::text)::INTEGER`, response.Data.Using)
+		assert.Equal(suite.T(), 3, response.Data.FailingRows)
+		assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+	})
+
+	suite.Run("Unsupported", func() {
+		suite.expectFieldLookup("published", "boolean")
+		suite.mock.ExpectQuery("SELECT data_type, character_maximum_length FROM information_schema.columns").
+			WillReturnRows(sqlmock.NewRows([]string{"data_type", "character_maximum_length"}).AddRow("boolean", nil))
+
+		req, _ := http.NewRequest("GET", "/api/v1/fields/test_collection/published/conversion?data_type=uuid", nil)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+		assert.Contains(suite.T(), w.Body.String(), "from boolean to uuid")
+	})
+
+	suite.Run("MissingDataType", func() {
+		req, _ := http.NewRequest("GET", "/api/v1/fields/test_collection/views/conversion", nil)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	})
+}
+
+func (suite *FieldHandlersTestSuite) TestUpdateFieldTypeConversion() {
+	suite.Run("Success", func() {
+		suite.expectFieldLookup("payload", "input")
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectQuery("SELECT data_type, character_maximum_length FROM information_schema.columns").
+			WithArgs("test_collection", "payload").
+			WillReturnRows(sqlmock.NewRows([]string{"data_type", "character_maximum_length"}).AddRow("character varying", 255))
+		suite.mock.ExpectQuery(`SELECT COUNT\(\*\) FROM "test_collection" WHERE "payload" IS NOT NULL AND \(.*can_cast_to`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		suite.mock.ExpectExec(`ALTER TABLE "test_collection" ALTER COLUMN "payload" DROP DEFAULT, ` +
+			`ALTER COLUMN "payload" TYPE JSONB USING NULLIF\(btrim\("payload"::text\), ''\)::jsonb`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		suite.mock.ExpectCommit()
+		suite.expectFieldLookup("payload", "input")
+
+		body, _ := json.Marshal(map[string]interface{}{"schema": map[string]interface{}{"data_type": "json"}})
+		req, _ := http.NewRequest("PATCH", "/api/v1/fields/test_collection/payload", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+		assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+	})
+
+	suite.Run("FailingRows", func() {
+		suite.expectFieldLookup("views", "input")
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectQuery("SELECT data_type, character_maximum_length FROM information_schema.columns").
+			WillReturnRows(sqlmock.NewRows([]string{"data_type", "character_maximum_length"}).AddRow("text", nil))
+		suite.mock.ExpectQuery(`SELECT COUNT\(\*\) FROM "test_collection"`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		suite.mock.ExpectRollback()
+
+		body, _ := json.Marshal(map[string]interface{}{"schema": map[string]interface{}{"data_type": "integer"}})
+		req, _ := http.NewRequest("PATCH", "/api/v1/fields/test_collection/views", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusConflict, w.Code)
+		assert.Contains(suite.T(), w.Body.String(), "2 rows cannot be converted to integer")
+		assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+	})
+
+	suite.Run("CastError", func() {
+		suite.expectFieldLookup("published_on", "input")
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectQuery("SELECT data_type, character_maximum_length FROM information_schema.columns").
+			WillReturnRows(sqlmock.NewRows([]string{"data_type", "character_maximum_length"}).AddRow("text", nil))
+		suite.mock.ExpectQuery(`SELECT COUNT\(\*\) FROM "test_collection"`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		// A value changed between the count and the conversion
+		suite.mock.ExpectExec(`ALTER TABLE "test_collection"`).
+			WillReturnError(&pq.Error{Code: "22008", Message: `date/time field value out of range: "2024-13-45"`})
+		suite.mock.ExpectRollback()
+
+		body, _ := json.Marshal(map[string]interface{}{"schema": map[string]interface{}{"data_type": "date"}})
+		req, _ := http.NewRequest("PATCH", "/api/v1/fields/test_collection/published_on", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusConflict, w.Code)
+		assert.Contains(suite.T(), w.Body.String(), "cannot be converted to date")
+		assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+	})
+}
+
 func (suite *FieldHandlersTestSuite) TestFieldValidation() {
 	// Test valid field names
 	validNames := []string{"field1", "my_field", "Field_Name", "f", "field123"}
@@ -443,11 +827,12 @@ func (suite *FieldHandlersTestSuite) TestFieldSchemaOperations() {
 
 	// Test default value formatting
 	defaultValueTests := map[interface{}]string{
-		"hello":     "'hello'",
-		true:        "true",
-		false:       "false",
-		nil:         "NULL",
-		float64(42): "42",
+		"hello":      "'hello'",
+		true:         "true",
+		false:        "false",
+		nil:          "NULL",
+		float64(42):  "42",
+		float64(1.5): "1.5",
 	}
 
 	for input, expected := range defaultValueTests {
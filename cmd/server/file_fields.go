@@ -0,0 +1,446 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// filesJunctionField is the junction column of a files field pointing to the file
+const filesJunctionField = "files_id"
+
+// What happens to the items using a file when the file is deleted, set with
+// the on_delete schema option of file and files fields
+const (
+	// fileDeleteRestrict blocks deleting files that are in use
+	fileDeleteRestrict = "restrict"
+	// fileDeleteUnlink clears file fields and removes the file from files fields
+	fileDeleteUnlink = "unlink"
+)
+
+// fileInterfaces maps the interfaces of file fields to the special they imply
+var fileInterfaces = map[string]string{
+	"file":       specialFile,
+	"file-image": specialFile,
+	"files":      specialFiles,
+}
+
+// fileFieldSpecial adds the special a file interface implies, so file
+// fields can be created by interface alone
+func fileFieldSpecial(interfaceType *string, special []string) []string {
+	if interfaceType == nil {
+		return special
+	}
+	implied, ok := fileInterfaces[*interfaceType]
+	if !ok || hasSpecial(special, implied) {
+		return special
+	}
+	return append(special, implied)
+}
+
+// fileOnDelete returns the on_delete option of a file field's schema
+func fileOnDelete(schema *FieldSchema) (string, error) {
+	if schema == nil || schema.OnDelete == nil || *schema.OnDelete == "" {
+		return fileDeleteRestrict, nil
+	}
+	switch *schema.OnDelete {
+	case fileDeleteRestrict, fileDeleteUnlink:
+		return *schema.OnDelete, nil
+	}
+	return "", errors.New("on_delete must be restrict or unlink")
+}
+
+// createFileColumn adds the column of a file field
+func createFileColumn(tx *sql.Tx, collectionName, fieldName, onDelete string) error {
+	action := "RESTRICT"
+	if onDelete == fileDeleteUnlink {
+		action = "SET NULL"
+	}
+
+	_, err := tx.Exec(`ALTER TABLE "` + collectionName + `" ADD COLUMN "` + fieldName + `" UUID
+		CONSTRAINT "fk_` + collectionName + `_` + fieldName + `" REFERENCES files (id) ON DELETE ` + action)
+	return err
+}
+
+// createFilesCollection sets up the junction collection of a files field,
+// holding one row per item and file in the order of the sort column
+func createFilesCollection(tx *sql.Tx, collectionName, fieldName, onDelete string) error {
+	junction := junctionCollection(collectionName, fieldName)
+	parentField := junctionParentField(collectionName)
+
+	idType, err := createJunctionTable(tx, collectionName, junction)
+	if err != nil {
+		return err
+	}
+
+	action := "RESTRICT"
+	if onDelete == fileDeleteUnlink {
+		action = "CASCADE"
+	}
+	_, err = tx.Exec(`ALTER TABLE "` + junction + `"
+		ADD COLUMN "` + parentField + `" ` + idType + ` NOT NULL REFERENCES "` + collectionName + `" (id) ON DELETE CASCADE,
+		ADD COLUMN "` + filesJunctionField + `" UUID NOT NULL REFERENCES files (id) ON DELETE ` + action + `,
+		ADD COLUMN sort INTEGER,
+		ADD CONSTRAINT "uq_` + junction + `_file" UNIQUE ("` + parentField + `", "` + filesJunctionField + `")`)
+	if err != nil {
+		return err
+	}
+
+	fileInterface := "file"
+	for _, field := range []Field{
+		{Field: parentField, Required: true, Hidden: true},
+		{Field: filesJunctionField, Interface: &fileInterface, Special: []string{specialFile}, Required: true},
+		{Field: "sort", Hidden: true},
+	} {
+		if err := insertFieldMetadata(tx, junction, &field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isForeignKeyViolation reports whether a statement failed because a row is
+// still referenced, such as a file used by a file field
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// takeFilesValues removes the values of files fields from data, since they
+// have no column, and returns them as lists of file IDs
+func takeFilesValues(fields []FieldInfo, data Item) (map[string][]string, []ValidationError) {
+	values := make(map[string][]string)
+	var violations []ValidationError
+
+	for _, field := range fields {
+		value, present := data[field.Field]
+		if !present || !hasSpecial(field.Special, specialFiles) {
+			continue
+		}
+		delete(data, field.Field)
+
+		ids, ok := fileIDList(value)
+		if !ok {
+			violations = append(violations, ValidationError{
+				Field:   field.Field,
+				Message: fmt.Sprintf("Field '%s' must be a list of file IDs", field.Field),
+			})
+			continue
+		}
+		values[field.Field] = ids
+	}
+
+	return values, violations
+}
+
+// fileIDList converts a JSON value into a list of distinct file IDs; null
+// is an empty list
+func fileIDList(value interface{}) ([]string, bool) {
+	if value == nil {
+		return []string{}, true
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	ids := make([]string, 0, len(list))
+	seen := make(map[string]bool)
+	for _, entry := range list {
+		id, ok := entry.(string)
+		if !ok || !uuidRegexp.MatchString(id) {
+			return nil, false
+		}
+		id = strings.ToLower(id)
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, true
+}
+
+// takeFileValues takes the values of files fields out of data and checks
+// that every file and files field of data points to existing files.
+func (h *ItemsHandler) takeFileValues(c *gin.Context, fields []FieldInfo, data Item) (map[string][]string, bool) {
+	filesValues, violations := takeFilesValues(fields, data)
+	if len(violations) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "errors": violations})
+		return nil, false
+	}
+
+	violations, err := h.checkFileReferences(fields, data, filesValues)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while checking files")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	if len(violations) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "errors": violations})
+		return nil, false
+	}
+	return filesValues, true
+}
+
+// checkFileReferences returns a violation for each file or files field of
+// data, or in filesValues, that points to a file that doesn't exist
+func (h *ItemsHandler) checkFileReferences(fields []FieldInfo, data Item, filesValues map[string][]string) ([]ValidationError, error) {
+	references := make(map[string][]string)
+	var ids []string
+	for _, field := range fields {
+		if hasSpecial(field.Special, specialFile) {
+			if id, ok := data[field.Field].(string); ok && uuidRegexp.MatchString(id) {
+				id = strings.ToLower(id)
+				references[field.Field] = []string{id}
+				ids = append(ids, id)
+			}
+		}
+	}
+	for field, fieldIDs := range filesValues {
+		references[field] = fieldIDs
+		ids = append(ids, fieldIDs...)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var existing pq.StringArray
+	err := h.db.QueryRow(`SELECT COALESCE(array_agg(id::text), '{}') FROM files WHERE id::text = ANY($1)`,
+		pq.Array(ids)).Scan(&existing)
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		exists[id] = true
+	}
+
+	var violations []ValidationError
+	for _, field := range fields {
+		for _, id := range references[field.Field] {
+			if !exists[id] {
+				violations = append(violations, ValidationError{
+					Field:   field.Field,
+					Message: fmt.Sprintf("Field '%s' references a file that doesn't exist: %s", field.Field, id),
+				})
+				break
+			}
+		}
+	}
+	return violations, nil
+}
+
+// saveFilesValues replaces the files of an item's files fields, keeping the
+// order they were sent in. It runs in the transaction writing the item's row.
+func saveFilesValues(tx *sql.Tx, collectionName, itemID string, filesValues map[string][]string) error {
+	parentField := junctionParentField(collectionName)
+	for field, ids := range filesValues {
+		junction := junctionCollection(collectionName, field)
+		if _, err := tx.Exec(fmt.Sprintf(`DELETE FROM "%s" WHERE "%s" = $1`, junction, parentField), itemID); err != nil {
+			return err
+		}
+		for i, id := range ids {
+			_, err := tx.Exec(fmt.Sprintf(`INSERT INTO "%s" ("%s", "%s", sort) VALUES ($1, $2, $3)`,
+				junction, parentField, filesJunctionField), itemID, id, i+1)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyFileFields adds the file IDs of files fields to items and, for the
+// file and files fields listed in ?expand, replaces IDs with the files'
+// metadata.
+func (h *ItemsHandler) applyFileFields(c *gin.Context, collectionName string, fields []FieldInfo, items []Item) bool {
+	visible := func(field FieldInfo) bool {
+		return !field.Hidden || showHidden(c)
+	}
+
+	expand := make(map[string]bool)
+	if raw := c.Query("expand"); raw != "" {
+		for _, name := range strings.Split(raw, ",") {
+			name = strings.TrimSpace(name)
+			known := false
+			for _, field := range fields {
+				if field.Field == name && visible(field) &&
+					(hasSpecial(field.Special, specialFile) || hasSpecial(field.Special, specialFiles)) {
+					known = true
+					break
+				}
+			}
+			if !known {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown file field to expand: " + name})
+				return false
+			}
+			expand[name] = true
+		}
+	}
+
+	for _, field := range fields {
+		if !hasSpecial(field.Special, specialFiles) || !visible(field) {
+			continue
+		}
+		if err := h.loadFilesValues(collectionName, field.Field, items); err != nil {
+			logrus.WithError(err).WithField("field", field.Field).Error("Error loading files field")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return false
+		}
+	}
+
+	if len(expand) == 0 {
+		return true
+	}
+	return h.expandFiles(c, items, expand)
+}
+
+// loadFilesValues sets a files field of items to the IDs of its files
+func (h *ItemsHandler) loadFilesValues(collectionName, fieldName string, items []Item) error {
+	junction := junctionCollection(collectionName, fieldName)
+	parentField := junctionParentField(collectionName)
+
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		if item != nil {
+			item[fieldName] = []interface{}{}
+			ids = append(ids, fmt.Sprint(item["id"]))
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows, err := h.db.Query(fmt.Sprintf(`SELECT "%s"::text, "%s"::text FROM "%s" WHERE "%s"::text = ANY($1) ORDER BY sort, id`,
+		parentField, filesJunctionField, junction, parentField), pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	files := make(map[string][]interface{})
+	for rows.Next() {
+		var parentID, fileID string
+		if err := rows.Scan(&parentID, &fileID); err != nil {
+			return err
+		}
+		files[parentID] = append(files[parentID], fileID)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item != nil {
+			if ids, ok := files[fmt.Sprint(item["id"])]; ok {
+				item[fieldName] = ids
+			}
+		}
+	}
+	return nil
+}
+
+// expandFiles replaces the file IDs of the fields in expand with the files'
+// metadata. Anonymous requests only see the files and fields the Public role
+// may read; files it may not read stay IDs.
+func (h *ItemsHandler) expandFiles(c *gin.Context, items []Item, expand map[string]bool) bool {
+	permission, ok := h.resolvePublicPermission(c, filesCollection)
+	if !ok {
+		return false
+	}
+
+	seen := make(map[string]bool)
+	var ids []string
+	for _, item := range items {
+		for field := range expand {
+			if item == nil {
+				continue
+			}
+			for _, id := range fileFieldIDs(item[field]) {
+				if !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return true
+	}
+
+	rows, err := h.db.Query(`SELECT * FROM files WHERE id::text = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		logrus.WithError(err).Error("Database error while expanding files")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		logrus.WithError(err).Error("Error getting column names")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+
+	files := make(map[string]Item)
+	for rows.Next() {
+		file, err := scanItemRow(rows, columns)
+		if err != nil {
+			logrus.WithError(err).Error("Error scanning file row")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return false
+		}
+		if matches, err := matchesFilter(permission.filter(), file); err != nil || !matches {
+			continue
+		}
+		files[fmt.Sprint(file["id"])] = permission.applyFields(file)
+	}
+
+	expandValue := func(id string) interface{} {
+		if file, ok := files[id]; ok {
+			return file
+		}
+		return id
+	}
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		for field := range expand {
+			switch value := item[field].(type) {
+			case string:
+				item[field] = expandValue(value)
+			case []interface{}:
+				expanded := make([]interface{}, len(value))
+				for i, id := range value {
+					expanded[i] = expandValue(fmt.Sprint(id))
+				}
+				item[field] = expanded
+			}
+		}
+	}
+	return true
+}
+
+// fileFieldIDs returns the file IDs held by a file or files field value
+func fileFieldIDs(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		ids := make([]string, 0, len(v))
+		for _, id := range v {
+			ids = append(ids, fmt.Sprint(id))
+		}
+		return ids
+	}
+	return nil
+}
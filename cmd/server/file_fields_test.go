@@ -0,0 +1,170 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testFileID1 = "6f1c2a3b-4d5e-4f60-8a7b-9c0d1e2f3a4b"
+	testFileID2 = "7a2d3b4c-5e6f-4a71-9b8c-0d1e2f3a4b5c"
+)
+
+// expectFileFields mocks the fields of an articles collection with a cover
+// file field and a gallery files field
+func (suite *ItemHandlersTestSuite) expectFileFields() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").
+		WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows().
+		AddRow("title", false, nil, nil, false, false, nil, nil, "character varying", 255, "YES").
+		AddRow("cover", false, nil, nil, false, false, nil, "{file}", "uuid", nil, "YES").
+		AddRow("gallery", false, nil, nil, false, false, nil, "{files}", nil, nil, nil))
+}
+
+func (suite *ItemHandlersTestSuite) TestCreateItem_FileFields() {
+	suite.expectFileFields()
+	suite.mock.ExpectQuery(`SELECT COALESCE\(array_agg\(id::text\), '\{\}'\) FROM files WHERE id::text = ANY\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"ids"}).AddRow("{" + testFileID1 + "," + testFileID2 + "}"))
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery(`INSERT INTO "articles"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id"}).AddRow("item-1"))
+	suite.mock.ExpectExec(`DELETE FROM "articles_gallery" WHERE "articles_id" = \$1`).WithArgs("item-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectExec(`INSERT INTO "articles_gallery" \("articles_id", "files_id", sort\)`).
+		WithArgs("item-1", testFileID2, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec(`INSERT INTO "articles_gallery" \("articles_id", "files_id", sort\)`).
+		WithArgs("item-1", testFileID1, 2).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectCommit()
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "title", "cover"}).AddRow("item-1", "Trip", testFileID1))
+	suite.mock.ExpectQuery(`SELECT "articles_id"::text, "files_id"::text FROM "articles_gallery"`).
+		WithArgs(pq.Array([]string{"item-1"})).
+		WillReturnRows(sqlmock.NewRows([]string{"articles_id", "files_id"}).
+			AddRow("item-1", testFileID2).AddRow("item-1", testFileID1))
+
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/articles", Item{
+		"title":   "Trip",
+		"cover":   testFileID1,
+		"gallery": []string{testFileID2, testFileID1, testFileID2},
+	}, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+	var response map[string]map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), []interface{}{testFileID2, testFileID1}, response["data"]["gallery"])
+}
+
+func (suite *ItemHandlersTestSuite) TestCreateItem_FileFieldsFailure() {
+	suite.expectFileFields()
+	suite.mock.ExpectQuery(`FROM files WHERE id::text = ANY\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"ids"}).AddRow("{" + testFileID1 + "}"))
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery(`INSERT INTO "articles"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id"}).AddRow("item-1"))
+	suite.mock.ExpectExec(`DELETE FROM "articles_gallery"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectExec(`INSERT INTO "articles_gallery"`).WillReturnError(sql.ErrConnDone)
+	// The item isn't created without its files
+	suite.mock.ExpectRollback()
+
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/articles", Item{
+		"title":   "Trip",
+		"gallery": []string{testFileID1},
+	}, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
+}
+
+func (suite *ItemHandlersTestSuite) TestCreateItem_UnknownFile() {
+	suite.expectFileFields()
+	suite.mock.ExpectQuery(`FROM files WHERE id::text = ANY\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"ids"}).AddRow("{" + testFileID1 + "}"))
+
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/articles", Item{
+		"cover":   testFileID1,
+		"gallery": []string{testFileID2},
+	}, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"field":"gallery"`)
+	assert.NotContains(suite.T(), w.Body.String(), `"field":"cover"`)
+}
+
+func (suite *ItemHandlersTestSuite) TestCreateItem_InvalidFilesValue() {
+	suite.expectFileFields()
+
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/articles", Item{
+		"gallery": "not-a-list",
+	}, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *ItemHandlersTestSuite) TestGetItem_ExpandFiles() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").
+		WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "title", "cover"}).AddRow("item-1", "Trip", testFileID1))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows().
+		AddRow("title", false, nil, nil, false, false, nil, nil, "character varying", 255, "YES").
+		AddRow("cover", false, nil, nil, false, false, nil, "{file}", "uuid", nil, "YES").
+		AddRow("gallery", false, nil, nil, false, false, nil, "{files}", nil, nil, nil))
+	suite.mock.ExpectQuery(`FROM "articles_gallery"`).
+		WillReturnRows(sqlmock.NewRows([]string{"articles_id", "files_id"}).
+			AddRow("item-1", testFileID1).AddRow("item-1", testFileID2))
+	suite.mock.ExpectQuery(`SELECT \* FROM files WHERE id::text = ANY\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "filename_download", "type"}).
+			AddRow(testFileID1, "beach.jpg", "image/jpeg"))
+
+	req, router := suite.createAuthenticatedRequest("GET", "/api/v1/items/articles/item-1?expand=cover,gallery",
+		nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response map[string]map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	cover := response["data"]["cover"].(map[string]interface{})
+	assert.Equal(suite.T(), "beach.jpg", cover["filename_download"])
+	gallery := response["data"]["gallery"].([]interface{})
+	require.Len(suite.T(), gallery, 2)
+	assert.Equal(suite.T(), "image/jpeg", gallery[0].(map[string]interface{})["type"])
+	// Files that couldn't be loaded stay IDs
+	assert.Equal(suite.T(), testFileID2, gallery[1])
+}
+
+func (suite *ItemHandlersTestSuite) TestGetItem_ExpandUnknownField() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").
+		WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "title"}).AddRow("item-1", "Trip"))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows().
+		AddRow("title", false, nil, nil, false, false, nil, nil, "character varying", 255, "YES"))
+
+	req, router := suite.createAuthenticatedRequest("GET", "/api/v1/items/articles/item-1?expand=title",
+		nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
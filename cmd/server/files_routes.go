@@ -0,0 +1,898 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/hooks"
+	"gorectus/internal/storage"
+)
+
+// filesCollection is the table holding file metadata
+const filesCollection = "files"
+
+// FilesHandler handles file uploads, metadata and asset downloads
+type FilesHandler struct {
+	db                     *sql.DB
+	storage                *storage.Locations
+	assets                 *assetCache
+	items                  *ItemsHandler
+	authMiddleware         gin.HandlerFunc
+	optionalAuthMiddleware gin.HandlerFunc
+	optionsHandler         gin.HandlerFunc
+	events                 *hooks.Bus
+}
+
+// NewFilesHandler creates a new files handler storing contents in locations
+// and transformed images in assets
+func NewFilesHandler(server ServerInterface, locations *storage.Locations, assets *assetCache) *FilesHandler {
+	return &FilesHandler{
+		db:                     server.GetDB(),
+		storage:                locations,
+		assets:                 assets,
+		items:                  NewItemsHandler(server),
+		authMiddleware:         server.AuthMiddleware(),
+		optionalAuthMiddleware: server.OptionalAuthMiddleware(),
+		optionsHandler:         server.OptionsHandler(),
+		events:                 server.Events(),
+	}
+}
+
+// SetupRoutes sets up file and asset routes
+func (h *FilesHandler) SetupRoutes(v1 *gin.RouterGroup) {
+	// CORS preflight OPTIONS for files endpoints
+	v1.OPTIONS("/files", h.optionsHandler)
+	v1.OPTIONS("/files/:id", h.optionsHandler)
+	v1.OPTIONS("/files/:id/url", h.optionsHandler)
+	v1.OPTIONS("/assets/:id", h.optionsHandler)
+
+	// Files routes (reads fall back to the Public role, writes are protected)
+	files := v1.Group("/files")
+	{
+		files.GET("", h.optionalAuthMiddleware, h.getFiles)
+		files.POST("", h.authMiddleware, h.uploadFile)
+		files.GET("/:id", h.optionalAuthMiddleware, h.getFile)
+		files.PATCH("/:id", h.authMiddleware, h.updateFile)
+		files.DELETE("/:id", h.authMiddleware, h.deleteFile)
+		files.GET("/:id/url", h.optionalAuthMiddleware, h.getFileURL)
+	}
+
+	v1.GET("/assets/:id", h.optionalAuthMiddleware, h.getAsset)
+}
+
+// fileColumns are the columns of the files table
+var fileColumns = []string{
+	"id", "storage", "filename_disk", "filename_download", "title", "description",
+	"type", "filesize", "checksum", "uploaded_by", "folder", "created_at", "updated_at",
+}
+
+// UpdateFileRequest represents the file metadata that can be changed
+type UpdateFileRequest struct {
+	Title            *string `json:"title"`
+	Description      *string `json:"description"`
+	FilenameDownload *string `json:"filename_download"`
+	// Folder moves the file; null moves it to the root
+	Folder nullableID `json:"folder" swaggertype:"string"`
+}
+
+// GetFiles lists file metadata
+//
+//	@Summary		List files
+//	@Description	Get a paginated list of uploaded files, newest first unless sorted otherwise
+//	@Tags			files
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			page	query		int		false	"Page number"
+//	@Param			limit	query		int		false	"Limit the number of results"
+//	@Param			folder	query		string	false	"Only files in this folder, or null for files outside of folders"
+//	@Param			filter	query		string	false	"JSON filter, as for items"
+//	@Param			sort	query		string	false	"Comma-separated fields to sort by, prefixed with - for descending order"
+//	@Success		200		{object}	map[string]interface{}	"Files with pagination metadata"
+//	@Failure		400		{object}	ErrorResponse	"Invalid filter or sort"
+//	@Failure		403		{object}	ErrorResponse	"Access denied"
+//	@Failure		500		{object}	ErrorResponse	"Internal server error"
+//	@Router			/files [get]
+func (h *FilesHandler) getFiles(c *gin.Context) {
+	page := 1
+	limit := 50
+	if pageStr := c.Query("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+	offset := (page - 1) * limit
+
+	// Anonymous requests are limited to what the Public role may read
+	permission, ok := h.items.resolvePublicPermission(c, filesCollection)
+	if !ok {
+		return
+	}
+
+	// Filters and sorts of the request are limited to fields it may read
+	queryable := func(field string) bool {
+		return permission.allowsField(field) && slices.Contains(fileColumns, field)
+	}
+	queryFilter, err := parseFilterQuery(c.Query("filter"), queryable)
+	if err == nil {
+		_, _, err = buildFilterSQL(queryFilter, 1)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	order, err := parseSortQuery(c.Query("sort"), queryable)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if order == "" {
+		order = "created_at DESC"
+	}
+
+	// Folder listings are filters on the folder column
+	if folder, ok := c.GetQuery("folder"); ok {
+		var folderFilter map[string]interface{}
+		if folder == "" || folder == "null" {
+			folderFilter = map[string]interface{}{"folder": map[string]interface{}{"_null": true}}
+		} else if uuidRegexp.MatchString(folder) {
+			folderFilter = map[string]interface{}{"folder": map[string]interface{}{"_eq": folder}}
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder"})
+			return
+		}
+		queryFilter = combineFilters(queryFilter, folderFilter)
+	}
+
+	whereClause, whereArgs, err := buildFilterSQL(combineFilters(permission.filter(), queryFilter), 1)
+	if err != nil {
+		logrus.WithError(err).Error("Invalid permission filter")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid permission filter"})
+		return
+	}
+	if whereClause != "" {
+		whereClause = " WHERE " + whereClause
+	}
+
+	query := fmt.Sprintf(`SELECT * FROM files%s ORDER BY %s LIMIT $%d OFFSET $%d`,
+		whereClause, order, len(whereArgs)+1, len(whereArgs)+2)
+	rows, err := h.db.Query(query, append(whereArgs, limit, offset)...)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while fetching files")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		logrus.WithError(err).Error("Error getting column names")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	files := []Item{}
+	for rows.Next() {
+		file, err := scanItemRow(rows, columns)
+		if err != nil {
+			logrus.WithError(err).Error("Error scanning file row")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		files = append(files, permission.applyFields(file))
+	}
+
+	var total int
+	err = h.db.QueryRow(`SELECT COUNT(*) FROM files`+whereClause, whereArgs...).Scan(&total)
+	if err != nil {
+		logrus.WithError(err).Error("Error counting files")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": files,
+		"meta": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// GetFile returns a file's metadata
+//
+//	@Summary		Get file metadata
+//	@Description	Get the metadata of an uploaded file
+//	@Tags			files
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string		true	"File ID"
+//	@Success		200	{object}	FileModel	"File metadata"
+//	@Failure		403	{object}	ErrorResponse	"Access denied"
+//	@Failure		404	{object}	ErrorResponse	"File not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/files/{id} [get]
+func (h *FilesHandler) getFile(c *gin.Context) {
+	permission, file, ok := h.resolveReadableFile(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": permission.applyFields(file)})
+}
+
+// UploadFile stores an uploaded file
+//
+//	@Summary		Upload a file
+//	@Description	Upload a file as multipart form data. The MIME type, size and SHA-256 checksum are recorded with the file
+//	@Tags			files
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			file		formData	file	true	"File contents"
+//	@Param			title		formData	string	false	"Title, defaults to the file name"
+//	@Param			description	formData	string	false	"Description"
+//	@Param			storage		formData	string	false	"Storage location, defaults to the first configured location"
+//	@Param			folder		formData	string	false	"Folder ID, defaults to the storage_default_folder setting"
+//	@Success		201			{object}	FileModel	"Uploaded file"
+//	@Failure		400			{object}	ErrorResponse	"No file uploaded, unknown storage location or folder"
+//	@Failure		401			{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403			{object}	ErrorResponse	"App access required"
+//	@Failure		500			{object}	ErrorResponse	"Internal server error"
+//	@Router			/files [post]
+func (h *FilesHandler) uploadFile(c *gin.Context) {
+	// Uploads are open to users of the admin app
+	if !hasAppAccess(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "App access required"})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+
+	location, driver := h.storage.Default()
+	if name := c.PostForm("storage"); name != "" {
+		var ok bool
+		if driver, ok = h.storage.Get(name); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown storage location '" + name + "'"})
+			return
+		}
+		location = name
+	}
+
+	folder, ok := h.uploadFolder(c)
+	if !ok {
+		return
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to open uploaded file")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read upload"})
+		return
+	}
+	defer src.Close()
+
+	id, err := newUUID()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to generate file ID")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+
+	filenameDownload := filepath.Base(header.Filename)
+	filenameDisk := id + strings.ToLower(filepath.Ext(filenameDownload))
+
+	reader := bufio.NewReader(src)
+	sniffed, _ := reader.Peek(512)
+	mimeType := uploadMimeType(header.Header.Get("Content-Type"), sniffed)
+
+	title := c.PostForm("title")
+	if title == "" {
+		title = strings.TrimSuffix(filenameDownload, filepath.Ext(filenameDownload))
+	}
+	var description interface{}
+	if value := c.PostForm("description"); value != "" {
+		description = value
+	}
+
+	// Filters see the upload's metadata before the contents are stored; the
+	// title and description they leave are written
+	event := requestEvent(c, scopeFiles, scopeFiles, hooks.ActionCreate, map[string]interface{}{
+		"storage":           location,
+		"folder":            folder,
+		"filename_download": filenameDownload,
+		"title":             title,
+		"description":       description,
+		"type":              mimeType,
+		"filesize":          header.Size,
+	})
+	if !runFilterHooks(c, h.events, &event) {
+		return
+	}
+	if value, ok := event.Payload["title"].(string); ok {
+		title = value
+	}
+	if value, ok := event.Payload["description"].(string); ok {
+		description = value
+	} else if event.Payload["description"] == nil {
+		description = nil
+	}
+
+	hash := sha256.New()
+	if err := driver.Put(c.Request.Context(), filenameDisk, io.TeeReader(reader, hash)); err != nil {
+		logrus.WithError(err).Error("Failed to store uploaded file")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+
+	_, err = h.db.Exec(`
+		INSERT INTO files (
+			id, storage, filename_disk, filename_download, title, description,
+			type, filesize, checksum, uploaded_by, folder
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, id, location, filenameDisk, filenameDownload, title, description,
+		mimeType, header.Size, hex.EncodeToString(hash.Sum(nil)), userValue(c.GetString("user_id")), folder)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while creating file")
+		if err := driver.Delete(c.Request.Context(), filenameDisk); err != nil {
+			logrus.WithError(err).WithField("filename_disk", filenameDisk).Warn("Failed to remove orphaned file")
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	file, err := h.items.getItemByID(filesCollection, id)
+	if err != nil {
+		logrus.WithError(err).Error("Error fetching uploaded file")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"file_id":  id,
+		"storage":  location,
+		"filesize": header.Size,
+		"type":     mimeType,
+	}).Info("File uploaded successfully")
+	emitActionHooks(c, h.events, scopeFiles, scopeFiles, hooks.ActionCreate, file, id)
+	c.JSON(http.StatusCreated, gin.H{"data": file})
+}
+
+// UpdateFile changes a file's metadata
+//
+//	@Summary		Update file metadata
+//	@Description	Update the title, description or download name of a file, or move it to another folder. Admins and the uploader may update a file
+//	@Tags			files
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string				true	"File ID"
+//	@Param			file	body		UpdateFileRequest	true	"File metadata"
+//	@Success		200		{object}	FileModel	"Updated file"
+//	@Failure		400		{object}	ErrorResponse	"Invalid request payload"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	ErrorResponse	"Access denied"
+//	@Failure		404		{object}	ErrorResponse	"File not found"
+//	@Failure		500		{object}	ErrorResponse	"Internal server error"
+//	@Router			/files/{id} [patch]
+func (h *FilesHandler) updateFile(c *gin.Context) {
+	fileID := c.Param("id")
+
+	if _, ok := h.resolveWritableFile(c, fileID); !ok {
+		return
+	}
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeFiles, scopeFiles, hooks.ActionUpdate, nil, fileID)) {
+		return
+	}
+
+	var req UpdateFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid update file request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	var updates []string
+	var args []interface{}
+	if req.Title != nil {
+		args = append(args, *req.Title)
+		updates = append(updates, "title = $"+strconv.Itoa(len(args)))
+	}
+	if req.Description != nil {
+		args = append(args, *req.Description)
+		updates = append(updates, "description = $"+strconv.Itoa(len(args)))
+	}
+	if req.FilenameDownload != nil {
+		name := filepath.Base(*req.FilenameDownload)
+		if name == "." || name == string(filepath.Separator) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filename_download"})
+			return
+		}
+		args = append(args, name)
+		updates = append(updates, "filename_download = $"+strconv.Itoa(len(args)))
+	}
+	if req.Folder.Set {
+		var folder interface{}
+		if req.Folder.Value != nil {
+			exists, err := folderExists(h.db, *req.Folder.Value)
+			if err != nil {
+				logrus.WithError(err).Error("Database error while checking folder")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+				return
+			}
+			if !exists {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Folder not found"})
+				return
+			}
+			folder = *req.Folder.Value
+		}
+		args = append(args, folder)
+		updates = append(updates, "folder = $"+strconv.Itoa(len(args)))
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	args = append(args, fileID)
+	query := fmt.Sprintf(`UPDATE files SET %s WHERE id = $%d`, strings.Join(updates, ", "), len(args))
+	if _, err := h.db.Exec(query, args...); err != nil {
+		logrus.WithError(err).Error("Database error while updating file")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	file, err := h.items.getItemByID(filesCollection, fileID)
+	if err != nil {
+		logrus.WithError(err).Error("Error fetching updated file")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithField("file_id", fileID).Info("File updated successfully")
+	emitActionHooks(c, h.events, scopeFiles, scopeFiles, hooks.ActionUpdate, file, fileID)
+	c.JSON(http.StatusOK, gin.H{"data": file})
+}
+
+// DeleteFile removes a file and its contents
+//
+//	@Summary		Delete a file
+//	@Description	Delete a file's metadata and stored contents. Admins and the uploader may delete a file
+//	@Tags			files
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"File ID"
+//	@Success		200	{object}	SuccessMessage	"File deleted successfully"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Access denied"
+//	@Failure		404	{object}	ErrorResponse	"File not found"
+//	@Failure		409	{object}	ErrorResponse	"File is in use by a file field that restricts deletion"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/files/{id} [delete]
+func (h *FilesHandler) deleteFile(c *gin.Context) {
+	fileID := c.Param("id")
+
+	file, ok := h.resolveWritableFile(c, fileID)
+	if !ok {
+		return
+	}
+	event := requestEvent(c, scopeFiles, scopeFiles, hooks.ActionDelete, nil, fileID)
+	if !runFilterHooks(c, h.events, &event) {
+		return
+	}
+
+	if _, err := h.db.Exec("DELETE FROM files WHERE id = $1", fileID); isForeignKeyViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "File is in use"})
+		return
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while deleting file")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	h.removeStoredFile(c.Request.Context(), file)
+
+	logrus.WithField("file_id", fileID).Info("File deleted successfully")
+	emitActionHooks(c, h.events, scopeFiles, scopeFiles, hooks.ActionDelete, file, fileID)
+	c.JSON(http.StatusOK, gin.H{"message": "File deleted successfully"})
+}
+
+// removeStoredFile removes the contents and transformed variants of a deleted
+// file. The row is gone either way, so failures only leave wasted space and
+// are logged.
+func (h *FilesHandler) removeStoredFile(ctx context.Context, file Item) {
+	filenameDisk := fmt.Sprint(file["filename_disk"])
+	if driver, ok := h.storage.Get(fmt.Sprint(file["storage"])); !ok {
+		logrus.WithField("storage", file["storage"]).Warn("Stored file is in an unknown storage location")
+	} else if err := driver.Delete(ctx, filenameDisk); err != nil {
+		logrus.WithError(err).WithField("filename_disk", filenameDisk).Warn("Failed to remove stored file")
+	}
+	if err := h.assets.purge(fmt.Sprint(file["id"])); err != nil {
+		logrus.WithError(err).WithField("file_id", file["id"]).Warn("Failed to remove transformed assets")
+	}
+}
+
+// uploadFolder returns the folder of an upload: the folder form field, or the
+// storage_default_folder setting.
+func (h *FilesHandler) uploadFolder(c *gin.Context) (interface{}, bool) {
+	folder := c.PostForm("folder")
+	if folder == "" {
+		var defaultFolder sql.NullString
+		// A default folder kept from before folders existed is ignored
+		err := h.db.QueryRow(`SELECT storage_default_folder FROM settings
+			WHERE storage_default_folder IN (SELECT id FROM folders) LIMIT 1`).Scan(&defaultFolder)
+		if err != nil && err != sql.ErrNoRows {
+			logrus.WithError(err).Error("Database error while loading the default folder")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return nil, false
+		}
+		if !defaultFolder.Valid {
+			return nil, true
+		}
+		return defaultFolder.String, true
+	}
+
+	exists, err := folderExists(h.db, folder)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while checking folder")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Folder not found"})
+		return nil, false
+	}
+	return folder, true
+}
+
+// GetAsset streams a file's contents
+//
+//	@Summary		Download a file
+//	@Description	Stream the contents of a file. Single byte ranges are supported with the Range header. Images can be resized, cropped and converted, as allowed by the storage_asset_transform setting
+//	@Tags			files
+//	@Produce		octet-stream
+//	@Security		BearerAuth
+//	@Param			id					path		string	true	"File ID"
+//	@Param			download			query		bool	false	"Send as an attachment instead of inline; only images, video, audio and PDFs are ever served inline"
+//	@Param			key					query		string	false	"Asset preset from storage_asset_presets"
+//	@Param			width				query		int		false	"Width of the transformed image"
+//	@Param			height				query		int		false	"Height of the transformed image"
+//	@Param			fit					query		string	false	"How the image fits width and height: cover (default), contain, inside or outside"
+//	@Param			format				query		string	false	"Output format: jpg or png"
+//	@Param			quality				query		int		false	"JPEG quality (1-100)"
+//	@Param			withoutEnlargement	query		bool	false	"Don't enlarge images smaller than the requested size"
+//	@Param			Range				header		string	false	"Byte range, e.g. bytes=0-1023"
+//	@Success		200					{file}		binary	"File contents"
+//	@Success		206					{file}		binary	"Requested byte range"
+//	@Failure		400					{object}	ErrorResponse	"Invalid transformation"
+//	@Failure		403					{object}	ErrorResponse	"Access denied or transformation not allowed"
+//	@Failure		404					{object}	ErrorResponse	"File not found"
+//	@Failure		416					{object}	ErrorResponse	"Range not satisfiable"
+//	@Failure		500					{object}	ErrorResponse	"Internal server error"
+//	@Router			/assets/{id} [get]
+func (h *FilesHandler) getAsset(c *gin.Context) {
+	_, file, ok := h.resolveReadableFile(c)
+	if !ok {
+		return
+	}
+
+	transform, ok := h.resolveAssetTransform(c)
+	if !ok {
+		return
+	}
+	if transform != nil {
+		h.serveTransformedAsset(c, file, *transform)
+		return
+	}
+
+	size := toInt64(file["filesize"])
+	etag := ""
+	if checksum, ok := file["checksum"].(string); ok && checksum != "" {
+		etag = `"` + checksum + `"`
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	offset, length, partial, err := parseRange(c.GetHeader("Range"), size)
+	if err != nil {
+		c.Header("Content-Range", fmt.Sprintf("bytes */%d", size))
+		c.JSON(http.StatusRequestedRangeNotSatisfiable, gin.H{"error": "Range not satisfiable"})
+		return
+	}
+
+	driver, ok := h.fileDriver(c, file)
+	if !ok {
+		return
+	}
+
+	filenameDisk := fmt.Sprint(file["filename_disk"])
+	reader, err := driver.Open(c.Request.Context(), filenameDisk, offset, length)
+	if errors.Is(err, storage.ErrNotFound) {
+		logrus.WithField("filename_disk", filenameDisk).Error("Stored file is missing")
+		c.JSON(http.StatusNotFound, gin.H{"error": "File contents not found"})
+		return
+	} else if err != nil {
+		logrus.WithError(err).Error("Failed to open stored file")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer reader.Close()
+
+	contentType, _ := file["type"].(string)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType(assetDisposition(c, contentType),
+		map[string]string{"filename": fmt.Sprint(file["filename_download"])}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Accept-Ranges", "bytes")
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+
+	status := http.StatusOK
+	if partial {
+		status = http.StatusPartialContent
+		c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, size))
+	} else {
+		length = size
+	}
+
+	c.DataFromReader(status, length, contentType, reader, nil)
+}
+
+// GetFileURL returns a temporary download URL
+//
+//	@Summary		Get a temporary download URL
+//	@Description	Get a presigned URL downloading the file straight from its storage location, for locations that support it (S3)
+//	@Tags			files
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"File ID"
+//	@Param			expires	query		int		false	"Seconds until the URL expires (default 3600, at most 604800)"
+//	@Success		200		{object}	map[string]interface{}	"Presigned URL and its expiry"
+//	@Failure		400		{object}	ErrorResponse	"Invalid expiry or storage location without presigned URLs"
+//	@Failure		403		{object}	ErrorResponse	"Access denied"
+//	@Failure		404		{object}	ErrorResponse	"File not found"
+//	@Failure		500		{object}	ErrorResponse	"Internal server error"
+//	@Router			/files/{id}/url [get]
+func (h *FilesHandler) getFileURL(c *gin.Context) {
+	expires := defaultPresignExpiry
+	if expiresStr := c.Query("expires"); expiresStr != "" {
+		seconds, err := strconv.Atoi(expiresStr)
+		if err != nil || seconds <= 0 || time.Duration(seconds)*time.Second > maxPresignExpiry {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expires value"})
+			return
+		}
+		expires = time.Duration(seconds) * time.Second
+	}
+
+	_, file, ok := h.resolveReadableFile(c)
+	if !ok {
+		return
+	}
+
+	driver, ok := h.fileDriver(c, file)
+	if !ok {
+		return
+	}
+
+	presigner, ok := driver.(storage.Presigner)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Storage location doesn't support presigned URLs"})
+		return
+	}
+
+	url, err := presigner.PresignGet(fmt.Sprint(file["filename_disk"]), fmt.Sprint(file["filename_download"]), expires)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to presign file URL")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create URL"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"url":     url,
+		"expires": time.Now().Add(expires).UTC().Format(time.RFC3339),
+	}})
+}
+
+// Presigned URLs are valid for an hour unless requested otherwise, and S3
+// doesn't accept more than a week
+const (
+	defaultPresignExpiry = time.Hour
+	maxPresignExpiry     = 7 * 24 * time.Hour
+)
+
+// fileDriver returns the driver of the location a file is stored in, failing
+// when the location is no longer configured.
+func (h *FilesHandler) fileDriver(c *gin.Context, file Item) (storage.Driver, bool) {
+	location := fmt.Sprint(file["storage"])
+	driver, ok := h.storage.Get(location)
+	if !ok {
+		logrus.WithField("storage", location).Error("Stored file is in an unknown storage location")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Storage location not configured"})
+		return nil, false
+	}
+	return driver, true
+}
+
+// resolveReadableFile loads the file of a read request, applying the Public
+// role's permission to anonymous requests.
+func (h *FilesHandler) resolveReadableFile(c *gin.Context) (*ItemPermission, Item, bool) {
+	permission, ok := h.items.resolvePublicPermission(c, filesCollection)
+	if !ok {
+		return nil, nil, false
+	}
+
+	file, err := h.items.getItemByIDWithFilter(filesCollection, c.Param("id"), permission.filter())
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return nil, nil, false
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while fetching file")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, nil, false
+	}
+
+	return permission, file, true
+}
+
+// resolveWritableFile loads a file the requesting user may change: admins may
+// change any file, other users the files they uploaded.
+func (h *FilesHandler) resolveWritableFile(c *gin.Context, fileID string) (Item, bool) {
+	file, err := h.items.getItemByID(filesCollection, fileID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return nil, false
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while fetching file")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+
+	uploadedBy, _ := file["uploaded_by"].(string)
+	if !isAdminOrSelf(c, uploadedBy) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return file, true
+}
+
+// assetDisposition returns how an asset of contentType is served. Only media
+// types browsers display without running scripts are shown inline; anything
+// else, such as HTML or SVG uploads, is always downloaded.
+func assetDisposition(c *gin.Context, contentType string) string {
+	if c.Query("download") == "true" || !isInlineMediaType(contentType) {
+		return "attachment"
+	}
+	return "inline"
+}
+
+// isInlineMediaType reports whether a MIME type is safe to display inline
+func isInlineMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "image/svg+xml":
+		return false
+	case strings.HasPrefix(mediaType, "image/"), strings.HasPrefix(mediaType, "video/"),
+		strings.HasPrefix(mediaType, "audio/"), mediaType == "application/pdf":
+		return true
+	}
+	return false
+}
+
+// uploadMimeType returns the MIME type of an upload: the type the client sent,
+// or the type sniffed from the first bytes when it sent none or a generic one
+func uploadMimeType(sent string, head []byte) string {
+	if mediaType, params, err := mime.ParseMediaType(sent); err == nil && mediaType != "application/octet-stream" {
+		return mime.FormatMediaType(mediaType, params)
+	}
+	return http.DetectContentType(head)
+}
+
+// errRangeNotSatisfiable is returned for ranges outside of the file
+var errRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// parseRange parses a Range header for a file of size bytes. Only a single
+// byte range is served partially; anything else is served as the whole file.
+func parseRange(header string, size int64) (offset, length int64, partial bool, err error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if header == "" || !ok || strings.Contains(spec, ",") {
+		return 0, -1, false, nil
+	}
+
+	startStr, endStr, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return 0, -1, false, nil
+	}
+
+	if startStr == "" {
+		// bytes=-n is the last n bytes
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil {
+			return 0, -1, false, nil
+		}
+		if n <= 0 || size == 0 {
+			return 0, 0, false, errRangeNotSatisfiable
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, n, true, nil
+	}
+
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 {
+		return 0, -1, false, nil
+	}
+	if start >= size {
+		return 0, 0, false, errRangeNotSatisfiable
+	}
+
+	end := size - 1
+	if endStr != "" {
+		if end, err = strconv.ParseInt(endStr, 10, 64); err != nil || end < start {
+			return 0, -1, false, nil
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+
+	return start, end - start + 1, true, nil
+}
+
+// toInt64 converts a scanned integer column value
+func toInt64(value interface{}) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	case []byte:
+		n, _ := strconv.ParseInt(string(v), 10, 64)
+		return n
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	default:
+		return 0
+	}
+}
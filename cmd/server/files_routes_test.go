@@ -0,0 +1,464 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gorectus/internal/storage"
+)
+
+// Test suite for file handlers
+type FileHandlersTestSuite struct {
+	suite.Suite
+	db      *sql.DB
+	mock    sqlmock.Sqlmock
+	storage *storage.Local
+	assets  *assetCache
+}
+
+func (suite *FileHandlersTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+	logrus.SetLevel(logrus.PanicLevel)
+}
+
+func (suite *FileHandlersTestSuite) SetupTest() {
+	db, mock, err := sqlmock.New()
+	require.NoError(suite.T(), err)
+	suite.db = db
+	suite.mock = mock
+
+	suite.storage, err = storage.NewLocal(suite.T().TempDir())
+	require.NoError(suite.T(), err)
+	suite.assets, err = newAssetCache(suite.T().TempDir(), defaultAssetCacheMaxSize)
+	require.NoError(suite.T(), err)
+}
+
+func (suite *FileHandlersTestSuite) TearDownTest() {
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+	suite.db.Close()
+}
+
+// router serves the files routes as the given user
+func (suite *FileHandlersTestSuite) router(userID string, admin bool) *gin.Engine {
+	router := gin.New()
+	mockServer := &mockItemServerInterface{
+		db: suite.db,
+		customAuthFunc: func(c *gin.Context) {
+			c.Set("user_id", userID)
+			c.Set("admin_access", admin)
+			c.Set("app_access", true)
+			c.Next()
+		},
+	}
+	archive, err := storage.NewS3(storage.S3Config{
+		Endpoint:       "http://localhost:9000",
+		Bucket:         "archive",
+		AccessKey:      "minio",
+		SecretKey:      "minio123",
+		ForcePathStyle: true,
+	})
+	require.NoError(suite.T(), err)
+	locations := storage.NewLocations([]string{"local", "archive"},
+		map[string]storage.Driver{"local": suite.storage, "archive": archive})
+
+	NewFilesHandler(mockServer, locations, suite.assets).SetupRoutes(router.Group("/api/v1"))
+	return router
+}
+
+// capturedArg matches any string argument and keeps it
+type capturedArg struct {
+	value string
+}
+
+func (a *capturedArg) Match(v driver.Value) bool {
+	a.value, _ = v.(string)
+	return true
+}
+
+func fileRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "storage", "filename_disk", "filename_download", "title", "description",
+		"type", "filesize", "checksum", "uploaded_by", "folder", "created_at", "updated_at",
+	})
+}
+
+func (suite *FileHandlersTestSuite) TestUploadFile() {
+	contents := "Hello, world"
+	checksum := sha256.Sum256([]byte(contents))
+
+	filenameDisk := &capturedArg{}
+	suite.mock.ExpectQuery(`SELECT storage_default_folder FROM settings`).
+		WillReturnRows(sqlmock.NewRows([]string{"storage_default_folder"}).AddRow(nil))
+	suite.mock.ExpectExec("INSERT INTO files").
+		WithArgs(sqlmock.AnyArg(), "local", filenameDisk, "notes.txt", "notes", nil,
+			"text/plain; charset=utf-8", int64(len(contents)), hex.EncodeToString(checksum[:]), "user-1", nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WillReturnRows(
+		fileRows().AddRow("f1", "local", "f1.txt", "notes.txt", "Notes", nil, "text/plain; charset=utf-8",
+			int64(len(contents)), hex.EncodeToString(checksum[:]), "user-1", nil, nil, nil))
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", "notes.txt")
+	part.Write([]byte(contents))
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/api/v1/files", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+
+	suite.router("user-1", false).ServeHTTP(w, req)
+
+	require.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	// The stored file is named after the file ID with the original extension
+	require.True(suite.T(), strings.HasSuffix(filenameDisk.value, ".txt"))
+	reader, err := suite.storage.Open(context.Background(), filenameDisk.value, 0, -1)
+	require.NoError(suite.T(), err)
+	defer reader.Close()
+	stored, _ := io.ReadAll(reader)
+	assert.Equal(suite.T(), contents, string(stored))
+}
+
+func (suite *FileHandlersTestSuite) TestUploadFile_NoFile() {
+	req := httptest.NewRequest("POST", "/api/v1/files", strings.NewReader(""))
+	w := httptest.NewRecorder()
+
+	suite.router("user-1", false).ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *FileHandlersTestSuite) TestUploadFile_UnknownStorage() {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", "notes.txt")
+	part.Write([]byte("Hello"))
+	writer.WriteField("storage", "missing")
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/api/v1/files", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+
+	suite.router("user-1", false).ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Unknown storage location 'missing'")
+}
+
+func (suite *FileHandlersTestSuite) TestGetFileURL() {
+	suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WithArgs("f1").WillReturnRows(
+		fileRows().AddRow("f1", "archive", "f1.pdf", "report.pdf", "Report", nil, "application/pdf", int64(12), "abc", nil, nil, nil, nil))
+
+	req := httptest.NewRequest("GET", "/api/v1/files/f1/url?expires=600", nil)
+	w := httptest.NewRecorder()
+
+	suite.router("user-1", false).ServeHTTP(w, req)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var response struct {
+		Data struct {
+			URL string `json:"url"`
+		} `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(suite.T(), strings.HasPrefix(response.Data.URL, "http://localhost:9000/archive/f1.pdf?"))
+	assert.Contains(suite.T(), response.Data.URL, "X-Amz-Expires=600")
+}
+
+func (suite *FileHandlersTestSuite) TestGetFileURL_LocalStorage() {
+	suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WithArgs("f1").WillReturnRows(
+		fileRows().AddRow("f1", "local", "f1.txt", "notes.txt", "Notes", nil, "text/plain", int64(12), "abc", nil, nil, nil, nil))
+
+	req := httptest.NewRequest("GET", "/api/v1/files/f1/url", nil)
+	w := httptest.NewRecorder()
+
+	suite.router("user-1", false).ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *FileHandlersTestSuite) TestGetAsset() {
+	require.NoError(suite.T(), suite.storage.Put(context.Background(), "f1.txt", strings.NewReader("Hello, world")))
+	suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WithArgs("f1").WillReturnRows(
+		fileRows().AddRow("f1", "local", "f1.txt", "notes.txt", "Notes", nil, "text/plain", int64(12), "abc", nil, nil, nil, nil))
+
+	req := httptest.NewRequest("GET", "/api/v1/assets/f1?download=true", nil)
+	w := httptest.NewRecorder()
+
+	suite.router("user-1", false).ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "Hello, world", w.Body.String())
+	assert.Equal(suite.T(), "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), `attachment; filename=notes.txt`, w.Header().Get("Content-Disposition"))
+	assert.Equal(suite.T(), `"abc"`, w.Header().Get("ETag"))
+}
+
+func (suite *FileHandlersTestSuite) TestGetAsset_InlineTypes() {
+	tests := map[string]string{
+		"application/pdf":          "inline",
+		"video/mp4":                "inline",
+		"text/html; charset=utf-8": "attachment",
+		"image/svg+xml":            "attachment",
+	}
+	for contentType, disposition := range tests {
+		suite.Run(contentType, func() {
+			require.NoError(suite.T(), suite.storage.Put(context.Background(), "f1.bin", strings.NewReader("<script>")))
+			suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WithArgs("f1").WillReturnRows(
+				fileRows().AddRow("f1", "local", "f1.bin", "upload", "Upload", nil, contentType, int64(8), "abc", nil, nil, nil, nil))
+
+			req := httptest.NewRequest("GET", "/api/v1/assets/f1", nil)
+			w := httptest.NewRecorder()
+
+			suite.router("user-1", false).ServeHTTP(w, req)
+
+			assert.Equal(suite.T(), http.StatusOK, w.Code)
+			assert.Equal(suite.T(), disposition+"; filename=upload", w.Header().Get("Content-Disposition"))
+			assert.Equal(suite.T(), "nosniff", w.Header().Get("X-Content-Type-Options"))
+		})
+	}
+}
+
+func (suite *FileHandlersTestSuite) TestGetAsset_Range() {
+	require.NoError(suite.T(), suite.storage.Put(context.Background(), "f1.txt", strings.NewReader("Hello, world")))
+	suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WithArgs("f1").WillReturnRows(
+		fileRows().AddRow("f1", "local", "f1.txt", "notes.txt", "Notes", nil, "text/plain", int64(12), "abc", nil, nil, nil, nil))
+
+	req := httptest.NewRequest("GET", "/api/v1/assets/f1", nil)
+	req.Header.Set("Range", "bytes=7-")
+	w := httptest.NewRecorder()
+
+	suite.router("user-1", false).ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusPartialContent, w.Code)
+	assert.Equal(suite.T(), "world", w.Body.String())
+	assert.Equal(suite.T(), "bytes 7-11/12", w.Header().Get("Content-Range"))
+}
+
+func (suite *FileHandlersTestSuite) TestGetAsset_RangeNotSatisfiable() {
+	suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WithArgs("f1").WillReturnRows(
+		fileRows().AddRow("f1", "local", "f1.txt", "notes.txt", "Notes", nil, "text/plain", int64(12), "abc", nil, nil, nil, nil))
+
+	req := httptest.NewRequest("GET", "/api/v1/assets/f1", nil)
+	req.Header.Set("Range", "bytes=20-30")
+	w := httptest.NewRecorder()
+
+	suite.router("user-1", false).ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusRequestedRangeNotSatisfiable, w.Code)
+	assert.Equal(suite.T(), "bytes */12", w.Header().Get("Content-Range"))
+}
+
+func (suite *FileHandlersTestSuite) TestDeleteFile() {
+	require.NoError(suite.T(), suite.storage.Put(context.Background(), "f1.txt", strings.NewReader("Hello")))
+	suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WithArgs("f1").WillReturnRows(
+		fileRows().AddRow("f1", "local", "f1.txt", "notes.txt", "Notes", nil, "text/plain", int64(5), "abc", "user-1", nil, nil, nil))
+	suite.mock.ExpectExec("DELETE FROM files WHERE id").WithArgs("f1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	req := httptest.NewRequest("DELETE", "/api/v1/files/f1", nil)
+	w := httptest.NewRecorder()
+
+	suite.router("user-1", false).ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	_, err := suite.storage.Open(context.Background(), "f1.txt", 0, -1)
+	assert.ErrorIs(suite.T(), err, storage.ErrNotFound)
+}
+
+func (suite *FileHandlersTestSuite) TestDeleteFile_InUse() {
+	require.NoError(suite.T(), suite.storage.Put(context.Background(), "f1.txt", strings.NewReader("Hello")))
+	suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WithArgs("f1").WillReturnRows(
+		fileRows().AddRow("f1", "local", "f1.txt", "notes.txt", "Notes", nil, "text/plain", int64(5), "abc", "user-1", nil, nil, nil))
+	suite.mock.ExpectExec("DELETE FROM files WHERE id").WithArgs("f1").
+		WillReturnError(&pq.Error{Code: "23503", Message: "violates foreign key constraint"})
+
+	req := httptest.NewRequest("DELETE", "/api/v1/files/f1", nil)
+	w := httptest.NewRecorder()
+
+	suite.router("user-1", false).ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	// The contents are kept along with the row
+	reader, err := suite.storage.Open(context.Background(), "f1.txt", 0, -1)
+	require.NoError(suite.T(), err)
+	reader.Close()
+}
+
+func (suite *FileHandlersTestSuite) TestDeleteFile_OtherUploader() {
+	suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WithArgs("f1").WillReturnRows(
+		fileRows().AddRow("f1", "local", "f1.txt", "notes.txt", "Notes", nil, "text/plain", int64(5), "abc", "user-2", nil, nil, nil))
+
+	req := httptest.NewRequest("DELETE", "/api/v1/files/f1", nil)
+	w := httptest.NewRecorder()
+
+	suite.router("user-1", false).ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *FileHandlersTestSuite) TestUpdateFile() {
+	suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WithArgs("f1").WillReturnRows(
+		fileRows().AddRow("f1", "local", "f1.txt", "notes.txt", "Notes", nil, "text/plain", int64(5), "abc", "user-2", nil, nil, nil))
+	suite.mock.ExpectExec(`UPDATE files SET title = \$1, filename_download = \$2 WHERE id = \$3`).
+		WithArgs("Report", "report.txt", "f1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WithArgs("f1").WillReturnRows(
+		fileRows().AddRow("f1", "local", "f1.txt", "report.txt", "Report", nil, "text/plain", int64(5), "abc", "user-2", nil, nil, nil))
+
+	req := httptest.NewRequest("PATCH", "/api/v1/files/f1",
+		strings.NewReader(`{"title": "Report", "filename_download": "../report.txt"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	suite.router("admin", true).ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *FileHandlersTestSuite) TestGetFiles_FolderAndSort() {
+	folderID := "7d2f6a34-5c1e-4b7a-9f0e-2a8c1d3e4f50"
+	suite.mock.ExpectQuery(`SELECT \* FROM files WHERE "folder" = \$1 ORDER BY "filesize" DESC, "title" ASC LIMIT \$2 OFFSET \$3`).
+		WithArgs(folderID, 50, 0).
+		WillReturnRows(fileRows().AddRow("f1", "local", "f1.txt", "notes.txt", "Notes", nil, "text/plain", int64(5), "abc", "user-1", folderID, nil, nil))
+	suite.mock.ExpectQuery(`SELECT COUNT\(\*\) FROM files WHERE "folder" = \$1`).WithArgs(folderID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	req := httptest.NewRequest("GET", "/api/v1/files?folder="+folderID+"&sort=-filesize,title", nil)
+	w := httptest.NewRecorder()
+
+	suite.router("user-1", false).ServeHTTP(w, req)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	files := response["data"].([]interface{})
+	require.Len(suite.T(), files, 1)
+	assert.Equal(suite.T(), folderID, files[0].(map[string]interface{})["folder"])
+}
+
+func (suite *FileHandlersTestSuite) TestGetFiles_RootFolderWithFilter() {
+	suite.mock.ExpectQuery(`SELECT \* FROM files WHERE \("type" = \$1 AND "folder" IS NULL\) ORDER BY created_at DESC`).
+		WithArgs("image/png", 50, 0).
+		WillReturnRows(fileRows())
+	suite.mock.ExpectQuery(`SELECT COUNT\(\*\) FROM files WHERE`).WithArgs("image/png").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	req := httptest.NewRequest("GET", `/api/v1/files?folder=null&filter={"type":{"_eq":"image/png"}}`, nil)
+	w := httptest.NewRecorder()
+
+	suite.router("user-1", false).ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+}
+
+func (suite *FileHandlersTestSuite) TestGetFiles_InvalidQuery() {
+	for _, url := range []string{
+		"/api/v1/files?sort=password",
+		`/api/v1/files?filter={"secret":{"_eq":"x"}}`,
+		"/api/v1/files?filter=nope",
+		"/api/v1/files?folder=not-a-folder",
+	} {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+
+		suite.router("user-1", false).ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, url)
+	}
+}
+
+func (suite *FileHandlersTestSuite) TestUpdateFile_MoveToFolder() {
+	folderID := "7d2f6a34-5c1e-4b7a-9f0e-2a8c1d3e4f50"
+	suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WithArgs("f1").WillReturnRows(
+		fileRows().AddRow("f1", "local", "f1.txt", "notes.txt", "Notes", nil, "text/plain", int64(5), "abc", "user-1", nil, nil, nil))
+	suite.mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM folders WHERE id = \$1\)`).WithArgs(folderID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	suite.mock.ExpectExec(`UPDATE files SET folder = \$1 WHERE id = \$2`).
+		WithArgs(folderID, "f1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectQuery(`SELECT \* FROM "files" WHERE id = \$1`).WithArgs("f1").WillReturnRows(
+		fileRows().AddRow("f1", "local", "f1.txt", "notes.txt", "Notes", nil, "text/plain", int64(5), "abc", "user-1", folderID, nil, nil))
+
+	req := httptest.NewRequest("PATCH", "/api/v1/files/f1", strings.NewReader(`{"folder": "`+folderID+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	suite.router("user-1", false).ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+}
+
+func (suite *FileHandlersTestSuite) TestUploadFile_UnknownFolder() {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("folder", "missing")
+	part, _ := writer.CreateFormFile("file", "notes.txt")
+	part.Write([]byte("Hello"))
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/api/v1/files", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+
+	suite.router("user-1", false).ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func TestFileHandlersTestSuite(t *testing.T) {
+	suite.Run(t, new(FileHandlersTestSuite))
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		header         string
+		offset, length int64
+		partial, err   bool
+	}{
+		{"", 0, -1, false, false},
+		{"bytes=0-99", 0, 100, true, false},
+		{"bytes=100-", 100, 900, true, false},
+		{"bytes=-100", 900, 100, true, false},
+		{"bytes=-5000", 0, 1000, true, false},
+		{"bytes=990-5000", 990, 10, true, false},
+		{"bytes=1000-", 0, 0, false, true},
+		{"bytes=0-1,5-9", 0, -1, false, false},
+		{"items=0-9", 0, -1, false, false},
+		{"bytes=9-0", 0, -1, false, false},
+	}
+
+	for _, tt := range tests {
+		offset, length, partial, err := parseRange(tt.header, 1000)
+		assert.Equal(t, tt.err, err != nil, tt.header)
+		assert.Equal(t, tt.partial, partial, tt.header)
+		assert.Equal(t, tt.offset, offset, tt.header)
+		assert.Equal(t, tt.length, length, tt.header)
+	}
+}
+
+func TestUploadMimeType(t *testing.T) {
+	assert.Equal(t, "image/png", uploadMimeType("image/png", nil))
+	assert.Equal(t, "text/plain; charset=utf-8", uploadMimeType("application/octet-stream", []byte("Hello")))
+	assert.Equal(t, "image/png", uploadMimeType("", []byte("\x89PNG\r\n\x1a\n")))
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// buildFilterSQL translates a filter object (as stored in permissions.permissions)
+// into a SQL WHERE fragment. Placeholders start at argIndex.
+//
+// Supported forms:
+//
+//	{"status": {"_eq": "published"}}
+//	{"_and": [{...}, {...}]}, {"_or": [{...}, {...}]}
+//
+// An empty filter produces an empty fragment.
+func buildFilterSQL(filter map[string]interface{}, argIndex int) (string, []interface{}, error) {
+	if len(filter) == 0 {
+		return "", nil, nil
+	}
+
+	// Sort keys so the generated SQL is deterministic
+	keys := make([]string, 0, len(filter))
+	for key := range filter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	clauses := []string{}
+	args := []interface{}{}
+
+	for _, key := range keys {
+		value := filter[key]
+
+		switch key {
+		case "_and", "_or":
+			group, ok := value.([]interface{})
+			if !ok {
+				return "", nil, fmt.Errorf("%s expects an array of filters", key)
+			}
+
+			groupClauses := []string{}
+			for _, entry := range group {
+				subFilter, ok := entry.(map[string]interface{})
+				if !ok {
+					return "", nil, fmt.Errorf("%s expects an array of filters", key)
+				}
+				clause, subArgs, err := buildFilterSQL(subFilter, argIndex+len(args))
+				if err != nil {
+					return "", nil, err
+				}
+				if clause != "" {
+					groupClauses = append(groupClauses, clause)
+					args = append(args, subArgs...)
+				}
+			}
+
+			if len(groupClauses) > 0 {
+				joiner := " AND "
+				if key == "_or" {
+					joiner = " OR "
+				}
+				clauses = append(clauses, "("+strings.Join(groupClauses, joiner)+")")
+			}
+		default:
+			if !isValidFieldName(key) {
+				return "", nil, fmt.Errorf("invalid filter field: %s", key)
+			}
+
+			operators, ok := value.(map[string]interface{})
+			if !ok {
+				// Plain values are shorthand for _eq
+				operators = map[string]interface{}{"_eq": value}
+			}
+
+			clause, fieldArgs, err := buildFieldFilterSQL(key, operators, argIndex+len(args))
+			if err != nil {
+				return "", nil, err
+			}
+			clauses = append(clauses, clause)
+			args = append(args, fieldArgs...)
+		}
+	}
+
+	return strings.Join(clauses, " AND "), args, nil
+}
+
+// buildFieldFilterSQL builds the conditions for a single field's operator object
+func buildFieldFilterSQL(field string, operators map[string]interface{}, argIndex int) (string, []interface{}, error) {
+	operatorNames := make([]string, 0, len(operators))
+	for op := range operators {
+		operatorNames = append(operatorNames, op)
+	}
+	sort.Strings(operatorNames)
+
+	column := `"` + field + `"`
+	clauses := []string{}
+	args := []interface{}{}
+
+	nextPlaceholder := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", argIndex+len(args)-1)
+	}
+
+	for _, op := range operatorNames {
+		value := operators[op]
+
+		switch op {
+		case "_eq":
+			if value == nil {
+				clauses = append(clauses, column+" IS NULL")
+			} else {
+				clauses = append(clauses, column+" = "+nextPlaceholder(value))
+			}
+		case "_neq":
+			if value == nil {
+				clauses = append(clauses, column+" IS NOT NULL")
+			} else {
+				clauses = append(clauses, column+" <> "+nextPlaceholder(value))
+			}
+		case "_lt":
+			clauses = append(clauses, column+" < "+nextPlaceholder(value))
+		case "_lte":
+			clauses = append(clauses, column+" <= "+nextPlaceholder(value))
+		case "_gt":
+			clauses = append(clauses, column+" > "+nextPlaceholder(value))
+		case "_gte":
+			clauses = append(clauses, column+" >= "+nextPlaceholder(value))
+		case "_in", "_nin":
+			values, ok := value.([]interface{})
+			if !ok {
+				return "", nil, fmt.Errorf("%s expects an array for field %s", op, field)
+			}
+			if len(values) == 0 {
+				// Nothing is in an empty set
+				if op == "_in" {
+					clauses = append(clauses, "FALSE")
+				}
+				continue
+			}
+			placeholders := make([]string, 0, len(values))
+			for _, v := range values {
+				placeholders = append(placeholders, nextPlaceholder(v))
+			}
+			keyword := " IN "
+			if op == "_nin" {
+				keyword = " NOT IN "
+			}
+			clauses = append(clauses, column+keyword+"("+strings.Join(placeholders, ", ")+")")
+		case "_null":
+			if isTruthy(value) {
+				clauses = append(clauses, column+" IS NULL")
+			} else {
+				clauses = append(clauses, column+" IS NOT NULL")
+			}
+		case "_nnull":
+			if isTruthy(value) {
+				clauses = append(clauses, column+" IS NOT NULL")
+			} else {
+				clauses = append(clauses, column+" IS NULL")
+			}
+		case "_contains":
+			clauses = append(clauses, column+"::text LIKE "+nextPlaceholder("%"+fmt.Sprint(value)+"%"))
+		case "_ncontains":
+			clauses = append(clauses, column+"::text NOT LIKE "+nextPlaceholder("%"+fmt.Sprint(value)+"%"))
+		case "_icontains":
+			clauses = append(clauses, column+"::text ILIKE "+nextPlaceholder("%"+fmt.Sprint(value)+"%"))
+		case "_starts_with":
+			clauses = append(clauses, column+"::text LIKE "+nextPlaceholder(fmt.Sprint(value)+"%"))
+		case "_ends_with":
+			clauses = append(clauses, column+"::text LIKE "+nextPlaceholder("%"+fmt.Sprint(value)))
+		default:
+			return "", nil, fmt.Errorf("unsupported filter operator: %s", op)
+		}
+	}
+
+	if len(clauses) == 0 {
+		return "TRUE", args, nil
+	}
+
+	return strings.Join(clauses, " AND "), args, nil
+}
+
+// parseFilterQuery parses the JSON filter object of a ?filter query parameter,
+// in the syntax of buildFilterSQL. Every field it references must be allowed.
+// An empty parameter produces no filter.
+func parseFilterQuery(raw string, allowed func(string) bool) (map[string]interface{}, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var filter map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &filter); err != nil {
+		return nil, errors.New("filter must be a JSON object")
+	}
+	if err := checkFilterFields(filter, allowed); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// checkFilterFields checks the fields referenced by a filter, including those
+// in _and and _or groups
+func checkFilterFields(filter map[string]interface{}, allowed func(string) bool) error {
+	for key, value := range filter {
+		if key == "_and" || key == "_or" {
+			group, _ := value.([]interface{})
+			for _, entry := range group {
+				if subFilter, ok := entry.(map[string]interface{}); ok {
+					if err := checkFilterFields(subFilter, allowed); err != nil {
+						return err
+					}
+				}
+			}
+			continue
+		}
+		if !allowed(key) {
+			return fmt.Errorf("unknown filter field: %s", key)
+		}
+	}
+	return nil
+}
+
+// matchesFilter evaluates a filter object against an item in memory, with the
+// same semantics as the SQL built by buildFilterSQL
+func matchesFilter(filter map[string]interface{}, item Item) (bool, error) {
+	for key, value := range filter {
+		switch key {
+		case "_and", "_or":
+			group, ok := value.([]interface{})
+			if !ok {
+				return false, fmt.Errorf("%s expects an array of filters", key)
+			}
+
+			matched := key == "_and" || len(group) == 0
+			for _, entry := range group {
+				subFilter, ok := entry.(map[string]interface{})
+				if !ok {
+					return false, fmt.Errorf("%s expects an array of filters", key)
+				}
+				subMatch, err := matchesFilter(subFilter, item)
+				if err != nil {
+					return false, err
+				}
+				if key == "_and" && !subMatch {
+					matched = false
+				}
+				if key == "_or" && subMatch {
+					matched = true
+				}
+			}
+			if !matched {
+				return false, nil
+			}
+		default:
+			operators, ok := value.(map[string]interface{})
+			if !ok {
+				// Plain values are shorthand for _eq
+				operators = map[string]interface{}{"_eq": value}
+			}
+
+			matched, err := matchesFieldFilter(key, operators, item[key])
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// matchesFieldFilter evaluates a single field's operator object against its value
+func matchesFieldFilter(field string, operators map[string]interface{}, actual interface{}) (bool, error) {
+	for op, operand := range operators {
+		var matched bool
+
+		switch op {
+		case "_eq":
+			matched = valuesEqual(actual, operand)
+		case "_neq":
+			matched = !valuesEqual(actual, operand)
+		case "_lt", "_lte", "_gt", "_gte":
+			comparison, ok := compareValues(actual, operand)
+			if !ok {
+				return false, nil
+			}
+			switch op {
+			case "_lt":
+				matched = comparison < 0
+			case "_lte":
+				matched = comparison <= 0
+			case "_gt":
+				matched = comparison > 0
+			case "_gte":
+				matched = comparison >= 0
+			}
+		case "_in", "_nin":
+			values, ok := operand.([]interface{})
+			if !ok {
+				return false, fmt.Errorf("%s expects an array for field %s", op, field)
+			}
+			found := false
+			for _, v := range values {
+				if valuesEqual(actual, v) {
+					found = true
+					break
+				}
+			}
+			// SQL never matches NULL with IN or NOT IN
+			matched = actual != nil && found == (op == "_in")
+		case "_null":
+			matched = (actual == nil) == isTruthy(operand)
+		case "_nnull":
+			matched = (actual != nil) == isTruthy(operand)
+		case "_contains", "_ncontains", "_icontains", "_starts_with", "_ends_with":
+			if actual == nil {
+				return false, nil
+			}
+			text, needle := fmt.Sprint(actual), fmt.Sprint(operand)
+			switch op {
+			case "_contains":
+				matched = strings.Contains(text, needle)
+			case "_ncontains":
+				matched = !strings.Contains(text, needle)
+			case "_icontains":
+				matched = strings.Contains(strings.ToLower(text), strings.ToLower(needle))
+			case "_starts_with":
+				matched = strings.HasPrefix(text, needle)
+			case "_ends_with":
+				matched = strings.HasSuffix(text, needle)
+			}
+		default:
+			return false, fmt.Errorf("unsupported filter operator: %s", op)
+		}
+
+		if !matched {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// valuesEqual compares two JSON values, treating numbers and strings loosely
+// the way Postgres compares a parameter with a column
+func valuesEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if comparison, ok := compareValues(a, b); ok {
+		return comparison == 0
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// compareValues orders two numbers or two strings; ok is false for other pairs
+func compareValues(a, b interface{}) (int, bool) {
+	if left, ok := numericValue(a); ok {
+		right, ok := numericValue(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case left < right:
+			return -1, true
+		case left > right:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	left, ok := a.(string)
+	if !ok {
+		return 0, false
+	}
+	right, ok := b.(string)
+	if !ok {
+		return 0, false
+	}
+	return strings.Compare(left, right), true
+}
+
+// numericValue converts JSON numbers and scanned integer columns to float64
+func numericValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// isTruthy interprets a filter operand as a boolean
+func isTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true" || v == "1"
+	case float64:
+		return v != 0
+	case nil:
+		return false
+	default:
+		return true
+	}
+}
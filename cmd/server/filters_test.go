@@ -0,0 +1,157 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildFilterSQL(t *testing.T) {
+	tests := []struct {
+		name         string
+		filter       map[string]interface{}
+		argIndex     int
+		expectedSQL  string
+		expectedArgs []interface{}
+	}{
+		{
+			name:         "Empty filter",
+			filter:       nil,
+			argIndex:     1,
+			expectedSQL:  "",
+			expectedArgs: nil,
+		},
+		{
+			name:         "Equality",
+			filter:       map[string]interface{}{"status": map[string]interface{}{"_eq": "published"}},
+			argIndex:     1,
+			expectedSQL:  `"status" = $1`,
+			expectedArgs: []interface{}{"published"},
+		},
+		{
+			name:         "Plain value shorthand",
+			filter:       map[string]interface{}{"status": "published"},
+			argIndex:     3,
+			expectedSQL:  `"status" = $3`,
+			expectedArgs: []interface{}{"published"},
+		},
+		{
+			name: "Multiple fields sorted",
+			filter: map[string]interface{}{
+				"views":  map[string]interface{}{"_gte": float64(10)},
+				"author": map[string]interface{}{"_null": false},
+			},
+			argIndex:     1,
+			expectedSQL:  `"author" IS NOT NULL AND "views" >= $1`,
+			expectedArgs: []interface{}{float64(10)},
+		},
+		{
+			name: "In list",
+			filter: map[string]interface{}{
+				"status": map[string]interface{}{"_in": []interface{}{"draft", "published"}},
+			},
+			argIndex:     1,
+			expectedSQL:  `"status" IN ($1, $2)`,
+			expectedArgs: []interface{}{"draft", "published"},
+		},
+		{
+			name: "Or group",
+			filter: map[string]interface{}{
+				"_or": []interface{}{
+					map[string]interface{}{"status": map[string]interface{}{"_eq": "published"}},
+					map[string]interface{}{"title": map[string]interface{}{"_contains": "news"}},
+				},
+			},
+			argIndex:     1,
+			expectedSQL:  `("status" = $1 OR "title"::text LIKE $2)`,
+			expectedArgs: []interface{}{"published", "%news%"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := buildFilterSQL(tt.filter, tt.argIndex)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSQL, sql)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
+
+func TestBuildFilterSQLErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter map[string]interface{}
+	}{
+		{"Invalid field name", map[string]interface{}{"status; DROP TABLE users": "x"}},
+		{"Unknown operator", map[string]interface{}{"status": map[string]interface{}{"_regex": "x"}}},
+		{"Invalid group", map[string]interface{}{"_and": "x"}},
+		{"Invalid in operand", map[string]interface{}{"status": map[string]interface{}{"_in": "x"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := buildFilterSQL(tt.filter, 1)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMatchesFilter(t *testing.T) {
+	item := Item{"status": "published", "views": int64(12), "title": "Hello World", "deleted_at": nil}
+
+	tests := []struct {
+		name     string
+		filter   map[string]interface{}
+		expected bool
+	}{
+		{"Empty filter", nil, true},
+		{"Plain value shorthand", map[string]interface{}{"status": "published"}, true},
+		{"Not equal", map[string]interface{}{"status": map[string]interface{}{"_neq": "published"}}, false},
+		{"Scanned integer compared with JSON number", map[string]interface{}{"views": map[string]interface{}{"_gte": float64(10)}}, true},
+		{"Greater than", map[string]interface{}{"views": map[string]interface{}{"_gt": float64(12)}}, false},
+		{"In", map[string]interface{}{"status": map[string]interface{}{"_in": []interface{}{"draft", "published"}}}, true},
+		{"Not in never matches null", map[string]interface{}{"deleted_at": map[string]interface{}{"_nin": []interface{}{"x"}}}, false},
+		{"Null", map[string]interface{}{"deleted_at": map[string]interface{}{"_null": true}}, true},
+		{"Case-insensitive contains", map[string]interface{}{"title": map[string]interface{}{"_icontains": "world"}}, true},
+		{"Starts with", map[string]interface{}{"title": map[string]interface{}{"_starts_with": "World"}}, false},
+		{"Or group", map[string]interface{}{"_or": []interface{}{
+			map[string]interface{}{"status": "draft"},
+			map[string]interface{}{"views": map[string]interface{}{"_lt": float64(20)}},
+		}}, true},
+		{"And group", map[string]interface{}{"_and": []interface{}{
+			map[string]interface{}{"status": "published"},
+			map[string]interface{}{"deleted_at": map[string]interface{}{"_nnull": true}},
+		}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, err := matchesFilter(tt.filter, item)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, matched)
+		})
+	}
+
+	_, err := matchesFilter(map[string]interface{}{"status": map[string]interface{}{"_like": "x"}}, item)
+	assert.Error(t, err)
+}
+
+func TestParseFilterQuery(t *testing.T) {
+	allowed := func(field string) bool { return field == "status" || field == "views" }
+
+	filter, err := parseFilterQuery("", allowed)
+	require.NoError(t, err)
+	assert.Nil(t, filter)
+
+	filter, err = parseFilterQuery(`{"_or": [{"status": "draft"}, {"views": {"_gt": 10}}]}`, allowed)
+	require.NoError(t, err)
+	assert.Len(t, filter["_or"], 2)
+
+	_, err = parseFilterQuery(`{"_and": [{"password": {"_starts_with": "a"}}]}`, allowed)
+	assert.EqualError(t, err, "unknown filter field: password")
+
+	_, err = parseFilterQuery(`["status"]`, allowed)
+	assert.Error(t, err)
+}
@@ -0,0 +1,865 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/cron"
+	"gorectus/internal/hooks"
+)
+
+// Triggers that start a flow
+const (
+	flowTriggerEvent    = "event"
+	flowTriggerSchedule = "schedule"
+	flowTriggerManual   = "manual"
+	flowTriggerWebhook  = "webhook"
+)
+
+var flowTriggers = []string{flowTriggerEvent, flowTriggerSchedule, flowTriggerManual, flowTriggerWebhook}
+
+// Types of the operations a flow is made of
+const (
+	operationCondition  = "condition"
+	operationItemCreate = "item-create"
+	operationItemUpdate = "item-update"
+	operationMail       = "mail"
+	operationRequest    = "request"
+	operationTransform  = "transform"
+)
+
+var operationTypes = []string{operationCondition, operationItemCreate, operationItemUpdate,
+	operationMail, operationRequest, operationTransform}
+
+// Flow run statuses
+const (
+	flowRunRunning = "running"
+	flowRunSuccess = "success"
+	flowRunFailed  = "failed"
+)
+
+const (
+	// flowMaxSteps stops flows whose operations loop
+	flowMaxSteps = 100
+	// flowResponseLimit is the number of response body bytes a request
+	// operation reads
+	flowResponseLimit = 1 << 20
+	// flowMaxDepth is the depth of the events no event flow runs for, ending
+	// chains of flows triggering each other through the items they write
+	flowMaxDepth = 3
+)
+
+// errConditionNotMet rejects a condition operation. A flow whose condition
+// isn't met and has no reject operation ends successfully.
+var errConditionNotMet = errors.New("condition not met")
+
+// flowTrigger is what started a flow run. Data is available to operations
+// as $trigger.
+type flowTrigger struct {
+	Type           string
+	Data           interface{}
+	Accountability hooks.Accountability
+	// Depth is the depth of the triggering event, 0 for other triggers
+	Depth int
+}
+
+// flowRunKey is the context key of the running flow, whose item writes emit
+// events marked with it
+type flowRunKey struct{}
+
+// flowRunOrigin is what the events of a flow run's writes carry
+type flowRunOrigin struct {
+	Origin         string
+	Depth          int
+	Accountability hooks.Accountability
+}
+
+// flowOrigin returns the event origin of a flow's changes
+func flowOrigin(flowID string) string {
+	return "flow:" + flowID
+}
+
+// flowOperation is an operation as the runner executes it
+type flowOperation struct {
+	ID      string
+	Key     string
+	Type    string
+	Options map[string]interface{}
+	Resolve sql.NullString
+	Reject  sql.NullString
+}
+
+// flowStep records the outcome of one operation of a run
+type flowStep struct {
+	Operation string      `json:"operation"`
+	Type      string      `json:"type"`
+	Status    string      `json:"status"`
+	Data      interface{} `json:"data"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// flowResult is the outcome of a flow run. Last is the result of the last
+// operation run.
+type flowResult struct {
+	ID     string      `json:"id"`
+	Status string      `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	Last   interface{} `json:"data"`
+}
+
+// flowMailer sends the emails of mail operations
+type flowMailer interface {
+	Send(to []string, subject, body string) error
+}
+
+// flowRunner runs flows and logs every run in flow_runs. Event flows run as
+// action hooks and scheduled flows from a job; manual and webhook flows are
+// run by their routes.
+type flowRunner struct {
+	db     *sql.DB
+	items  *ItemsHandler
+	client *http.Client
+	mailer flowMailer
+	// events receives the events of items written by flows
+	events *hooks.Bus
+	// running tracks the scheduled and asynchronous webhook flows started
+	// in the background
+	running sync.WaitGroup
+}
+
+// newFlowRunner creates a runner sending mail with the SMTP settings
+func newFlowRunner(db *sql.DB) *flowRunner {
+	return &flowRunner{
+		db:     db,
+		items:  &ItemsHandler{db: db},
+		client: &http.Client{Timeout: 30 * time.Second},
+		mailer: &smtpMailer{db: db},
+	}
+}
+
+// register runs the active event flows subscribed to the collection and
+// action of every event of the bus, and emits the events of items written by
+// flows on it
+func (r *flowRunner) register(events *hooks.Bus) {
+	r.events = events
+	events.Action("*", func(ctx context.Context, event hooks.Event) {
+		if err := r.runEventFlows(ctx, event); err != nil {
+			logrus.WithError(err).WithField("event", event.Name).Error("Failed to run event flows")
+		}
+	})
+}
+
+// runEventFlows runs the event flows matching an event one after another.
+// A flow doesn't run for the changes it made itself, and no flow runs for
+// events flowMaxDepth deep.
+func (r *flowRunner) runEventFlows(ctx context.Context, event hooks.Event) error {
+	if event.Depth >= flowMaxDepth {
+		logrus.WithFields(logrus.Fields{"event": event.Name, "origin": event.Origin}).
+			Warn("Not running event flows for a change made by a chain of flows")
+		return nil
+	}
+	rows, err := r.db.Query(`
+		SELECT id, operation FROM flows
+		WHERE status = 'active' AND trigger = 'event'
+		  AND options->'collections' ? $1 AND options->'actions' ? $2
+		ORDER BY name, id`, event.Collection, event.Action)
+	if err != nil {
+		return err
+	}
+	type eventFlow struct {
+		id    string
+		start sql.NullString
+	}
+	var flows []eventFlow
+	for rows.Next() {
+		var flow eventFlow
+		if err := rows.Scan(&flow.id, &flow.start); err != nil {
+			rows.Close()
+			return err
+		}
+		if flowOrigin(flow.id) == event.Origin {
+			continue
+		}
+		flows = append(flows, flow)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	trigger := flowTrigger{
+		Type: flowTriggerEvent,
+		Data: map[string]interface{}{
+			"event":      event.Name,
+			"collection": event.Collection,
+			"action":     event.Action,
+			"keys":       event.Keys,
+			"payload":    event.Payload,
+		},
+		Accountability: event.Accountability,
+		Depth:          event.Depth,
+	}
+	for _, flow := range flows {
+		if _, err := r.run(ctx, flow.id, flow.start.String, trigger); err != nil {
+			logrus.WithError(err).WithField("flow_id", flow.id).Error("Failed to run flow")
+		}
+	}
+	return nil
+}
+
+// scheduledFlowsJob starts the scheduled flows every minute. It runs
+// through the job scheduler, whose lock makes one server start each minute's
+// flows when several share the database.
+func (r *flowRunner) scheduledFlowsJob() jobDefinition {
+	return jobDefinition{
+		Name:        "run-scheduled-flows",
+		Description: "Start the scheduled flows due this minute",
+		Schedule:    "* * * * *",
+		Run: func(ctx context.Context, options map[string]interface{}) (interface{}, error) {
+			// A run claimed late still starts the flows of the minute it claimed
+			started, err := r.runScheduled(ctx, jobRunAt(ctx).Truncate(time.Minute))
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"started": started}, nil
+		},
+	}
+}
+
+// runScheduled starts the active scheduled flows whose cron expression
+// matches minute in the background and returns how many were started
+func (r *flowRunner) runScheduled(ctx context.Context, minute time.Time) (int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, operation, COALESCE(options->>'cron', '') FROM flows
+		WHERE status = 'active' AND trigger = 'schedule'`)
+	if err != nil {
+		return 0, err
+	}
+	type scheduledFlow struct {
+		id    string
+		start sql.NullString
+	}
+	var due []scheduledFlow
+	for rows.Next() {
+		var flow scheduledFlow
+		var expr string
+		if err := rows.Scan(&flow.id, &flow.start, &expr); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		schedule, err := cron.Parse(expr)
+		if err != nil {
+			logrus.WithError(err).WithField("flow_id", flow.id).Warn("Skipping flow with invalid cron expression")
+			continue
+		}
+		if schedule.Matches(minute) {
+			due = append(due, flow)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	trigger := flowTrigger{
+		Type: flowTriggerSchedule,
+		Data: map[string]interface{}{"timestamp": minute.UTC()},
+	}
+	for _, flow := range due {
+		r.running.Add(1)
+		go func(id, start string) {
+			defer r.running.Done()
+			if _, err := r.run(context.Background(), id, start, trigger); err != nil {
+				logrus.WithError(err).WithField("flow_id", id).Error("Failed to run flow")
+			}
+		}(flow.id, flow.start.String)
+	}
+	return len(due), nil
+}
+
+// run runs a flow from its start operation and logs the run. The error is
+// only set when the run couldn't be logged; failed operations are reported
+// in the result.
+func (r *flowRunner) run(ctx context.Context, flowID, start string, trigger flowTrigger) (*flowResult, error) {
+	var operations map[string]*flowOperation
+	if start != "" {
+		var err error
+		if operations, err = r.loadOperations(flowID); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &flowResult{Status: flowRunSuccess}
+	if err := r.db.QueryRow(`INSERT INTO flow_runs (flow, trigger) VALUES ($1, $2) RETURNING id`,
+		flowID, trigger.Type).Scan(&result.ID); err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, flowRunKey{}, flowRunOrigin{
+		Origin:         flowOrigin(flowID),
+		Depth:          trigger.Depth + 1,
+		Accountability: trigger.Accountability,
+	})
+	triggerData := normalizeFlowValue(trigger.Data)
+	data := map[string]interface{}{
+		"$trigger": triggerData,
+		"$last":    triggerData,
+		"$accountability": map[string]interface{}{
+			"user":  trigger.Accountability.User,
+			"role":  trigger.Accountability.Role,
+			"admin": trigger.Accountability.Admin,
+		},
+	}
+	steps, runErr := r.execute(ctx, start, operations, data)
+	if runErr != nil {
+		result.Status = flowRunFailed
+		result.Error = runErr.Error()
+	}
+	result.Last = data["$last"]
+
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	stepsJSON, err := json.Marshal(steps)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.db.Exec(`
+		UPDATE flow_runs SET status = $2, data = $3, steps = $4, error = $5, finished_at = NOW()
+		WHERE id = $1`,
+		result.ID, result.Status, string(dataJSON), string(stepsJSON), nullableString(result.Error)); err != nil {
+		return nil, err
+	}
+
+	entry := logrus.WithFields(logrus.Fields{
+		"flow_id": flowID,
+		"run_id":  result.ID,
+		"trigger": trigger.Type,
+	})
+	if runErr != nil {
+		entry.WithError(runErr).Warn("Flow run failed")
+	} else {
+		entry.Debug("Flow run finished")
+	}
+	return result, nil
+}
+
+// loadOperations loads the operations of a flow by ID
+func (r *flowRunner) loadOperations(flowID string) (map[string]*flowOperation, error) {
+	rows, err := r.db.Query(`SELECT id, key, type, options, resolve, reject FROM operations WHERE flow = $1`, flowID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	operations := map[string]*flowOperation{}
+	for rows.Next() {
+		var operation flowOperation
+		var options []byte
+		if err := rows.Scan(&operation.ID, &operation.Key, &operation.Type, &options,
+			&operation.Resolve, &operation.Reject); err != nil {
+			return nil, err
+		}
+		if len(options) > 0 {
+			if err := json.Unmarshal(options, &operation.Options); err != nil {
+				return nil, fmt.Errorf("invalid options of operation %s: %w", operation.Key, err)
+			}
+		}
+		operations[operation.ID] = &operation
+	}
+	return operations, rows.Err()
+}
+
+// execute runs operations from start, following resolve links after
+// successes and reject links after failures. The result of every operation
+// is stored in data under its key and as $last.
+func (r *flowRunner) execute(ctx context.Context, start string, operations map[string]*flowOperation, data map[string]interface{}) ([]flowStep, error) {
+	steps := []flowStep{}
+	current := start
+	for current != "" {
+		if len(steps) == flowMaxSteps {
+			return steps, fmt.Errorf("flow exceeded %d operations", flowMaxSteps)
+		}
+		operation, ok := operations[current]
+		if !ok {
+			return steps, fmt.Errorf("operation %s not found", current)
+		}
+
+		result, err := r.runOperation(ctx, operation, data)
+		step := flowStep{Operation: operation.Key, Type: operation.Type, Status: "resolve"}
+		next := operation.Resolve
+		if err != nil {
+			result = map[string]interface{}{"error": err.Error()}
+			step.Status = "reject"
+			step.Error = err.Error()
+			next = operation.Reject
+		}
+		result = normalizeFlowValue(result)
+		step.Data = result
+		steps = append(steps, step)
+		data[operation.Key] = result
+		data["$last"] = result
+
+		if err != nil && !next.Valid {
+			if errors.Is(err, errConditionNotMet) {
+				return steps, nil
+			}
+			return steps, fmt.Errorf("operation %s: %w", operation.Key, err)
+		}
+		current = next.String
+	}
+	return steps, nil
+}
+
+// runOperation runs one operation with its options rendered against data
+func (r *flowRunner) runOperation(ctx context.Context, operation *flowOperation, data map[string]interface{}) (interface{}, error) {
+	options, _ := renderFlowTemplate(operation.Options, data).(map[string]interface{})
+	if options == nil {
+		options = map[string]interface{}{}
+	}
+
+	switch operation.Type {
+	case operationCondition:
+		return runConditionOperation(options, data)
+	case operationItemCreate:
+		return r.createFlowItem(ctx, options, data)
+	case operationItemUpdate:
+		return r.updateFlowItem(ctx, options, data)
+	case operationMail:
+		return r.sendFlowMail(options)
+	case operationRequest:
+		return r.sendFlowRequest(ctx, options)
+	case operationTransform:
+		return options["json"], nil
+	}
+	return nil, fmt.Errorf("unknown operation type %q", operation.Type)
+}
+
+// runConditionOperation matches data against the filter option, whose keys
+// are paths into the flow data such as "$trigger.payload.total"
+func runConditionOperation(options map[string]interface{}, data map[string]interface{}) (interface{}, error) {
+	filter, ok := options["filter"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("condition requires a filter object")
+	}
+	values := Item{}
+	collectFilterPaths(filter, func(path string) {
+		values[path] = resolveFlowPath(data, path)
+	})
+	matched, err := matchesFilter(filter, values)
+	if err != nil {
+		return nil, err
+	}
+	if !matched {
+		return false, errConditionNotMet
+	}
+	return true, nil
+}
+
+// collectFilterPaths calls fn with every field key of a filter
+func collectFilterPaths(filter map[string]interface{}, fn func(string)) {
+	for key, value := range filter {
+		if key != "_and" && key != "_or" {
+			fn(key)
+			continue
+		}
+		group, _ := value.([]interface{})
+		for _, entry := range group {
+			if subFilter, ok := entry.(map[string]interface{}); ok {
+				collectFilterPaths(subFilter, fn)
+			}
+		}
+	}
+}
+
+// flowItemData checks the collection and payload options of an item
+// operation, returning the collection, its fields and the data to write. key
+// is the item being updated, empty on create. The data passes the same
+// checks as writes through the items API.
+func (r *flowRunner) flowItemData(options map[string]interface{}, key string, userID string) (*ItemCollection, []FieldInfo, Item, error) {
+	name, _ := options["collection"].(string)
+	if name == "" {
+		return nil, nil, nil, errors.New("collection is required")
+	}
+	if isSystemCollection(name) {
+		return nil, nil, nil, fmt.Errorf("collection %s can't be written by flows", name)
+	}
+	collection, err := r.items.getItemCollection(name)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil, fmt.Errorf("collection %s not found", name)
+	} else if err != nil {
+		return nil, nil, nil, err
+	}
+	payload, ok := options["payload"].(map[string]interface{})
+	if !ok {
+		return nil, nil, nil, errors.New("payload must be an object")
+	}
+
+	action := actionCreate
+	var existing Item
+	if key != "" {
+		action = actionUpdate
+		existing, err = r.items.getItemByID(name, key)
+		if err == sql.ErrNoRows {
+			return nil, nil, nil, fmt.Errorf("item %s not found in %s", key, name)
+		} else if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	fields, err := r.items.getFieldsByCollection(name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	columns := map[string]bool{}
+	for _, field := range fields {
+		// Fields without a column, such as translations, can't be written
+		columns[field.Field] = field.Schema != nil
+	}
+	data := Item{}
+	for key, value := range payload {
+		if !columns[key] {
+			return nil, nil, nil, fmt.Errorf("unknown field %s", key)
+		}
+		data[key] = value
+	}
+	fields, writeErr := checkItemWrite(fields, data, existing)
+	if writeErr != nil {
+		return nil, nil, nil, writeErr
+	}
+	if err := applySpecials(fields, data, action, userID); err != nil {
+		return nil, nil, nil, err
+	}
+	return collection, fields, data, nil
+}
+
+// createFlowItem creates an item from the payload option
+func (r *flowRunner) createFlowItem(ctx context.Context, options map[string]interface{}, data map[string]interface{}) (interface{}, error) {
+	collection, fields, values, err := r.flowItemData(options, "", flowUser(data))
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	id, err := insertItemRow(tx, collection.Collection, values)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	item, err := r.items.getItemByID(collection.Collection, id)
+	if err != nil {
+		return nil, err
+	}
+	r.emitItemEvent(ctx, collection.Collection, hooks.ActionCreate, fields, item, id)
+	return item, nil
+}
+
+// updateFlowItem updates the item of the key option with the payload option
+func (r *flowRunner) updateFlowItem(ctx context.Context, options map[string]interface{}, data map[string]interface{}) (interface{}, error) {
+	key := flowString(options["key"])
+	if key == "" {
+		return nil, errors.New("key is required")
+	}
+	collection, fields, values, err := r.flowItemData(options, key, flowUser(data))
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	updates := make([]string, 0, len(columns)+1)
+	args := make([]interface{}, 0, len(columns)+1)
+	for i, column := range columns {
+		updates = append(updates, fmt.Sprintf(`"%s" = $%d`, column, i+1))
+		args = append(args, columnValue(values[column]))
+	}
+	if collection.HasUpdatedAt {
+		updates = append(updates, "updated_at = CURRENT_TIMESTAMP")
+	}
+	if len(updates) == 0 {
+		return nil, errors.New("payload is empty")
+	}
+	args = append(args, key)
+
+	result, err := r.db.Exec(fmt.Sprintf(`UPDATE "%s" SET %s WHERE id = $%d`,
+		collection.Collection, strings.Join(updates, ", "), len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return nil, fmt.Errorf("item %s not found in %s", key, collection.Collection)
+	}
+	item, err := r.items.getItemByID(collection.Collection, key)
+	if err != nil {
+		return nil, err
+	}
+	r.emitItemEvent(ctx, collection.Collection, hooks.ActionUpdate, fields, item, key)
+	return item, nil
+}
+
+// emitItemEvent emits the event of an item written by the flow running in
+// ctx, marked with the flow as its origin so flows don't trigger themselves
+func (r *flowRunner) emitItemEvent(ctx context.Context, collection, action string, fields []FieldInfo, item Item, key string) {
+	if r.events == nil {
+		return
+	}
+	event := hooks.NewEvent(scopeItems, collection, action, eventPayload(eventItem(fields, item)), key)
+	if origin, ok := ctx.Value(flowRunKey{}).(flowRunOrigin); ok {
+		event.Origin = origin.Origin
+		event.Depth = origin.Depth
+		event.Accountability = origin.Accountability
+	}
+	r.events.Emit(event)
+}
+
+// sendFlowMail sends the email of a mail operation
+func (r *flowRunner) sendFlowMail(options map[string]interface{}) (interface{}, error) {
+	var to []string
+	switch value := options["to"].(type) {
+	case string:
+		for _, address := range strings.Split(value, ",") {
+			if address = strings.TrimSpace(address); address != "" {
+				to = append(to, address)
+			}
+		}
+	case []interface{}:
+		for _, address := range value {
+			if text := strings.TrimSpace(flowString(address)); text != "" {
+				to = append(to, text)
+			}
+		}
+	}
+	if len(to) == 0 {
+		return nil, errors.New("at least one recipient is required")
+	}
+	subject := flowString(options["subject"])
+	if err := r.mailer.Send(to, subject, flowString(options["body"])); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"to": to, "subject": subject}, nil
+}
+
+// sendFlowRequest sends the HTTP request of a request operation. The
+// response body is parsed when it is JSON.
+func (r *flowRunner) sendFlowRequest(ctx context.Context, options map[string]interface{}) (interface{}, error) {
+	url := flowString(options["url"])
+	if !validateWebhookURL(url) {
+		return nil, errors.New("url must be an absolute http or https URL")
+	}
+	method := strings.ToUpper(flowString(options["method"]))
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	contentType := ""
+	switch value := options["body"].(type) {
+	case nil:
+	case string:
+		body = strings.NewReader(value)
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(encoded)
+		contentType = "application/json"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("User-Agent", "GoRectus-Flow/1.0")
+	if headers, ok := options["headers"].(map[string]interface{}); ok {
+		for name, value := range headers {
+			req.Header.Set(name, flowString(value))
+		}
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(io.LimitReader(resp.Body, flowResponseLimit))
+	if err != nil {
+		return nil, err
+	}
+	var responseData interface{} = string(responseBody)
+	var parsed interface{}
+	if json.Unmarshal(responseBody, &parsed) == nil {
+		responseData = parsed
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return map[string]interface{}{"status": resp.StatusCode, "data": responseData}, nil
+}
+
+// smtpMailer sends email through the SMTP server configured in settings.
+// The password is read from SMTP_PASSWORD.
+type smtpMailer struct {
+	db *sql.DB
+}
+
+// Send sends a plain text email
+func (m *smtpMailer) Send(to []string, subject, body string) error {
+	var enabled bool
+	var host, port, user, from string
+	err := m.db.QueryRow(`
+		SELECT COALESCE(email_enabled, false), COALESCE(smtp_host, ''), COALESCE(smtp_port, '587'),
+		       COALESCE(smtp_user, ''), COALESCE(smtp_from_email, '')
+		FROM settings LIMIT 1`).Scan(&enabled, &host, &port, &user, &from)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if !enabled {
+		return errors.New("email is disabled in settings")
+	}
+	if host == "" || from == "" {
+		return errors.New("SMTP configuration incomplete")
+	}
+	for _, address := range append([]string{from}, to...) {
+		if strings.ContainsAny(address, "\r\n") {
+			return fmt.Errorf("invalid address %q", address)
+		}
+	}
+
+	var auth smtp.Auth
+	if user != "" {
+		auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(subject)
+	message := "From: " + from + "\r\n" +
+		"To: " + strings.Join(to, ", ") + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body
+	return smtp.SendMail(host+":"+port, auth, from, to, []byte(message))
+}
+
+// flowPlaceholder matches the {{ path }} placeholders of operation options
+var flowPlaceholder = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// renderFlowTemplate replaces the placeholders in the strings of value with
+// values from the flow data. A string that is a single placeholder takes
+// the raw value, so objects and numbers keep their type.
+func renderFlowTemplate(value interface{}, data map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if match := flowPlaceholder.FindStringSubmatch(v); match != nil && match[0] == strings.TrimSpace(v) {
+			return resolveFlowPath(data, match[1])
+		}
+		return flowPlaceholder.ReplaceAllStringFunc(v, func(placeholder string) string {
+			path := flowPlaceholder.FindStringSubmatch(placeholder)[1]
+			return flowString(resolveFlowPath(data, path))
+		})
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(v))
+		for key, entry := range v {
+			rendered[key] = renderFlowTemplate(entry, data)
+		}
+		return rendered
+	case []interface{}:
+		rendered := make([]interface{}, len(v))
+		for i, entry := range v {
+			rendered[i] = renderFlowTemplate(entry, data)
+		}
+		return rendered
+	}
+	return value
+}
+
+// resolveFlowPath looks up a dotted path such as "$trigger.payload.title"
+// or "request.data.items.0.id" in the flow data, returning nil when it
+// doesn't exist
+func resolveFlowPath(data map[string]interface{}, path string) interface{} {
+	var current interface{} = data
+	for _, part := range strings.Split(path, ".") {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			current = v[part]
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil
+			}
+			current = v[index]
+		default:
+			return nil
+		}
+	}
+	return current
+}
+
+// normalizeFlowValue converts a value to plain JSON types, so results of
+// all operations can be looked up alike
+func normalizeFlowValue(value interface{}) interface{} {
+	switch value.(type) {
+	case nil, string, bool, float64:
+		return value
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(encoded, &normalized); err != nil {
+		return nil
+	}
+	return normalized
+}
+
+// flowString converts a flow value to text. Strings are used as they are;
+// other values are written as JSON.
+func flowString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+// flowUser returns the user the flow runs for, if any
+func flowUser(data map[string]interface{}) string {
+	user, _ := resolveFlowPath(data, "$accountability.user").(string)
+	return user
+}
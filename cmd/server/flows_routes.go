@@ -0,0 +1,803 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/cron"
+)
+
+// flowSecretHeader carries the secret of webhook flows
+const flowSecretHeader = "X-GoRectus-Flow-Secret"
+
+// FlowsHandler handles flow routes
+type FlowsHandler struct {
+	db             *sql.DB
+	flows          *flowRunner
+	authMiddleware gin.HandlerFunc
+	optionsHandler gin.HandlerFunc
+}
+
+// NewFlowsHandler creates a new flows handler running flows with runner
+func NewFlowsHandler(server ServerInterface, runner *flowRunner) *FlowsHandler {
+	return &FlowsHandler{
+		db:             server.GetDB(),
+		flows:          runner,
+		authMiddleware: server.AuthMiddleware(),
+		optionsHandler: server.OptionsHandler(),
+	}
+}
+
+// SetupRoutes sets up flow routes
+func (h *FlowsHandler) SetupRoutes(v1 *gin.RouterGroup) {
+	// CORS preflight OPTIONS for flows endpoints
+	v1.OPTIONS("/flows", h.optionsHandler)
+	v1.OPTIONS("/flows/:id", h.optionsHandler)
+	v1.OPTIONS("/flows/:id/runs", h.optionsHandler)
+	v1.OPTIONS("/flows/:id/trigger", h.optionsHandler)
+	v1.OPTIONS("/flows/webhook/:id", h.optionsHandler)
+
+	// Webhook flows are triggered by other services, authenticated by the
+	// flow's secret
+	v1.GET("/flows/webhook/:id", h.triggerWebhookFlow)
+	v1.POST("/flows/webhook/:id", h.triggerWebhookFlow)
+
+	// Flows routes (admin only)
+	flows := v1.Group("/flows")
+	flows.Use(h.authMiddleware, requireAdmin())
+	{
+		flows.GET("", h.getFlows)
+		flows.POST("", h.createFlow)
+		flows.GET("/:id", h.getFlow)
+		flows.PATCH("/:id", h.updateFlow)
+		flows.DELETE("/:id", h.deleteFlow)
+		flows.GET("/:id/runs", h.getFlowRuns)
+		flows.POST("/:id/trigger", h.triggerManualFlow)
+	}
+}
+
+// Flow represents an automation started by a trigger
+type Flow struct {
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Description *string                `json:"description"`
+	Status      string                 `json:"status"`
+	Trigger     string                 `json:"trigger"`
+	Options     map[string]interface{} `json:"options"`
+	Operation   *string                `json:"operation"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+// FlowRun represents one run of a flow
+type FlowRun struct {
+	ID         string          `json:"id"`
+	Flow       string          `json:"flow"`
+	Trigger    string          `json:"trigger"`
+	Status     string          `json:"status"`
+	Data       json.RawMessage `json:"data"`
+	Steps      json.RawMessage `json:"steps"`
+	Error      *string         `json:"error"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at"`
+}
+
+// CreateFlowRequest represents the request body for creating a flow
+type CreateFlowRequest struct {
+	Name        string                 `json:"name" binding:"required"`
+	Description *string                `json:"description"`
+	Status      string                 `json:"status"`
+	Trigger     string                 `json:"trigger" binding:"required"`
+	Options     map[string]interface{} `json:"options"`
+}
+
+// UpdateFlowRequest represents the request body for updating a flow
+type UpdateFlowRequest struct {
+	Name        *string                 `json:"name"`
+	Description *string                 `json:"description"`
+	Status      *string                 `json:"status"`
+	Trigger     *string                 `json:"trigger"`
+	Options     *map[string]interface{} `json:"options"`
+	// Operation is the first operation run; it must belong to the flow. An
+	// empty string clears it.
+	Operation *string `json:"operation"`
+}
+
+const flowColumns = `id, name, description, status, trigger, options, operation, created_at, updated_at`
+
+const flowRunColumns = `id, flow, trigger, status, data, steps, error, started_at, finished_at`
+
+// scanFlow scans a row of flowColumns
+func scanFlow(scanner interface{ Scan(...interface{}) error }) (*Flow, error) {
+	var flow Flow
+	var description, operation sql.NullString
+	var options []byte
+	if err := scanner.Scan(&flow.ID, &flow.Name, &description, &flow.Status, &flow.Trigger, &options,
+		&operation, &flow.CreatedAt, &flow.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if description.Valid {
+		flow.Description = &description.String
+	}
+	if operation.Valid {
+		flow.Operation = &operation.String
+	}
+	flow.Options = map[string]interface{}{}
+	if len(options) > 0 {
+		if err := json.Unmarshal(options, &flow.Options); err != nil {
+			return nil, err
+		}
+	}
+	return &flow, nil
+}
+
+// scanFlowRun scans a row of flowRunColumns
+func scanFlowRun(scanner interface{ Scan(...interface{}) error }) (*FlowRun, error) {
+	var run FlowRun
+	var data, steps []byte
+	var errMessage sql.NullString
+	var finishedAt sql.NullTime
+	if err := scanner.Scan(&run.ID, &run.Flow, &run.Trigger, &run.Status, &data, &steps, &errMessage,
+		&run.StartedAt, &finishedAt); err != nil {
+		return nil, err
+	}
+	run.Data = json.RawMessage("null")
+	if len(data) > 0 {
+		run.Data = json.RawMessage(data)
+	}
+	run.Steps = json.RawMessage("[]")
+	if len(steps) > 0 {
+		run.Steps = json.RawMessage(steps)
+	}
+	if errMessage.Valid {
+		run.Error = &errMessage.String
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	return &run, nil
+}
+
+// validateFlowOptions checks the options of a trigger, returning a message
+// for the client when they are invalid
+func validateFlowOptions(trigger string, options map[string]interface{}) (string, bool) {
+	switch trigger {
+	case flowTriggerEvent:
+		collections, ok := options["collections"].([]interface{})
+		if !ok || len(collections) == 0 {
+			return "Event flows require at least one collection", false
+		}
+		for _, collection := range collections {
+			if name, ok := collection.(string); !ok || strings.TrimSpace(name) == "" {
+				return "Invalid collection name", false
+			}
+		}
+		actions, ok := options["actions"].([]interface{})
+		if !ok || len(actions) == 0 {
+			return "Event flows require at least one action", false
+		}
+		for _, action := range actions {
+			if name, ok := action.(string); !ok || !slices.Contains(webhookActions, name) {
+				return fmt.Sprintf("Invalid action: %v", action), false
+			}
+		}
+	case flowTriggerSchedule:
+		expr, _ := options["cron"].(string)
+		if _, err := cron.Parse(expr); err != nil {
+			return "Invalid cron expression: " + expr, false
+		}
+	case flowTriggerWebhook:
+		if method, ok := options["method"]; ok && method != http.MethodGet && method != http.MethodPost {
+			return fmt.Sprintf("Invalid method: %v", method), false
+		}
+		// Anyone can reach the webhook route, so the secret is what stops
+		// strangers from writing items through the flow
+		if secret, ok := options["secret"].(string); !ok || strings.TrimSpace(secret) == "" {
+			return "Webhook flows require a secret", false
+		}
+		if async, ok := options["async"]; ok {
+			if _, ok := async.(bool); !ok {
+				return "Async must be a boolean", false
+			}
+		}
+	case flowTriggerManual:
+	default:
+		return "Invalid trigger: " + trigger, false
+	}
+	return "", true
+}
+
+// GetFlows lists flows
+//
+//	@Summary		List flows
+//	@Description	Get all flows ordered by name
+//	@Tags			flows
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{array}		FlowModel		"Flows"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Admin access required"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/flows [get]
+func (h *FlowsHandler) getFlows(c *gin.Context) {
+	rows, err := h.db.Query(`SELECT ` + flowColumns + ` FROM flows ORDER BY name, id`)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while fetching flows")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	flows := []Flow{}
+	for rows.Next() {
+		flow, err := scanFlow(rows)
+		if err != nil {
+			logrus.WithError(err).Error("Error scanning flow row")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		flows = append(flows, *flow)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": flows})
+}
+
+// CreateFlow creates a flow
+//
+//	@Summary		Create a flow
+//	@Description	Create a flow started by an event, a schedule, a manual trigger or an incoming webhook. Add its operations through /operations and set the first one as the flow's operation
+//	@Tags			flows
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			flow	body		CreateFlowRequest	true	"Flow"
+//	@Success		201		{object}	FlowModel		"Created flow"
+//	@Failure		400		{object}	ErrorResponse	"Invalid request payload"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	ErrorResponse	"Admin access required"
+//	@Failure		500		{object}	ErrorResponse	"Internal server error"
+//	@Router			/flows [post]
+func (h *FlowsHandler) createFlow(c *gin.Context) {
+	var req CreateFlowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid create flow request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Flow name is required"})
+		return
+	}
+	status := req.Status
+	if status == "" {
+		status = "active"
+	} else if status != "active" && status != "inactive" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status: " + status})
+		return
+	}
+	if req.Options == nil {
+		req.Options = map[string]interface{}{}
+	}
+	if message, ok := validateFlowOptions(req.Trigger, req.Options); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+	options, err := json.Marshal(req.Options)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	var flowID string
+	err = h.db.QueryRow(`
+		INSERT INTO flows (name, description, status, trigger, options)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		name, req.Description, status, req.Trigger, string(options)).Scan(&flowID)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while creating flow")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	flow, err := h.getFlowByID(flowID)
+	if err != nil {
+		logrus.WithError(err).Error("Error fetching created flow")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"flow_id":    flowID,
+		"created_by": c.GetString("user_id"),
+	}).Info("Flow created successfully")
+	c.JSON(http.StatusCreated, gin.H{"data": flow})
+}
+
+// GetFlow returns a flow
+//
+//	@Summary		Get a flow
+//	@Description	Get a flow by ID
+//	@Tags			flows
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Flow ID"
+//	@Success		200	{object}	FlowModel		"Flow"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Admin access required"
+//	@Failure		404	{object}	ErrorResponse	"Flow not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/flows/{id} [get]
+func (h *FlowsHandler) getFlow(c *gin.Context) {
+	flow, ok := h.resolveFlow(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": flow})
+}
+
+// UpdateFlow updates a flow
+//
+//	@Summary		Update a flow
+//	@Description	Update a flow. Changing the trigger requires options valid for the new trigger
+//	@Tags			flows
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string				true	"Flow ID"
+//	@Param			flow	body		UpdateFlowRequest	true	"Flow changes"
+//	@Success		200		{object}	FlowModel		"Updated flow"
+//	@Failure		400		{object}	ErrorResponse	"Invalid request payload"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	ErrorResponse	"Admin access required"
+//	@Failure		404		{object}	ErrorResponse	"Flow not found"
+//	@Failure		500		{object}	ErrorResponse	"Internal server error"
+//	@Router			/flows/{id} [patch]
+func (h *FlowsHandler) updateFlow(c *gin.Context) {
+	flow, ok := h.resolveFlow(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateFlowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid update flow request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	var updates []string
+	var args []interface{}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		updates = append(updates, column+" = $"+strconv.Itoa(len(args)))
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Flow name is required"})
+			return
+		}
+		set("name", name)
+	}
+	if req.Description != nil {
+		set("description", nullableString(*req.Description))
+	}
+	if req.Status != nil {
+		if *req.Status != "active" && *req.Status != "inactive" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status: " + *req.Status})
+			return
+		}
+		set("status", *req.Status)
+	}
+	if req.Trigger != nil || req.Options != nil {
+		trigger, options := flow.Trigger, flow.Options
+		if req.Trigger != nil {
+			trigger = *req.Trigger
+		}
+		if req.Options != nil {
+			options = *req.Options
+		}
+		if options == nil {
+			options = map[string]interface{}{}
+		}
+		if message, ok := validateFlowOptions(trigger, options); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		encoded, err := json.Marshal(options)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+		set("trigger", trigger)
+		set("options", string(encoded))
+	}
+	if req.Operation != nil {
+		if *req.Operation != "" {
+			belongs, err := h.operationBelongsToFlow(*req.Operation, flow.ID)
+			if err != nil {
+				logrus.WithError(err).Error("Database error while checking flow operation")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+				return
+			}
+			if !belongs {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Operation does not belong to this flow"})
+				return
+			}
+		}
+		set("operation", nullableString(*req.Operation))
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	args = append(args, flow.ID)
+	query := `UPDATE flows SET ` + strings.Join(updates, ", ") + ` WHERE id = $` + strconv.Itoa(len(args))
+	if _, err := h.db.Exec(query, args...); err != nil {
+		logrus.WithError(err).Error("Database error while updating flow")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	updated, err := h.getFlowByID(flow.ID)
+	if err != nil {
+		logrus.WithError(err).Error("Error fetching updated flow")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithField("flow_id", flow.ID).Info("Flow updated successfully")
+	c.JSON(http.StatusOK, gin.H{"data": updated})
+}
+
+// DeleteFlow deletes a flow
+//
+//	@Summary		Delete a flow
+//	@Description	Delete a flow along with its operations and run log
+//	@Tags			flows
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Flow ID"
+//	@Success		200	{object}	SuccessMessage	"Success message"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Admin access required"
+//	@Failure		404	{object}	ErrorResponse	"Flow not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/flows/{id} [delete]
+func (h *FlowsHandler) deleteFlow(c *gin.Context) {
+	flow, ok := h.resolveFlow(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.db.Exec(`DELETE FROM flows WHERE id = $1`, flow.ID); err != nil {
+		logrus.WithError(err).Error("Database error while deleting flow")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithField("flow_id", flow.ID).Info("Flow deleted successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Flow deleted successfully"})
+}
+
+// GetFlowRuns lists the runs of a flow
+//
+//	@Summary		List flow runs
+//	@Description	Get a paginated log of the runs of a flow, newest first, with the result of every operation run
+//	@Tags			flows
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"Flow ID"
+//	@Param			status	query		string	false	"Only runs with this status (running, success or failed)"
+//	@Param			page	query		int		false	"Page number"
+//	@Param			limit	query		int		false	"Limit the number of results"
+//	@Success		200		{object}	map[string]interface{}	"Runs with pagination metadata"
+//	@Failure		400		{object}	ErrorResponse	"Invalid status"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	ErrorResponse	"Admin access required"
+//	@Failure		404		{object}	ErrorResponse	"Flow not found"
+//	@Failure		500		{object}	ErrorResponse	"Internal server error"
+//	@Router			/flows/{id}/runs [get]
+func (h *FlowsHandler) getFlowRuns(c *gin.Context) {
+	flow, ok := h.resolveFlow(c)
+	if !ok {
+		return
+	}
+
+	page := 1
+	limit := 50
+	if pageStr := c.Query("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+	offset := (page - 1) * limit
+
+	where := `flow = $1`
+	args := []interface{}{flow.ID}
+	if status := c.Query("status"); status != "" {
+		if status != flowRunRunning && status != flowRunSuccess && status != flowRunFailed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status: " + status})
+			return
+		}
+		where += ` AND status = $2`
+		args = append(args, status)
+	}
+
+	var total int
+	if err := h.db.QueryRow(`SELECT COUNT(*) FROM flow_runs WHERE `+where, args...).Scan(&total); err != nil {
+		logrus.WithError(err).Error("Database error while counting flow runs")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	query := `SELECT ` + flowRunColumns + ` FROM flow_runs WHERE ` + where +
+		` ORDER BY started_at DESC, id LIMIT $` + strconv.Itoa(len(args)+1) + ` OFFSET $` + strconv.Itoa(len(args)+2)
+	rows, err := h.db.Query(query, append(args, limit, offset)...)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while fetching flow runs")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	runs := []FlowRun{}
+	for rows.Next() {
+		run, err := scanFlowRun(rows)
+		if err != nil {
+			logrus.WithError(err).Error("Error scanning flow run row")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		runs = append(runs, *run)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": runs,
+		"meta": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// TriggerManualFlow runs a manual flow
+//
+//	@Summary		Trigger a manual flow
+//	@Description	Run a manual flow now. The JSON request body, if any, is available to operations as $trigger.body. The run is returned with the result of its last operation
+//	@Tags			flows
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string					true	"Flow ID"
+//	@Param			body	body		map[string]interface{}	false	"Data passed to the flow"
+//	@Success		200		{object}	FlowResultModel	"Flow run"
+//	@Failure		400		{object}	ErrorResponse	"Flow is not an active manual flow"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	ErrorResponse	"Admin access required"
+//	@Failure		404		{object}	ErrorResponse	"Flow not found"
+//	@Failure		500		{object}	ErrorResponse	"Internal server error"
+//	@Router			/flows/{id}/trigger [post]
+func (h *FlowsHandler) triggerManualFlow(c *gin.Context) {
+	flow, ok := h.resolveFlow(c)
+	if !ok {
+		return
+	}
+	if flow.Trigger != flowTriggerManual {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Flow is not a manual flow"})
+		return
+	}
+	if flow.Status != "active" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Flow is inactive"})
+		return
+	}
+
+	body, ok := readFlowBody(c)
+	if !ok {
+		return
+	}
+
+	trigger := flowTrigger{
+		Type:           flowTriggerManual,
+		Data:           map[string]interface{}{"body": body},
+		Accountability: requestAccountability(c),
+	}
+	result, err := h.flows.run(c.Request.Context(), flow.ID, flowStart(flow), trigger)
+	if err != nil {
+		logrus.WithError(err).WithField("flow_id", flow.ID).Error("Failed to run flow")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"flow_id":      flow.ID,
+		"run_id":       result.ID,
+		"triggered_by": c.GetString("user_id"),
+	}).Info("Flow triggered manually")
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// TriggerWebhookFlow runs a webhook flow
+//
+//	@Summary		Trigger a webhook flow
+//	@Description	Run a webhook flow for an incoming request. The flow's secret is required in the X-GoRectus-Flow-Secret header. The method, query and JSON body are available to operations as $trigger. Synchronous flows respond with the result of their last operation; async flows respond right away
+//	@Tags			flows
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Flow ID"
+//	@Param			body	body		map[string]interface{}	false	"Data passed to the flow"
+//	@Success		200		{object}	map[string]interface{}	"Result of the last operation"
+//	@Success		202		{object}	SuccessMessage	"Flow started"
+//	@Failure		400		{object}	ErrorResponse	"Invalid request payload"
+//	@Failure		401		{object}	ErrorResponse	"Invalid flow secret"
+//	@Failure		404		{object}	ErrorResponse	"Flow not found"
+//	@Failure		405		{object}	ErrorResponse	"Method not allowed"
+//	@Failure		500		{object}	ErrorResponse	"Flow failed"
+//	@Router			/flows/webhook/{id} [post]
+func (h *FlowsHandler) triggerWebhookFlow(c *gin.Context) {
+	flowID := c.Param("id")
+	if !uuidRegexp.MatchString(flowID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Flow not found"})
+		return
+	}
+	flow, err := h.getFlowByID(flowID)
+	if err != nil && err != sql.ErrNoRows {
+		logrus.WithError(err).Error("Database error while fetching flow")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	// Only active webhook flows can be triggered from outside
+	if err == sql.ErrNoRows || flow.Trigger != flowTriggerWebhook || flow.Status != "active" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Flow not found"})
+		return
+	}
+
+	method, _ := flow.Options["method"].(string)
+	if method == "" {
+		method = http.MethodPost
+	}
+	if c.Request.Method != method {
+		c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+		return
+	}
+	// Flows saved without a secret can't be triggered
+	if secret, _ := flow.Options["secret"].(string); secret == "" ||
+		subtle.ConstantTimeCompare([]byte(c.GetHeader(flowSecretHeader)), []byte(secret)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid flow secret"})
+		return
+	}
+
+	body, ok := readFlowBody(c)
+	if !ok {
+		return
+	}
+	query := map[string]interface{}{}
+	for key, values := range c.Request.URL.Query() {
+		query[key] = values[0]
+	}
+	trigger := flowTrigger{
+		Type: flowTriggerWebhook,
+		Data: map[string]interface{}{
+			"method": c.Request.Method,
+			"query":  query,
+			"body":   body,
+		},
+	}
+
+	if async, _ := flow.Options["async"].(bool); async {
+		h.flows.running.Add(1)
+		go func() {
+			defer h.flows.running.Done()
+			if _, err := h.flows.run(context.Background(), flow.ID, flowStart(flow), trigger); err != nil {
+				logrus.WithError(err).WithField("flow_id", flow.ID).Error("Failed to run flow")
+			}
+		}()
+		c.JSON(http.StatusAccepted, gin.H{"message": "Flow started"})
+		return
+	}
+
+	result, err := h.flows.run(c.Request.Context(), flow.ID, flowStart(flow), trigger)
+	if err != nil {
+		logrus.WithError(err).WithField("flow_id", flow.ID).Error("Failed to run flow")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if result.Status != flowRunSuccess {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Flow failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result.Last})
+}
+
+// readFlowBody reads the optional JSON body passed to a flow, writing the
+// error response when it isn't JSON
+func readFlowBody(c *gin.Context) (interface{}, bool) {
+	if c.Request.Body == nil {
+		return nil, true
+	}
+	raw, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return nil, false
+	}
+	if len(strings.TrimSpace(string(raw))) == 0 {
+		return nil, true
+	}
+	var body interface{}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return nil, false
+	}
+	return body, true
+}
+
+// flowStart returns the first operation of a flow, or "" when it has none
+func flowStart(flow *Flow) string {
+	if flow.Operation == nil {
+		return ""
+	}
+	return *flow.Operation
+}
+
+// operationBelongsToFlow reports whether an operation is part of a flow
+func (h *FlowsHandler) operationBelongsToFlow(operationID, flowID string) (bool, error) {
+	if !uuidRegexp.MatchString(operationID) {
+		return false, nil
+	}
+	var exists bool
+	err := h.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM operations WHERE id = $1 AND flow = $2)`,
+		operationID, flowID).Scan(&exists)
+	return exists, err
+}
+
+// resolveFlow loads the flow of the :id parameter, writing a 404 when it
+// doesn't exist
+func (h *FlowsHandler) resolveFlow(c *gin.Context) (*Flow, bool) {
+	flowID := c.Param("id")
+	if !uuidRegexp.MatchString(flowID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Flow not found"})
+		return nil, false
+	}
+
+	flow, err := h.getFlowByID(flowID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Flow not found"})
+		return nil, false
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while fetching flow")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	return flow, true
+}
+
+// getFlowByID loads a flow, or sql.ErrNoRows if it doesn't exist
+func (h *FlowsHandler) getFlowByID(flowID string) (*Flow, error) {
+	return scanFlow(h.db.QueryRow(`SELECT `+flowColumns+` FROM flows WHERE id = $1`, flowID))
+}
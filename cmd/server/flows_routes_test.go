@@ -0,0 +1,381 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// Test suite for flow and operation handlers
+type FlowHandlersTestSuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+}
+
+func (suite *FlowHandlersTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+	logrus.SetLevel(logrus.PanicLevel)
+}
+
+func (suite *FlowHandlersTestSuite) SetupTest() {
+	db, mock, err := sqlmock.New()
+	require.NoError(suite.T(), err)
+	suite.db = db
+	suite.mock = mock
+}
+
+func (suite *FlowHandlersTestSuite) TearDownTest() {
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+	suite.db.Close()
+}
+
+// request serves a request to the flow and operation routes as the given user
+func (suite *FlowHandlersTestSuite) request(method, url, body string, admin bool, headers ...string) *httptest.ResponseRecorder {
+	router := gin.New()
+	mockServer := &mockItemServerInterface{
+		db: suite.db,
+		customAuthFunc: func(c *gin.Context) {
+			c.Set("user_id", "user-1")
+			c.Set("admin_access", admin)
+			c.Set("app_access", true)
+			c.Next()
+		},
+	}
+	NewFlowsHandler(mockServer, newFlowRunner(suite.db)).SetupRoutes(router.Group("/api/v1"))
+	NewOperationsHandler(mockServer).SetupRoutes(router.Group("/api/v1"))
+
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func flowRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "name", "description", "status", "trigger", "options", "operation",
+		"created_at", "updated_at"})
+}
+
+func operationRowsFull() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "flow", "key", "name", "type", "options", "resolve", "reject",
+		"created_at", "updated_at"})
+}
+
+func (suite *FlowHandlersTestSuite) expectFlow(trigger, options string, operation interface{}) {
+	now := time.Now()
+	suite.mock.ExpectQuery(`FROM flows WHERE id = \$1`).WithArgs(testFlowID).
+		WillReturnRows(flowRows().AddRow(testFlowID, "Large orders", nil, "active", trigger, []byte(options),
+			operation, now, now))
+}
+
+func (suite *FlowHandlersTestSuite) expectOperation() {
+	now := time.Now()
+	suite.mock.ExpectQuery(`FROM operations WHERE id = \$1`).WithArgs(testOperationID).
+		WillReturnRows(operationRowsFull().AddRow(testOperationID, testFlowID, "notify", nil, "mail",
+			[]byte(`{"to":"sales@example.com","subject":"Hi"}`), nil, nil, now, now))
+}
+
+func (suite *FlowHandlersTestSuite) TestGetFlows() {
+	now := time.Now()
+	suite.mock.ExpectQuery(`FROM flows ORDER BY name, id`).
+		WillReturnRows(flowRows().AddRow(testFlowID, "Large orders", "Notify sales", "active", "event",
+			[]byte(`{"collections":["orders"],"actions":["create"]}`), testOperationID, now, now))
+
+	w := suite.request("GET", "/api/v1/flows", "", true)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Data []Flow `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(suite.T(), response.Data, 1)
+	assert.Equal(suite.T(), testOperationID, *response.Data[0].Operation)
+	assert.Equal(suite.T(), []interface{}{"orders"}, response.Data[0].Options["collections"])
+}
+
+func (suite *FlowHandlersTestSuite) TestGetFlows_NonAdmin() {
+	w := suite.request("GET", "/api/v1/flows", "", false)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *FlowHandlersTestSuite) TestCreateFlow() {
+	suite.mock.ExpectQuery(`INSERT INTO flows`).
+		WithArgs("Nightly report", nil, "active", "schedule", `{"cron":"0 2 * * *"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testFlowID))
+	suite.expectFlow("schedule", `{"cron":"0 2 * * *"}`, nil)
+
+	w := suite.request("POST", "/api/v1/flows",
+		`{"name":" Nightly report ","trigger":"schedule","options":{"cron":"0 2 * * *"}}`, true)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+}
+
+func (suite *FlowHandlersTestSuite) TestCreateFlow_Invalid() {
+	tests := map[string]string{
+		"trigger":     `{"name":"a","trigger":"hourly"}`,
+		"cron":        `{"name":"a","trigger":"schedule","options":{"cron":"every day"}}`,
+		"collections": `{"name":"a","trigger":"event","options":{"actions":["create"]}}`,
+		"action":      `{"name":"a","trigger":"event","options":{"collections":["orders"],"actions":["read"]}}`,
+		"method":      `{"name":"a","trigger":"webhook","options":{"method":"DELETE","secret":"s3cret"}}`,
+		"secret":      `{"name":"a","trigger":"webhook","options":{"method":"POST"}}`,
+		"status":      `{"name":"a","trigger":"manual","status":"paused"}`,
+	}
+	for name, body := range tests {
+		suite.Run(name, func() {
+			w := suite.request("POST", "/api/v1/flows", body, true)
+
+			assert.Equal(suite.T(), http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+}
+
+func (suite *FlowHandlersTestSuite) TestUpdateFlow_Operation() {
+	suite.expectFlow("manual", `{}`, nil)
+	suite.mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM operations WHERE id = \$1 AND flow = \$2\)`).
+		WithArgs(testOperationID, testFlowID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	suite.mock.ExpectExec(`UPDATE flows SET status = \$1, operation = \$2 WHERE id = \$3`).
+		WithArgs("inactive", testOperationID, testFlowID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.expectFlow("manual", `{}`, testOperationID)
+
+	w := suite.request("PATCH", "/api/v1/flows/"+testFlowID,
+		`{"status":"inactive","operation":"`+testOperationID+`"}`, true)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+}
+
+func (suite *FlowHandlersTestSuite) TestUpdateFlow_ForeignOperation() {
+	suite.expectFlow("manual", `{}`, nil)
+	suite.mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM operations`).
+		WithArgs(testOperationID, testFlowID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	w := suite.request("PATCH", "/api/v1/flows/"+testFlowID, `{"operation":"`+testOperationID+`"}`, true)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *FlowHandlersTestSuite) TestUpdateFlow_TriggerRequiresOptions() {
+	suite.expectFlow("manual", `{}`, nil)
+
+	w := suite.request("PATCH", "/api/v1/flows/"+testFlowID, `{"trigger":"schedule"}`, true)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *FlowHandlersTestSuite) TestDeleteFlow() {
+	suite.expectFlow("manual", `{}`, nil)
+	suite.mock.ExpectExec(`DELETE FROM flows WHERE id = \$1`).WithArgs(testFlowID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := suite.request("DELETE", "/api/v1/flows/"+testFlowID, "", true)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *FlowHandlersTestSuite) TestGetFlowRuns() {
+	now := time.Now()
+	suite.expectFlow("manual", `{}`, nil)
+	suite.mock.ExpectQuery(`SELECT COUNT\(\*\) FROM flow_runs WHERE flow = \$1 AND status = \$2`).
+		WithArgs(testFlowID, "failed").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	suite.mock.ExpectQuery(`FROM flow_runs WHERE flow = \$1 AND status = \$2 ORDER BY started_at DESC, id LIMIT \$3 OFFSET \$4`).
+		WithArgs(testFlowID, "failed", 50, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "flow", "trigger", "status", "data", "steps", "error",
+			"started_at", "finished_at"}).
+			AddRow(testRunID, testFlowID, "manual", "failed", []byte(`{"$last":{"error":"boom"}}`),
+				[]byte(`[{"operation":"call","type":"request","status":"reject","data":{"error":"boom"},"error":"boom"}]`),
+				"operation call: boom", now, now))
+
+	w := suite.request("GET", "/api/v1/flows/"+testFlowID+"/runs?status=failed", "", true)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Data []FlowRun `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(suite.T(), response.Data, 1)
+	assert.Equal(suite.T(), "operation call: boom", *response.Data[0].Error)
+	assert.Contains(suite.T(), string(response.Data[0].Steps), `"reject"`)
+}
+
+func (suite *FlowHandlersTestSuite) TestTriggerManualFlow() {
+	suite.expectFlow("manual", `{}`, testOperationID)
+	suite.mock.ExpectQuery(`FROM operations WHERE flow = \$1`).WithArgs(testFlowID).
+		WillReturnRows(operationRows().
+			AddRow(testOperationID, "greeting", "transform", []byte(`{"json":{"text":"Hi {{ $trigger.body.name }}","by":"{{ $accountability.user }}"}}`), nil, nil))
+	suite.mock.ExpectQuery(`INSERT INTO flow_runs`).WithArgs(testFlowID, "manual").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testRunID))
+	suite.mock.ExpectExec(`UPDATE flow_runs`).
+		WithArgs(testRunID, "success", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := suite.request("POST", "/api/v1/flows/"+testFlowID+"/trigger", `{"name":"Ada"}`, true)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(suite.T(), `{"data":{"id":"`+testRunID+`","status":"success","data":{"text":"Hi Ada","by":"user-1"}}}`,
+		w.Body.String())
+}
+
+func (suite *FlowHandlersTestSuite) TestTriggerManualFlow_NotManual() {
+	suite.expectFlow("webhook", `{}`, nil)
+
+	w := suite.request("POST", "/api/v1/flows/"+testFlowID+"/trigger", "", true)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *FlowHandlersTestSuite) TestTriggerWebhookFlow() {
+	suite.expectFlow("webhook", `{"secret":"s3cret"}`, testOperationID)
+	suite.mock.ExpectQuery(`FROM operations WHERE flow = \$1`).WithArgs(testFlowID).
+		WillReturnRows(operationRows().
+			AddRow(testOperationID, "echo", "transform", []byte(`{"json":{"order":"{{ $trigger.body.order }}","source":"{{ $trigger.query.source }}"}}`), nil, nil))
+	suite.mock.ExpectQuery(`INSERT INTO flow_runs`).WithArgs(testFlowID, "webhook").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testRunID))
+	suite.mock.ExpectExec(`UPDATE flow_runs`).
+		WithArgs(testRunID, "success", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Webhook flows don't need a user, only the flow's secret
+	w := suite.request("POST", "/api/v1/flows/webhook/"+testFlowID+"?source=shop", `{"order":7}`, false,
+		flowSecretHeader, "s3cret")
+
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(suite.T(), `{"data":{"order":7,"source":"shop"}}`, w.Body.String())
+}
+
+func (suite *FlowHandlersTestSuite) TestTriggerWebhookFlow_InvalidSecret() {
+	suite.expectFlow("webhook", `{"secret":"s3cret"}`, testOperationID)
+
+	w := suite.request("POST", "/api/v1/flows/webhook/"+testFlowID, `{}`, false, flowSecretHeader, "guess")
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *FlowHandlersTestSuite) TestTriggerWebhookFlow_NoSecret() {
+	// Flows saved before secrets were required stay closed
+	suite.expectFlow("webhook", `{}`, testOperationID)
+
+	w := suite.request("POST", "/api/v1/flows/webhook/"+testFlowID, `{}`, false)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *FlowHandlersTestSuite) TestTriggerWebhookFlow_WrongMethod() {
+	suite.expectFlow("webhook", `{"secret":"s3cret"}`, nil)
+
+	w := suite.request("GET", "/api/v1/flows/webhook/"+testFlowID, "", false)
+
+	assert.Equal(suite.T(), http.StatusMethodNotAllowed, w.Code)
+}
+
+func (suite *FlowHandlersTestSuite) TestTriggerWebhookFlow_NotWebhook() {
+	suite.expectFlow("manual", `{}`, nil)
+
+	w := suite.request("POST", "/api/v1/flows/webhook/"+testFlowID, "", false)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *FlowHandlersTestSuite) TestCreateOperation() {
+	nextID := "9e5c7d1b-0f2a-4b4c-d38e-9f0a1b2c3d4e"
+	suite.mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM flows WHERE id = \$1\)`).WithArgs(testFlowID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	suite.mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM operations WHERE id = \$1 AND flow = \$2\)`).
+		WithArgs(nextID, testFlowID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	suite.mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM operations WHERE flow = \$1 AND key = \$2\)`).
+		WithArgs(testFlowID, "notify").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	suite.mock.ExpectQuery(`INSERT INTO operations`).
+		WithArgs(testFlowID, "notify", nil, "mail", `{"subject":"Hi","to":"sales@example.com"}`, nextID, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testOperationID))
+	suite.expectOperation()
+
+	w := suite.request("POST", "/api/v1/operations", `{"flow":"`+testFlowID+`","key":"notify","type":"mail",
+		"options":{"to":"sales@example.com","subject":"Hi"},"resolve":"`+nextID+`"}`, true)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+}
+
+func (suite *FlowHandlersTestSuite) TestCreateOperation_KeyExists() {
+	suite.mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM flows`).WithArgs(testFlowID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	suite.mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM operations WHERE flow = \$1 AND key = \$2\)`).
+		WithArgs(testFlowID, "notify").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	w := suite.request("POST", "/api/v1/operations", `{"flow":"`+testFlowID+`","key":"notify","type":"transform",
+		"options":{"json":1}}`, true)
+
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *FlowHandlersTestSuite) TestCreateOperation_Invalid() {
+	tests := map[string]string{
+		"type":      `{"flow":"` + testFlowID + `","key":"a","type":"sleep"}`,
+		"key":       `{"flow":"` + testFlowID + `","key":"$last","type":"transform","options":{"json":1}}`,
+		"condition": `{"flow":"` + testFlowID + `","key":"a","type":"condition","options":{"filter":"yes"}}`,
+		"request":   `{"flow":"` + testFlowID + `","key":"a","type":"request","options":{}}`,
+	}
+	for name, body := range tests {
+		suite.Run(name, func() {
+			w := suite.request("POST", "/api/v1/operations", body, true)
+
+			assert.Equal(suite.T(), http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+}
+
+func (suite *FlowHandlersTestSuite) TestUpdateOperation_SelfLink() {
+	suite.expectOperation()
+
+	w := suite.request("PATCH", "/api/v1/operations/"+testOperationID, `{"resolve":"`+testOperationID+`"}`, true)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *FlowHandlersTestSuite) TestUpdateOperation_ClearLink() {
+	suite.expectOperation()
+	suite.mock.ExpectExec(`UPDATE operations SET reject = \$1 WHERE id = \$2`).
+		WithArgs(nil, testOperationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.expectOperation()
+
+	w := suite.request("PATCH", "/api/v1/operations/"+testOperationID, `{"reject":""}`, true)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+}
+
+func (suite *FlowHandlersTestSuite) TestDeleteOperation() {
+	suite.expectOperation()
+	suite.mock.ExpectExec(`DELETE FROM operations WHERE id = \$1`).WithArgs(testOperationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := suite.request("DELETE", "/api/v1/operations/"+testOperationID, "", true)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func TestFlowHandlersTestSuite(t *testing.T) {
+	suite.Run(t, new(FlowHandlersTestSuite))
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gorectus/internal/hooks"
+)
+
+const (
+	testFlowID      = "6b2f4a8e-7c9d-4e1f-a05b-6c7d8e9f0a1b"
+	testOperationID = "7c3a5b9f-8d0e-4f2a-b16c-7d8e9f0a1b2c"
+	testRunID       = "8d4b6c0a-9e1f-4a3b-c27d-8e9f0a1b2c3d"
+)
+
+type sentMail struct {
+	to            []string
+	subject, body string
+}
+
+// fakeMailer records the emails of mail operations
+type fakeMailer struct {
+	sent []sentMail
+}
+
+func (m *fakeMailer) Send(to []string, subject, body string) error {
+	m.sent = append(m.sent, sentMail{to: to, subject: subject, body: body})
+	return nil
+}
+
+func newTestFlowRunner(t *testing.T) (*flowRunner, sqlmock.Sqlmock, *fakeMailer) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, mock.ExpectationsWereMet())
+		db.Close()
+	})
+	runner := newFlowRunner(db)
+	mailer := &fakeMailer{}
+	runner.mailer = mailer
+	return runner, mock, mailer
+}
+
+func operationRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "key", "type", "options", "resolve", "reject"})
+}
+
+// expectFlowRun expects a run to be started
+func expectFlowRun(mock sqlmock.Sqlmock, trigger string) {
+	mock.ExpectQuery(`INSERT INTO flow_runs \(flow, trigger\)`).WithArgs(testFlowID, trigger).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testRunID))
+}
+
+// expectFlowRunLogged expects a run to be finished with a status
+func expectFlowRunLogged(mock sqlmock.Sqlmock, status string) {
+	mock.ExpectExec(`UPDATE flow_runs SET status = \$2`).
+		WithArgs(testRunID, status, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestRenderFlowTemplate(t *testing.T) {
+	data := map[string]interface{}{
+		"$trigger": map[string]interface{}{
+			"payload": map[string]interface{}{"total": float64(1200), "customer": "Ada"},
+			"keys":    []interface{}{"42"},
+		},
+	}
+	options := map[string]interface{}{
+		"total":   "{{ $trigger.payload.total }}",
+		"subject": "Order {{$trigger.keys.0}} by {{ $trigger.payload.customer }}",
+		"nested":  []interface{}{map[string]interface{}{"missing": "{{ $trigger.nope }}"}},
+		"plain":   true,
+	}
+
+	rendered := renderFlowTemplate(options, data)
+
+	assert.Equal(t, map[string]interface{}{
+		"total":   float64(1200),
+		"subject": "Order 42 by Ada",
+		"nested":  []interface{}{map[string]interface{}{"missing": nil}},
+		"plain":   true,
+	}, rendered)
+	assert.Equal(t, "Total: 1200", renderFlowTemplate("Total: {{ $trigger.payload.total }}", data))
+}
+
+func TestFlowRunner_EventFlow(t *testing.T) {
+	runner, mock, mailer := newTestFlowRunner(t)
+	checkID := testOperationID
+	notifyID := "9e5c7d1b-0f2a-4b4c-d38e-9f0a1b2c3d4e"
+	prioritizeID := "0f6d8e2c-1a3b-4c5d-e49f-0a1b2c3d4e5f"
+
+	mock.ExpectQuery(`FROM flows\s+WHERE status = 'active' AND trigger = 'event'\s+AND options->'collections' \? \$1 AND options->'actions' \? \$2`).
+		WithArgs("orders", "create").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "operation"}).AddRow(testFlowID, checkID))
+	mock.ExpectQuery(`FROM operations WHERE flow = \$1`).WithArgs(testFlowID).
+		WillReturnRows(operationRows().
+			AddRow(checkID, "is_large", "condition", []byte(`{"filter":{"$trigger.payload.total":{"_gt":1000}}}`), notifyID, nil).
+			AddRow(notifyID, "notify", "mail", []byte(`{"to":"sales@example.com","subject":"Order {{ $trigger.keys.0 }} needs attention","body":"Total: {{ $trigger.payload.total }}"}`), prioritizeID, nil).
+			AddRow(prioritizeID, "prioritize", "item-update", []byte(`{"collection":"orders","key":"{{ $trigger.keys.0 }}","payload":{"priority":"high"}}`), nil, nil))
+	expectFlowRun(mock, flowTriggerEvent)
+	mock.ExpectQuery("FROM collections WHERE collection").WithArgs("orders").
+		WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	mock.ExpectQuery(`SELECT \* FROM "orders" WHERE id = \$1`).WithArgs("42").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "total", "priority"}).AddRow("42", 1200, nil))
+	mock.ExpectQuery("SELECT f.field, f.required").WithArgs("orders").WillReturnRows(fieldInfoRows().
+		AddRow("total", true, nil, nil, false, false, nil, nil, "numeric", nil, "NO").
+		AddRow("priority", false, nil, nil, false, false, nil, nil, "character varying", 20, "YES"))
+	mock.ExpectExec(`UPDATE "orders" SET "priority" = \$1, updated_at = CURRENT_TIMESTAMP WHERE id = \$2`).
+		WithArgs("high", "42").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "orders" WHERE id = \$1`).WithArgs("42").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "total", "priority"}).AddRow("42", 1200, "high"))
+	expectFlowRunLogged(mock, flowRunSuccess)
+
+	events := hooks.New()
+	runner.register(events)
+	// The events of the flow's writes are caught on their own bus
+	written := hooks.New()
+	var updated hooks.Event
+	written.Action("items.update", func(ctx context.Context, event hooks.Event) {
+		updated = event
+	})
+	runner.events = written
+	event := hooks.NewEvent(scopeItems, "orders", hooks.ActionCreate, map[string]interface{}{"total": 1200}, "42")
+	events.Emit(event)
+	events.Wait()
+	written.Wait()
+
+	assert.Equal(t, "orders", updated.Collection)
+	assert.Equal(t, []string{"42"}, updated.Keys)
+	assert.Equal(t, "high", updated.Payload["priority"])
+	assert.Equal(t, flowOrigin(testFlowID), updated.Origin)
+	assert.Equal(t, 1, updated.Depth)
+	require.Len(t, mailer.sent, 1)
+	assert.Equal(t, []string{"sales@example.com"}, mailer.sent[0].to)
+	assert.Equal(t, "Order 42 needs attention", mailer.sent[0].subject)
+	assert.Equal(t, "Total: 1200", mailer.sent[0].body)
+}
+
+func TestFlowRunner_EventFlowOwnChanges(t *testing.T) {
+	runner, mock, _ := newTestFlowRunner(t)
+	otherID := "1a7e9f3d-2b4c-4d6e-f5a0-1b2c3d4e5f60"
+
+	// The flow that made the change isn't run for it, other flows are
+	mock.ExpectQuery(`FROM flows\s+WHERE status = 'active' AND trigger = 'event'`).WithArgs("orders", "update").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "operation"}).AddRow(testFlowID, nil).AddRow(otherID, nil))
+	mock.ExpectQuery(`INSERT INTO flow_runs`).WithArgs(otherID, flowTriggerEvent).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testRunID))
+	expectFlowRunLogged(mock, flowRunSuccess)
+
+	event := hooks.NewEvent(scopeItems, "orders", hooks.ActionUpdate, nil, "42")
+	event.Origin = flowOrigin(testFlowID)
+	event.Depth = 1
+	require.NoError(t, runner.runEventFlows(context.Background(), event))
+
+	// Changes made by a chain of flows run none
+	event.Depth = flowMaxDepth
+	require.NoError(t, runner.runEventFlows(context.Background(), event))
+}
+
+func TestFlowRunner_ConditionNotMet(t *testing.T) {
+	runner, mock, mailer := newTestFlowRunner(t)
+	notifyID := "9e5c7d1b-0f2a-4b4c-d38e-9f0a1b2c3d4e"
+
+	mock.ExpectQuery(`FROM operations WHERE flow = \$1`).WithArgs(testFlowID).
+		WillReturnRows(operationRows().
+			AddRow(testOperationID, "is_large", "condition", []byte(`{"filter":{"$trigger.payload.total":{"_gt":1000}}}`), notifyID, nil).
+			AddRow(notifyID, "notify", "mail", []byte(`{"to":"sales@example.com","subject":"Large order"}`), nil, nil))
+	expectFlowRun(mock, flowTriggerEvent)
+	expectFlowRunLogged(mock, flowRunSuccess)
+
+	result, err := runner.run(context.Background(), testFlowID, testOperationID, flowTrigger{
+		Type: flowTriggerEvent,
+		Data: map[string]interface{}{"payload": map[string]interface{}{"total": 500}},
+	})
+
+	require.NoError(t, err)
+	// A condition that isn't met ends the run without an error
+	assert.Equal(t, flowRunSuccess, result.Status)
+	assert.Empty(t, mailer.sent)
+}
+
+func TestFlowRunner_RequestAndTransform(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"rate":0.5}`))
+	}))
+	defer server.Close()
+
+	runner, mock, _ := newTestFlowRunner(t)
+	transformID := "9e5c7d1b-0f2a-4b4c-d38e-9f0a1b2c3d4e"
+	mock.ExpectQuery(`FROM operations WHERE flow = \$1`).WithArgs(testFlowID).
+		WillReturnRows(operationRows().
+			AddRow(testOperationID, "rates", "request", []byte(`{"url":"`+server.URL+`","method":"post","body":{"amount":"{{ $trigger.body.amount }}"}}`), transformID, nil).
+			AddRow(transformID, "result", "transform", []byte(`{"json":{"rate":"{{ $last.data.rate }}","status":"{{ rates.status }}"}}`), nil, nil))
+	expectFlowRun(mock, flowTriggerManual)
+	expectFlowRunLogged(mock, flowRunSuccess)
+
+	result, err := runner.run(context.Background(), testFlowID, testOperationID, flowTrigger{
+		Type: flowTriggerManual,
+		Data: map[string]interface{}{"body": map[string]interface{}{"amount": 10}},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, flowRunSuccess, result.Status)
+	assert.Equal(t, map[string]interface{}{"rate": 0.5, "status": float64(200)}, result.Last)
+}
+
+func TestFlowRunner_FailedOperation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	runner, mock, _ := newTestFlowRunner(t)
+	fallbackID := "9e5c7d1b-0f2a-4b4c-d38e-9f0a1b2c3d4e"
+
+	// Failures continue with the reject operation when there is one
+	mock.ExpectQuery(`FROM operations WHERE flow = \$1`).WithArgs(testFlowID).
+		WillReturnRows(operationRows().
+			AddRow(testOperationID, "call", "request", []byte(`{"url":"`+server.URL+`"}`), nil, fallbackID).
+			AddRow(fallbackID, "fallback", "transform", []byte(`{"json":"{{ call.error }}"}`), nil, nil))
+	expectFlowRun(mock, flowTriggerManual)
+	expectFlowRunLogged(mock, flowRunSuccess)
+
+	result, err := runner.run(context.Background(), testFlowID, testOperationID, flowTrigger{Type: flowTriggerManual})
+	require.NoError(t, err)
+	assert.Equal(t, flowRunSuccess, result.Status)
+	assert.Equal(t, "unexpected response status 502", result.Last)
+
+	// Without one the run fails
+	mock.ExpectQuery(`FROM operations WHERE flow = \$1`).WithArgs(testFlowID).
+		WillReturnRows(operationRows().
+			AddRow(testOperationID, "call", "request", []byte(`{"url":"`+server.URL+`"}`), nil, nil))
+	expectFlowRun(mock, flowTriggerManual)
+	expectFlowRunLogged(mock, flowRunFailed)
+
+	result, err = runner.run(context.Background(), testFlowID, testOperationID, flowTrigger{Type: flowTriggerManual})
+	require.NoError(t, err)
+	assert.Equal(t, flowRunFailed, result.Status)
+	assert.Equal(t, "operation call: unexpected response status 502", result.Error)
+}
+
+func TestFlowRunner_Loop(t *testing.T) {
+	runner, mock, _ := newTestFlowRunner(t)
+	mock.ExpectQuery(`FROM operations WHERE flow = \$1`).WithArgs(testFlowID).
+		WillReturnRows(operationRows().
+			AddRow(testOperationID, "again", "transform", []byte(`{"json":1}`), testOperationID, nil))
+	expectFlowRun(mock, flowTriggerManual)
+	expectFlowRunLogged(mock, flowRunFailed)
+
+	result, err := runner.run(context.Background(), testFlowID, testOperationID, flowTrigger{Type: flowTriggerManual})
+
+	require.NoError(t, err)
+	assert.Equal(t, flowRunFailed, result.Status)
+	assert.Contains(t, result.Error, "exceeded")
+}
+
+func TestFlowRunner_CreateItemUnknownField(t *testing.T) {
+	runner, mock, _ := newTestFlowRunner(t)
+	mock.ExpectQuery(`FROM operations WHERE flow = \$1`).WithArgs(testFlowID).
+		WillReturnRows(operationRows().
+			AddRow(testOperationID, "log", "item-create", []byte(`{"collection":"audit","payload":{"secret":"x"}}`), nil, nil))
+	expectFlowRun(mock, flowTriggerManual)
+	mock.ExpectQuery("FROM collections WHERE collection").WithArgs("audit").
+		WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	mock.ExpectQuery("SELECT f.field, f.required").WithArgs("audit").WillReturnRows(fieldInfoRows().
+		AddRow("message", false, nil, nil, false, false, nil, nil, "text", nil, "YES"))
+	expectFlowRunLogged(mock, flowRunFailed)
+
+	result, err := runner.run(context.Background(), testFlowID, testOperationID, flowTrigger{Type: flowTriggerManual})
+
+	require.NoError(t, err)
+	assert.Equal(t, "operation log: unknown field secret", result.Error)
+}
+
+func TestFlowRunner_CreateItemValidation(t *testing.T) {
+	tests := map[string]struct {
+		payload string
+		error   string
+	}{
+		"required": {`{"level":"info"}`, "operation log: Required field 'message' is missing"},
+		"rules": {`{"message":"Hi","level":"debug"}`,
+			"operation log: Validation failed: Field 'level' must be one of the allowed values"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			runner, mock, _ := newTestFlowRunner(t)
+			mock.ExpectQuery(`FROM operations WHERE flow = \$1`).WithArgs(testFlowID).
+				WillReturnRows(operationRows().
+					AddRow(testOperationID, "log", "item-create", []byte(`{"collection":"audit","payload":`+tt.payload+`}`), nil, nil))
+			expectFlowRun(mock, flowTriggerManual)
+			mock.ExpectQuery("FROM collections WHERE collection").WithArgs("audit").
+				WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+			mock.ExpectQuery("SELECT f.field, f.required").WithArgs("audit").WillReturnRows(fieldInfoRows().
+				AddRow("message", true, nil, nil, false, false, nil, nil, "text", nil, "YES").
+				AddRow("level", false, []byte(`{"enum":["info","error"]}`), nil, false, false, nil, nil, "character varying", 10, "YES"))
+			// Nothing is written
+			expectFlowRunLogged(mock, flowRunFailed)
+
+			result, err := runner.run(context.Background(), testFlowID, testOperationID, flowTrigger{Type: flowTriggerManual})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.error, result.Error)
+		})
+	}
+}
+
+func TestFlowRunner_RunScheduled(t *testing.T) {
+	runner, mock, _ := newTestFlowRunner(t)
+	minute := time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC)
+	otherID := "9e5c7d1b-0f2a-4b4c-d38e-9f0a1b2c3d4e"
+
+	mock.ExpectQuery(`FROM flows\s+WHERE status = 'active' AND trigger = 'schedule'`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "operation", "cron"}).
+			AddRow(testFlowID, nil, "0 9 * * 1-5").
+			AddRow(otherID, nil, "30 * * * *").
+			AddRow(otherID, nil, "not cron"))
+	// Only the flow matching the minute runs
+	expectFlowRun(mock, flowTriggerSchedule)
+	expectFlowRunLogged(mock, flowRunSuccess)
+
+	started, err := runner.runScheduled(context.Background(), minute)
+	runner.running.Wait()
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, started)
+}
+
+func TestFlowRunner_ScheduledFlowsJob(t *testing.T) {
+	runner, mock, _ := newTestFlowRunner(t)
+	job := runner.scheduledFlowsJob()
+	// The run claimed 9:00 but started a poll later
+	ctx := context.WithValue(context.Background(), jobRunAtKey{}, time.Date(2024, 3, 11, 9, 0, 0, 0, time.Local))
+
+	mock.ExpectQuery(`FROM flows\s+WHERE status = 'active' AND trigger = 'schedule'`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "operation", "cron"}).AddRow(testFlowID, nil, "0 9 * * 1-5"))
+	expectFlowRun(mock, flowTriggerSchedule)
+	expectFlowRunLogged(mock, flowRunSuccess)
+
+	result, err := job.Run(ctx, map[string]interface{}{})
+	runner.running.Wait()
+
+	require.NoError(t, err)
+	assert.Equal(t, "* * * * *", job.Schedule)
+	assert.Equal(t, map[string]interface{}{"started": 1}, result)
+}
@@ -0,0 +1,603 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/hooks"
+)
+
+// How DELETE /folders/:id handles the contents of a non-empty folder
+const (
+	// folderContentsMove moves subfolders and files to the folder's parent
+	folderContentsMove = "move"
+	// folderContentsDelete deletes subfolders and files along with the folder
+	folderContentsDelete = "delete"
+)
+
+// FoldersHandler handles folder routes
+type FoldersHandler struct {
+	db             *sql.DB
+	files          *FilesHandler
+	authMiddleware gin.HandlerFunc
+	optionsHandler gin.HandlerFunc
+	events         *hooks.Bus
+}
+
+// NewFoldersHandler creates a new folders handler. Files deleted with their
+// folder are removed from storage by files.
+func NewFoldersHandler(server ServerInterface, files *FilesHandler) *FoldersHandler {
+	return &FoldersHandler{
+		db:             server.GetDB(),
+		files:          files,
+		authMiddleware: server.AuthMiddleware(),
+		optionsHandler: server.OptionsHandler(),
+		events:         server.Events(),
+	}
+}
+
+// SetupRoutes sets up folder routes
+func (h *FoldersHandler) SetupRoutes(v1 *gin.RouterGroup) {
+	// CORS preflight OPTIONS for folders endpoints
+	v1.OPTIONS("/folders", h.optionsHandler)
+	v1.OPTIONS("/folders/:id", h.optionsHandler)
+
+	// Folders routes (app access required)
+	folders := v1.Group("/folders")
+	folders.Use(h.authMiddleware, requireAppAccess())
+	{
+		folders.GET("", h.getFolders)
+		folders.POST("", h.createFolder)
+		folders.GET("/:id", h.getFolder)
+		folders.PATCH("/:id", h.updateFolder)
+		folders.DELETE("/:id", h.deleteFolder)
+	}
+}
+
+// Folder represents a folder organizing files
+type Folder struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Parent    *string   `json:"parent"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateFolderRequest represents the request body for creating a folder
+type CreateFolderRequest struct {
+	Name   string  `json:"name" binding:"required"`
+	Parent *string `json:"parent"`
+}
+
+// UpdateFolderRequest represents the request body for renaming or moving a folder
+type UpdateFolderRequest struct {
+	Name *string `json:"name"`
+	// Parent moves the folder; null moves it to the root
+	Parent nullableID `json:"parent" swaggertype:"string"`
+}
+
+// nullableID is an optional JSON ID that distinguishes null, which clears
+// the value, from leaving it out
+type nullableID struct {
+	Set   bool
+	Value *string
+}
+
+// UnmarshalJSON records that the field was present
+func (n *nullableID) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if string(data) == "null" {
+		n.Value = nil
+		return nil
+	}
+	return json.Unmarshal(data, &n.Value)
+}
+
+// folderExists reports whether a folder exists. Malformed IDs match nothing.
+func folderExists(db *sql.DB, id string) (bool, error) {
+	if !uuidRegexp.MatchString(id) {
+		return false, nil
+	}
+	var exists bool
+	err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM folders WHERE id = $1)`, id).Scan(&exists)
+	return exists, err
+}
+
+// GetFolders lists folders
+//
+//	@Summary		List folders
+//	@Description	Get all folders ordered by name, or the folders of one parent
+//	@Tags			folders
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			parent	query		string	false	"Only subfolders of this folder, or null for top-level folders"
+//	@Success		200		{array}		FolderModel	"Folders"
+//	@Failure		400		{object}	ErrorResponse	"Invalid parent"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	ErrorResponse	"App access required"
+//	@Failure		500		{object}	ErrorResponse	"Internal server error"
+//	@Router			/folders [get]
+func (h *FoldersHandler) getFolders(c *gin.Context) {
+	query := `SELECT id, name, parent, created_at, updated_at FROM folders`
+	var args []interface{}
+	if parent, ok := c.GetQuery("parent"); ok {
+		if parent == "" || parent == "null" {
+			query += ` WHERE parent IS NULL`
+		} else if uuidRegexp.MatchString(parent) {
+			query += ` WHERE parent = $1`
+			args = append(args, parent)
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent"})
+			return
+		}
+	}
+	query += ` ORDER BY name, id`
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while fetching folders")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	folders := []Folder{}
+	for rows.Next() {
+		folder, err := scanFolder(rows)
+		if err != nil {
+			logrus.WithError(err).Error("Error scanning folder row")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		folders = append(folders, *folder)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": folders})
+}
+
+// CreateFolder creates a folder
+//
+//	@Summary		Create a folder
+//	@Description	Create a folder, optionally inside another folder
+//	@Tags			folders
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			folder	body		CreateFolderRequest	true	"Folder"
+//	@Success		201		{object}	FolderModel	"Created folder"
+//	@Failure		400		{object}	ErrorResponse	"Invalid request payload or parent folder not found"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	ErrorResponse	"App access required"
+//	@Failure		500		{object}	ErrorResponse	"Internal server error"
+//	@Router			/folders [post]
+func (h *FoldersHandler) createFolder(c *gin.Context) {
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeFolders, scopeFolders, hooks.ActionCreate, nil)) {
+		return
+	}
+
+	var req CreateFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid create folder request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Folder name is required"})
+		return
+	}
+
+	if req.Parent != nil && !h.checkParent(c, *req.Parent) {
+		return
+	}
+
+	var folderID string
+	err := h.db.QueryRow(`INSERT INTO folders (name, parent) VALUES ($1, $2) RETURNING id`, name, req.Parent).Scan(&folderID)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while creating folder")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	folder, err := h.getFolderByID(folderID)
+	if err != nil {
+		logrus.WithError(err).Error("Error fetching created folder")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"folder_id":  folderID,
+		"created_by": c.GetString("user_id"),
+	}).Info("Folder created successfully")
+	emitActionHooks(c, h.events, scopeFolders, scopeFolders, hooks.ActionCreate, folder, folderID)
+	c.JSON(http.StatusCreated, gin.H{"data": folder})
+}
+
+// GetFolder returns a folder
+//
+//	@Summary		Get a folder
+//	@Description	Get a folder by ID
+//	@Tags			folders
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Folder ID"
+//	@Success		200	{object}	FolderModel	"Folder"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"App access required"
+//	@Failure		404	{object}	ErrorResponse	"Folder not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/folders/{id} [get]
+func (h *FoldersHandler) getFolder(c *gin.Context) {
+	folder, ok := h.resolveFolder(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": folder})
+}
+
+// UpdateFolder renames or moves a folder
+//
+//	@Summary		Update a folder
+//	@Description	Rename a folder or move it into another folder. A folder can't be moved into itself or one of its subfolders
+//	@Tags			folders
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string				true	"Folder ID"
+//	@Param			folder	body		UpdateFolderRequest	true	"Folder changes"
+//	@Success		200		{object}	FolderModel	"Updated folder"
+//	@Failure		400		{object}	ErrorResponse	"Invalid request payload, parent folder not found or moved into itself"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	ErrorResponse	"App access required"
+//	@Failure		404		{object}	ErrorResponse	"Folder not found"
+//	@Failure		500		{object}	ErrorResponse	"Internal server error"
+//	@Router			/folders/{id} [patch]
+func (h *FoldersHandler) updateFolder(c *gin.Context) {
+	folder, ok := h.resolveFolder(c)
+	if !ok {
+		return
+	}
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeFolders, scopeFolders, hooks.ActionUpdate, nil, folder.ID)) {
+		return
+	}
+
+	var req UpdateFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid update folder request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	var updates []string
+	var args []interface{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Folder name is required"})
+			return
+		}
+		args = append(args, name)
+		updates = append(updates, "name = $"+strconv.Itoa(len(args)))
+	}
+	if req.Parent.Set {
+		if req.Parent.Value != nil && !h.checkParent(c, *req.Parent.Value) {
+			return
+		}
+		args = append(args, req.Parent.Value)
+		updates = append(updates, "parent = $"+strconv.Itoa(len(args)))
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to begin transaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	// The folder and the path above its new parent are locked until the move
+	// commits, so concurrent moves can't create a cycle between them
+	if err := tx.QueryRow(`SELECT id FROM folders WHERE id = $1 FOR UPDATE`, folder.ID).Scan(new(string)); err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Folder not found"})
+		return
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while locking folder")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if req.Parent.Set && req.Parent.Value != nil {
+		inside, err := isInside(tx, *req.Parent.Value, folder.ID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent folder not found"})
+			return
+		} else if err != nil {
+			logrus.WithError(err).Error("Database error while checking folder ancestors")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if inside {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A folder can't be moved into itself or its subfolders"})
+			return
+		}
+	}
+
+	args = append(args, folder.ID)
+	query := `UPDATE folders SET ` + strings.Join(updates, ", ") + ` WHERE id = $` + strconv.Itoa(len(args))
+	if _, err := tx.Exec(query, args...); err != nil {
+		logrus.WithError(err).Error("Database error while updating folder")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		logrus.WithError(err).Error("Failed to commit transaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	updated, err := h.getFolderByID(folder.ID)
+	if err != nil {
+		logrus.WithError(err).Error("Error fetching updated folder")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithField("folder_id", folder.ID).Info("Folder updated successfully")
+	emitActionHooks(c, h.events, scopeFolders, scopeFolders, hooks.ActionUpdate, updated, folder.ID)
+	c.JSON(http.StatusOK, gin.H{"data": updated})
+}
+
+// DeleteFolder deletes a folder
+//
+//	@Summary		Delete a folder
+//	@Description	Delete a folder. A folder with subfolders or files is only deleted with contents=move, which moves them to the folder's parent, or contents=delete (admin only), which deletes them as well
+//	@Tags			folders
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string	true	"Folder ID"
+//	@Param			contents	query		string	false	"What to do with the contents: move or delete"
+//	@Success		200			{object}	SuccessMessage	"Folder deleted successfully"
+//	@Failure		400			{object}	ErrorResponse	"Invalid contents value"
+//	@Failure		401			{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403			{object}	ErrorResponse	"App or admin access required"
+//	@Failure		404			{object}	ErrorResponse	"Folder not found"
+//	@Failure		409			{object}	ErrorResponse	"Folder isn't empty, or holds files in use"
+//	@Failure		500			{object}	ErrorResponse	"Internal server error"
+//	@Router			/folders/{id} [delete]
+func (h *FoldersHandler) deleteFolder(c *gin.Context) {
+	contents := c.Query("contents")
+	if contents != "" && contents != folderContentsMove && contents != folderContentsDelete {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "contents must be move or delete"})
+		return
+	}
+	// Deleting contents can delete files of other users
+	if contents == folderContentsDelete && !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	folder, ok := h.resolveFolder(c)
+	if !ok {
+		return
+	}
+	event := requestEvent(c, scopeFolders, scopeFolders, hooks.ActionDelete, nil, folder.ID)
+	if !runFilterHooks(c, h.events, &event) {
+		return
+	}
+
+	var subfolders, files int
+	err := h.db.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM folders WHERE parent = $1),
+		       (SELECT COUNT(*) FROM files WHERE folder = $1)
+	`, folder.ID).Scan(&subfolders, &files)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while checking folder contents")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if (subfolders > 0 || files > 0) && contents == "" {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":      "Folder isn't empty; delete it with contents=move or contents=delete",
+			"subfolders": subfolders,
+			"files":      files,
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to start transaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var deletedFiles []Item
+	switch {
+	case subfolders == 0 && files == 0:
+		// Nothing to handle
+	case contents == folderContentsMove:
+		if _, err := tx.Exec(`UPDATE folders SET parent = $1 WHERE parent = $2`, folder.Parent, folder.ID); err != nil {
+			logrus.WithError(err).Error("Database error while moving subfolders")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if _, err := tx.Exec(`UPDATE files SET folder = $1 WHERE folder = $2`, folder.Parent, folder.ID); err != nil {
+			logrus.WithError(err).Error("Database error while moving files")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+	case contents == folderContentsDelete:
+		deletedFiles, err = deleteFolderContents(tx, folder.ID)
+		if isForeignKeyViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Folder holds files that are in use"})
+			return
+		} else if err != nil {
+			logrus.WithError(err).Error("Database error while deleting folder contents")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM folders WHERE id = $1`, folder.ID); err != nil {
+		logrus.WithError(err).Error("Database error while deleting folder")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		logrus.WithError(err).Error("Failed to commit folder deletion")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	for _, file := range deletedFiles {
+		h.files.removeStoredFile(c.Request.Context(), file)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"folder_id":     folder.ID,
+		"contents":      contents,
+		"deleted_files": len(deletedFiles),
+	}).Info("Folder deleted successfully")
+	emitActionHooks(c, h.events, scopeFolders, scopeFolders, hooks.ActionDelete, folder, folder.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Folder deleted successfully"})
+}
+
+// deleteFolderContents deletes the subfolders of a folder, recursively, and
+// the files in all of them. It returns the deleted files so their contents can
+// be removed after the transaction commits.
+func deleteFolderContents(tx *sql.Tx, folderID string) ([]Item, error) {
+	var descendants pq.StringArray
+	err := tx.QueryRow(`
+		WITH RECURSIVE tree AS (
+			SELECT id FROM folders WHERE parent = $1
+			UNION ALL
+			SELECT f.id FROM folders f JOIN tree ON f.parent = tree.id
+		)
+		SELECT COALESCE(array_agg(id::text), '{}') FROM tree
+	`, folderID).Scan(&descendants)
+	if err != nil {
+		return nil, err
+	}
+	folderIDs := append([]string{folderID}, descendants...)
+
+	rows, err := tx.Query(`DELETE FROM files WHERE folder = ANY($1::uuid[]) RETURNING id, storage, filename_disk`, pq.Array(folderIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []Item
+	for rows.Next() {
+		var id, location, filenameDisk string
+		if err := rows.Scan(&id, &location, &filenameDisk); err != nil {
+			return nil, err
+		}
+		files = append(files, Item{"id": id, "storage": location, "filename_disk": filenameDisk})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Constraints are checked at the end of the statement, so the order
+	// of the folders doesn't matter
+	if len(descendants) > 0 {
+		if _, err := tx.Exec(`DELETE FROM folders WHERE id = ANY($1::uuid[])`, pq.Array([]string(descendants))); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// resolveFolder loads the folder of the request.
+func (h *FoldersHandler) resolveFolder(c *gin.Context) (*Folder, bool) {
+	folderID := c.Param("id")
+	if !uuidRegexp.MatchString(folderID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Folder not found"})
+		return nil, false
+	}
+
+	folder, err := h.getFolderByID(folderID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Folder not found"})
+		return nil, false
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while fetching folder")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	return folder, true
+}
+
+// checkParent checks that a parent folder exists.
+func (h *FoldersHandler) checkParent(c *gin.Context, parentID string) bool {
+	exists, err := folderExists(h.db, parentID)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while checking parent folder")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parent folder not found"})
+		return false
+	}
+	return true
+}
+
+// isInside reports whether folderID is ancestorID or one of its subfolders.
+// It walks up from folderID one folder at a time, locking each one, so the
+// path can't change until the transaction ends. sql.ErrNoRows means folderID
+// doesn't exist.
+func isInside(tx *sql.Tx, folderID, ancestorID string) (bool, error) {
+	seen := make(map[string]bool)
+	for id := folderID; id != ""; {
+		if id == ancestorID {
+			return true, nil
+		}
+		if seen[id] {
+			return false, fmt.Errorf("folder %s is part of a cycle", id)
+		}
+		seen[id] = true
+
+		var parent sql.NullString
+		if err := tx.QueryRow(`SELECT parent FROM folders WHERE id = $1 FOR UPDATE`, id).Scan(&parent); err != nil {
+			return false, err
+		}
+		id = parent.String
+	}
+	return false, nil
+}
+
+// getFolderByID fetches a folder
+func (h *FoldersHandler) getFolderByID(folderID string) (*Folder, error) {
+	row := h.db.QueryRow(`SELECT id, name, parent, created_at, updated_at FROM folders WHERE id = $1`, folderID)
+	return scanFolder(row)
+}
+
+// scanFolder scans a folder from a row of id, name, parent, created_at and
+// updated_at
+func scanFolder(row interface{ Scan(...interface{}) error }) (*Folder, error) {
+	var folder Folder
+	if err := row.Scan(&folder.ID, &folder.Name, &folder.Parent, &folder.CreatedAt, &folder.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
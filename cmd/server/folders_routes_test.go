@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gorectus/internal/storage"
+)
+
+const (
+	testFolderID    = "0b6f8a2e-1c3d-4e5f-8a9b-0c1d2e3f4a5b"
+	testSubfolderID = "1c7a9b3f-2d4e-4f6a-9b0c-1d2e3f4a5b6c"
+	testParentID    = "2d8b0c4a-3e5f-4a7b-8c1d-2e3f4a5b6c7d"
+)
+
+// Test suite for folder handlers
+type FolderHandlersTestSuite struct {
+	suite.Suite
+	db      *sql.DB
+	mock    sqlmock.Sqlmock
+	storage *storage.Local
+}
+
+func (suite *FolderHandlersTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+	logrus.SetLevel(logrus.PanicLevel)
+}
+
+func (suite *FolderHandlersTestSuite) SetupTest() {
+	db, mock, err := sqlmock.New()
+	require.NoError(suite.T(), err)
+	suite.db = db
+	suite.mock = mock
+
+	suite.storage, err = storage.NewLocal(suite.T().TempDir())
+	require.NoError(suite.T(), err)
+}
+
+func (suite *FolderHandlersTestSuite) TearDownTest() {
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+	suite.db.Close()
+}
+
+// request serves a request to the folder routes as the given user
+func (suite *FolderHandlersTestSuite) request(method, url, body string, admin bool) *httptest.ResponseRecorder {
+	router := gin.New()
+	mockServer := &mockItemServerInterface{
+		db: suite.db,
+		customAuthFunc: func(c *gin.Context) {
+			c.Set("user_id", "user-1")
+			c.Set("admin_access", admin)
+			c.Set("app_access", true)
+			c.Next()
+		},
+	}
+	assets, err := newAssetCache(suite.T().TempDir(), defaultAssetCacheMaxSize)
+	require.NoError(suite.T(), err)
+	locations := storage.NewLocations([]string{"local"}, map[string]storage.Driver{"local": suite.storage})
+	files := NewFilesHandler(mockServer, locations, assets)
+	NewFoldersHandler(mockServer, files).SetupRoutes(router.Group("/api/v1"))
+
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func folderRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "name", "parent", "created_at", "updated_at"})
+}
+
+func (suite *FolderHandlersTestSuite) expectFolder(id, name string, parent interface{}) {
+	now := time.Now()
+	suite.mock.ExpectQuery(`SELECT id, name, parent, created_at, updated_at FROM folders WHERE id = \$1`).
+		WithArgs(id).
+		WillReturnRows(folderRows().AddRow(id, name, parent, now, now))
+}
+
+func (suite *FolderHandlersTestSuite) expectFolderExists(id string, exists bool) {
+	suite.mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM folders WHERE id = \$1\)`).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(exists))
+}
+
+func (suite *FolderHandlersTestSuite) expectContents(id string, subfolders, files int) {
+	suite.mock.ExpectQuery(`SELECT \(SELECT COUNT\(\*\) FROM folders WHERE parent = \$1\)`).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"subfolders", "files"}).AddRow(subfolders, files))
+}
+
+func (suite *FolderHandlersTestSuite) TestGetFolders_TopLevel() {
+	now := time.Now()
+	suite.mock.ExpectQuery(`SELECT id, name, parent, created_at, updated_at FROM folders WHERE parent IS NULL ORDER BY name, id`).
+		WillReturnRows(folderRows().AddRow(testFolderID, "Photos", nil, now, now))
+
+	w := suite.request("GET", "/api/v1/folders?parent=null", "", false)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var response map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	folders := response["data"].([]interface{})
+	require.Len(suite.T(), folders, 1)
+	assert.Equal(suite.T(), "Photos", folders[0].(map[string]interface{})["name"])
+}
+
+func (suite *FolderHandlersTestSuite) TestGetFolders_InvalidParent() {
+	w := suite.request("GET", "/api/v1/folders?parent=photos", "", false)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *FolderHandlersTestSuite) TestCreateFolder() {
+	suite.expectFolderExists(testParentID, true)
+	suite.mock.ExpectQuery(`INSERT INTO folders \(name, parent\) VALUES \(\$1, \$2\) RETURNING id`).
+		WithArgs("Photos", testParentID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testFolderID))
+	suite.expectFolder(testFolderID, "Photos", testParentID)
+
+	w := suite.request("POST", "/api/v1/folders", `{"name": " Photos ", "parent": "`+testParentID+`"}`, false)
+
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+	var response map[string]map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), testFolderID, response["data"]["id"])
+	assert.Equal(suite.T(), testParentID, response["data"]["parent"])
+}
+
+func (suite *FolderHandlersTestSuite) TestCreateFolder_ParentNotFound() {
+	suite.expectFolderExists(testParentID, false)
+
+	w := suite.request("POST", "/api/v1/folders", `{"name": "Photos", "parent": "`+testParentID+`"}`, false)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *FolderHandlersTestSuite) TestGetFolder_NotFound() {
+	suite.mock.ExpectQuery(`SELECT id, name, parent, created_at, updated_at FROM folders WHERE id = \$1`).
+		WithArgs(testFolderID).
+		WillReturnError(sql.ErrNoRows)
+
+	w := suite.request("GET", "/api/v1/folders/"+testFolderID, "", false)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	// Malformed IDs never reach the database
+	w = suite.request("GET", "/api/v1/folders/photos", "", false)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+// expectFolderLock expects the moved folder to be locked in a transaction
+func (suite *FolderHandlersTestSuite) expectFolderLock(id string) {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery(`SELECT id FROM folders WHERE id = \$1 FOR UPDATE`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+}
+
+// expectAncestor expects a folder on the path to the root to be locked
+func (suite *FolderHandlersTestSuite) expectAncestor(id string, parent interface{}) {
+	suite.mock.ExpectQuery(`SELECT parent FROM folders WHERE id = \$1 FOR UPDATE`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"parent"}).AddRow(parent))
+}
+
+func (suite *FolderHandlersTestSuite) TestUpdateFolder() {
+	suite.expectFolder(testFolderID, "Photos", nil)
+	suite.expectFolderExists(testParentID, true)
+	suite.expectFolderLock(testFolderID)
+	suite.expectAncestor(testParentID, nil)
+	suite.mock.ExpectExec(`UPDATE folders SET name = \$1, parent = \$2 WHERE id = \$3`).
+		WithArgs("Pictures", testParentID, testFolderID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+	suite.expectFolder(testFolderID, "Pictures", testParentID)
+
+	w := suite.request("PATCH", "/api/v1/folders/"+testFolderID,
+		`{"name": "Pictures", "parent": "`+testParentID+`"}`, false)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+}
+
+func (suite *FolderHandlersTestSuite) TestUpdateFolder_MoveToRoot() {
+	suite.expectFolder(testFolderID, "Photos", testParentID)
+	suite.expectFolderLock(testFolderID)
+	suite.mock.ExpectExec(`UPDATE folders SET parent = \$1 WHERE id = \$2`).
+		WithArgs(nil, testFolderID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+	suite.expectFolder(testFolderID, "Photos", nil)
+
+	w := suite.request("PATCH", "/api/v1/folders/"+testFolderID, `{"parent": null}`, false)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+}
+
+func (suite *FolderHandlersTestSuite) TestUpdateFolder_IntoSubfolder() {
+	suite.expectFolder(testFolderID, "Photos", nil)
+	suite.expectFolderExists(testSubfolderID, true)
+	suite.expectFolderLock(testFolderID)
+	suite.expectAncestor(testSubfolderID, testFolderID)
+	suite.mock.ExpectRollback()
+
+	w := suite.request("PATCH", "/api/v1/folders/"+testFolderID, `{"parent": "`+testSubfolderID+`"}`, false)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "itself or its subfolders")
+}
+
+// A parent deleted after the existence check is reported like a missing one
+func (suite *FolderHandlersTestSuite) TestUpdateFolder_ParentDeleted() {
+	suite.expectFolder(testFolderID, "Photos", nil)
+	suite.expectFolderExists(testParentID, true)
+	suite.expectFolderLock(testFolderID)
+	suite.mock.ExpectQuery(`SELECT parent FROM folders WHERE id = \$1 FOR UPDATE`).WithArgs(testParentID).
+		WillReturnError(sql.ErrNoRows)
+	suite.mock.ExpectRollback()
+
+	w := suite.request("PATCH", "/api/v1/folders/"+testFolderID, `{"parent": "`+testParentID+`"}`, false)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Parent folder not found")
+}
+
+func (suite *FolderHandlersTestSuite) TestDeleteFolder_Empty() {
+	suite.expectFolder(testFolderID, "Photos", nil)
+	suite.expectContents(testFolderID, 0, 0)
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`DELETE FROM folders WHERE id = \$1`).WithArgs(testFolderID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	w := suite.request("DELETE", "/api/v1/folders/"+testFolderID, "", false)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+}
+
+func (suite *FolderHandlersTestSuite) TestDeleteFolder_NotEmpty() {
+	suite.expectFolder(testFolderID, "Photos", nil)
+	suite.expectContents(testFolderID, 1, 3)
+
+	w := suite.request("DELETE", "/api/v1/folders/"+testFolderID, "", false)
+
+	require.Equal(suite.T(), http.StatusConflict, w.Code)
+	var response map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), float64(1), response["subfolders"])
+	assert.Equal(suite.T(), float64(3), response["files"])
+}
+
+func (suite *FolderHandlersTestSuite) TestDeleteFolder_MoveContents() {
+	suite.expectFolder(testFolderID, "Photos", testParentID)
+	suite.expectContents(testFolderID, 1, 3)
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`UPDATE folders SET parent = \$1 WHERE parent = \$2`).
+		WithArgs(testParentID, testFolderID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec(`UPDATE files SET folder = \$1 WHERE folder = \$2`).
+		WithArgs(testParentID, testFolderID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	suite.mock.ExpectExec(`DELETE FROM folders WHERE id = \$1`).WithArgs(testFolderID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	w := suite.request("DELETE", "/api/v1/folders/"+testFolderID+"?contents=move", "", false)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+}
+
+func (suite *FolderHandlersTestSuite) TestDeleteFolder_DeleteContents() {
+	require.NoError(suite.T(), suite.storage.Put(context.Background(), "f1.txt", strings.NewReader("Hello")))
+	suite.expectFolder(testFolderID, "Photos", nil)
+	suite.expectContents(testFolderID, 1, 1)
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery(`WITH RECURSIVE tree`).WithArgs(testFolderID).
+		WillReturnRows(sqlmock.NewRows([]string{"array_agg"}).AddRow("{" + testSubfolderID + "}"))
+	suite.mock.ExpectQuery(`DELETE FROM files WHERE folder = ANY\(\$1::uuid\[\]\) RETURNING id, storage, filename_disk`).
+		WithArgs(pq.Array([]string{testFolderID, testSubfolderID})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "storage", "filename_disk"}).AddRow("f1", "local", "f1.txt"))
+	suite.mock.ExpectExec(`DELETE FROM folders WHERE id = ANY\(\$1::uuid\[\]\)`).
+		WithArgs(pq.Array([]string{testSubfolderID})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec(`DELETE FROM folders WHERE id = \$1`).WithArgs(testFolderID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	w := suite.request("DELETE", "/api/v1/folders/"+testFolderID+"?contents=delete", "", true)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	_, err := suite.storage.Open(context.Background(), "f1.txt", 0, -1)
+	assert.ErrorIs(suite.T(), err, storage.ErrNotFound)
+}
+
+func (suite *FolderHandlersTestSuite) TestDeleteFolder_DeleteContentsRequiresAdmin() {
+	w := suite.request("DELETE", "/api/v1/folders/"+testFolderID+"?contents=delete", "", false)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = suite.request("DELETE", "/api/v1/folders/"+testFolderID+"?contents=keep", "", true)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func TestFolderHandlersTestSuite(t *testing.T) {
+	suite.Run(t, new(FolderHandlersTestSuite))
+}
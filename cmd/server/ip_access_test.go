@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPAllowed(t *testing.T) {
+	tests := []struct {
+		name      string
+		ip        string
+		allowList []string
+		expected  bool
+	}{
+		{"Empty allow-list allows everything", "203.0.113.5", nil, true},
+		{"Matching CIDR", "10.1.2.3", []string{"10.0.0.0/8"}, true},
+		{"Non-matching CIDR", "192.168.1.10", []string{"10.0.0.0/8"}, false},
+		{"Exact address", "192.168.1.10", []string{"192.168.1.10"}, true},
+		{"IPv6 CIDR", "2001:db8::1", []string{"2001:db8::/32"}, true},
+		{"Invalid entries are skipped", "10.1.2.3", []string{"not-an-ip", "10.0.0.0/8"}, true},
+		{"Unparseable client IP", "", []string{"10.0.0.0/8"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ipAllowed(tt.ip, tt.allowList))
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	assert.Nil(t, parseTrustedProxies(""))
+	assert.Equal(t, []string{"10.0.0.1", "172.16.0.0/12"}, parseTrustedProxies(" 10.0.0.1, 172.16.0.0/12 ,"))
+}
+
+func TestAuthMiddlewareIPAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logrus.SetLevel(logrus.PanicLevel)
+
+	token, err := generateJWT("user-1", "user@example.com", "Editor")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		trusted    []string
+		expected   int
+	}{
+		{"Allowed IP", "10.1.2.3:1234", "", nil, http.StatusOK},
+		{"Rejected IP", "192.168.1.10:1234", "", nil, http.StatusForbidden},
+		{"Forwarded header ignored from untrusted proxy", "192.168.1.10:1234", "10.1.2.3", nil, http.StatusForbidden},
+		{"Forwarded header honored from trusted proxy", "192.168.1.10:1234", "10.1.2.3", []string{"192.168.1.0/24"}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			router := gin.New()
+			require.NoError(t, router.SetTrustedProxies(tt.trusted))
+			server := &Server{db: db, router: router}
+
+			mock.ExpectQuery("SELECT r.id, r.name, r.ip_access, r.admin_access, r.app_access FROM users u").
+				WithArgs("user-1").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "ip_access", "admin_access", "app_access"}).
+					AddRow("role-1", "Editor", "{10.0.0.0/8}", false, true))
+			if tt.expected == http.StatusForbidden {
+				mock.ExpectExec("INSERT INTO activity").
+					WithArgs(activityActionIPDenied, "user-1", sqlmock.AnyArg(), sqlmock.AnyArg(),
+						nil, nil, sqlmock.AnyArg(), nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+
+			router.GET("/protected", server.authMiddleware(), func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "ok"})
+			})
+
+			req := httptest.NewRequest("GET", "/protected", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("Authorization", "Bearer "+token)
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -10,22 +10,30 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/hooks"
+	"gorectus/internal/schema"
 )
 
 // ItemsHandler handles item-related routes
 type ItemsHandler struct {
-	db             *sql.DB
-	authMiddleware gin.HandlerFunc
-	optionsHandler gin.HandlerFunc
+	db                     *sql.DB
+	authMiddleware         gin.HandlerFunc
+	optionalAuthMiddleware gin.HandlerFunc
+	optionsHandler         gin.HandlerFunc
+	events                 *hooks.Bus
 }
 
 // NewItemsHandler creates a new items handler
 func NewItemsHandler(server ServerInterface) *ItemsHandler {
 	return &ItemsHandler{
-		db:             server.GetDB(),
-		authMiddleware: server.AuthMiddleware(),
-		optionsHandler: server.OptionsHandler(),
+		db:                     server.GetDB(),
+		authMiddleware:         server.AuthMiddleware(),
+		optionalAuthMiddleware: server.OptionalAuthMiddleware(),
+		optionsHandler:         server.OptionsHandler(),
+		events:                 server.Events(),
 	}
 }
 
@@ -34,15 +42,18 @@ func (h *ItemsHandler) SetupRoutes(v1 *gin.RouterGroup) {
 	// CORS preflight OPTIONS for items endpoints
 	v1.OPTIONS("/items/:collection", h.optionsHandler)
 
-	// Items routes (protected)
+	// Items routes (reads fall back to the Public role, writes are protected)
 	items := v1.Group("/items")
-	items.Use(h.authMiddleware)
 	{
-		items.GET("/:collection", h.getItems)
-		items.POST("/:collection", h.createItem)
-		items.GET("/:collection/:id", h.getItem)
-		items.PATCH("/:collection/:id", h.updateItem)
-		items.DELETE("/:collection/:id", h.deleteItem)
+		items.GET("/:collection", h.optionalAuthMiddleware, h.getItems)
+		items.POST("/:collection", h.authMiddleware, h.createItem)
+		items.PATCH("/:collection", h.authMiddleware, h.updateSingleton)
+		items.POST("/:collection/sort", h.authMiddleware, h.sortItems)
+		items.GET("/:collection/:id", h.optionalAuthMiddleware, h.getItem)
+		items.PATCH("/:collection/:id", h.authMiddleware, h.updateItem)
+		items.DELETE("/:collection/:id", h.authMiddleware, h.deleteItem)
+		items.POST("/:collection/:id/unarchive", h.authMiddleware, h.unarchiveItem)
+		items.POST("/:collection/:id/duplicate", h.authMiddleware, h.duplicateItem)
 	}
 }
 
@@ -51,34 +62,98 @@ type Item map[string]interface{}
 
 // FieldInfo represents basic field information needed for validation
 type FieldInfo struct {
-	Field    string `json:"field"`
-	Required bool   `json:"required"`
+	Field             string                 `json:"field"`
+	Required          bool                   `json:"required"`
+	Validation        map[string]interface{} `json:"validation"`
+	ValidationMessage *string                `json:"validation_message"`
+	Readonly          bool                   `json:"readonly"`
+	Hidden            bool                   `json:"hidden"`
+	Conditions        []FieldCondition       `json:"conditions"`
+	Special           []string               `json:"special"`
+	// Schema is nil for fields without a database column
+	Schema *FieldSchema `json:"schema"`
+}
+
+// ItemPermission represents a role's permission rule for an action on a collection
+type ItemPermission struct {
+	Filter map[string]interface{} `json:"permissions"`
+	Fields []string               `json:"fields"`
 }
 
-// isAdmin checks if the requesting user is an admin
-func (h *ItemsHandler) isAdmin(c *gin.Context) bool {
-	currentUserRole := c.GetString("user_role")
-	return currentUserRole == "Administrator"
+// ItemCollection holds the collection settings that change how items are served
+type ItemCollection struct {
+	Collection string `json:"collection"`
+	// Singleton collections hold a single item, served as an object
+	Singleton bool `json:"singleton"`
+	// Deleting an item sets ArchiveField to ArchiveValue when both are configured
+	ArchiveField     *string `json:"archive_field"`
+	ArchiveValue     *string `json:"archive_value"`
+	UnarchiveValue   *string `json:"unarchive_value"`
+	ArchiveAppFilter bool    `json:"archive_app_filter"`
+	// SortField orders item lists manually when set
+	SortField *string `json:"sort_field"`
+	// DuplicationFields are copied when an item is duplicated
+	DuplicationFields []string `json:"item_duplication_fields"`
+	// DisplayTemplate renders an item's title, such as "{{first_name}} {{last_name}}"
+	DisplayTemplate *string `json:"display_template"`
+	// Tables adopted as collections may lack the timestamp columns
+	HasCreatedAt bool `json:"-"`
+	HasUpdatedAt bool `json:"-"`
 }
 
-// checkCollectionExists verifies if a collection exists
-func (h *ItemsHandler) checkCollectionExists(collectionName string) error {
-	var exists bool
-	err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM collections WHERE collection = $1)", collectionName).Scan(&exists)
+// getItemCollection loads a collection's settings, or sql.ErrNoRows if it doesn't exist
+func (h *ItemsHandler) getItemCollection(collectionName string) (*ItemCollection, error) {
+	collection := &ItemCollection{Collection: collectionName}
+	var duplicationFieldsBytes []byte
+	err := h.db.QueryRow(`
+		SELECT COALESCE(singleton, false), archive_field, archive_value, unarchive_value,
+		       COALESCE(archive_app_filter, true), sort_field, item_duplication_fields,
+		       display_template,
+		       EXISTS(SELECT 1 FROM information_schema.columns
+		              WHERE table_schema = current_schema() AND table_name = $1 AND column_name = 'created_at'),
+		       EXISTS(SELECT 1 FROM information_schema.columns
+		              WHERE table_schema = current_schema() AND table_name = $1 AND column_name = 'updated_at')
+		FROM collections
+		WHERE collection = $1
+	`, collectionName).Scan(&collection.Singleton, &collection.ArchiveField, &collection.ArchiveValue,
+		&collection.UnarchiveValue, &collection.ArchiveAppFilter, &collection.SortField, &duplicationFieldsBytes,
+		&collection.DisplayTemplate, &collection.HasCreatedAt, &collection.HasUpdatedAt)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if !exists {
-		return sql.ErrNoRows
+
+	if duplicationFieldsBytes != nil {
+		if err := json.Unmarshal(duplicationFieldsBytes, &collection.DuplicationFields); err != nil {
+			logrus.WithError(err).WithField("collection", collectionName).Warn("Ignoring invalid item_duplication_fields")
+		}
+	}
+
+	return collection, nil
+}
+
+// resolveCollection loads the collection of a request.
+//
+// Like the other handler helpers that take the gin.Context and return a bool,
+// it writes the error response itself and returns false when the request must
+// stop, so callers only return.
+func (h *ItemsHandler) resolveCollection(c *gin.Context, collectionName string) (*ItemCollection, bool) {
+	collection, err := h.getItemCollection(collectionName)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return nil, false
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while checking collection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
 	}
-	return nil
+	return collection, true
 }
 
 // Items handlers implementations
 // GetItems retrieves all items from a collection
 //
 //	@Summary		Get all items from a collection
-//	@Description	Retrieve a list of all items from a specific collection
+//	@Description	Retrieve a list of all items from a specific collection, or the item of a singleton collection. Requests without a token are served with the Public role's read permission
 //	@Tags			items
 //	@Accept			json
 //	@Produce		json
@@ -86,8 +161,17 @@ func (h *ItemsHandler) checkCollectionExists(collectionName string) error {
 //	@Param			collection	path		string		true	"Collection name"
 //	@Param			limit		query		int			false	"Limit the number of results"
 //	@Param			offset		query		int			false	"Offset for pagination"
-//	@Success		200			{array}		ItemModel	"List of items"
+//	@Param			archived	query		string		false	"Include archived items: true (only archived), false or all"
+//	@Param			show_hidden	query		bool		false	"Include hidden fields (admin only)"
+//	@Param			display		query		bool		false	"Add the rendered display template as $display"
+//	@Param			lang		query		string		false	"Merge in translations in this language, falling back to the default language"
+//	@Param			expand		query		string		false	"Comma-separated file fields to return with the files' metadata instead of IDs"
+//	@Param			filter		query		string		false	"JSON filter, e.g. {\"status\":{\"_eq\":\"published\"}}"
+//	@Param			sort		query		string		false	"Comma-separated fields to sort by, prefixed with - for descending order"
+//	@Success		200			{array}		ItemModel	"List of items, or a single item for singletons"
+//	@Failure		400			{object}	ErrorResponse	"Invalid filter or sort"
 //	@Failure		401			{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403			{object}	ErrorResponse	"Public role has no read access"
 //	@Failure		404			{object}	ErrorResponse	"Collection not found"
 //	@Failure		500			{object}	ErrorResponse	"Internal server error"
 //	@Router			/items/{collection} [get]
@@ -95,12 +179,8 @@ func (h *ItemsHandler) getItems(c *gin.Context) {
 	collectionName := c.Param("collection")
 
 	// Check if collection exists
-	if err := h.checkCollectionExists(collectionName); err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
-		return
-	} else if err != nil {
-		logrus.WithError(err).Error("Database error while checking collection")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	collection, ok := h.resolveCollection(c, collectionName)
+	if !ok {
 		return
 	}
 
@@ -120,10 +200,93 @@ func (h *ItemsHandler) getItems(c *gin.Context) {
 
 	offset := (page - 1) * limit
 
+	// Anonymous requests are limited to what the Public role may read
+	permission, ok := h.resolvePublicPermission(c, collectionName)
+	if !ok {
+		return
+	}
+
+	// Archived items are left out unless asked for
+	filter := permission.filter()
+	if !collection.Singleton {
+		mode, ok := archivedMode(c, collection)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "archived must be true, false or all"})
+			return
+		}
+		filter = combineFilters(filter, collection.archiveFilter(mode))
+	}
+
+	// Fields decide which values are hidden or never returned
+	fields, err := h.getFieldsByCollection(collectionName)
+	if err != nil {
+		logrus.WithError(err).Error("Error getting collection fields")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// Filters and sorts of the request are limited to fields it may read
+	queryable := queryableField(c, fields, permission)
+	queryFilter, err := parseFilterQuery(c.Query("filter"), queryable)
+	if err == nil {
+		_, _, err = buildFilterSQL(queryFilter, 1)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	order, err := parseSortQuery(c.Query("sort"), queryable)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if order == "" {
+		order = collection.itemOrder()
+	}
+
+	whereClause, whereArgs, err := buildFilterSQL(combineFilters(filter, queryFilter), 1)
+	if err != nil {
+		logrus.WithError(err).Error("Invalid permission filter")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid permission filter"})
+		return
+	}
+	if whereClause != "" {
+		whereClause = " WHERE " + whereClause
+	}
+
+	// Singletons are served as a single object without pagination
+	if collection.Singleton {
+		item, err := h.getSingletonItem(collection, permission.filter())
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusOK, gin.H{"data": nil})
+			return
+		} else if err != nil {
+			logrus.WithError(err).Error("Database error while fetching singleton item")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		item = outputItem(c, fields, item)
+		if !h.applyTranslations(c, collectionName, fields, []Item{item}) {
+			return
+		}
+		if !h.applyFileFields(c, collectionName, fields, []Item{item}) {
+			return
+		}
+		item = permission.applyFields(item)
+		if !h.applyDisplay(c, collection, []Item{item}) {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": item})
+		return
+	}
+
 	// Build query - use safe table name quoting
-	query := fmt.Sprintf(`SELECT * FROM "%s" ORDER BY created_at DESC LIMIT $1 OFFSET $2`, collectionName)
+	query := fmt.Sprintf(`SELECT * FROM "%s"%s ORDER BY %s LIMIT $%d OFFSET $%d`,
+		collectionName, whereClause, order, len(whereArgs)+1, len(whereArgs)+2)
 
-	rows, err := h.db.Query(query, limit, offset)
+	rows, err := h.db.Query(query, append(whereArgs, limit, offset)...)
 	if err != nil {
 		logrus.WithError(err).Error("Database error while fetching items")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
@@ -141,52 +304,33 @@ func (h *ItemsHandler) getItems(c *gin.Context) {
 
 	var items []Item
 	for rows.Next() {
-		// Create a slice of interface{} to receive the row data
-		values := make([]interface{}, len(columns))
-		valuePointers := make([]interface{}, len(columns))
-		for i := range columns {
-			valuePointers[i] = &values[i]
-		}
-
-		// Scan the row into the value pointers
-		if err := rows.Scan(valuePointers...); err != nil {
+		item, err := scanItemRow(rows, columns)
+		if err != nil {
 			logrus.WithError(err).Error("Error scanning item row")
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 			return
 		}
 
-		// Create the item map
-		item := make(Item)
-		for i, col := range columns {
-			val := values[i]
-			if val != nil {
-				// Handle different PostgreSQL types
-				switch v := val.(type) {
-				case []byte:
-					// Try to parse as JSON first, fallback to string
-					var jsonVal interface{}
-					if err := json.Unmarshal(v, &jsonVal); err == nil {
-						item[col] = jsonVal
-					} else {
-						item[col] = string(v)
-					}
-				case time.Time:
-					item[col] = v.Format(time.RFC3339)
-				default:
-					item[col] = v
-				}
-			} else {
-				item[col] = nil
-			}
-		}
+		items = append(items, outputItem(c, fields, item))
+	}
 
-		items = append(items, item)
+	if !h.applyTranslations(c, collectionName, fields, items) {
+		return
+	}
+	if !h.applyFileFields(c, collectionName, fields, items) {
+		return
+	}
+	for i := range items {
+		items[i] = permission.applyFields(items[i])
+	}
+	if !h.applyDisplay(c, collection, items) {
+		return
 	}
 
 	// Get total count
-	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM "%s"`, collectionName)
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM "%s"%s`, collectionName, whereClause)
 	var total int
-	err = h.db.QueryRow(countQuery).Scan(&total)
+	err = h.db.QueryRow(countQuery, whereArgs...).Scan(&total)
 	if err != nil {
 		logrus.WithError(err).Error("Error counting items")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
@@ -214,14 +358,14 @@ func (h *ItemsHandler) getItems(c *gin.Context) {
 //	@Param			collection	path		string		true	"Collection name"
 //	@Param			item		body		ItemModel	true	"Item data"
 //	@Success		201			{object}	ItemModel	"Created item"
-//	@Failure		400			{object}	ErrorResponse	"Invalid request payload"
+//	@Failure		400			{object}	ErrorResponse	"Invalid request payload, readonly field or singleton collection"
 //	@Failure		401			{object}	ErrorResponse	"Unauthorized"
 //	@Failure		404			{object}	ErrorResponse	"Collection not found"
 //	@Failure		500			{object}	ErrorResponse	"Internal server error"
 //	@Router			/items/{collection} [post]
 func (h *ItemsHandler) createItem(c *gin.Context) {
 	// Only admins can create items
-	if !h.isAdmin(c) {
+	if !isAdmin(c) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
 		return
 	}
@@ -229,12 +373,24 @@ func (h *ItemsHandler) createItem(c *gin.Context) {
 	collectionName := c.Param("collection")
 
 	// Check if collection exists
-	if err := h.checkCollectionExists(collectionName); err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+	collection, ok := h.resolveCollection(c, collectionName)
+	if !ok {
 		return
-	} else if err != nil {
-		logrus.WithError(err).Error("Database error while checking collection")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	}
+
+	if collection.Singleton {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Singleton collections hold a single item, use PATCH /items/" + collectionName})
+		return
+	}
+
+	h.insertItem(c, collection)
+}
+
+// insertItem creates an item from the request body and writes the response
+func (h *ItemsHandler) insertItem(c *gin.Context, collection *ItemCollection) {
+	collectionName := collection.Collection
+
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeItems, collectionName, hooks.ActionCreate, nil)) {
 		return
 	}
 
@@ -263,12 +419,24 @@ func (h *ItemsHandler) createItem(c *gin.Context) {
 		return
 	}
 
-	// Validate required fields
-	for _, field := range fields {
-		if field.Required && requestData[field.Field] == nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Required field '%s' is missing", field.Field)})
-			return
-		}
+	// Conditions can make fields readonly or required depending on the values sent
+	fields, writeErr := checkItemWrite(fields, requestData, nil)
+	if writeErr != nil {
+		c.JSON(http.StatusBadRequest, writeErr.response())
+		return
+	}
+
+	// Files fields are written to their junction once the item exists
+	filesValues, ok := h.takeFileValues(c, fields, requestData)
+	if !ok {
+		return
+	}
+
+	// Generate values and hash secrets for fields with a special behavior
+	if err := applySpecials(fields, requestData, actionCreate, c.GetString("user_id")); err != nil {
+		logrus.WithError(err).Error("Error applying special field behaviors")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process item"})
+		return
 	}
 
 	// Build insert query
@@ -282,37 +450,57 @@ func (h *ItemsHandler) createItem(c *gin.Context) {
 		placeholders = append(placeholders, fmt.Sprintf("$%d", argIndex))
 
 		// Convert complex types to JSON
-		if val != nil {
-			switch v := val.(type) {
-			case map[string]interface{}, []interface{}:
-				jsonBytes, _ := json.Marshal(v)
-				values = append(values, jsonBytes)
-			default:
-				values = append(values, v)
-			}
-		} else {
-			values = append(values, nil)
-		}
+		values = append(values, columnValue(val))
 
 		argIndex++
 	}
 
 	insertQuery := fmt.Sprintf(
-		`INSERT INTO "%s" (%s) VALUES (%s) RETURNING id, created_at, updated_at`,
+		`INSERT INTO "%s" (%s) VALUES (%s) RETURNING id`,
 		collectionName,
 		strings.Join(columns, ", "),
 		strings.Join(placeholders, ", "),
 	)
+	if len(columns) == 0 {
+		// Only files fields were sent
+		insertQuery = fmt.Sprintf(`INSERT INTO "%s" DEFAULT VALUES RETURNING id`, collectionName)
+	}
 
-	var newID string
-	var createdAt, updatedAt time.Time
-	err = h.db.QueryRow(insertQuery, values...).Scan(&newID, &createdAt, &updatedAt)
+	tx, err := h.db.Begin()
 	if err != nil {
+		logrus.WithError(err).Error("Failed to start transaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var newID string
+	if collection.Singleton {
+		newID, err = h.insertSingletonRow(tx, collectionName, insertQuery, values)
+	} else {
+		err = tx.QueryRow(insertQuery, values...).Scan(&newID)
+	}
+	if err == errSingletonExists {
+		c.JSON(http.StatusConflict, gin.H{"error": "Singleton collection already has an item"})
+		return
+	} else if err != nil {
 		logrus.WithError(err).Error("Database error while creating item")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
 
+	if err := saveFilesValues(tx, collectionName, newID, filesValues); err != nil {
+		logrus.WithError(err).Error("Database error while saving files fields")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		logrus.WithError(err).Error("Failed to commit transaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
 	// Get the created item
 	item, err := h.getItemByID(collectionName, newID)
 	if err != nil {
@@ -325,6 +513,12 @@ func (h *ItemsHandler) createItem(c *gin.Context) {
 		"collection": collectionName,
 		"item_id":    newID,
 	}).Info("Item created successfully")
+	emitActionHooks(c, h.events, scopeItems, collectionName, hooks.ActionCreate, eventItem(fields, item), newID)
+
+	item = outputItem(c, fields, item)
+	if !h.applyFileFields(c, collectionName, fields, []Item{item}) {
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{"data": item})
 }
@@ -332,15 +526,20 @@ func (h *ItemsHandler) createItem(c *gin.Context) {
 // GetItem retrieves a specific item from a collection
 //
 //	@Summary		Get item by ID
-//	@Description	Retrieve a specific item from a collection by its ID
+//	@Description	Retrieve a specific item from a collection by its ID. Requests without a token are served with the Public role's read permission
 //	@Tags			items
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			collection	path		string		true	"Collection name"
 //	@Param			id			path		string		true	"Item ID"
+//	@Param			show_hidden	query		bool		false	"Include hidden fields (admin only)"
+//	@Param			display		query		bool		false	"Add the rendered display template as $display"
+//	@Param			lang		query		string		false	"Merge in translations in this language, falling back to the default language"
+//	@Param			expand		query		string		false	"Comma-separated file fields to return with the files' metadata instead of IDs"
 //	@Success		200			{object}	ItemModel	"Item details"
 //	@Failure		401			{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403			{object}	ErrorResponse	"Public role has no read access"
 //	@Failure		404			{object}	ErrorResponse	"Item not found"
 //	@Failure		500			{object}	ErrorResponse	"Internal server error"
 //	@Router			/items/{collection}/{id} [get]
@@ -349,16 +548,18 @@ func (h *ItemsHandler) getItem(c *gin.Context) {
 	itemID := c.Param("id")
 
 	// Check if collection exists
-	if err := h.checkCollectionExists(collectionName); err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+	collection, ok := h.resolveCollection(c, collectionName)
+	if !ok {
 		return
-	} else if err != nil {
-		logrus.WithError(err).Error("Database error while checking collection")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	}
+
+	// Anonymous requests are limited to what the Public role may read
+	permission, ok := h.resolvePublicPermission(c, collectionName)
+	if !ok {
 		return
 	}
 
-	item, err := h.getItemByID(collectionName, itemID)
+	item, err := h.getItemByIDWithFilter(collectionName, itemID, permission.filter())
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
 		return
@@ -368,6 +569,25 @@ func (h *ItemsHandler) getItem(c *gin.Context) {
 		return
 	}
 
+	// Fields decide which values are hidden or never returned
+	fields, err := h.getFieldsByCollection(collectionName)
+	if err != nil {
+		logrus.WithError(err).Error("Error getting collection fields")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	item = outputItem(c, fields, item)
+	if !h.applyTranslations(c, collectionName, fields, []Item{item}) {
+		return
+	}
+	if !h.applyFileFields(c, collectionName, fields, []Item{item}) {
+		return
+	}
+	item = permission.applyFields(item)
+	if !h.applyDisplay(c, collection, []Item{item}) {
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"data": item})
 }
 
@@ -383,14 +603,14 @@ func (h *ItemsHandler) getItem(c *gin.Context) {
 //	@Param			id			path		string		true	"Item ID"
 //	@Param			item		body		ItemModel	true	"Updated item data"
 //	@Success		200			{object}	ItemModel	"Updated item"
-//	@Failure		400			{object}	ErrorResponse	"Invalid request payload"
+//	@Failure		400			{object}	ErrorResponse	"Invalid request payload or readonly field"
 //	@Failure		401			{object}	ErrorResponse	"Unauthorized"
 //	@Failure		404			{object}	ErrorResponse	"Item not found"
 //	@Failure		500			{object}	ErrorResponse	"Internal server error"
 //	@Router			/items/{collection}/{id} [patch]
 func (h *ItemsHandler) updateItem(c *gin.Context) {
 	// Only admins can update items
-	if !h.isAdmin(c) {
+	if !isAdmin(c) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
 		return
 	}
@@ -398,17 +618,13 @@ func (h *ItemsHandler) updateItem(c *gin.Context) {
 	itemID := c.Param("id")
 
 	// Check if collection exists
-	if err := h.checkCollectionExists(collectionName); err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
-		return
-	} else if err != nil {
-		logrus.WithError(err).Error("Database error while checking collection")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	collection, ok := h.resolveCollection(c, collectionName)
+	if !ok {
 		return
 	}
 
 	// Check if item exists
-	_, err := h.getItemByID(collectionName, itemID)
+	existing, err := h.getItemByID(collectionName, itemID)
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
 		return
@@ -418,6 +634,16 @@ func (h *ItemsHandler) updateItem(c *gin.Context) {
 		return
 	}
 
+	h.patchItem(c, collection, itemID, existing)
+}
+
+// patchItem applies the request body to an existing item and writes the response
+func (h *ItemsHandler) patchItem(c *gin.Context, collection *ItemCollection, itemID string, existing Item) {
+	collectionName := collection.Collection
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeItems, collectionName, hooks.ActionUpdate, nil, itemID)) {
+		return
+	}
+
 	var requestData Item
 	if err := c.ShouldBindJSON(&requestData); err != nil {
 		logrus.WithError(err).Error("Invalid update item request payload")
@@ -435,6 +661,33 @@ func (h *ItemsHandler) updateItem(c *gin.Context) {
 		return
 	}
 
+	fields, err := h.getFieldsByCollection(collectionName)
+	if err != nil {
+		logrus.WithError(err).Error("Error getting collection fields")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// Filter rules and conditions see the item as it will be after the update
+	fields, writeErr := checkItemWrite(fields, requestData, existing)
+	if writeErr != nil {
+		c.JSON(http.StatusBadRequest, writeErr.response())
+		return
+	}
+
+	// Files fields are written to their junction
+	filesValues, ok := h.takeFileValues(c, fields, requestData)
+	if !ok {
+		return
+	}
+
+	// Stamp updated values and hash secrets for fields with a special behavior
+	if err := applySpecials(fields, requestData, actionUpdate, c.GetString("user_id")); err != nil {
+		logrus.WithError(err).Error("Error applying special field behaviors")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process item"})
+		return
+	}
+
 	// Build update query
 	updateFields := make([]string, 0, len(requestData))
 	values := make([]interface{}, 0, len(requestData)+1)
@@ -444,35 +697,49 @@ func (h *ItemsHandler) updateItem(c *gin.Context) {
 		updateFields = append(updateFields, fmt.Sprintf(`"%s" = $%d`, col, argIndex))
 
 		// Convert complex types to JSON
-		if val != nil {
-			switch v := val.(type) {
-			case map[string]interface{}, []interface{}:
-				jsonBytes, _ := json.Marshal(v)
-				values = append(values, jsonBytes)
-			default:
-				values = append(values, v)
-			}
-		} else {
-			values = append(values, nil)
-		}
+		values = append(values, columnValue(val))
 
 		argIndex++
 	}
 
-	// Add updated_at
-	updateFields = append(updateFields, "updated_at = CURRENT_TIMESTAMP")
+	if collection.HasUpdatedAt {
+		updateFields = append(updateFields, "updated_at = CURRENT_TIMESTAMP")
+	}
 	values = append(values, itemID)
 
-	updateQuery := fmt.Sprintf(
-		`UPDATE "%s" SET %s WHERE id = $%d`,
-		collectionName,
-		strings.Join(updateFields, ", "),
-		argIndex,
-	)
-
-	_, err = h.db.Exec(updateQuery, values...)
+	tx, err := h.db.Begin()
 	if err != nil {
-		logrus.WithError(err).Error("Database error while updating item")
+		logrus.WithError(err).Error("Failed to start transaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	// Updates of only files fields leave the row of tables without updated_at as is
+	if len(updateFields) > 0 {
+		updateQuery := fmt.Sprintf(
+			`UPDATE "%s" SET %s WHERE id = $%d`,
+			collectionName,
+			strings.Join(updateFields, ", "),
+			argIndex,
+		)
+
+		_, err = tx.Exec(updateQuery, values...)
+		if err != nil {
+			logrus.WithError(err).Error("Database error while updating item")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+	}
+
+	if err := saveFilesValues(tx, collectionName, itemID, filesValues); err != nil {
+		logrus.WithError(err).Error("Database error while saving files fields")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		logrus.WithError(err).Error("Failed to commit transaction")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
@@ -489,6 +756,12 @@ func (h *ItemsHandler) updateItem(c *gin.Context) {
 		"collection": collectionName,
 		"item_id":    itemID,
 	}).Info("Item updated successfully")
+	emitActionHooks(c, h.events, scopeItems, collectionName, hooks.ActionUpdate, eventItem(fields, item), itemID)
+
+	item = outputItem(c, fields, item)
+	if !h.applyFileFields(c, collectionName, fields, []Item{item}) {
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"data": item})
 }
@@ -496,7 +769,7 @@ func (h *ItemsHandler) updateItem(c *gin.Context) {
 // deleteItem deletes an item from a collection
 //
 //	@Summary		Delete an item
-//	@Description	Delete a specific item from a collection by its ID
+//	@Description	Delete a specific item from a collection by its ID, or archive it when the collection has an archive field
 //	@Tags			items
 //	@Accept			json
 //	@Produce		json
@@ -510,7 +783,7 @@ func (h *ItemsHandler) updateItem(c *gin.Context) {
 //	@Router			/items/{collection}/{id} [delete]
 func (h *ItemsHandler) deleteItem(c *gin.Context) {
 	// Only admins can delete items
-	if !h.isAdmin(c) {
+	if !isAdmin(c) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
 		return
 	}
@@ -519,17 +792,13 @@ func (h *ItemsHandler) deleteItem(c *gin.Context) {
 	itemID := c.Param("id")
 
 	// Check if collection exists
-	if err := h.checkCollectionExists(collectionName); err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
-		return
-	} else if err != nil {
-		logrus.WithError(err).Error("Database error while checking collection")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	collection, ok := h.resolveCollection(c, collectionName)
+	if !ok {
 		return
 	}
 
 	// Check if item exists
-	_, err := h.getItemByID(collectionName, itemID)
+	existing, err := h.getItemByID(collectionName, itemID)
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
 		return
@@ -539,6 +808,44 @@ func (h *ItemsHandler) deleteItem(c *gin.Context) {
 		return
 	}
 
+	event := requestEvent(c, scopeItems, collectionName, hooks.ActionDelete, nil, itemID)
+	if !runFilterHooks(c, h.events, &event) {
+		return
+	}
+
+	// The fields tell what the delete or archive event may carry of the item
+	fields, err := h.getFieldsByCollection(collectionName)
+	if err != nil {
+		logrus.WithError(err).Error("Error getting collection fields")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// Collections with an archive field keep their items
+	if collection.archives() {
+		if err := h.setArchiveValue(collection, itemID, collection.ArchiveValue); err != nil {
+			logrus.WithError(err).Error("Database error while archiving item")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		item, err := h.getItemByID(collectionName, itemID)
+		if err != nil {
+			logrus.WithError(err).Error("Error fetching archived item")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"collection": collectionName,
+			"item_id":    itemID,
+		}).Info("Item archived successfully")
+		emitActionHooks(c, h.events, scopeItems, collectionName, hooks.ActionUpdate, eventItem(fields, item), itemID)
+
+		c.JSON(http.StatusOK, gin.H{"message": "Item archived successfully"})
+		return
+	}
+
 	// Delete item
 	deleteQuery := fmt.Sprintf(`DELETE FROM "%s" WHERE id = $1`, collectionName)
 	_, err = h.db.Exec(deleteQuery, itemID)
@@ -552,17 +859,22 @@ func (h *ItemsHandler) deleteItem(c *gin.Context) {
 		"collection": collectionName,
 		"item_id":    itemID,
 	}).Info("Item deleted successfully")
+	emitActionHooks(c, h.events, scopeItems, collectionName, hooks.ActionDelete, eventItem(fields, existing), itemID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Item deleted successfully"})
 }
 
-// Helper method to get fields by collection
+// Helper method to get fields by collection, with the data type of their column
 func (h *ItemsHandler) getFieldsByCollection(collectionName string) ([]FieldInfo, error) {
 	query := `
-		SELECT field, required
-		FROM fields 
-		WHERE collection = $1
-		ORDER BY sort ASC, field ASC
+		SELECT f.field, f.required, f.validation, f.validation_message,
+		       COALESCE(f.readonly, false), COALESCE(f.hidden, false), f.conditions, f.special,
+		       c.data_type, c.character_maximum_length, c.is_nullable
+		FROM fields f
+		LEFT JOIN information_schema.columns c
+		  ON c.table_schema = current_schema() AND c.table_name = f.collection AND c.column_name = f.field
+		WHERE f.collection = $1
+		ORDER BY f.sort ASC, f.field ASC
 	`
 
 	rows, err := h.db.Query(query, collectionName)
@@ -574,10 +886,37 @@ func (h *ItemsHandler) getFieldsByCollection(collectionName string) ([]FieldInfo
 	var fields []FieldInfo
 	for rows.Next() {
 		var field FieldInfo
-		err := rows.Scan(&field.Field, &field.Required)
+		var validationBytes, conditionsBytes []byte
+		var dataType, nullable sql.NullString
+		var maxLength sql.NullInt64
+		var special pq.StringArray
+
+		err := rows.Scan(&field.Field, &field.Required, &validationBytes, &field.ValidationMessage,
+			&field.Readonly, &field.Hidden, &conditionsBytes, &special, &dataType, &maxLength, &nullable)
 		if err != nil {
 			return nil, err
 		}
+
+		if validationBytes != nil {
+			json.Unmarshal(validationBytes, &field.Validation)
+		}
+		field.Special = []string(special)
+		if conditionsBytes != nil {
+			if err := json.Unmarshal(conditionsBytes, &field.Conditions); err != nil {
+				logrus.WithError(err).WithField("field", field.Field).Warn("Ignoring invalid field conditions")
+			}
+		}
+
+		if dataType.Valid {
+			field.Schema = &FieldSchema{DataType: schema.NormalizeDataType(dataType.String)}
+			if maxLength.Valid {
+				length := int(maxLength.Int64)
+				field.Schema.MaxLength = &length
+			}
+			isNullable := nullable.String == "YES"
+			field.Schema.IsNullable = &isNullable
+		}
+
 		fields = append(fields, field)
 	}
 
@@ -586,9 +925,22 @@ func (h *ItemsHandler) getFieldsByCollection(collectionName string) ([]FieldInfo
 
 // Helper method to get item by ID
 func (h *ItemsHandler) getItemByID(collectionName, itemID string) (Item, error) {
-	query := fmt.Sprintf(`SELECT * FROM "%s" WHERE id = $1`, collectionName)
+	return h.getItemByIDWithFilter(collectionName, itemID, nil)
+}
 
-	rows, err := h.db.Query(query, itemID)
+// getItemByIDWithFilter gets an item by ID, only matching rows that also satisfy the filter
+func (h *ItemsHandler) getItemByIDWithFilter(collectionName, itemID string, filter map[string]interface{}) (Item, error) {
+	whereClause, whereArgs, err := buildFilterSQL(filter, 2)
+	if err != nil {
+		return nil, err
+	}
+	if whereClause != "" {
+		whereClause = " AND " + whereClause
+	}
+
+	query := fmt.Sprintf(`SELECT * FROM "%s" WHERE id = $1%s`, collectionName, whereClause)
+
+	rows, err := h.db.Query(query, append([]interface{}{itemID}, whereArgs...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -604,6 +956,102 @@ func (h *ItemsHandler) getItemByID(collectionName, itemID string) (Item, error)
 		return nil, err
 	}
 
+	return scanItemRow(rows, columns)
+}
+
+// resolvePublicPermission loads the Public role's read permission for anonymous
+// requests. Authenticated requests get a nil permission, which applies no limits.
+func (h *ItemsHandler) resolvePublicPermission(c *gin.Context, collectionName string) (*ItemPermission, bool) {
+	if !c.GetBool("is_public") {
+		return nil, true
+	}
+
+	permission, err := h.getRolePermission(publicRoleID, collectionName, "read")
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while fetching permissions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+
+	return permission, true
+}
+
+// getRolePermission loads a role's permission rule for an action on a collection
+func (h *ItemsHandler) getRolePermission(roleID, collectionName, action string) (*ItemPermission, error) {
+	var permissionsBytes []byte
+	var fields pq.StringArray
+
+	err := h.db.QueryRow(`
+		SELECT permissions, fields
+		FROM permissions
+		WHERE role_id = $1 AND collection = $2 AND action = $3
+		LIMIT 1
+	`, roleID, collectionName, action).Scan(&permissionsBytes, &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	permission := &ItemPermission{Fields: []string(fields)}
+	if permissionsBytes != nil {
+		if err := json.Unmarshal(permissionsBytes, &permission.Filter); err != nil {
+			return nil, err
+		}
+	}
+
+	return permission, nil
+}
+
+// filter returns the row filter of the permission, if any
+func (p *ItemPermission) filter() map[string]interface{} {
+	if p == nil {
+		return nil
+	}
+	return p.Filter
+}
+
+// allowsField reports whether the permission allows a field. A nil
+// permission or a "*" entry allows every field.
+func (p *ItemPermission) allowsField(name string) bool {
+	if p == nil {
+		return true
+	}
+	for _, field := range p.Fields {
+		if field == "*" || field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// applyFields strips fields the permission doesn't allow. A nil permission
+// or a "*" entry allows every field.
+func (p *ItemPermission) applyFields(item Item) Item {
+	if p == nil {
+		return item
+	}
+
+	allowed := make(map[string]bool, len(p.Fields))
+	for _, field := range p.Fields {
+		if field == "*" {
+			return item
+		}
+		allowed[field] = true
+	}
+
+	for field := range item {
+		if !allowed[field] {
+			delete(item, field)
+		}
+	}
+
+	return item
+}
+
+// scanItemRow scans the current row into an Item keyed by column name
+func scanItemRow(rows *sql.Rows, columns []string) (Item, error) {
 	// Create a slice of interface{} to receive the row data
 	values := make([]interface{}, len(columns))
 	valuePointers := make([]interface{}, len(columns))
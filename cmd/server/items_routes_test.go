@@ -11,10 +11,13 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+
+	"gorectus/internal/hooks"
 )
 
 // Test suite for item handlers
@@ -60,6 +63,7 @@ func (suite *ItemHandlersTestSuite) TearDownTest() {
 type mockItemServerInterface struct {
 	db             *sql.DB
 	customAuthFunc gin.HandlerFunc
+	events         *hooks.Bus
 }
 
 func (m *mockItemServerInterface) GetDB() *sql.DB {
@@ -75,10 +79,22 @@ func (m *mockItemServerInterface) AuthMiddleware() gin.HandlerFunc {
 		c.Set("user_id", "550e8400-e29b-41d4-a716-446655440000")
 		c.Set("user_email", "admin@example.com")
 		c.Set("user_role", "Administrator")
+		c.Set("admin_access", true)
+		c.Set("app_access", true)
 		c.Next()
 	}
 }
 
+func (m *mockItemServerInterface) OptionalAuthMiddleware() gin.HandlerFunc {
+	return m.AuthMiddleware()
+}
+
+func (m *mockItemServerInterface) InvalidateRoleAccess() {}
+
+func (m *mockItemServerInterface) Webhooks() *webhookDispatcher { return nil }
+
+func (m *mockItemServerInterface) Events() *hooks.Bus { return m.events }
+
 func (m *mockItemServerInterface) OptionsHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
@@ -98,6 +114,8 @@ func (suite *ItemHandlersTestSuite) createAuthenticatedRequest(method, url strin
 		c.Set("user_id", userID)
 		c.Set("user_email", "test@example.com")
 		c.Set("user_role", role)
+		c.Set("admin_access", role == "Administrator")
+		c.Set("app_access", true)
 		c.Next()
 	}
 
@@ -128,8 +146,10 @@ func (suite *ItemHandlersTestSuite) createAuthenticatedRequest(method, url strin
 // Test GetItems endpoint
 func (suite *ItemHandlersTestSuite) TestGetItems_Success() {
 	// Mock collection exists check
-	existsRows := sqlmock.NewRows([]string{"exists"}).AddRow(true)
-	suite.mock.ExpectQuery("SELECT EXISTS").WillReturnRows(existsRows)
+	collectionRow := collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true)
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRow)
+
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
 
 	// Mock the items query
 	rows := sqlmock.NewRows([]string{"id", "title", "description", "created_at", "updated_at"}).
@@ -161,8 +181,8 @@ func (suite *ItemHandlersTestSuite) TestGetItems_Success() {
 
 func (suite *ItemHandlersTestSuite) TestGetItems_CollectionNotFound() {
 	// Mock collection doesn't exist
-	existsRows := sqlmock.NewRows([]string{"exists"}).AddRow(false)
-	suite.mock.ExpectQuery("SELECT EXISTS").WillReturnRows(existsRows)
+	collectionRow := collectionRows()
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRow)
 
 	req, router := suite.createAuthenticatedRequest("GET", "/api/v1/items/nonexistent", nil, "test-user", "Administrator")
 	w := httptest.NewRecorder()
@@ -177,6 +197,77 @@ func (suite *ItemHandlersTestSuite) TestGetItems_CollectionNotFound() {
 	assert.Equal(suite.T(), "Collection not found", response["error"])
 }
 
+func (suite *ItemHandlersTestSuite) TestGetItems_PublicAccess() {
+	// Mock collection exists check
+	collectionRow := collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true)
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRow)
+
+	// Public role may read published items, title only
+	permissionRows := sqlmock.NewRows([]string{"permissions", "fields"}).
+		AddRow([]byte(`{"status": {"_eq": "published"}}`), "{id,title}")
+	suite.mock.ExpectQuery("SELECT permissions, fields FROM permissions").
+		WithArgs(publicRoleID, "articles", "read").
+		WillReturnRows(permissionRows)
+
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
+
+	rows := sqlmock.NewRows([]string{"id", "title", "status", "created_at", "updated_at"}).
+		AddRow("test-id-1", "Published Item", "published", time.Now(), time.Now())
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles" WHERE "status" = \$1`).
+		WithArgs("published", 50, 0).
+		WillReturnRows(rows)
+
+	countRows := sqlmock.NewRows([]string{"count"}).AddRow(1)
+	suite.mock.ExpectQuery(`SELECT COUNT\(\*\) FROM "articles" WHERE "status" = \$1`).
+		WithArgs("published").
+		WillReturnRows(countRows)
+
+	router := gin.New()
+	mockServer := &mockItemServerInterface{
+		db: suite.db,
+		customAuthFunc: func(c *gin.Context) {
+			c.Set("user_role", "Public")
+			c.Set("is_public", true)
+			c.Next()
+		},
+	}
+	NewItemsHandler(mockServer).SetupRoutes(router.Group("/api/v1"))
+
+	req := httptest.NewRequest("GET", "/api/v1/items/articles", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+
+	data := response["data"].([]interface{})
+	require.Len(suite.T(), data, 1)
+	item := data[0].(map[string]interface{})
+	assert.Equal(suite.T(), "Published Item", item["title"])
+	assert.NotContains(suite.T(), item, "status")
+	assert.NotContains(suite.T(), item, "created_at")
+}
+
+// collectionRows returns the columns of the collection settings query
+func collectionRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"singleton", "archive_field", "archive_value", "unarchive_value", "archive_app_filter", "sort_field",
+		"item_duplication_fields", "display_template", "has_created_at", "has_updated_at",
+	})
+}
+
+// fieldInfoRows returns the columns of the fields query used for validation
+func fieldInfoRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"field", "required", "validation", "validation_message",
+		"readonly", "hidden", "conditions", "special", "data_type", "character_maximum_length", "is_nullable",
+	})
+}
+
 // Test CreateItem endpoint
 func (suite *ItemHandlersTestSuite) TestCreateItem_Success() {
 	itemData := Item{
@@ -186,21 +277,23 @@ func (suite *ItemHandlersTestSuite) TestCreateItem_Success() {
 	}
 
 	// Mock collection exists check
-	existsRows := sqlmock.NewRows([]string{"exists"}).AddRow(true)
-	suite.mock.ExpectQuery("SELECT EXISTS").WillReturnRows(existsRows)
+	collectionRow := collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true)
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRow)
 
 	// Mock fields query for validation
-	fieldRows := sqlmock.NewRows([]string{"field", "required"}).
-		AddRow("title", true).
-		AddRow("description", false).
-		AddRow("status", false)
-	suite.mock.ExpectQuery("SELECT field, required FROM fields").WillReturnRows(fieldRows)
+	fieldRows := fieldInfoRows().
+		AddRow("title", true, nil, nil, false, false, nil, nil, "character varying", 255, "NO").
+		AddRow("description", false, nil, nil, false, false, nil, nil, "text", nil, "YES").
+		AddRow("status", false, []byte(`{"enum": ["active", "draft"]}`), nil, false, false, nil, nil, "character varying", 20, "YES")
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldRows)
 
 	// Mock insert
+	suite.mock.ExpectBegin()
 	suite.mock.ExpectQuery("INSERT INTO").WillReturnRows(
-		sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
-			AddRow("new-item-id", time.Now(), time.Now()),
+		sqlmock.NewRows([]string{"id"}).
+			AddRow("new-item-id"),
 	)
+	suite.mock.ExpectCommit()
 
 	// Mock fetching created item
 	itemRows := sqlmock.NewRows([]string{"id", "title", "description", "status", "created_at", "updated_at"}).
@@ -224,20 +317,57 @@ func (suite *ItemHandlersTestSuite) TestCreateItem_Success() {
 	assert.Equal(suite.T(), "New Test Item", data["title"])
 }
 
+func (suite *ItemHandlersTestSuite) TestCreateItem_SpecialFields() {
+	collectionRow := collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true)
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRow)
+
+	// owner is required but filled in from the caller
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows().
+		AddRow("title", true, nil, nil, false, false, nil, nil, "character varying", 255, "NO").
+		AddRow("owner", true, nil, nil, false, false, nil, "{user-created}", "uuid", nil, "NO").
+		AddRow("secret", false, nil, nil, false, false, nil, "{hash}", "character varying", 255, "YES"))
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("INSERT INTO").WillReturnRows(
+		sqlmock.NewRows([]string{"id"}).
+			AddRow("new-item-id"),
+	)
+	suite.mock.ExpectCommit()
+
+	itemRows := sqlmock.NewRows([]string{"id", "title", "owner", "secret"}).
+		AddRow("new-item-id", "New Test Item", "test-user", "$2a$10$hashedvalue")
+	suite.mock.ExpectQuery("SELECT \\* FROM").WillReturnRows(itemRows)
+
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/test_collection",
+		Item{"title": "New Test Item", "secret": "hunter2"}, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "test-user", response.Data["owner"])
+	assert.NotContains(suite.T(), response.Data, "secret")
+}
+
 func (suite *ItemHandlersTestSuite) TestCreateItem_MissingRequiredField() {
 	itemData := Item{
 		"description": "Missing title field",
 	}
 
 	// Mock collection exists check
-	existsRows := sqlmock.NewRows([]string{"exists"}).AddRow(true)
-	suite.mock.ExpectQuery("SELECT EXISTS").WillReturnRows(existsRows)
+	collectionRow := collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true)
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRow)
 
 	// Mock fields query for validation - title is required
-	fieldRows := sqlmock.NewRows([]string{"field", "required"}).
-		AddRow("title", true).
-		AddRow("description", false)
-	suite.mock.ExpectQuery("SELECT field, required FROM fields").WillReturnRows(fieldRows)
+	fieldRows := fieldInfoRows().
+		AddRow("title", true, nil, nil, false, false, nil, nil, "character varying", 255, "NO").
+		AddRow("description", false, nil, nil, false, false, nil, nil, "text", nil, "YES")
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldRows)
 
 	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/test_collection", itemData, "test-user", "Administrator")
 	w := httptest.NewRecorder()
@@ -252,17 +382,722 @@ func (suite *ItemHandlersTestSuite) TestCreateItem_MissingRequiredField() {
 	assert.Contains(suite.T(), response["error"], "Required field 'title' is missing")
 }
 
+func (suite *ItemHandlersTestSuite) TestCreateItem_ValidationFailed() {
+	itemData := Item{
+		"title":  "ok",
+		"email":  "not-an-email",
+		"rating": 7.5,
+	}
+
+	collectionRow := collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true)
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRow)
+
+	fieldRows := fieldInfoRows().
+		AddRow("title", true, []byte(`{"min_length": 3}`), "Title is too short", false, false, nil, nil, "character varying", 255, "NO").
+		AddRow("email", false, []byte(`{"format": "email"}`), nil, false, false, nil, nil, "character varying", 255, "YES").
+		AddRow("rating", false, nil, nil, false, false, nil, nil, "integer", nil, "YES")
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WithArgs("test_collection").WillReturnRows(fieldRows)
+
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/test_collection", itemData, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	var response struct {
+		Error  string            `json:"error"`
+		Errors []ValidationError `json:"errors"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "Validation failed", response.Error)
+	assert.Equal(suite.T(), []ValidationError{
+		{Field: "title", Message: "Title is too short"},
+		{Field: "email", Message: "Field 'email' must be a valid email address"},
+		{Field: "rating", Message: "Field 'rating' must be an integer"},
+	}, response.Errors)
+}
+
+func (suite *ItemHandlersTestSuite) TestUpdateItem_FilterRuleUsesStoredValues() {
+	collectionRow := collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true)
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRow)
+
+	itemRows := sqlmock.NewRows([]string{"id", "status", "published_at"}).
+		AddRow("test-item-id", "draft", nil)
+	suite.mock.ExpectQuery("SELECT \\* FROM").WillReturnRows(itemRows)
+
+	// Published items need a publication date
+	fieldRows := fieldInfoRows().
+		AddRow("status", false, []byte(`{"_or": [{"status": {"_neq": "published"}}, {"published_at": {"_nnull": true}}]}`),
+			nil, false, false, nil, nil, "character varying", 20, "YES")
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldRows)
+
+	req, router := suite.createAuthenticatedRequest("PATCH", "/api/v1/items/test_collection/test-item-id",
+		Item{"status": "published"}, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Field 'status' does not satisfy the validation rule")
+}
+
+func (suite *ItemHandlersTestSuite) TestUpdateItem_ReadonlyField() {
+	collectionRow := collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true)
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRow)
+
+	itemRows := sqlmock.NewRows([]string{"id", "slug"}).AddRow("test-item-id", "first-post")
+	suite.mock.ExpectQuery("SELECT \\* FROM").WillReturnRows(itemRows)
+
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows().
+		AddRow("slug", false, nil, nil, true, false, nil, nil, "character varying", 255, "YES"))
+
+	req, router := suite.createAuthenticatedRequest("PATCH", "/api/v1/items/test_collection/test-item-id",
+		Item{"slug": "renamed"}, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Field 'slug' is read-only")
+}
+
+func (suite *ItemHandlersTestSuite) TestUpdateItem_ConditionRequiresField() {
+	collectionRow := collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true)
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRow)
+
+	itemRows := sqlmock.NewRows([]string{"id", "status", "published_at"}).
+		AddRow("test-item-id", "draft", nil)
+	suite.mock.ExpectQuery("SELECT \\* FROM").WillReturnRows(itemRows)
+
+	// Publishing requires a publication date
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows().
+		AddRow("status", false, nil, nil, false, false, nil, nil, "character varying", 20, "YES").
+		AddRow("published_at", false, nil, nil, false, false,
+			[]byte(`[{"name": "Published", "rule": {"status": {"_eq": "published"}}, "required": true}]`),
+			nil, "timestamp with time zone", nil, "YES"))
+
+	req, router := suite.createAuthenticatedRequest("PATCH", "/api/v1/items/test_collection/test-item-id",
+		Item{"status": "published"}, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Required field 'published_at' is missing")
+}
+
+func (suite *ItemHandlersTestSuite) TestGetItems_Singleton() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRows().AddRow(true, nil, nil, nil, true, nil, nil, nil, true, true))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
+
+	itemRows := sqlmock.NewRows([]string{"id", "headline"}).AddRow("home-id", "Welcome")
+	suite.mock.ExpectQuery(`SELECT \* FROM "homepage" ORDER BY created_at ASC LIMIT 1`).WillReturnRows(itemRows)
+
+	req, router := suite.createAuthenticatedRequest("GET", "/api/v1/items/homepage", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotContains(suite.T(), response, "meta")
+	data := response["data"].(map[string]interface{})
+	assert.Equal(suite.T(), "Welcome", data["headline"])
+}
+
+func (suite *ItemHandlersTestSuite) TestCreateItem_SingletonRejected() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRows().AddRow(true, nil, nil, nil, true, nil, nil, nil, true, true))
+
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/homepage",
+		Item{"headline": "Welcome"}, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "PATCH /items/homepage")
+}
+
+func (suite *ItemHandlersTestSuite) TestUpdateSingleton_CreatesItem() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRows().AddRow(true, nil, nil, nil, true, nil, nil, nil, true, true))
+	suite.mock.ExpectQuery(`SELECT \* FROM "homepage" ORDER BY created_at ASC LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "headline"}))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`LOCK TABLE "homepage"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM "homepage"\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	suite.mock.ExpectQuery(`INSERT INTO "homepage"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id"}).AddRow("home-id"))
+	suite.mock.ExpectCommit()
+
+	suite.mock.ExpectQuery("SELECT \\* FROM").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "headline"}).AddRow("home-id", "Welcome"))
+
+	req, router := suite.createAuthenticatedRequest("PATCH", "/api/v1/items/homepage",
+		Item{"headline": "Welcome"}, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "home-id")
+}
+
+func (suite *ItemHandlersTestSuite) TestUpdateSingleton_UpdatesItem() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRows().AddRow(true, nil, nil, nil, true, nil, nil, nil, true, true))
+	suite.mock.ExpectQuery(`SELECT \* FROM "homepage" ORDER BY created_at ASC LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "headline"}).AddRow("home-id", "Welcome"))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`UPDATE "homepage" SET "headline" = \$1, updated_at = CURRENT_TIMESTAMP WHERE id = \$2`).
+		WithArgs("Hello again", "home-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+	suite.mock.ExpectQuery("SELECT \\* FROM").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "headline"}).AddRow("home-id", "Hello again"))
+
+	req, router := suite.createAuthenticatedRequest("PATCH", "/api/v1/items/homepage",
+		Item{"headline": "Hello again"}, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Hello again")
+}
+
+func (suite *ItemHandlersTestSuite) TestUpdateSingleton_NotSingleton() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+
+	req, router := suite.createAuthenticatedRequest("PATCH", "/api/v1/items/test_collection",
+		Item{"title": "Test"}, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+// archiveCollectionRow configures status as the archive field
+func archiveCollectionRow() *sqlmock.Rows {
+	return collectionRows().AddRow(false, "status", "archived", "draft", true, nil, nil, nil, true, true)
+}
+
+func (suite *ItemHandlersTestSuite) TestGetItems_ExcludesArchived() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(archiveCollectionRow())
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
+
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles" WHERE \("status" IS NULL OR "status" <> \$1\)`).
+		WithArgs("archived", 50, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("test-id-1", "published"))
+	suite.mock.ExpectQuery(`SELECT COUNT\(\*\) FROM "articles" WHERE \("status" IS NULL OR "status" <> \$1\)`).
+		WithArgs("archived").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	req, router := suite.createAuthenticatedRequest("GET", "/api/v1/items/articles", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *ItemHandlersTestSuite) TestGetItems_ArchivedOnly() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(archiveCollectionRow())
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
+
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles" WHERE "status" = \$1`).
+		WithArgs("archived", 50, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("test-id-1", "archived"))
+	suite.mock.ExpectQuery(`SELECT COUNT\(\*\) FROM "articles" WHERE "status" = \$1`).
+		WithArgs("archived").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	req, router := suite.createAuthenticatedRequest("GET", "/api/v1/items/articles?archived=true", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *ItemHandlersTestSuite) TestGetItems_InvalidArchivedMode() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(archiveCollectionRow())
+
+	req, router := suite.createAuthenticatedRequest("GET", "/api/v1/items/articles?archived=maybe", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *ItemHandlersTestSuite) TestDeleteItem_Archives() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(archiveCollectionRow())
+	suite.mock.ExpectQuery("SELECT \\* FROM").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("test-item-id", "published"))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
+	suite.mock.ExpectExec(`UPDATE "articles" SET "status" = \$1, updated_at = CURRENT_TIMESTAMP WHERE id = \$2`).
+		WithArgs("archived", "test-item-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectQuery("SELECT \\* FROM").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("test-item-id", "archived"))
+
+	req, router := suite.createAuthenticatedRequest("DELETE", "/api/v1/items/articles/test-item-id", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Item archived successfully")
+}
+
+func (suite *ItemHandlersTestSuite) TestUnarchiveItem() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(archiveCollectionRow())
+	suite.mock.ExpectQuery("SELECT \\* FROM").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("test-item-id", "archived"))
+	suite.mock.ExpectExec(`UPDATE "articles" SET "status" = \$1`).
+		WithArgs("draft", "test-item-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectQuery("SELECT \\* FROM").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("test-item-id", "draft"))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
+
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/articles/test-item-id/unarchive", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"status":"draft"`)
+}
+
+func (suite *ItemHandlersTestSuite) TestUnarchiveItem_NoArchiveField() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/test_collection/test-item-id/unarchive", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+// sortedCollectionRow configures sort as the sort field
+func (suite *ItemHandlersTestSuite) TestGetItems_Display() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(
+		collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, "{{title}} by {{author.first_name}}", true, true))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "title", "author"}).AddRow("a1", "Hello", "u1").AddRow("a2", "Draft", nil))
+	suite.mock.ExpectQuery("FROM information_schema.table_constraints").WithArgs("articles").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name", "table_name", "column_name", "udt_name"}).AddRow("author", "users", "id", "uuid"))
+	suite.mock.ExpectQuery(`SELECT \* FROM "users" WHERE "id" = ANY\(\$1::"uuid"\[\]\)`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "first_name", "password"}).AddRow("u1", "Ada", "secret"))
+	suite.mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	req, router := suite.createAuthenticatedRequest("GET", "/api/v1/items/articles?display=true", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"$display":"Hello by Ada"`)
+	assert.Contains(suite.T(), w.Body.String(), `"$display":"Draft by "`)
+	// The related item is only used for rendering
+	assert.Contains(suite.T(), w.Body.String(), `"author":"u1"`)
+	assert.NotContains(suite.T(), w.Body.String(), "secret")
+}
+
+func (suite *ItemHandlersTestSuite) TestGetItem_DisplayWithoutTemplate() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(
+		collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles" WHERE id = \$1`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "title"}).AddRow("a1", "Hello"))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
+
+	req, router := suite.createAuthenticatedRequest("GET", "/api/v1/items/articles/a1?display=true", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"$display":"a1"`)
+}
+
+func (suite *ItemHandlersTestSuite) TestGetItems_Translations() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(
+		collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WithArgs("articles").WillReturnRows(
+		fieldInfoRows().AddRow("translations", false, nil, nil, false, false, nil, "{translations}", nil, nil, nil))
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "status"}).AddRow("a1", "published").AddRow("a2", "draft").AddRow("a3", "draft"))
+	suite.mock.ExpectQuery("SELECT default_language FROM settings").
+		WillReturnRows(sqlmock.NewRows([]string{"default_language"}).AddRow("en-US"))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WithArgs("articles_translations").WillReturnRows(
+		fieldInfoRows().AddRow("note", false, nil, nil, false, true, nil, nil, "text", nil, "YES"))
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles_translations" WHERE "articles_id"::text = ANY\(\$1\) AND "language" = ANY\(\$2\)`).
+		WithArgs(pq.Array([]string{"a1", "a2", "a3"}), pq.Array([]string{"de", "en-US"})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "articles_id", "language", "title", "note"}).
+			AddRow("t1", "a1", "de", "Hallo", "intern").
+			AddRow("t2", "a1", "en-US", "Hello", nil).
+			AddRow("t3", "a2", "en-US", "Draft", nil))
+	suite.mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	req, router := suite.createAuthenticatedRequest("GET", "/api/v1/items/articles?lang=de", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	data := response["data"].([]interface{})
+	assert.Equal(suite.T(), "Hallo", data[0].(map[string]interface{})["title"])
+	assert.Equal(suite.T(), "Draft", data[1].(map[string]interface{})["title"])
+	assert.NotContains(suite.T(), data[2], "title")
+	// Hidden fields of the translations collection stay hidden
+	assert.NotContains(suite.T(), w.Body.String(), "intern")
+	assert.NotContains(suite.T(), w.Body.String(), "articles_id")
+}
+
+// servePublic serves a request to the item routes as an anonymous user
+func (suite *ItemHandlersTestSuite) servePublic(url string) *httptest.ResponseRecorder {
+	router := gin.New()
+	mockServer := &mockItemServerInterface{
+		db: suite.db,
+		customAuthFunc: func(c *gin.Context) {
+			c.Set("user_role", "Public")
+			c.Set("is_public", true)
+			c.Next()
+		},
+	}
+	NewItemsHandler(mockServer).SetupRoutes(router.Group("/api/v1"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+	return w
+}
+
+// expectPublicTranslatedArticles mocks an anonymous read of articles with a
+// translations field, up to the translations' permission
+func (suite *ItemHandlersTestSuite) expectPublicTranslatedArticles() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(
+		collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	suite.mock.ExpectQuery("SELECT permissions, fields FROM permissions").WithArgs(publicRoleID, "articles", "read").
+		WillReturnRows(sqlmock.NewRows([]string{"permissions", "fields"}).AddRow(nil, "{*}"))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WithArgs("articles").WillReturnRows(
+		fieldInfoRows().AddRow("translations", false, nil, nil, false, false, nil, "{translations}", nil, nil, nil))
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "title"}).AddRow("a1", "Hello"))
+	suite.mock.ExpectQuery("SELECT default_language FROM settings").
+		WillReturnRows(sqlmock.NewRows([]string{"default_language"}).AddRow("en-US"))
+}
+
+func (suite *ItemHandlersTestSuite) TestGetItems_PublicTranslations() {
+	suite.expectPublicTranslatedArticles()
+	// Public may read the English translations' titles only
+	suite.mock.ExpectQuery("SELECT permissions, fields FROM permissions").WithArgs(publicRoleID, "articles_translations", "read").
+		WillReturnRows(sqlmock.NewRows([]string{"permissions", "fields"}).
+			AddRow([]byte(`{"language": {"_eq": "en-US"}}`), "{title}"))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WithArgs("articles_translations").WillReturnRows(fieldInfoRows())
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles_translations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "articles_id", "language", "title", "note"}).
+			AddRow("t1", "a1", "de", "Hallo", "Entwurf").
+			AddRow("t2", "a1", "en-US", "Hello there", "Draft"))
+	suite.mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	w := suite.servePublic("/api/v1/items/articles?lang=de")
+
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Body.String(), `"title":"Hello there"`)
+	assert.NotContains(suite.T(), w.Body.String(), "Hallo")
+	assert.NotContains(suite.T(), w.Body.String(), "Draft")
+}
+
+func (suite *ItemHandlersTestSuite) TestGetItems_PublicTranslationsNotReadable() {
+	suite.expectPublicTranslatedArticles()
+	suite.mock.ExpectQuery("SELECT permissions, fields FROM permissions").WithArgs(publicRoleID, "articles_translations", "read").
+		WillReturnError(sql.ErrNoRows)
+	suite.mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	w := suite.servePublic("/api/v1/items/articles?lang=de")
+
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Body.String(), `"title":"Hello"`)
+}
+
+func sortedCollectionRow() *sqlmock.Rows {
+	return collectionRows().AddRow(false, nil, nil, nil, true, "sort", nil, nil, true, true)
+}
+
+func (suite *ItemHandlersTestSuite) TestGetItems_OrderedBySortField() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(sortedCollectionRow())
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
+	suite.mock.ExpectQuery(`SELECT \* FROM "tasks" ORDER BY "sort" ASC NULLS LAST, created_at DESC LIMIT \$1 OFFSET \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "sort"}).AddRow("a", 1).AddRow("b", 2))
+	suite.mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	req, router := suite.createAuthenticatedRequest("GET", "/api/v1/items/tasks", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *ItemHandlersTestSuite) TestGetItems_FilterAndSort() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(sortedCollectionRow())
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows().
+		AddRow("title", false, nil, nil, false, false, nil, nil, "text", nil, "YES").
+		AddRow("secret", false, nil, nil, false, true, nil, nil, "text", nil, "YES"))
+	suite.mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE "title"::text LIKE \$1 ORDER BY "title" DESC, "created_at" ASC LIMIT \$2 OFFSET \$3`).
+		WithArgs("%report%", 50, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow("a", "Weekly report"))
+	suite.mock.ExpectQuery("SELECT COUNT").WithArgs("%report%").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	req, router := suite.createAuthenticatedRequest("GET",
+		`/api/v1/items/tasks?filter={"title":{"_contains":"report"}}&sort=-title,created_at`, nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+}
+
+func (suite *ItemHandlersTestSuite) TestGetItems_FilterOnHiddenField() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(sortedCollectionRow())
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows().
+		AddRow("secret", false, nil, nil, false, true, nil, nil, "text", nil, "YES"))
+
+	req, router := suite.createAuthenticatedRequest("GET",
+		`/api/v1/items/tasks?filter={"secret":{"_starts_with":"a"}}`, nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *ItemHandlersTestSuite) TestSortItems_MovesUp() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(sortedCollectionRow())
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`LOCK TABLE "tasks"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectQuery(`SELECT COUNT\(\*\) - COUNT\(DISTINCT "sort"\) FROM "tasks"`).
+		WillReturnRows(sqlmock.NewRows([]string{"conflicts"}).AddRow(0))
+	suite.mock.ExpectQuery(`SELECT "sort" FROM "tasks" WHERE id = \$1`).WithArgs("e").
+		WillReturnRows(sqlmock.NewRows([]string{"sort"}).AddRow(5))
+	suite.mock.ExpectQuery(`SELECT "sort" FROM "tasks" WHERE id = \$1`).WithArgs("b").
+		WillReturnRows(sqlmock.NewRows([]string{"sort"}).AddRow(2))
+	suite.mock.ExpectExec(`UPDATE "tasks" SET "sort" = "sort" \+ \$1 WHERE "sort" BETWEEN \$2 AND \$3`).
+		WithArgs(int64(1), int64(2), int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	suite.mock.ExpectExec(`UPDATE "tasks" SET "sort" = \$1 WHERE id = \$2`).
+		WithArgs(int64(2), "e").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/tasks/sort",
+		SortItemRequest{Item: "e", To: "b"}, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"sort":2`)
+}
+
+func (suite *ItemHandlersTestSuite) TestSortItems_RenumbersDuplicates() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(sortedCollectionRow())
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`LOCK TABLE "tasks"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectQuery(`SELECT COUNT\(\*\) - COUNT\(DISTINCT "sort"\) FROM "tasks"`).
+		WillReturnRows(sqlmock.NewRows([]string{"conflicts"}).AddRow(3))
+	suite.mock.ExpectExec(`ROW_NUMBER\(\) OVER \(ORDER BY "sort" ASC NULLS LAST, created_at DESC\)`).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	suite.mock.ExpectQuery(`SELECT "sort" FROM "tasks" WHERE id = \$1`).WithArgs("a").
+		WillReturnRows(sqlmock.NewRows([]string{"sort"}).AddRow(1))
+	suite.mock.ExpectQuery(`SELECT "sort" FROM "tasks" WHERE id = \$1`).WithArgs("d").
+		WillReturnRows(sqlmock.NewRows([]string{"sort"}).AddRow(4))
+	suite.mock.ExpectExec(`UPDATE "tasks" SET "sort" = "sort" \+ \$1`).
+		WithArgs(int64(-1), int64(2), int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	suite.mock.ExpectExec(`UPDATE "tasks" SET "sort" = \$1 WHERE id = \$2`).
+		WithArgs(int64(4), "a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/tasks/sort",
+		SortItemRequest{Item: "a", To: "d", Position: "after"}, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *ItemHandlersTestSuite) TestSortItems_ItemNotFound() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(sortedCollectionRow())
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`LOCK TABLE "tasks"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectQuery(`SELECT COUNT`).WillReturnRows(sqlmock.NewRows([]string{"conflicts"}).AddRow(0))
+	suite.mock.ExpectQuery(`SELECT "sort" FROM "tasks"`).WillReturnRows(sqlmock.NewRows([]string{"sort"}))
+	suite.mock.ExpectRollback()
+
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/tasks/sort",
+		SortItemRequest{Item: "missing", To: "b"}, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *ItemHandlersTestSuite) TestSortItems_NoSortField() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/test_collection/sort",
+		SortItemRequest{Item: "a", To: "b"}, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *ItemHandlersTestSuite) TestDuplicateItem() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WithArgs("articles").WillReturnRows(
+		collectionRows().AddRow(false, nil, nil, nil, true, nil, []byte(`["title", "slug", "tags", "key", "pin", "gallery", "comments.*"]`), nil, true, true))
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles" WHERE id = \$1`).WithArgs("source-id").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "title", "slug", "tags", "key", "pin", "status", "created_at"}).
+			AddRow("source-id", "Hello", "hello", []byte(`["a","b"]`), "0d6a0b0e-3f0a-4a59-9a7c-5a3c1a1e2b3c", "$2a$10$hashedvalue", "published", time.Now()))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows().
+		AddRow("key", false, nil, nil, false, false, nil, "{uuid}", "uuid", nil, "YES").
+		AddRow("pin", false, nil, nil, false, false, nil, "{hash}", "character varying", 255, "YES").
+		AddRow("gallery", false, nil, nil, false, false, nil, "{files}", nil, nil, nil))
+
+	// comments point to articles through article_id
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WithArgs("comments").WillReturnRows(
+		collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	suite.mock.ExpectQuery("FROM information_schema.table_constraints").WithArgs("comments", "articles").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("article_id"))
+	// The files field is listed, so its junction rows are copied too
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WithArgs("articles_gallery").WillReturnRows(
+		collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	suite.mock.ExpectQuery("FROM pg_index").WillReturnRows(sqlmock.NewRows([]string{"relname", "attname"}).
+		AddRow("articles", "slug").AddRow("comments", "ref"))
+
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WithArgs("comments").WillReturnRows(fieldInfoRows().
+		AddRow("body", true, nil, nil, false, false, nil, nil, "text", nil, "NO").
+		AddRow("posted_on", false, nil, nil, false, false, nil, "{date-created}", "timestamp", nil, "YES"))
+	suite.mock.ExpectQuery(`SELECT \* FROM "comments" WHERE "article_id" = \$1`).WithArgs("source-id").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "article_id", "body", "ref", "posted_on", "created_at"}).
+			AddRow("comment-id", "source-id", "Nice", "ref-1", time.Now(), time.Now()))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WithArgs("articles_gallery").WillReturnRows(fieldInfoRows().
+		AddRow("articles_id", true, nil, nil, false, true, nil, nil, "character varying", 255, "NO").
+		AddRow("files_id", true, nil, nil, false, false, nil, "{file}", "uuid", nil, "NO").
+		AddRow("sort", false, nil, nil, false, true, nil, nil, "integer", nil, "YES"))
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles_gallery" WHERE "articles_id" = \$1`).WithArgs("source-id").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "articles_id", "files_id", "sort"}).
+			AddRow(int64(7), "source-id", "8f14e45f-ceea-467a-9d3c-3c7e5e5b6a01", int64(1)))
+
+	suite.mock.ExpectBegin()
+	// The copied hash is kept rather than hashed again, and the unique slug
+	// isn't copied
+	suite.mock.ExpectQuery(`INSERT INTO "articles" \("key", "pin", "tags", "title"\) VALUES \(\$1, \$2, \$3, \$4\) RETURNING id`).
+		WithArgs(sqlmock.AnyArg(), "$2a$10$hashedvalue", []byte(`["a","b"]`), "Hello").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("copy-id"))
+	// The unique ref isn't copied and the creation date is set again
+	suite.mock.ExpectQuery(`INSERT INTO "comments" \("article_id", "body", "posted_on"\) VALUES \(\$1, \$2, \$3\) RETURNING id`).
+		WithArgs("copy-id", "Nice", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("comment-copy-id"))
+	suite.mock.ExpectQuery(`INSERT INTO "articles_gallery" \("articles_id", "files_id", "sort"\) VALUES \(\$1, \$2, \$3\) RETURNING id`).
+		WithArgs("copy-id", "8f14e45f-ceea-467a-9d3c-3c7e5e5b6a01", float64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("8"))
+	suite.mock.ExpectCommit()
+
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles" WHERE id = \$1`).WithArgs("copy-id").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "title", "status"}).AddRow("copy-id", "Hello", nil))
+
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/articles/source-id/duplicate", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Body.String(), "copy-id")
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+// Related copies are checked like created items before anything is written
+func (suite *ItemHandlersTestSuite) TestDuplicateItem_InvalidRelatedItem() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WithArgs("articles").WillReturnRows(
+		collectionRows().AddRow(false, nil, nil, nil, true, nil, []byte(`["title", "comments.author"]`), nil, true, true))
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles" WHERE id = \$1`).WithArgs("source-id").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "title"}).AddRow("source-id", "Hello"))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WithArgs("comments").WillReturnRows(
+		collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	suite.mock.ExpectQuery("FROM information_schema.table_constraints").WithArgs("comments", "articles").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("article_id"))
+	suite.mock.ExpectQuery("FROM pg_index").WillReturnRows(sqlmock.NewRows([]string{"relname", "attname"}))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WithArgs("comments").WillReturnRows(fieldInfoRows().
+		AddRow("body", true, nil, nil, false, false, nil, nil, "text", nil, "NO").
+		AddRow("author", false, nil, nil, false, false, nil, nil, "character varying", 255, "YES"))
+	suite.mock.ExpectQuery(`SELECT \* FROM "comments" WHERE "article_id" = \$1`).WithArgs("source-id").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "article_id", "body", "author"}).AddRow("comment-id", "source-id", "Nice", "Ann"))
+
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/articles/source-id/duplicate", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Required field 'body' is missing")
+	assert.Contains(suite.T(), w.Body.String(), `"collection":"comments"`)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *ItemHandlersTestSuite) TestDuplicateItem_AmbiguousRelation() {
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WithArgs("articles").WillReturnRows(
+		collectionRows().AddRow(false, nil, nil, nil, true, nil, []byte(`["title", "links.*"]`), nil, true, true))
+	suite.mock.ExpectQuery(`SELECT \* FROM "articles"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "title"}).AddRow("source-id", "Hello"))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WithArgs("links").WillReturnRows(
+		collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	suite.mock.ExpectQuery("FROM information_schema.table_constraints").WillReturnRows(
+		sqlmock.NewRows([]string{"column_name"}).AddRow("from_article").AddRow("to_article"))
+
+	req, router := suite.createAuthenticatedRequest("POST", "/api/v1/items/articles/source-id/duplicate", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "exactly one column")
+}
+
 // Test GetItem endpoint
 func (suite *ItemHandlersTestSuite) TestGetItem_Success() {
 	// Mock collection exists check
-	existsRows := sqlmock.NewRows([]string{"exists"}).AddRow(true)
-	suite.mock.ExpectQuery("SELECT EXISTS").WillReturnRows(existsRows)
+	collectionRow := collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true)
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRow)
 
 	// Mock fetching item
 	itemRows := sqlmock.NewRows([]string{"id", "title", "description", "created_at", "updated_at"}).
 		AddRow("test-item-id", "Test Item", "Test description", time.Now(), time.Now())
 	suite.mock.ExpectQuery("SELECT \\* FROM").WillReturnRows(itemRows)
 
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
+
 	req, router := suite.createAuthenticatedRequest("GET", "/api/v1/items/test_collection/test-item-id", nil, "test-user", "Administrator")
 	w := httptest.NewRecorder()
 
@@ -280,10 +1115,66 @@ func (suite *ItemHandlersTestSuite) TestGetItem_Success() {
 	assert.Equal(suite.T(), "Test Item", data["title"])
 }
 
+func (suite *ItemHandlersTestSuite) TestGetItem_OmitsHiddenFields() {
+	collectionRow := collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true)
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRow)
+
+	itemRows := sqlmock.NewRows([]string{"id", "title", "notes", "status"}).
+		AddRow("test-item-id", "Test Item", "Internal notes", "archived")
+	suite.mock.ExpectQuery("SELECT \\* FROM").WillReturnRows(itemRows)
+
+	// notes is always hidden, title only once the item is archived
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WithArgs("test_collection").WillReturnRows(fieldInfoRows().
+		AddRow("id", false, nil, nil, false, true, nil, nil, "uuid", nil, "NO").
+		AddRow("title", false, nil, nil, false, false,
+			[]byte(`[{"name": "Archived", "rule": {"status": {"_eq": "archived"}}, "hidden": true}]`), nil, "text", nil, "YES").
+		AddRow("notes", false, nil, nil, false, true, nil, nil, "text", nil, "YES"))
+
+	req, router := suite.createAuthenticatedRequest("GET", "/api/v1/items/test_collection/test-item-id", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "test-item-id", response.Data["id"])
+	assert.Equal(suite.T(), "archived", response.Data["status"])
+	assert.NotContains(suite.T(), response.Data, "title")
+	assert.NotContains(suite.T(), response.Data, "notes")
+}
+
+func (suite *ItemHandlersTestSuite) TestGetItem_ShowHidden() {
+	collectionRow := collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true)
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRow)
+
+	itemRows := sqlmock.NewRows([]string{"id", "notes", "password"}).
+		AddRow("test-item-id", "Internal notes", "$2a$10$hashedvalue")
+	suite.mock.ExpectQuery("SELECT \\* FROM").WillReturnRows(itemRows)
+
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows().
+		AddRow("notes", false, nil, nil, false, true, nil, nil, "text", nil, "YES").
+		AddRow("password", false, nil, nil, false, false, nil, "{hash}", "character varying", 255, "YES"))
+
+	req, router := suite.createAuthenticatedRequest("GET", "/api/v1/items/test_collection/test-item-id?show_hidden=true",
+		nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Internal notes")
+	// Hashed values are never returned
+	assert.NotContains(suite.T(), w.Body.String(), "password")
+}
+
 func (suite *ItemHandlersTestSuite) TestGetItem_NotFound() {
 	// Mock collection exists check
-	existsRows := sqlmock.NewRows([]string{"exists"}).AddRow(true)
-	suite.mock.ExpectQuery("SELECT EXISTS").WillReturnRows(existsRows)
+	collectionRow := collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true)
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRow)
 
 	// Mock item not found
 	suite.mock.ExpectQuery("SELECT \\* FROM").WillReturnRows(sqlmock.NewRows([]string{"id", "title"}))
@@ -309,16 +1200,22 @@ func (suite *ItemHandlersTestSuite) TestUpdateItem_Success() {
 	}
 
 	// Mock collection exists check
-	existsRows := sqlmock.NewRows([]string{"exists"}).AddRow(true)
-	suite.mock.ExpectQuery("SELECT EXISTS").WillReturnRows(existsRows)
+	collectionRow := collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true)
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRow)
 
 	// Mock item exists check
 	itemRows := sqlmock.NewRows([]string{"id", "title", "description", "created_at", "updated_at"}).
 		AddRow("test-item-id", "Old Title", "Old description", time.Now(), time.Now())
 	suite.mock.ExpectQuery("SELECT \\* FROM").WillReturnRows(itemRows)
 
+	// Mock fields query for validation
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows().
+		AddRow("title", true, nil, nil, false, false, nil, nil, "character varying", 255, "NO"))
+
 	// Mock update
+	suite.mock.ExpectBegin()
 	suite.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectCommit()
 
 	// Mock fetching updated item
 	updatedRows := sqlmock.NewRows([]string{"id", "title", "description", "created_at", "updated_at"}).
@@ -342,16 +1239,52 @@ func (suite *ItemHandlersTestSuite) TestUpdateItem_Success() {
 	assert.Equal(suite.T(), "Updated Title", data["title"])
 }
 
+func (suite *ItemHandlersTestSuite) TestItems_WithoutTimestampColumns() {
+	// Adopted tables may have no created_at and updated_at columns
+	noTimestamps := func() *sqlmock.Rows {
+		return collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, false, false)
+	}
+
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(noTimestamps())
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
+	suite.mock.ExpectQuery(`SELECT \* FROM "legacy_orders" ORDER BY id LIMIT`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "reference"}).AddRow("1", "A-1"))
+	suite.mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	req, router := suite.createAuthenticatedRequest("GET", "/api/v1/items/legacy_orders", nil, "test-user", "Administrator")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(noTimestamps())
+	suite.mock.ExpectQuery("SELECT \\* FROM").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "reference"}).AddRow("1", "A-1"))
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows().
+		AddRow("reference", false, nil, nil, false, false, nil, nil, "character varying", 40, "NO"))
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`UPDATE "legacy_orders" SET "reference" = \$1 WHERE id = \$2`).
+		WithArgs("A-2", "1").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+	suite.mock.ExpectQuery("SELECT \\* FROM").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "reference"}).AddRow("1", "A-2"))
+
+	req, router = suite.createAuthenticatedRequest("PATCH", "/api/v1/items/legacy_orders/1", Item{"reference": "A-2"}, "test-user", "Administrator")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+}
+
 // Test DeleteItem endpoint
 func (suite *ItemHandlersTestSuite) TestDeleteItem_Success() {
 	// Mock collection exists check
-	existsRows := sqlmock.NewRows([]string{"exists"}).AddRow(true)
-	suite.mock.ExpectQuery("SELECT EXISTS").WillReturnRows(existsRows)
+	collectionRow := collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true)
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRow)
 
 	// Mock item exists check
 	itemRows := sqlmock.NewRows([]string{"id", "title", "description", "created_at", "updated_at"}).
 		AddRow("test-item-id", "Test Item", "Test description", time.Now(), time.Now())
 	suite.mock.ExpectQuery("SELECT \\* FROM").WillReturnRows(itemRows)
+	suite.mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows())
 
 	// Mock delete
 	suite.mock.ExpectExec("DELETE FROM").WillReturnResult(sqlmock.NewResult(0, 1))
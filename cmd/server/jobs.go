@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/cron"
+)
+
+// Job statuses
+const (
+	jobStatusActive = "active"
+	jobStatusPaused = "paused"
+)
+
+// Triggers that start a job run
+const (
+	jobTriggerSchedule = "schedule"
+	jobTriggerManual   = "manual"
+)
+
+// Job run statuses
+const (
+	jobRunRunning = "running"
+	jobRunSuccess = "success"
+	jobRunFailed  = "failed"
+)
+
+const (
+	// jobPollInterval is how often due jobs are looked for
+	jobPollInterval = 15 * time.Second
+	// jobLockNamespace is the first key of the advisory locks held while a
+	// job runs; the second is the hash of the job's name
+	jobLockNamespace = 0x6a6f6273
+)
+
+// jobHandler runs a job with its options and returns a summary of what it
+// did, stored with the run
+type jobHandler func(ctx context.Context, options map[string]interface{}) (interface{}, error)
+
+// jobDefinition is a job the server can run. Schedule and Options are the
+// defaults of the job's row; admins may change them.
+type jobDefinition struct {
+	Name        string
+	Description string
+	Schedule    string
+	Options     map[string]interface{}
+	Run         jobHandler
+	// Validate checks options set by an admin, if the job takes any
+	Validate func(options map[string]interface{}) error
+}
+
+// dueJob is a job row the scheduler runs
+type dueJob struct {
+	ID       string
+	Name     string
+	Schedule string
+	Options  map[string]interface{}
+	// RunAt is the next_run_at a scheduled run claimed, zero for manual runs
+	RunAt time.Time
+}
+
+// jobRunAtKey is the context key of the time a run was scheduled for
+type jobRunAtKey struct{}
+
+// jobRunAt returns the time the running job was scheduled for: the slot a
+// scheduled run claimed, which may be a poll interval in the past, or now
+// for manual runs. It is in the server time zone, which cron schedules use.
+func jobRunAt(ctx context.Context) time.Time {
+	if runAt, ok := ctx.Value(jobRunAtKey{}).(time.Time); ok {
+		return runAt.Local()
+	}
+	return time.Now()
+}
+
+// jobScheduler runs the registered jobs on their cron schedule. Job rows
+// live in the database, so schedules, pauses and run history are shared by
+// all servers. A job only runs while its server holds the job's Postgres
+// advisory lock, so one replica runs it at a time.
+type jobScheduler struct {
+	db      *sql.DB
+	mu      sync.RWMutex
+	jobs    map[string]jobDefinition
+	running sync.WaitGroup
+}
+
+// newJobScheduler creates a scheduler without jobs
+func newJobScheduler(db *sql.DB) *jobScheduler {
+	return &jobScheduler{db: db, jobs: map[string]jobDefinition{}}
+}
+
+// Register adds a job. Its row is created by sync.
+func (s *jobScheduler) Register(job jobDefinition) error {
+	if _, err := cron.Parse(job.Schedule); err != nil {
+		return fmt.Errorf("job %s: %w", job.Name, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.jobs[job.Name]; exists {
+		return fmt.Errorf("job %s is already registered", job.Name)
+	}
+	s.jobs[job.Name] = job
+	return nil
+}
+
+// definition returns a registered job
+func (s *jobScheduler) definition(name string) (jobDefinition, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[name]
+	return job, ok
+}
+
+// sync creates the rows of registered jobs that don't have one yet. Existing
+// rows keep the schedule, status and options set by admins.
+func (s *jobScheduler) sync() error {
+	s.mu.RLock()
+	names := make([]string, 0, len(s.jobs))
+	for name := range s.jobs {
+		names = append(names, name)
+	}
+	s.mu.RUnlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		job, _ := s.definition(name)
+		schedule, _ := cron.Parse(job.Schedule)
+		options := job.Options
+		if options == nil {
+			options = map[string]interface{}{}
+		}
+		encoded, err := json.Marshal(options)
+		if err != nil {
+			return fmt.Errorf("job %s: %w", name, err)
+		}
+		if _, err := s.db.Exec(`
+			INSERT INTO jobs (name, description, schedule, options, next_run_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description`,
+			name, nullableString(job.Description), job.Schedule, string(encoded), schedule.Next(time.Now())); err != nil {
+			return fmt.Errorf("job %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// run starts the due jobs on every poll interval until ctx is done
+func (s *jobScheduler) run(ctx context.Context) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.runDue(ctx); err != nil {
+			logrus.WithError(err).Error("Failed to run due jobs")
+		}
+		select {
+		case <-ctx.Done():
+			s.running.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDue starts every active job whose next run is due in the background
+func (s *jobScheduler) runDue(ctx context.Context) error {
+	rows, err := s.db.Query(`
+		SELECT id, name, schedule, options, next_run_at FROM jobs
+		WHERE status = 'active' AND next_run_at <= NOW()
+		ORDER BY next_run_at`)
+	if err != nil {
+		return err
+	}
+	var due []dueJob
+	for rows.Next() {
+		var job dueJob
+		var options []byte
+		if err := rows.Scan(&job.ID, &job.Name, &job.Schedule, &options, &job.RunAt); err != nil {
+			rows.Close()
+			return err
+		}
+		if len(options) > 0 {
+			if err := json.Unmarshal(options, &job.Options); err != nil {
+				logrus.WithError(err).WithField("job", job.Name).Warn("Ignoring invalid job options")
+			}
+		}
+		due = append(due, job)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, job := range due {
+		if _, ok := s.definition(job.Name); !ok {
+			// Jobs of another server version stay for the servers that know them
+			continue
+		}
+		s.running.Add(1)
+		go func(job dueJob) {
+			defer s.running.Done()
+			if err := s.runScheduled(ctx, job); err != nil {
+				logrus.WithError(err).WithField("job", job.Name).Error("Failed to run job")
+			}
+		}(job)
+	}
+	return nil
+}
+
+// runScheduled runs a due job if this server wins its lock and the run
+// hasn't been made by another server meanwhile
+func (s *jobScheduler) runScheduled(ctx context.Context, job dueJob) error {
+	schedule, err := cron.Parse(job.Schedule)
+	if err != nil {
+		return err
+	}
+	conn, locked, err := s.lock(ctx, job.Name)
+	if err != nil || !locked {
+		return err
+	}
+	defer s.unlock(conn, job.Name)
+
+	// Moving the next run claims this one; it fails when another server
+	// ran the job while this one waited for the lock
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE jobs SET next_run_at = $2
+		WHERE id = $1 AND status = 'active' AND next_run_at <= NOW()`,
+		job.ID, schedule.Next(time.Now()))
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+
+	runID, err := s.startRun(job.ID, jobTriggerSchedule)
+	if err != nil {
+		return err
+	}
+	return s.finishRun(ctx, job, runID)
+}
+
+// trigger runs a job now, whatever its status and schedule. The run is
+// returned once started; locked is false when the job is already running.
+func (s *jobScheduler) trigger(job dueJob) (runID string, locked bool, err error) {
+	conn, locked, err := s.lock(context.Background(), job.Name)
+	if err != nil || !locked {
+		return "", locked, err
+	}
+	runID, err = s.startRun(job.ID, jobTriggerManual)
+	if err != nil {
+		s.unlock(conn, job.Name)
+		return "", true, err
+	}
+
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		defer s.unlock(conn, job.Name)
+		if err := s.finishRun(context.Background(), job, runID); err != nil {
+			logrus.WithError(err).WithField("job", job.Name).Error("Failed to run job")
+		}
+	}()
+	return runID, true, nil
+}
+
+// lock tries to take the advisory lock of a job. Advisory locks belong to a
+// database session, so the lock is held on a dedicated connection that
+// unlock releases.
+func (s *jobScheduler) lock(ctx context.Context, name string) (*sql.Conn, bool, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, hashtext($2))`,
+		jobLockNamespace, name).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+	return conn, true, nil
+}
+
+// unlock releases the advisory lock of a job and its connection
+func (s *jobScheduler) unlock(conn *sql.Conn, name string) {
+	if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1, hashtext($2))`,
+		jobLockNamespace, name); err != nil {
+		logrus.WithError(err).WithField("job", name).Error("Failed to release job lock")
+	}
+	conn.Close()
+}
+
+// startRun records a running run of a job
+func (s *jobScheduler) startRun(jobID, trigger string) (string, error) {
+	var runID string
+	err := s.db.QueryRow(`INSERT INTO job_runs (job, trigger) VALUES ($1, $2) RETURNING id`,
+		jobID, trigger).Scan(&runID)
+	return runID, err
+}
+
+// finishRun runs a job's handler and records the outcome of the run
+func (s *jobScheduler) finishRun(ctx context.Context, job dueJob, runID string) error {
+	definition, ok := s.definition(job.Name)
+	var result interface{}
+	var runErr error
+	if !ok {
+		runErr = fmt.Errorf("job %s is not registered", job.Name)
+	} else {
+		options := job.Options
+		if options == nil {
+			options = map[string]interface{}{}
+		}
+		if !job.RunAt.IsZero() {
+			ctx = context.WithValue(ctx, jobRunAtKey{}, job.RunAt)
+		}
+		result, runErr = runJobHandler(ctx, definition.Run, options)
+	}
+
+	status := jobRunSuccess
+	var errMessage interface{}
+	if runErr != nil {
+		status = jobRunFailed
+		errMessage = runErr.Error()
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`
+		UPDATE job_runs SET status = $2, result = $3, error = $4, finished_at = NOW()
+		WHERE id = $1`, runID, status, string(encoded), errMessage); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`UPDATE jobs SET last_run_at = NOW(), last_status = $2 WHERE id = $1`,
+		job.ID, status); err != nil {
+		return err
+	}
+
+	entry := logrus.WithFields(logrus.Fields{"job": job.Name, "run_id": runID})
+	if runErr != nil {
+		entry.WithError(runErr).Error("Job failed")
+	} else {
+		entry.Debug("Job finished")
+	}
+	return nil
+}
+
+// runJobHandler runs a handler, turning a panic into an error
+func runJobHandler(ctx context.Context, run jobHandler, options map[string]interface{}) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return run(ctx, options)
+}
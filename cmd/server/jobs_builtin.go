@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorectus/internal/hooks"
+)
+
+// cleanupSessionsJob deletes expired sessions
+func cleanupSessionsJob(db *sql.DB) jobDefinition {
+	return jobDefinition{
+		Name:        "cleanup-sessions",
+		Description: "Delete expired sessions",
+		Schedule:    "*/15 * * * *",
+		Run: func(ctx context.Context, options map[string]interface{}) (interface{}, error) {
+			result, err := db.ExecContext(ctx, `DELETE FROM sessions WHERE expires < NOW()`)
+			if err != nil {
+				return nil, err
+			}
+			deleted, err := result.RowsAffected()
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"deleted": deleted}, nil
+		},
+	}
+}
+
+// scheduledPublication configures the publish-scheduled job for a
+// collection: items whose Field is From are set to To once DateField has
+// passed
+type scheduledPublication struct {
+	Collection string `json:"collection"`
+	Field      string `json:"field"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	DateField  string `json:"date_field"`
+}
+
+// parseScheduledPublications reads the collections option of the
+// publish-scheduled job
+func parseScheduledPublications(options map[string]interface{}) ([]scheduledPublication, error) {
+	raw, ok := options["collections"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var publications []scheduledPublication
+	if err := json.Unmarshal(encoded, &publications); err != nil {
+		return nil, errors.New("collections must be a list of scheduled publications")
+	}
+	for _, publication := range publications {
+		if publication.Collection == "" || publication.Field == "" || publication.DateField == "" ||
+			publication.From == "" || publication.To == "" {
+			return nil, errors.New("scheduled publications require collection, field, from, to and date_field")
+		}
+		if publication.Field == publication.DateField {
+			return nil, errors.New("field and date_field must differ")
+		}
+		if isSystemCollection(publication.Collection) {
+			return nil, fmt.Errorf("collection %s can't be published", publication.Collection)
+		}
+	}
+	return publications, nil
+}
+
+// publishScheduledJob flips the status of items whose publication date has
+// passed, in the collections configured in its options. The updates emit
+// items.update events like changes made through the API.
+func publishScheduledJob(db *sql.DB, events *hooks.Bus) jobDefinition {
+	return jobDefinition{
+		Name:        "publish-scheduled",
+		Description: "Publish items whose scheduled publication date has passed",
+		Schedule:    "* * * * *",
+		Options:     map[string]interface{}{"collections": []interface{}{}},
+		Validate: func(options map[string]interface{}) error {
+			_, err := parseScheduledPublications(options)
+			return err
+		},
+		Run: func(ctx context.Context, options map[string]interface{}) (interface{}, error) {
+			publications, err := parseScheduledPublications(options)
+			if err != nil {
+				return nil, err
+			}
+			published := map[string]interface{}{}
+			for _, publication := range publications {
+				keys, err := publishScheduledItems(ctx, db, publication)
+				if err != nil {
+					return published, fmt.Errorf("%s: %w", publication.Collection, err)
+				}
+				published[publication.Collection] = len(keys)
+				for _, key := range keys {
+					events.Emit(hooks.NewEvent(scopeItems, publication.Collection, hooks.ActionUpdate,
+						map[string]interface{}{publication.Field: publication.To}, key))
+				}
+			}
+			return map[string]interface{}{"published": published}, nil
+		},
+	}
+}
+
+// publishScheduledItems publishes the due items of a collection, returning
+// their keys
+func publishScheduledItems(ctx context.Context, db *sql.DB, publication scheduledPublication) ([]string, error) {
+	collection, err := (&ItemsHandler{db: db}).getItemCollection(publication.Collection)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("collection not found")
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Column names come from options, so they must be fields of the collection
+	var known int
+	if err := db.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT field) FROM fields WHERE collection = $1 AND field IN ($2, $3)`,
+		publication.Collection, publication.Field, publication.DateField).Scan(&known); err != nil {
+		return nil, err
+	}
+	if known != 2 {
+		return nil, fmt.Errorf("unknown field %s or %s", publication.Field, publication.DateField)
+	}
+
+	touch := ""
+	if collection.HasUpdatedAt {
+		touch = ", updated_at = CURRENT_TIMESTAMP"
+	}
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+		UPDATE "%s" SET "%s" = $1%s
+		WHERE "%s" = $2 AND "%s" <= NOW()
+		RETURNING id`, publication.Collection, publication.Field, touch, publication.Field, publication.DateField),
+		publication.To, publication.From)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
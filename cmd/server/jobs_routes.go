@@ -0,0 +1,535 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/cron"
+)
+
+// JobsHandler handles scheduled job routes
+type JobsHandler struct {
+	db             *sql.DB
+	jobs           *jobScheduler
+	authMiddleware gin.HandlerFunc
+	optionsHandler gin.HandlerFunc
+}
+
+// NewJobsHandler creates a new jobs handler running jobs with scheduler
+func NewJobsHandler(server ServerInterface, scheduler *jobScheduler) *JobsHandler {
+	return &JobsHandler{
+		db:             server.GetDB(),
+		jobs:           scheduler,
+		authMiddleware: server.AuthMiddleware(),
+		optionsHandler: server.OptionsHandler(),
+	}
+}
+
+// SetupRoutes sets up job routes
+func (h *JobsHandler) SetupRoutes(v1 *gin.RouterGroup) {
+	// CORS preflight OPTIONS for jobs endpoints
+	v1.OPTIONS("/jobs", h.optionsHandler)
+	v1.OPTIONS("/jobs/:id", h.optionsHandler)
+	v1.OPTIONS("/jobs/:id/runs", h.optionsHandler)
+	v1.OPTIONS("/jobs/:id/trigger", h.optionsHandler)
+	v1.OPTIONS("/jobs/:id/pause", h.optionsHandler)
+	v1.OPTIONS("/jobs/:id/resume", h.optionsHandler)
+
+	// Jobs routes (admin only)
+	jobs := v1.Group("/jobs")
+	jobs.Use(h.authMiddleware, requireAdmin())
+	{
+		jobs.GET("", h.getJobs)
+		jobs.GET("/:id", h.getJob)
+		jobs.PATCH("/:id", h.updateJob)
+		jobs.GET("/:id/runs", h.getJobRuns)
+		jobs.POST("/:id/trigger", h.triggerJob)
+		jobs.POST("/:id/pause", h.pauseJob)
+		jobs.POST("/:id/resume", h.resumeJob)
+	}
+}
+
+// Job represents a background job run on a cron schedule
+type Job struct {
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Description *string                `json:"description"`
+	Schedule    string                 `json:"schedule"`
+	Status      string                 `json:"status"`
+	Options     map[string]interface{} `json:"options"`
+	NextRunAt   *time.Time             `json:"next_run_at"`
+	LastRunAt   *time.Time             `json:"last_run_at"`
+	LastStatus  *string                `json:"last_status"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+// JobRun represents one run of a job
+type JobRun struct {
+	ID         string          `json:"id"`
+	Job        string          `json:"job"`
+	Trigger    string          `json:"trigger"`
+	Status     string          `json:"status"`
+	Result     json.RawMessage `json:"result"`
+	Error      *string         `json:"error"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at"`
+}
+
+// UpdateJobRequest represents the request body for updating a job
+type UpdateJobRequest struct {
+	Schedule *string                 `json:"schedule"`
+	Options  *map[string]interface{} `json:"options"`
+}
+
+const jobColumns = `id, name, description, schedule, status, options, next_run_at, last_run_at, last_status, created_at, updated_at`
+
+const jobRunColumns = `id, job, trigger, status, result, error, started_at, finished_at`
+
+// scanJob scans a row of jobColumns
+func scanJob(scanner interface{ Scan(...interface{}) error }) (*Job, error) {
+	var job Job
+	var description, lastStatus sql.NullString
+	var nextRunAt, lastRunAt sql.NullTime
+	var options []byte
+	if err := scanner.Scan(&job.ID, &job.Name, &description, &job.Schedule, &job.Status, &options,
+		&nextRunAt, &lastRunAt, &lastStatus, &job.CreatedAt, &job.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if description.Valid {
+		job.Description = &description.String
+	}
+	// Paused jobs have no next run
+	if nextRunAt.Valid && job.Status == jobStatusActive {
+		job.NextRunAt = &nextRunAt.Time
+	}
+	if lastRunAt.Valid {
+		job.LastRunAt = &lastRunAt.Time
+	}
+	if lastStatus.Valid {
+		job.LastStatus = &lastStatus.String
+	}
+	job.Options = map[string]interface{}{}
+	if len(options) > 0 {
+		if err := json.Unmarshal(options, &job.Options); err != nil {
+			return nil, err
+		}
+	}
+	return &job, nil
+}
+
+// scanJobRun scans a row of jobRunColumns
+func scanJobRun(scanner interface{ Scan(...interface{}) error }) (*JobRun, error) {
+	var run JobRun
+	var result []byte
+	var errMessage sql.NullString
+	var finishedAt sql.NullTime
+	if err := scanner.Scan(&run.ID, &run.Job, &run.Trigger, &run.Status, &result, &errMessage,
+		&run.StartedAt, &finishedAt); err != nil {
+		return nil, err
+	}
+	run.Result = json.RawMessage("null")
+	if len(result) > 0 {
+		run.Result = json.RawMessage(result)
+	}
+	if errMessage.Valid {
+		run.Error = &errMessage.String
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	return &run, nil
+}
+
+// GetJobs lists jobs
+//
+//	@Summary		List jobs
+//	@Description	Get all background jobs ordered by name, with their schedule and last run
+//	@Tags			jobs
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{array}		JobModel		"Jobs"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Admin access required"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/jobs [get]
+func (h *JobsHandler) getJobs(c *gin.Context) {
+	rows, err := h.db.Query(`SELECT ` + jobColumns + ` FROM jobs ORDER BY name`)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while fetching jobs")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	jobs := []Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			logrus.WithError(err).Error("Error scanning job row")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		jobs = append(jobs, *job)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": jobs})
+}
+
+// GetJob returns a job
+//
+//	@Summary		Get a job
+//	@Description	Get a background job by ID
+//	@Tags			jobs
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Job ID"
+//	@Success		200	{object}	JobModel		"Job"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Admin access required"
+//	@Failure		404	{object}	ErrorResponse	"Job not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/jobs/{id} [get]
+func (h *JobsHandler) getJob(c *gin.Context) {
+	job, ok := h.resolveJob(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+// UpdateJob updates a job
+//
+//	@Summary		Update a job
+//	@Description	Change the cron schedule or the options of a background job. A new schedule takes effect from now
+//	@Tags			jobs
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string				true	"Job ID"
+//	@Param			job	body		UpdateJobRequest	true	"Job changes"
+//	@Success		200	{object}	JobModel		"Updated job"
+//	@Failure		400	{object}	ErrorResponse	"Invalid request payload"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Admin access required"
+//	@Failure		404	{object}	ErrorResponse	"Job not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/jobs/{id} [patch]
+func (h *JobsHandler) updateJob(c *gin.Context) {
+	job, ok := h.resolveJob(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid update job request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	var updates []string
+	var args []interface{}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		updates = append(updates, column+" = $"+strconv.Itoa(len(args)))
+	}
+	if req.Schedule != nil {
+		schedule, err := cron.Parse(*req.Schedule)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cron expression: " + *req.Schedule})
+			return
+		}
+		set("schedule", strings.TrimSpace(*req.Schedule))
+		set("next_run_at", schedule.Next(time.Now()))
+	}
+	if req.Options != nil {
+		options := *req.Options
+		if options == nil {
+			options = map[string]interface{}{}
+		}
+		if definition, ok := h.jobs.definition(job.Name); ok && definition.Validate != nil {
+			if err := definition.Validate(options); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid options: " + err.Error()})
+				return
+			}
+		}
+		encoded, err := json.Marshal(options)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+		set("options", string(encoded))
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	args = append(args, job.ID)
+	query := `UPDATE jobs SET ` + strings.Join(updates, ", ") + ` WHERE id = $` + strconv.Itoa(len(args))
+	if _, err := h.db.Exec(query, args...); err != nil {
+		logrus.WithError(err).Error("Database error while updating job")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	h.respondWithJob(c, job.ID, "Job updated successfully")
+}
+
+// TriggerJob runs a job now
+//
+//	@Summary		Trigger a job
+//	@Description	Run a background job now, even if it is paused. The run is started in the background and returned right away
+//	@Tags			jobs
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Job ID"
+//	@Success		202	{object}	JobRunModel		"Started run"
+//	@Failure		400	{object}	ErrorResponse	"Job is not registered on this server"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Admin access required"
+//	@Failure		404	{object}	ErrorResponse	"Job not found"
+//	@Failure		409	{object}	ErrorResponse	"Job is already running"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/jobs/{id}/trigger [post]
+func (h *JobsHandler) triggerJob(c *gin.Context) {
+	job, ok := h.resolveJob(c)
+	if !ok {
+		return
+	}
+	if _, ok := h.jobs.definition(job.Name); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Job is not registered on this server"})
+		return
+	}
+
+	runID, locked, err := h.jobs.trigger(dueJob{ID: job.ID, Name: job.Name, Schedule: job.Schedule, Options: job.Options})
+	if err != nil {
+		logrus.WithError(err).Error("Database error while starting job")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !locked {
+		c.JSON(http.StatusConflict, gin.H{"error": "Job is already running"})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"job":          job.Name,
+		"run_id":       runID,
+		"triggered_by": c.GetString("user_id"),
+	}).Info("Job triggered manually")
+	c.JSON(http.StatusAccepted, gin.H{"data": JobRun{
+		ID:        runID,
+		Job:       job.ID,
+		Trigger:   jobTriggerManual,
+		Status:    jobRunRunning,
+		Result:    json.RawMessage("null"),
+		StartedAt: time.Now().UTC(),
+	}})
+}
+
+// PauseJob pauses a job
+//
+//	@Summary		Pause a job
+//	@Description	Stop running a background job on its schedule. A run in progress finishes; paused jobs can still be triggered
+//	@Tags			jobs
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Job ID"
+//	@Success		200	{object}	JobModel		"Paused job"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Admin access required"
+//	@Failure		404	{object}	ErrorResponse	"Job not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/jobs/{id}/pause [post]
+func (h *JobsHandler) pauseJob(c *gin.Context) {
+	job, ok := h.resolveJob(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.db.Exec(`UPDATE jobs SET status = $2 WHERE id = $1`, job.ID, jobStatusPaused); err != nil {
+		logrus.WithError(err).Error("Database error while pausing job")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	h.respondWithJob(c, job.ID, "Job paused")
+}
+
+// ResumeJob resumes a paused job
+//
+//	@Summary		Resume a job
+//	@Description	Run a paused background job on its schedule again. Runs missed while it was paused are skipped
+//	@Tags			jobs
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Job ID"
+//	@Success		200	{object}	JobModel		"Resumed job"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Admin access required"
+//	@Failure		404	{object}	ErrorResponse	"Job not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/jobs/{id}/resume [post]
+func (h *JobsHandler) resumeJob(c *gin.Context) {
+	job, ok := h.resolveJob(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := cron.Parse(job.Schedule)
+	if err != nil {
+		logrus.WithError(err).WithField("job", job.Name).Error("Job has an invalid schedule")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid job schedule"})
+		return
+	}
+	if _, err := h.db.Exec(`UPDATE jobs SET status = $2, next_run_at = $3 WHERE id = $1`,
+		job.ID, jobStatusActive, schedule.Next(time.Now())); err != nil {
+		logrus.WithError(err).Error("Database error while resuming job")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	h.respondWithJob(c, job.ID, "Job resumed")
+}
+
+// GetJobRuns lists the runs of a job
+//
+//	@Summary		List job runs
+//	@Description	Get a paginated run history of a background job, newest first
+//	@Tags			jobs
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"Job ID"
+//	@Param			status	query		string	false	"Only runs with this status (running, success or failed)"
+//	@Param			page	query		int		false	"Page number"
+//	@Param			limit	query		int		false	"Limit the number of results"
+//	@Success		200		{object}	map[string]interface{}	"Runs with pagination metadata"
+//	@Failure		400		{object}	ErrorResponse	"Invalid status"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	ErrorResponse	"Admin access required"
+//	@Failure		404		{object}	ErrorResponse	"Job not found"
+//	@Failure		500		{object}	ErrorResponse	"Internal server error"
+//	@Router			/jobs/{id}/runs [get]
+func (h *JobsHandler) getJobRuns(c *gin.Context) {
+	job, ok := h.resolveJob(c)
+	if !ok {
+		return
+	}
+
+	page := 1
+	limit := 50
+	if pageStr := c.Query("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+	offset := (page - 1) * limit
+
+	where := `job = $1`
+	args := []interface{}{job.ID}
+	if status := c.Query("status"); status != "" {
+		if status != jobRunRunning && status != jobRunSuccess && status != jobRunFailed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status: " + status})
+			return
+		}
+		where += ` AND status = $2`
+		args = append(args, status)
+	}
+
+	var total int
+	if err := h.db.QueryRow(`SELECT COUNT(*) FROM job_runs WHERE `+where, args...).Scan(&total); err != nil {
+		logrus.WithError(err).Error("Database error while counting job runs")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	query := `SELECT ` + jobRunColumns + ` FROM job_runs WHERE ` + where +
+		` ORDER BY started_at DESC, id LIMIT $` + strconv.Itoa(len(args)+1) + ` OFFSET $` + strconv.Itoa(len(args)+2)
+	rows, err := h.db.Query(query, append(args, limit, offset)...)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while fetching job runs")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	runs := []JobRun{}
+	for rows.Next() {
+		run, err := scanJobRun(rows)
+		if err != nil {
+			logrus.WithError(err).Error("Error scanning job run row")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		runs = append(runs, *run)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": runs,
+		"meta": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// respondWithJob writes a job after a change to it
+func (h *JobsHandler) respondWithJob(c *gin.Context, jobID, message string) {
+	job, err := h.getJobByID(jobID)
+	if err != nil {
+		logrus.WithError(err).Error("Error fetching updated job")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithField("job", job.Name).Info(message)
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+// resolveJob loads the job of the :id parameter, writing a 404 when it
+// doesn't exist
+func (h *JobsHandler) resolveJob(c *gin.Context) (*Job, bool) {
+	jobID := c.Param("id")
+	if !uuidRegexp.MatchString(jobID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return nil, false
+	}
+
+	job, err := h.getJobByID(jobID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return nil, false
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while fetching job")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	return job, true
+}
+
+// getJobByID loads a job, or sql.ErrNoRows if it doesn't exist
+func (h *JobsHandler) getJobByID(jobID string) (*Job, error) {
+	return scanJob(h.db.QueryRow(`SELECT `+jobColumns+` FROM jobs WHERE id = $1`, jobID))
+}
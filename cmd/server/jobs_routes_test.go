@@ -0,0 +1,254 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// Test suite for job handlers
+type JobHandlersTestSuite struct {
+	suite.Suite
+	db        *sql.DB
+	mock      sqlmock.Sqlmock
+	scheduler *jobScheduler
+}
+
+func (suite *JobHandlersTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+	logrus.SetLevel(logrus.PanicLevel)
+}
+
+func (suite *JobHandlersTestSuite) SetupTest() {
+	db, mock, err := sqlmock.New()
+	require.NoError(suite.T(), err)
+	suite.db = db
+	suite.mock = mock
+	suite.scheduler = newJobScheduler(db)
+	require.NoError(suite.T(), suite.scheduler.Register(publishScheduledJob(db, nil)))
+}
+
+func (suite *JobHandlersTestSuite) TearDownTest() {
+	suite.scheduler.running.Wait()
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+	suite.db.Close()
+}
+
+// request serves a request to the job routes as the given user
+func (suite *JobHandlersTestSuite) request(method, url, body string, admin bool) *httptest.ResponseRecorder {
+	router := gin.New()
+	mockServer := &mockItemServerInterface{
+		db: suite.db,
+		customAuthFunc: func(c *gin.Context) {
+			c.Set("user_id", "user-1")
+			c.Set("admin_access", admin)
+			c.Set("app_access", true)
+			c.Next()
+		},
+	}
+	NewJobsHandler(mockServer, suite.scheduler).SetupRoutes(router.Group("/api/v1"))
+
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func jobRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "name", "description", "schedule", "status", "options", "next_run_at",
+		"last_run_at", "last_status", "created_at", "updated_at"})
+}
+
+// expectJob expects the test job to be loaded with a name and status
+func (suite *JobHandlersTestSuite) expectJob(name, status string) {
+	now := time.Now()
+	suite.mock.ExpectQuery(`FROM jobs WHERE id = \$1`).WithArgs(testJobID).
+		WillReturnRows(jobRows().AddRow(testJobID, name, "Publish items", "* * * * *", status,
+			[]byte(`{"collections":[]}`), now.Add(time.Minute), now, "success", now, now))
+}
+
+func (suite *JobHandlersTestSuite) TestGetJobs() {
+	now := time.Now()
+	suite.mock.ExpectQuery(`FROM jobs ORDER BY name`).
+		WillReturnRows(jobRows().
+			AddRow(testJobID, "cleanup-sessions", "Delete expired sessions", "*/15 * * * *", "active", []byte(`{}`),
+				now.Add(time.Minute), nil, nil, now, now).
+			AddRow("1a7e9f3d-2b4c-4d6e-f5a0-1b2c3d4e5f60", "publish-scheduled", nil, "* * * * *", "paused",
+				[]byte(`{"collections":[]}`), now, now, "failed", now, now))
+
+	w := suite.request("GET", "/api/v1/jobs", "", true)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Data []Job `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(suite.T(), response.Data, 2)
+	assert.Equal(suite.T(), "*/15 * * * *", response.Data[0].Schedule)
+	assert.NotNil(suite.T(), response.Data[0].NextRunAt)
+	assert.Nil(suite.T(), response.Data[0].LastRunAt)
+	// Paused jobs have no next run
+	assert.Nil(suite.T(), response.Data[1].NextRunAt)
+	assert.Equal(suite.T(), "failed", *response.Data[1].LastStatus)
+}
+
+func (suite *JobHandlersTestSuite) TestGetJobs_NonAdmin() {
+	w := suite.request("GET", "/api/v1/jobs", "", false)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *JobHandlersTestSuite) TestGetJob_NotFound() {
+	suite.mock.ExpectQuery(`FROM jobs WHERE id = \$1`).WithArgs(testJobID).WillReturnError(sql.ErrNoRows)
+
+	w := suite.request("GET", "/api/v1/jobs/"+testJobID, "", true)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *JobHandlersTestSuite) TestUpdateJob() {
+	suite.expectJob("publish-scheduled", "active")
+	suite.mock.ExpectExec(`UPDATE jobs SET schedule = \$1, next_run_at = \$2, options = \$3 WHERE id = \$4`).
+		WithArgs("*/5 * * * *", sqlmock.AnyArg(),
+			`{"collections":[{"collection":"articles","date_field":"publish_on","field":"status","from":"scheduled","to":"published"}]}`,
+			testJobID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.expectJob("publish-scheduled", "active")
+
+	w := suite.request("PATCH", "/api/v1/jobs/"+testJobID, `{"schedule":" */5 * * * * ","options":{"collections":[
+		{"collection":"articles","field":"status","from":"scheduled","to":"published","date_field":"publish_on"}]}}`, true)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+}
+
+func (suite *JobHandlersTestSuite) TestUpdateJob_Invalid() {
+	cases := map[string]string{
+		"schedule": `{"schedule":"61 * * * *"}`,
+		"options":  `{"options":{"collections":[{"collection":"articles"}]}}`,
+		"empty":    `{}`,
+	}
+	for name, body := range cases {
+		suite.Run(name, func() {
+			suite.expectJob("publish-scheduled", "active")
+
+			w := suite.request("PATCH", "/api/v1/jobs/"+testJobID, body, true)
+
+			assert.Equal(suite.T(), http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+}
+
+func (suite *JobHandlersTestSuite) TestTriggerJob() {
+	suite.expectJob("publish-scheduled", "paused")
+	// Paused jobs can still be triggered
+	expectJobLock(suite.mock, "publish-scheduled", true)
+	expectJobRun(suite.mock, jobTriggerManual, jobRunSuccess, `{"published":{}}`)
+	expectJobUnlock(suite.mock, "publish-scheduled")
+
+	w := suite.request("POST", "/api/v1/jobs/"+testJobID+"/trigger", "", true)
+	suite.scheduler.running.Wait()
+
+	require.Equal(suite.T(), http.StatusAccepted, w.Code, w.Body.String())
+	var response struct {
+		Data JobRun `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), testJobRunID, response.Data.ID)
+	assert.Equal(suite.T(), jobRunRunning, response.Data.Status)
+}
+
+func (suite *JobHandlersTestSuite) TestTriggerJob_AlreadyRunning() {
+	suite.expectJob("publish-scheduled", "active")
+	expectJobLock(suite.mock, "publish-scheduled", false)
+
+	w := suite.request("POST", "/api/v1/jobs/"+testJobID+"/trigger", "", true)
+
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *JobHandlersTestSuite) TestTriggerJob_NotRegistered() {
+	suite.expectJob("removed-job", "active")
+
+	w := suite.request("POST", "/api/v1/jobs/"+testJobID+"/trigger", "", true)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *JobHandlersTestSuite) TestPauseJob() {
+	suite.expectJob("publish-scheduled", "active")
+	suite.mock.ExpectExec(`UPDATE jobs SET status = \$2 WHERE id = \$1`).WithArgs(testJobID, jobStatusPaused).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.expectJob("publish-scheduled", "paused")
+
+	w := suite.request("POST", "/api/v1/jobs/"+testJobID+"/pause", "", true)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Data Job `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), jobStatusPaused, response.Data.Status)
+}
+
+func (suite *JobHandlersTestSuite) TestResumeJob() {
+	suite.expectJob("publish-scheduled", "paused")
+	// The next run is computed from now, skipping runs missed while paused
+	suite.mock.ExpectExec(`UPDATE jobs SET status = \$2, next_run_at = \$3 WHERE id = \$1`).
+		WithArgs(testJobID, jobStatusActive, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.expectJob("publish-scheduled", "active")
+
+	w := suite.request("POST", "/api/v1/jobs/"+testJobID+"/resume", "", true)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+}
+
+func (suite *JobHandlersTestSuite) TestGetJobRuns() {
+	now := time.Now()
+	suite.expectJob("publish-scheduled", "active")
+	suite.mock.ExpectQuery(`SELECT COUNT\(\*\) FROM job_runs WHERE job = \$1 AND status = \$2`).
+		WithArgs(testJobID, jobRunFailed).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	suite.mock.ExpectQuery(`FROM job_runs WHERE job = \$1 AND status = \$2 ORDER BY started_at DESC, id LIMIT \$3 OFFSET \$4`).
+		WithArgs(testJobID, jobRunFailed, 10, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "job", "trigger", "status", "result", "error", "started_at", "finished_at"}).
+			AddRow(testJobRunID, testJobID, jobTriggerSchedule, jobRunFailed, []byte(`null`), "articles: unknown field", now, now))
+
+	w := suite.request("GET", "/api/v1/jobs/"+testJobID+"/runs?status=failed&page=2&limit=10", "", true)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Data []JobRun               `json:"data"`
+		Meta map[string]interface{} `json:"meta"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(suite.T(), response.Data, 1)
+	assert.Equal(suite.T(), "articles: unknown field", *response.Data[0].Error)
+	assert.Equal(suite.T(), float64(1), response.Meta["total"])
+}
+
+func (suite *JobHandlersTestSuite) TestGetJobRuns_InvalidStatus() {
+	suite.expectJob("publish-scheduled", "active")
+
+	w := suite.request("GET", "/api/v1/jobs/"+testJobID+"/runs?status=done", "", true)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func TestJobHandlersTestSuite(t *testing.T) {
+	suite.Run(t, new(JobHandlersTestSuite))
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gorectus/internal/hooks"
+)
+
+const (
+	testJobID    = "9e5c7d1b-0f2a-4b4c-d38e-9f0a1b2c3d4e"
+	testJobRunID = "0f6d8e2c-1a3b-4c5d-e49f-0a1b2c3d4e5f"
+)
+
+func newTestJobScheduler(t *testing.T) (*jobScheduler, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, mock.ExpectationsWereMet())
+		db.Close()
+	})
+	return newJobScheduler(db), mock
+}
+
+// expectJobLock expects the advisory lock of a job to be tried
+func expectJobLock(mock sqlmock.Sqlmock, name string, locked bool) {
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1, hashtext\(\$2\)\)`).WithArgs(jobLockNamespace, name).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(locked))
+}
+
+// expectJobUnlock expects the advisory lock of a job to be released
+func expectJobUnlock(mock sqlmock.Sqlmock, name string) {
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1, hashtext\(\$2\)\)`).WithArgs(jobLockNamespace, name).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+// expectJobRun expects a run to be started and finished with a status
+func expectJobRun(mock sqlmock.Sqlmock, trigger, status string, result interface{}) {
+	mock.ExpectQuery(`INSERT INTO job_runs \(job, trigger\)`).WithArgs(testJobID, trigger).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testJobRunID))
+	mock.ExpectExec(`UPDATE job_runs SET status = \$2`).
+		WithArgs(testJobRunID, status, result, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE jobs SET last_run_at = NOW\(\), last_status = \$2`).WithArgs(testJobID, status).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func testJob(run jobHandler) jobDefinition {
+	return jobDefinition{Name: "test-job", Description: "Test", Schedule: "*/5 * * * *", Run: run}
+}
+
+func TestJobScheduler_Register(t *testing.T) {
+	scheduler, _ := newTestJobScheduler(t)
+	noop := func(ctx context.Context, options map[string]interface{}) (interface{}, error) { return nil, nil }
+
+	require.NoError(t, scheduler.Register(testJob(noop)))
+	assert.Error(t, scheduler.Register(testJob(noop)), "duplicate names are rejected")
+
+	invalid := testJob(noop)
+	invalid.Name = "invalid"
+	invalid.Schedule = "every minute"
+	assert.Error(t, scheduler.Register(invalid))
+}
+
+func TestJobScheduler_Sync(t *testing.T) {
+	scheduler, mock := newTestJobScheduler(t)
+	require.NoError(t, scheduler.Register(cleanupSessionsJob(scheduler.db)))
+	require.NoError(t, scheduler.Register(publishScheduledJob(scheduler.db, nil)))
+
+	// Rows are created in name order, keeping existing ones
+	mock.ExpectExec(`INSERT INTO jobs .* ON CONFLICT \(name\) DO UPDATE SET description`).
+		WithArgs("cleanup-sessions", "Delete expired sessions", "*/15 * * * *", "{}", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO jobs`).
+		WithArgs("publish-scheduled", sqlmock.AnyArg(), "* * * * *", `{"collections":[]}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, scheduler.sync())
+}
+
+func TestJobScheduler_RunDue(t *testing.T) {
+	scheduler, mock := newTestJobScheduler(t)
+	var got map[string]interface{}
+	var runAt time.Time
+	require.NoError(t, scheduler.Register(testJob(func(ctx context.Context, options map[string]interface{}) (interface{}, error) {
+		got = options
+		runAt = jobRunAt(ctx)
+		return map[string]interface{}{"done": 1}, nil
+	})))
+	slot := time.Date(2024, 3, 11, 9, 5, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT id, name, schedule, options, next_run_at FROM jobs\s+WHERE status = 'active' AND next_run_at <= NOW\(\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "schedule", "options", "next_run_at"}).
+			AddRow(testJobID, "test-job", "*/5 * * * *", []byte(`{"limit":10}`), slot).
+			AddRow("1a7e9f3d-2b4c-4d6e-f5a0-1b2c3d4e5f60", "removed-job", "* * * * *", []byte(`{}`), slot))
+	expectJobLock(mock, "test-job", true)
+	mock.ExpectExec(`UPDATE jobs SET next_run_at = \$2\s+WHERE id = \$1 AND status = 'active' AND next_run_at <= NOW\(\)`).
+		WithArgs(testJobID, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectJobRun(mock, jobTriggerSchedule, jobRunSuccess, `{"done":1}`)
+	expectJobUnlock(mock, "test-job")
+
+	require.NoError(t, scheduler.runDue(context.Background()))
+	scheduler.running.Wait()
+
+	assert.Equal(t, float64(10), got["limit"])
+	// The handler gets the slot it claimed, not the time it started
+	assert.True(t, slot.Equal(runAt), runAt)
+}
+
+func TestJobScheduler_RunScheduled_Locked(t *testing.T) {
+	scheduler, mock := newTestJobScheduler(t)
+	require.NoError(t, scheduler.Register(testJob(func(ctx context.Context, options map[string]interface{}) (interface{}, error) {
+		t.Error("job ran without its lock")
+		return nil, nil
+	})))
+
+	// Another server holds the lock
+	expectJobLock(mock, "test-job", false)
+
+	require.NoError(t, scheduler.runScheduled(context.Background(), dueJob{ID: testJobID, Name: "test-job", Schedule: "*/5 * * * *"}))
+}
+
+func TestJobScheduler_RunScheduled_AlreadyRun(t *testing.T) {
+	scheduler, mock := newTestJobScheduler(t)
+	require.NoError(t, scheduler.Register(testJob(func(ctx context.Context, options map[string]interface{}) (interface{}, error) {
+		t.Error("job ran twice")
+		return nil, nil
+	})))
+
+	// Another server ran the job while this one waited for the lock
+	expectJobLock(mock, "test-job", true)
+	mock.ExpectExec(`UPDATE jobs SET next_run_at = \$2`).WithArgs(testJobID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectJobUnlock(mock, "test-job")
+
+	require.NoError(t, scheduler.runScheduled(context.Background(), dueJob{ID: testJobID, Name: "test-job", Schedule: "*/5 * * * *"}))
+}
+
+func TestJobScheduler_Trigger(t *testing.T) {
+	scheduler, mock := newTestJobScheduler(t)
+	require.NoError(t, scheduler.Register(testJob(func(ctx context.Context, options map[string]interface{}) (interface{}, error) {
+		panic("boom")
+	})))
+
+	// A panicking job fails its run
+	expectJobLock(mock, "test-job", true)
+	expectJobRun(mock, jobTriggerManual, jobRunFailed, "null")
+	expectJobUnlock(mock, "test-job")
+
+	runID, locked, err := scheduler.trigger(dueJob{ID: testJobID, Name: "test-job", Schedule: "*/5 * * * *"})
+	scheduler.running.Wait()
+
+	require.NoError(t, err)
+	assert.True(t, locked)
+	assert.Equal(t, testJobRunID, runID)
+}
+
+func TestCleanupSessionsJob(t *testing.T) {
+	scheduler, mock := newTestJobScheduler(t)
+	job := cleanupSessionsJob(scheduler.db)
+
+	mock.ExpectExec(`DELETE FROM sessions WHERE expires < NOW\(\)`).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	result, err := job.Run(context.Background(), map[string]interface{}{})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"deleted": int64(3)}, result)
+}
+
+func TestParseScheduledPublications(t *testing.T) {
+	valid := map[string]interface{}{"collection": "articles", "field": "status", "from": "scheduled",
+		"to": "published", "date_field": "publish_on"}
+
+	publications, err := parseScheduledPublications(map[string]interface{}{"collections": []interface{}{valid}})
+	require.NoError(t, err)
+	require.Len(t, publications, 1)
+	assert.Equal(t, "publish_on", publications[0].DateField)
+
+	invalid := []map[string]interface{}{
+		{"collection": "articles", "field": "status", "from": "scheduled", "to": "published"},
+		{"collection": "articles", "field": "status", "from": "scheduled", "to": "published", "date_field": "status"},
+		{"collection": "users", "field": "status", "from": "draft", "to": "active", "date_field": "publish_on"},
+	}
+	for _, publication := range invalid {
+		_, err := parseScheduledPublications(map[string]interface{}{"collections": []interface{}{publication}})
+		assert.Error(t, err, publication)
+	}
+	_, err = parseScheduledPublications(map[string]interface{}{"collections": "articles"})
+	assert.Error(t, err)
+}
+
+func TestPublishScheduledJob(t *testing.T) {
+	scheduler, mock := newTestJobScheduler(t)
+	events := hooks.New()
+	var mu sync.Mutex
+	var emitted []hooks.Event
+	events.Action("items.update", func(ctx context.Context, event hooks.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		emitted = append(emitted, event)
+	})
+	job := publishScheduledJob(scheduler.db, events)
+	options := map[string]interface{}{"collections": []interface{}{map[string]interface{}{
+		"collection": "articles", "field": "status", "from": "scheduled", "to": "published", "date_field": "publish_on",
+	}}}
+
+	mock.ExpectQuery("FROM collections WHERE collection").WithArgs("articles").
+		WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	mock.ExpectQuery(`SELECT COUNT\(DISTINCT field\) FROM fields`).WithArgs("articles", "status", "publish_on").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(`UPDATE "articles" SET "status" = \$1, updated_at = CURRENT_TIMESTAMP\s+WHERE "status" = \$2 AND "publish_on" <= NOW\(\)`).
+		WithArgs("published", "scheduled").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("item-1").AddRow("item-2"))
+
+	result, err := job.Run(context.Background(), options)
+	events.Wait()
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"published": map[string]interface{}{"articles": 2}}, result)
+	require.Len(t, emitted, 2)
+	assert.Equal(t, "articles", emitted[0].Collection)
+	assert.Equal(t, "published", emitted[0].Payload["status"])
+}
+
+func TestPublishScheduledJob_UnknownField(t *testing.T) {
+	scheduler, mock := newTestJobScheduler(t)
+	job := publishScheduledJob(scheduler.db, nil)
+	options := map[string]interface{}{"collections": []interface{}{map[string]interface{}{
+		"collection": "articles", "field": "status", "from": "scheduled", "to": "published", "date_field": "publish_on",
+	}}}
+
+	mock.ExpectQuery("FROM collections WHERE collection").
+		WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, false, false))
+	mock.ExpectQuery(`SELECT COUNT\(DISTINCT field\) FROM fields`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	_, err := job.Run(context.Background(), options)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "articles")
+}
@@ -17,11 +17,16 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	_ "gorectus/docs" // This will be generated by swag
@@ -33,13 +38,31 @@ import (
 	"github.com/sirupsen/logrus"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+
+	"gorectus/internal/hooks"
+	"gorectus/internal/storage"
 )
 
 type Server struct {
-	db     *sql.DB
-	router *gin.Engine
+	db        *sql.DB
+	router    *gin.Engine
+	roleCache *roleAccessCache
+	storage   *storage.Locations
+	assets    *assetCache
+	webhooks  *webhookDispatcher
+	events    *hooks.Bus
+	flows     *flowRunner
+	jobs      *jobScheduler
+	// stop cancels the context the webhook dispatcher and the job scheduler
+	// run with; background tracks them until they have returned
+	stop       context.CancelFunc
+	background *sync.WaitGroup
 }
 
+// shutdownTimeout bounds how long in-flight requests and background work
+// may take to finish once the server is asked to stop
+const shutdownTimeout = 30 * time.Second
+
 // JWT Claims structure
 type JWTClaims struct {
 	UserID string `json:"user_id"`
@@ -120,6 +143,17 @@ func (s *Server) authMiddleware() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+
+		// Load the user's role and enforce its IP allow-list
+		role, err := s.loadUserRoleAccess(claims.UserID)
+		if err != nil {
+			respondRoleLookupError(c, err)
+			return
+		}
+		if !s.enforceIPAccess(c, claims.UserID, role) {
+			return
+		}
+
 		// Set CORS headers
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
@@ -128,7 +162,47 @@ func (s *Server) authMiddleware() gin.HandlerFunc {
 		// Add user info to context
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
-		c.Set("user_role", claims.Role)
+		setRoleContext(c, role)
+		c.Next()
+	}
+}
+
+// publicRoleID is the seeded role whose permissions apply to unauthenticated requests
+const publicRoleID = "550e8400-e29b-41d4-a716-446655440001"
+
+// Optional JWT middleware for routes that also serve unauthenticated requests.
+// Requests without an Authorization header are treated as the Public role;
+// requests with a header must still carry a valid token.
+func (s *Server) optionalAuthMiddleware() gin.HandlerFunc {
+	authenticate := s.authMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			authenticate(c)
+			return
+		}
+
+		// Set CORS headers
+		c.Header("Access-Control-Allow-Origin", "*")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Authorization, Content-Type")
+
+		// Enforce the Public role's IP allow-list
+		role, err := s.loadRoleAccess(publicRoleID)
+		if err != nil && err != sql.ErrNoRows {
+			respondRoleLookupError(c, err)
+			return
+		}
+		if role != nil && !s.enforceIPAccess(c, "", role) {
+			return
+		}
+
+		// Anonymous requests get the Public role
+		if role == nil {
+			role = &RoleAccess{ID: publicRoleID, Name: "Public"}
+		}
+		c.Set("user_id", "")
+		setRoleContext(c, role)
+		c.Set("is_public", true)
 		c.Next()
 	}
 }
@@ -179,8 +253,58 @@ func main() {
 
 	// Start server
 	logrus.WithField("port", port).Info("Starting gorectus server")
-	if err := server.router.Run(":" + port); err != nil {
-		logrus.WithError(err).Fatal("Failed to start server")
+	httpServer := &http.Server{Addr: ":" + port, Handler: server.router}
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logrus.WithError(err).Fatal("Failed to start server")
+		}
+	}()
+
+	// Stop on SIGINT or SIGTERM, letting requests and the work they started finish
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	logrus.Info("Shutting down server")
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		logrus.WithError(err).Error("Failed to finish in-flight requests")
+	}
+	if err := server.Shutdown(ctx); err != nil {
+		logrus.WithError(err).Error("Failed to finish background work")
+	}
+}
+
+// Shutdown waits for the action hooks emitted by requests, which enqueue
+// webhook deliveries and run event flows, then stops the webhook dispatcher
+// and the job scheduler and waits for them and for running flows. Pending
+// deliveries and jobs are picked up again on the next start. It gives up
+// when ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if err := waitContext(ctx, s.events.Wait); err != nil {
+		return fmt.Errorf("waiting for action hooks: %w", err)
+	}
+	s.stop()
+	return waitContext(ctx, func() {
+		s.background.Wait()
+		s.flows.running.Wait()
+	})
+}
+
+// waitContext runs wait and returns once it has, or with ctx's error once
+// ctx is done
+func waitContext(ctx context.Context, wait func()) error {
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
@@ -191,9 +315,83 @@ func NewServer() (*Server, error) {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
+	// Initialize file storage
+	fileStorage, err := storage.LocationsFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize file storage: %w", err)
+	}
+
+	// Transformed images are cached on local disk, whatever the storage
+	assetsRoot := os.Getenv("ASSETS_CACHE_ROOT")
+	if assetsRoot == "" {
+		assetsRoot = "./cache/assets"
+	}
+	assetsMaxSize := int64(defaultAssetCacheMaxSize)
+	if value := os.Getenv("ASSETS_CACHE_MAX_SIZE"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid ASSETS_CACHE_MAX_SIZE %q", value)
+		}
+		assetsMaxSize = n
+	}
+	assets, err := newAssetCache(assetsRoot, assetsMaxSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize asset cache: %w", err)
+	}
+
+	// Handlers run filter and action hooks on this bus; extensions register
+	// theirs on server.Events() before the server starts
+	events := hooks.New()
+	events.OnActionError = func(event hooks.Event, err error) {
+		logrus.WithError(err).WithField("event", event.Name).Error("Action hook failed")
+	}
+
+	// Webhook deliveries are sent in the background until the server shuts down
+	webhooks, err := webhookDispatcherFromEnv(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize webhooks: %w", err)
+	}
+	webhooks.register(events)
+	ctx, stop := context.WithCancel(context.Background())
+	background := &sync.WaitGroup{}
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		webhooks.run(ctx)
+	}()
+
+	// Event flows run as action hooks; scheduled flows are started by a job
+	flows := newFlowRunner(db)
+	flows.register(events)
+
+	// Background jobs run on their cron schedule under an advisory lock, so
+	// one replica runs each job at a time
+	jobs := newJobScheduler(db)
+	for _, job := range []jobDefinition{cleanupSessionsJob(db), publishScheduledJob(db, events), flows.scheduledFlowsJob()} {
+		if err := jobs.Register(job); err != nil {
+			stop()
+			return nil, fmt.Errorf("failed to register jobs: %w", err)
+		}
+	}
+	if err := jobs.sync(); err != nil {
+		stop()
+		return nil, fmt.Errorf("failed to initialize jobs: %w", err)
+	}
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		jobs.run(ctx)
+	}()
+
 	// Initialize Gin router
 	router := gin.Default()
 
+	// Only honor X-Forwarded-For when it comes from a configured proxy
+	if err := router.SetTrustedProxies(parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))); err != nil {
+		stop()
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES setting: %w", err)
+	}
+
 	// Add logrus middleware for HTTP request logging
 	router.Use(func(c *gin.Context) {
 		start := time.Now()
@@ -234,8 +432,17 @@ func NewServer() (*Server, error) {
 
 	// Create server instance
 	server := &Server{
-		db:     db,
-		router: router,
+		db:         db,
+		router:     router,
+		roleCache:  newRoleAccessCache(roleAccessCacheTTL),
+		storage:    fileStorage,
+		assets:     assets,
+		webhooks:   webhooks,
+		events:     events,
+		flows:      flows,
+		jobs:       jobs,
+		stop:       stop,
+		background: background,
 	}
 
 	// Setup routes
@@ -310,6 +517,13 @@ func (s *Server) setupRoutes() {
 		rolesHandler := NewRolesHandler(s)
 		dashboardHandler := NewDashboardHandler(s)
 		settingsHandler := NewSettingsHandler(s)
+		schemaHandler := NewSchemaHandler(s)
+		filesHandler := NewFilesHandler(s, s.storage, s.assets)
+		foldersHandler := NewFoldersHandler(s, filesHandler)
+		webhooksHandler := NewWebhooksHandler(s)
+		flowsHandler := NewFlowsHandler(s, s.flows)
+		operationsHandler := NewOperationsHandler(s)
+		jobsHandler := NewJobsHandler(s, s.jobs)
 
 		// Setup routes for each handler
 		authHandler.SetupRoutes(v1)
@@ -320,6 +534,13 @@ func (s *Server) setupRoutes() {
 		rolesHandler.SetupRoutes(v1)
 		dashboardHandler.SetupRoutes(v1)
 		settingsHandler.SetupRoutes(v1)
+		schemaHandler.SetupRoutes(v1)
+		filesHandler.SetupRoutes(v1)
+		foldersHandler.SetupRoutes(v1)
+		webhooksHandler.SetupRoutes(v1)
+		flowsHandler.SetupRoutes(v1)
+		operationsHandler.SetupRoutes(v1)
+		jobsHandler.SetupRoutes(v1)
 	}
 
 	// Swagger documentation endpoint
@@ -2,12 +2,15 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
@@ -15,6 +18,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+
+	"gorectus/internal/hooks"
 )
 
 // Test suite for server functionality
@@ -191,9 +196,7 @@ func (suite *ServerTestSuite) TestItemsEndpoints() {
 		path     string
 		expected int
 	}{
-		{"Get items", "GET", "/api/v1/items/test", http.StatusUnauthorized},
 		{"Create item", "POST", "/api/v1/items/test", http.StatusUnauthorized},
-		{"Get item", "GET", "/api/v1/items/test/1", http.StatusUnauthorized},
 		{"Update item", "PATCH", "/api/v1/items/test/1", http.StatusUnauthorized},
 		{"Delete item", "DELETE", "/api/v1/items/test/1", http.StatusUnauthorized},
 	}
@@ -215,6 +218,30 @@ func (suite *ServerTestSuite) TestItemsEndpoints() {
 	}
 }
 
+// Test that anonymous item reads are checked against the Public role
+func (suite *ServerTestSuite) TestItemsPublicReadWithoutPermission() {
+	suite.mock.ExpectQuery("SELECT id, name, ip_access, admin_access, app_access FROM roles").
+		WithArgs(publicRoleID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "ip_access", "admin_access", "app_access"}).AddRow(publicRoleID, "Public", nil, false, false))
+	suite.mock.ExpectQuery("FROM collections WHERE collection").WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	suite.mock.ExpectQuery("SELECT permissions, fields FROM permissions").
+		WithArgs(publicRoleID, "test", "read").
+		WillReturnError(sql.ErrNoRows)
+
+	req, _ := http.NewRequest("GET", "/api/v1/items/test", nil)
+	w := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Access denied", response["error"])
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
 // Test users endpoints (protected, require auth)
 func (suite *ServerTestSuite) TestUsersEndpoints() {
 	tests := []struct {
@@ -401,6 +428,52 @@ func TestNewServerWithMockDB(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+// testShutdownServer returns a server whose background worker runs until it
+// is stopped, and the bus its actions run on
+func testShutdownServer() (*Server, *hooks.Bus, *bool) {
+	events := hooks.New()
+	ctx, stop := context.WithCancel(context.Background())
+	background := &sync.WaitGroup{}
+	stopped := new(bool)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		<-ctx.Done()
+		*stopped = true
+	}()
+	return &Server{events: events, flows: &flowRunner{}, stop: stop, background: background}, events, stopped
+}
+
+func TestServerShutdown(t *testing.T) {
+	server, events, stopped := testShutdownServer()
+	var handled bool
+	events.Action("items.create", func(ctx context.Context, event hooks.Event) {
+		time.Sleep(10 * time.Millisecond)
+		// Workers are still running while actions finish
+		assert.False(t, *stopped)
+		handled = true
+	})
+	events.Emit(hooks.NewEvent("items", "articles", hooks.ActionCreate, nil, "1"))
+
+	require.NoError(t, server.Shutdown(context.Background()))
+	assert.True(t, handled)
+	assert.True(t, *stopped)
+}
+
+func TestServerShutdown_Timeout(t *testing.T) {
+	server, events, _ := testShutdownServer()
+	release := make(chan struct{})
+	defer close(release)
+	events.Action("*", func(ctx context.Context, event hooks.Event) {
+		<-release
+	})
+	events.Emit(hooks.NewEvent("items", "articles", hooks.ActionCreate, nil, "1"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, server.Shutdown(ctx), context.DeadlineExceeded)
+}
+
 // Benchmark tests
 func BenchmarkHealthCheck(b *testing.B) {
 	gin.SetMode(gin.TestMode)
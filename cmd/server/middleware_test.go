@@ -261,7 +261,7 @@ func TestEndpointAuthentication(t *testing.T) {
 		{"GET", "/api/v1/collections"},
 		{"GET", "/api/v1/users"},
 		{"GET", "/api/v1/roles"},
-		{"GET", "/api/v1/items/test"},
+		{"POST", "/api/v1/items/test"},
 	}
 
 	for _, endpoint := range protectedEndpoints {
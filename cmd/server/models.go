@@ -80,6 +80,125 @@ type ItemData struct {
 	// Examples: name, description, price, etc.
 }
 
+// FileModel represents the metadata of an uploaded file
+type FileModel struct {
+	ID               string    `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Storage          string    `json:"storage" example:"local"`
+	FilenameDisk     string    `json:"filename_disk" example:"123e4567-e89b-12d3-a456-426614174000.png"`
+	FilenameDownload string    `json:"filename_download" example:"logo.png"`
+	Title            *string   `json:"title" example:"logo"`
+	Description      *string   `json:"description" example:"Project logo"`
+	Type             *string   `json:"type" example:"image/png"`
+	Filesize         int64     `json:"filesize" example:"24816"`
+	Checksum         *string   `json:"checksum" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	UploadedBy       *string   `json:"uploaded_by" example:"456e7890-e89b-12d3-a456-426614174001"`
+	Folder           *string   `json:"folder" example:"789e0123-e89b-12d3-a456-426614174002"`
+	CreatedAt        time.Time `json:"created_at" example:"2023-01-01T10:30:00Z"`
+	UpdatedAt        time.Time `json:"updated_at" example:"2023-12-01T10:30:00Z"`
+}
+
+// FolderModel represents a folder organizing files
+type FolderModel struct {
+	ID        string    `json:"id" example:"789e0123-e89b-12d3-a456-426614174002"`
+	Name      string    `json:"name" example:"Logos"`
+	Parent    *string   `json:"parent" example:"789e0123-e89b-12d3-a456-426614174003"`
+	CreatedAt time.Time `json:"created_at" example:"2023-01-01T10:30:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2023-12-01T10:30:00Z"`
+}
+
+// WebhookModel represents a webhook
+type WebhookModel struct {
+	ID          string            `json:"id" example:"789e0123-e89b-12d3-a456-426614174004"`
+	Name        string            `json:"name" example:"Search indexer"`
+	URL         string            `json:"url" example:"https://indexer.example.com/hooks/gorectus"`
+	Method      string            `json:"method" example:"POST"`
+	Status      string            `json:"status" example:"active"`
+	Collections []string          `json:"collections" example:"articles,fields"`
+	Actions     []string          `json:"actions" example:"create,update,delete"`
+	HasSecret   bool              `json:"has_secret" example:"true"`
+	Headers     map[string]string `json:"headers"`
+	CreatedAt   time.Time         `json:"created_at" example:"2023-01-01T10:30:00Z"`
+	UpdatedAt   time.Time         `json:"updated_at" example:"2023-12-01T10:30:00Z"`
+}
+
+// WebhookDeliveryModel represents one event sent to a webhook
+type WebhookDeliveryModel struct {
+	ID             string                 `json:"id" example:"789e0123-e89b-12d3-a456-426614174005"`
+	Webhook        string                 `json:"webhook" example:"789e0123-e89b-12d3-a456-426614174004"`
+	Event          string                 `json:"event" example:"items.create"`
+	Payload        map[string]interface{} `json:"payload"`
+	Status         string                 `json:"status" example:"pending"`
+	Attempts       int                    `json:"attempts" example:"1"`
+	ResponseStatus *int                   `json:"response_status" example:"503"`
+	ResponseBody   *string                `json:"response_body" example:"Service Unavailable"`
+	Error          *string                `json:"error" example:"unexpected response status 503"`
+	NextAttemptAt  *time.Time             `json:"next_attempt_at" example:"2023-12-01T10:31:00Z"`
+	LastAttemptAt  *time.Time             `json:"last_attempt_at" example:"2023-12-01T10:30:30Z"`
+	CreatedAt      time.Time              `json:"created_at" example:"2023-12-01T10:30:00Z"`
+}
+
+// FlowModel represents an automation flow
+type FlowModel struct {
+	ID          string                 `json:"id" example:"789e0123-e89b-12d3-a456-426614174006"`
+	Name        string                 `json:"name" example:"Notify sales of large orders"`
+	Description *string                `json:"description" example:"Emails sales when an order over 1000 is created"`
+	Status      string                 `json:"status" example:"active"`
+	Trigger     string                 `json:"trigger" example:"event"`
+	Options     map[string]interface{} `json:"options"`
+	Operation   *string                `json:"operation" example:"789e0123-e89b-12d3-a456-426614174007"`
+	CreatedAt   time.Time              `json:"created_at" example:"2023-01-01T10:30:00Z"`
+	UpdatedAt   time.Time              `json:"updated_at" example:"2023-12-01T10:30:00Z"`
+}
+
+// OperationModel represents a step of a flow
+type OperationModel struct {
+	ID        string                 `json:"id" example:"789e0123-e89b-12d3-a456-426614174007"`
+	Flow      string                 `json:"flow" example:"789e0123-e89b-12d3-a456-426614174006"`
+	Key       string                 `json:"key" example:"is_large"`
+	Name      *string                `json:"name" example:"Is it a large order?"`
+	Type      string                 `json:"type" example:"condition"`
+	Options   map[string]interface{} `json:"options"`
+	Resolve   *string                `json:"resolve" example:"789e0123-e89b-12d3-a456-426614174008"`
+	Reject    *string                `json:"reject"`
+	CreatedAt time.Time              `json:"created_at" example:"2023-01-01T10:30:00Z"`
+	UpdatedAt time.Time              `json:"updated_at" example:"2023-12-01T10:30:00Z"`
+}
+
+// FlowResultModel represents the outcome of a flow run
+type FlowResultModel struct {
+	ID     string      `json:"id" example:"789e0123-e89b-12d3-a456-426614174009"`
+	Status string      `json:"status" example:"success"`
+	Error  string      `json:"error,omitempty" example:"operation notify: email is disabled in settings"`
+	Data   interface{} `json:"data"`
+}
+
+// JobModel represents a background job run on a cron schedule
+type JobModel struct {
+	ID          string                 `json:"id" example:"789e0123-e89b-12d3-a456-426614174010"`
+	Name        string                 `json:"name" example:"cleanup-sessions"`
+	Description *string                `json:"description" example:"Delete expired sessions"`
+	Schedule    string                 `json:"schedule" example:"*/15 * * * *"`
+	Status      string                 `json:"status" example:"active"`
+	Options     map[string]interface{} `json:"options"`
+	NextRunAt   *time.Time             `json:"next_run_at" example:"2023-12-01T10:45:00Z"`
+	LastRunAt   *time.Time             `json:"last_run_at" example:"2023-12-01T10:30:00Z"`
+	LastStatus  *string                `json:"last_status" example:"success"`
+	CreatedAt   time.Time              `json:"created_at" example:"2023-01-01T10:30:00Z"`
+	UpdatedAt   time.Time              `json:"updated_at" example:"2023-12-01T10:30:00Z"`
+}
+
+// JobRunModel represents one run of a job
+type JobRunModel struct {
+	ID         string      `json:"id" example:"789e0123-e89b-12d3-a456-426614174011"`
+	Job        string      `json:"job" example:"789e0123-e89b-12d3-a456-426614174010"`
+	Trigger    string      `json:"trigger" example:"schedule"`
+	Status     string      `json:"status" example:"success"`
+	Result     interface{} `json:"result"`
+	Error      *string     `json:"error"`
+	StartedAt  time.Time   `json:"started_at" example:"2023-12-01T10:30:00Z"`
+	FinishedAt *time.Time  `json:"finished_at" example:"2023-12-01T10:30:01Z"`
+}
+
 // FieldModel represents a field definition in a collection
 type FieldModel struct {
 	ID           string    `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
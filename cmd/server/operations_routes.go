@@ -0,0 +1,534 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// operationKeyRegexp matches operation keys, which name their results in
+// the flow data
+var operationKeyRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]{0,63}$`)
+
+// OperationsHandler handles flow operation routes
+type OperationsHandler struct {
+	db             *sql.DB
+	authMiddleware gin.HandlerFunc
+	optionsHandler gin.HandlerFunc
+}
+
+// NewOperationsHandler creates a new operations handler
+func NewOperationsHandler(server ServerInterface) *OperationsHandler {
+	return &OperationsHandler{
+		db:             server.GetDB(),
+		authMiddleware: server.AuthMiddleware(),
+		optionsHandler: server.OptionsHandler(),
+	}
+}
+
+// SetupRoutes sets up operation routes
+func (h *OperationsHandler) SetupRoutes(v1 *gin.RouterGroup) {
+	// CORS preflight OPTIONS for operations endpoints
+	v1.OPTIONS("/operations", h.optionsHandler)
+	v1.OPTIONS("/operations/:id", h.optionsHandler)
+
+	// Operations routes (admin only)
+	operations := v1.Group("/operations")
+	operations.Use(h.authMiddleware, requireAdmin())
+	{
+		operations.GET("", h.getOperations)
+		operations.POST("", h.createOperation)
+		operations.GET("/:id", h.getOperation)
+		operations.PATCH("/:id", h.updateOperation)
+		operations.DELETE("/:id", h.deleteOperation)
+	}
+}
+
+// Operation represents a step of a flow
+type Operation struct {
+	ID        string                 `json:"id"`
+	Flow      string                 `json:"flow"`
+	Key       string                 `json:"key"`
+	Name      *string                `json:"name"`
+	Type      string                 `json:"type"`
+	Options   map[string]interface{} `json:"options"`
+	Resolve   *string                `json:"resolve"`
+	Reject    *string                `json:"reject"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// CreateOperationRequest represents the request body for creating an operation
+type CreateOperationRequest struct {
+	Flow    string                 `json:"flow" binding:"required"`
+	Key     string                 `json:"key" binding:"required"`
+	Name    *string                `json:"name"`
+	Type    string                 `json:"type" binding:"required"`
+	Options map[string]interface{} `json:"options"`
+	Resolve *string                `json:"resolve"`
+	Reject  *string                `json:"reject"`
+}
+
+// UpdateOperationRequest represents the request body for updating an
+// operation. Empty resolve and reject values clear the link.
+type UpdateOperationRequest struct {
+	Key     *string                 `json:"key"`
+	Name    *string                 `json:"name"`
+	Type    *string                 `json:"type"`
+	Options *map[string]interface{} `json:"options"`
+	Resolve *string                 `json:"resolve"`
+	Reject  *string                 `json:"reject"`
+}
+
+const operationColumns = `id, flow, key, name, type, options, resolve, reject, created_at, updated_at`
+
+// scanOperation scans a row of operationColumns
+func scanOperation(scanner interface{ Scan(...interface{}) error }) (*Operation, error) {
+	var operation Operation
+	var name, resolve, reject sql.NullString
+	var options []byte
+	if err := scanner.Scan(&operation.ID, &operation.Flow, &operation.Key, &name, &operation.Type, &options,
+		&resolve, &reject, &operation.CreatedAt, &operation.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if name.Valid {
+		operation.Name = &name.String
+	}
+	if resolve.Valid {
+		operation.Resolve = &resolve.String
+	}
+	if reject.Valid {
+		operation.Reject = &reject.String
+	}
+	operation.Options = map[string]interface{}{}
+	if len(options) > 0 {
+		if err := json.Unmarshal(options, &operation.Options); err != nil {
+			return nil, err
+		}
+	}
+	return &operation, nil
+}
+
+// validateOperationOptions checks the options an operation type requires,
+// returning a message for the client when they are missing. Values may be
+// placeholders, so only their presence is checked.
+func validateOperationOptions(operationType string, options map[string]interface{}) (string, bool) {
+	require := func(names ...string) (string, bool) {
+		for _, name := range names {
+			if value, ok := options[name]; !ok || value == nil || value == "" {
+				return "The " + operationType + " operation requires the " + name + " option", false
+			}
+		}
+		return "", true
+	}
+
+	switch operationType {
+	case operationCondition:
+		if _, ok := options["filter"].(map[string]interface{}); !ok {
+			return "The condition operation requires a filter object", false
+		}
+	case operationItemCreate:
+		return require("collection", "payload")
+	case operationItemUpdate:
+		return require("collection", "key", "payload")
+	case operationMail:
+		return require("to", "subject")
+	case operationRequest:
+		return require("url")
+	case operationTransform:
+		if _, ok := options["json"]; !ok {
+			return "The transform operation requires the json option", false
+		}
+	default:
+		return "Invalid operation type: " + operationType, false
+	}
+	return "", true
+}
+
+// GetOperations lists operations
+//
+//	@Summary		List operations
+//	@Description	Get all flow operations, optionally only those of one flow
+//	@Tags			flows
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			flow	query		string	false	"Flow ID"
+//	@Success		200		{array}		OperationModel	"Operations"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	ErrorResponse	"Admin access required"
+//	@Failure		500		{object}	ErrorResponse	"Internal server error"
+//	@Router			/operations [get]
+func (h *OperationsHandler) getOperations(c *gin.Context) {
+	query := `SELECT ` + operationColumns + ` FROM operations`
+	var args []interface{}
+	if flowID := c.Query("flow"); flowID != "" {
+		if !uuidRegexp.MatchString(flowID) {
+			c.JSON(http.StatusOK, gin.H{"data": []Operation{}})
+			return
+		}
+		query += ` WHERE flow = $1`
+		args = append(args, flowID)
+	}
+	query += ` ORDER BY flow, key`
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while fetching operations")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	operations := []Operation{}
+	for rows.Next() {
+		operation, err := scanOperation(rows)
+		if err != nil {
+			logrus.WithError(err).Error("Error scanning operation row")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		operations = append(operations, *operation)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": operations})
+}
+
+// CreateOperation creates an operation
+//
+//	@Summary		Create an operation
+//	@Description	Create an operation of a flow. Resolve and reject name the operations run after it succeeds or fails; they must belong to the same flow
+//	@Tags			flows
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			operation	body		CreateOperationRequest	true	"Operation"
+//	@Success		201			{object}	OperationModel	"Created operation"
+//	@Failure		400			{object}	ErrorResponse	"Invalid request payload"
+//	@Failure		401			{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403			{object}	ErrorResponse	"Admin access required"
+//	@Failure		409			{object}	ErrorResponse	"Operation key already exists"
+//	@Failure		500			{object}	ErrorResponse	"Internal server error"
+//	@Router			/operations [post]
+func (h *OperationsHandler) createOperation(c *gin.Context) {
+	var req CreateOperationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid create operation request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if !operationKeyRegexp.MatchString(req.Key) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid operation key: " + req.Key})
+		return
+	}
+	if req.Options == nil {
+		req.Options = map[string]interface{}{}
+	}
+	if message, ok := validateOperationOptions(req.Type, req.Options); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+
+	var flowExists bool
+	if uuidRegexp.MatchString(req.Flow) {
+		if err := h.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM flows WHERE id = $1)`, req.Flow).Scan(&flowExists); err != nil {
+			logrus.WithError(err).Error("Database error while checking flow existence")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+	}
+	if !flowExists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Flow not found"})
+		return
+	}
+	if !h.checkLinks(c, req.Flow, "", req.Resolve, req.Reject) {
+		return
+	}
+	if !h.checkKeyAvailable(c, req.Flow, req.Key) {
+		return
+	}
+	options, err := json.Marshal(req.Options)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	var operationID string
+	err = h.db.QueryRow(`
+		INSERT INTO operations (flow, key, name, type, options, resolve, reject)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		req.Flow, req.Key, req.Name, req.Type, string(options), optionalLink(req.Resolve), optionalLink(req.Reject)).Scan(&operationID)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while creating operation")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	operation, err := h.getOperationByID(operationID)
+	if err != nil {
+		logrus.WithError(err).Error("Error fetching created operation")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"operation_id": operationID,
+		"flow_id":      req.Flow,
+	}).Info("Operation created successfully")
+	c.JSON(http.StatusCreated, gin.H{"data": operation})
+}
+
+// GetOperation returns an operation
+//
+//	@Summary		Get an operation
+//	@Description	Get a flow operation by ID
+//	@Tags			flows
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Operation ID"
+//	@Success		200	{object}	OperationModel	"Operation"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Admin access required"
+//	@Failure		404	{object}	ErrorResponse	"Operation not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/operations/{id} [get]
+func (h *OperationsHandler) getOperation(c *gin.Context) {
+	operation, ok := h.resolveOperation(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": operation})
+}
+
+// UpdateOperation updates an operation
+//
+//	@Summary		Update an operation
+//	@Description	Update a flow operation. Changing the type requires options valid for the new type
+//	@Tags			flows
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string					true	"Operation ID"
+//	@Param			operation	body		UpdateOperationRequest	true	"Operation changes"
+//	@Success		200			{object}	OperationModel	"Updated operation"
+//	@Failure		400			{object}	ErrorResponse	"Invalid request payload"
+//	@Failure		401			{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403			{object}	ErrorResponse	"Admin access required"
+//	@Failure		404			{object}	ErrorResponse	"Operation not found"
+//	@Failure		409			{object}	ErrorResponse	"Operation key already exists"
+//	@Failure		500			{object}	ErrorResponse	"Internal server error"
+//	@Router			/operations/{id} [patch]
+func (h *OperationsHandler) updateOperation(c *gin.Context) {
+	operation, ok := h.resolveOperation(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateOperationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid update operation request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	var updates []string
+	var args []interface{}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		updates = append(updates, column+" = $"+strconv.Itoa(len(args)))
+	}
+	if req.Key != nil && *req.Key != operation.Key {
+		if !operationKeyRegexp.MatchString(*req.Key) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid operation key: " + *req.Key})
+			return
+		}
+		if !h.checkKeyAvailable(c, operation.Flow, *req.Key) {
+			return
+		}
+		set("key", *req.Key)
+	}
+	if req.Name != nil {
+		set("name", nullableString(*req.Name))
+	}
+	if req.Type != nil || req.Options != nil {
+		operationType, options := operation.Type, operation.Options
+		if req.Type != nil {
+			operationType = *req.Type
+		}
+		if req.Options != nil {
+			options = *req.Options
+		}
+		if options == nil {
+			options = map[string]interface{}{}
+		}
+		if message, ok := validateOperationOptions(operationType, options); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		encoded, err := json.Marshal(options)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+		set("type", operationType)
+		set("options", string(encoded))
+	}
+	if req.Resolve != nil || req.Reject != nil {
+		if !h.checkLinks(c, operation.Flow, operation.ID, req.Resolve, req.Reject) {
+			return
+		}
+		if req.Resolve != nil {
+			set("resolve", optionalLink(req.Resolve))
+		}
+		if req.Reject != nil {
+			set("reject", optionalLink(req.Reject))
+		}
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	args = append(args, operation.ID)
+	query := `UPDATE operations SET ` + strings.Join(updates, ", ") + ` WHERE id = $` + strconv.Itoa(len(args))
+	if _, err := h.db.Exec(query, args...); err != nil {
+		logrus.WithError(err).Error("Database error while updating operation")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	updated, err := h.getOperationByID(operation.ID)
+	if err != nil {
+		logrus.WithError(err).Error("Error fetching updated operation")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithField("operation_id", operation.ID).Info("Operation updated successfully")
+	c.JSON(http.StatusOK, gin.H{"data": updated})
+}
+
+// DeleteOperation deletes an operation
+//
+//	@Summary		Delete an operation
+//	@Description	Delete a flow operation. Operations and flows continuing with it are unlinked
+//	@Tags			flows
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Operation ID"
+//	@Success		200	{object}	SuccessMessage	"Success message"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Admin access required"
+//	@Failure		404	{object}	ErrorResponse	"Operation not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/operations/{id} [delete]
+func (h *OperationsHandler) deleteOperation(c *gin.Context) {
+	operation, ok := h.resolveOperation(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.db.Exec(`DELETE FROM operations WHERE id = $1`, operation.ID); err != nil {
+		logrus.WithError(err).Error("Database error while deleting operation")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithField("operation_id", operation.ID).Info("Operation deleted successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Operation deleted successfully"})
+}
+
+// checkKeyAvailable checks that no other operation of the flow uses key,
+// writing a 409 when one does
+func (h *OperationsHandler) checkKeyAvailable(c *gin.Context, flowID, key string) bool {
+	var exists bool
+	if err := h.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM operations WHERE flow = $1 AND key = $2)`,
+		flowID, key).Scan(&exists); err != nil {
+		logrus.WithError(err).Error("Database error while checking operation key")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+	if exists {
+		c.JSON(http.StatusConflict, gin.H{"error": "Operation key already exists"})
+		return false
+	}
+	return true
+}
+
+// checkLinks checks that the resolve and reject operations set on an
+// operation belong to its flow and aren't the operation itself, writing the
+// error response when they don't
+func (h *OperationsHandler) checkLinks(c *gin.Context, flowID, operationID string, links ...*string) bool {
+	var ids []string
+	for _, link := range links {
+		if link == nil || *link == "" {
+			continue
+		}
+		if *link == operationID || !uuidRegexp.MatchString(*link) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid linked operation: " + *link})
+			return false
+		}
+		if !slices.Contains(ids, *link) {
+			ids = append(ids, *link)
+		}
+	}
+	for _, id := range ids {
+		var exists bool
+		if err := h.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM operations WHERE id = $1 AND flow = $2)`,
+			id, flowID).Scan(&exists); err != nil {
+			logrus.WithError(err).Error("Database error while checking linked operation")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return false
+		}
+		if !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Linked operation does not belong to this flow: " + id})
+			return false
+		}
+	}
+	return true
+}
+
+// optionalLink converts a resolve or reject link to a column value
+func optionalLink(link *string) interface{} {
+	if link == nil {
+		return nil
+	}
+	return nullableString(*link)
+}
+
+// resolveOperation loads the operation of the :id parameter, writing a 404
+// when it doesn't exist
+func (h *OperationsHandler) resolveOperation(c *gin.Context) (*Operation, bool) {
+	operationID := c.Param("id")
+	if !uuidRegexp.MatchString(operationID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Operation not found"})
+		return nil, false
+	}
+
+	operation, err := h.getOperationByID(operationID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Operation not found"})
+		return nil, false
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while fetching operation")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	return operation, true
+}
+
+// getOperationByID loads an operation, or sql.ErrNoRows if it doesn't exist
+func (h *OperationsHandler) getOperationByID(operationID string) (*Operation, error) {
+	return scanOperation(h.db.QueryRow(`SELECT `+operationColumns+` FROM operations WHERE id = $1`, operationID))
+}
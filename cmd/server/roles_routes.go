@@ -9,21 +9,27 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/hooks"
 )
 
 // RolesHandler handles role-related routes
 type RolesHandler struct {
-	db             *sql.DB
-	authMiddleware gin.HandlerFunc
-	optionsHandler gin.HandlerFunc
+	db                   *sql.DB
+	authMiddleware       gin.HandlerFunc
+	optionsHandler       gin.HandlerFunc
+	invalidateRoleAccess func()
+	events               *hooks.Bus
 }
 
 // NewRolesHandler creates a new roles handler
 func NewRolesHandler(server ServerInterface) *RolesHandler {
 	return &RolesHandler{
-		db:             server.GetDB(),
-		authMiddleware: server.AuthMiddleware(),
-		optionsHandler: server.OptionsHandler(),
+		db:                   server.GetDB(),
+		authMiddleware:       server.AuthMiddleware(),
+		optionsHandler:       server.OptionsHandler(),
+		invalidateRoleAccess: server.InvalidateRoleAccess,
+		events:               server.Events(),
 	}
 }
 
@@ -32,9 +38,9 @@ func (h *RolesHandler) SetupRoutes(v1 *gin.RouterGroup) {
 	// CORS preflight OPTIONS for roles endpoints
 	v1.OPTIONS("/roles", h.optionsHandler)
 
-	// Roles routes (protected)
+	// Roles routes (admin only)
 	roles := v1.Group("/roles")
-	roles.Use(h.authMiddleware)
+	roles.Use(h.authMiddleware, requireAdmin())
 	{
 		roles.GET("", h.getRoles)
 		roles.POST("", h.createRole)
@@ -80,20 +86,8 @@ type UpdateRoleRequest struct {
 	AppAccess   *bool    `json:"app_access"`
 }
 
-// isAdmin checks if the requesting user is an admin
-func (h *RolesHandler) isAdmin(c *gin.Context) bool {
-	currentUserRole := c.GetString("user_role")
-	return currentUserRole == "Administrator"
-}
-
 // Roles handlers implementations
 func (h *RolesHandler) getRoles(c *gin.Context) {
-	// Only admins can list all roles
-	if !h.isAdmin(c) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
-		return
-	}
-
 	// Parse query parameters for pagination
 	page := 1
 	limit := 50
@@ -163,9 +157,7 @@ func (h *RolesHandler) getRoles(c *gin.Context) {
 }
 
 func (h *RolesHandler) createRole(c *gin.Context) {
-	// Only admins can create roles
-	if !h.isAdmin(c) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeRoles, scopeRoles, hooks.ActionCreate, nil)) {
 		return
 	}
 
@@ -237,17 +229,12 @@ func (h *RolesHandler) createRole(c *gin.Context) {
 		"role_name":  req.Name,
 		"created_by": c.GetString("user_id"),
 	}).Info("Role created successfully")
+	emitActionHooks(c, h.events, scopeRoles, scopeRoles, hooks.ActionCreate, role, roleID)
 
 	c.JSON(http.StatusCreated, gin.H{"data": role})
 }
 
 func (h *RolesHandler) getRole(c *gin.Context) {
-	// Only admins can view roles
-	if !h.isAdmin(c) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
-		return
-	}
-
 	roleID := c.Param("id")
 
 	role, err := h.getRoleByID(roleID)
@@ -286,12 +273,6 @@ func (h *RolesHandler) getRoleByID(roleID string) (*Role, error) {
 }
 
 func (h *RolesHandler) updateRole(c *gin.Context) {
-	// Only admins can update roles
-	if !h.isAdmin(c) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
-		return
-	}
-
 	roleID := c.Param("id")
 
 	// Check if role exists
@@ -305,6 +286,10 @@ func (h *RolesHandler) updateRole(c *gin.Context) {
 		return
 	}
 
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeRoles, scopeRoles, hooks.ActionUpdate, nil, roleID)) {
+		return
+	}
+
 	var req UpdateRoleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logrus.WithError(err).Error("Invalid update role request payload")
@@ -312,9 +297,9 @@ func (h *RolesHandler) updateRole(c *gin.Context) {
 		return
 	}
 
-	// Prevent updating the Administrator role name to avoid breaking auth
-	if existingRole.Name == "Administrator" && req.Name != nil && *req.Name != "Administrator" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot change Administrator role name"})
+	// Prevent admins from locking themselves out by revoking their own role's admin access
+	if req.AdminAccess != nil && !*req.AdminAccess && roleID == c.GetString("role_id") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot remove admin access from your own role"})
 		return
 	}
 
@@ -397,6 +382,7 @@ func (h *RolesHandler) updateRole(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
+	h.invalidateRoleAccess()
 
 	// Fetch the updated role
 	role, err := h.getRoleByID(roleID)
@@ -410,17 +396,12 @@ func (h *RolesHandler) updateRole(c *gin.Context) {
 		"role_id":    roleID,
 		"updated_by": c.GetString("user_id"),
 	}).Info("Role updated successfully")
+	emitActionHooks(c, h.events, scopeRoles, scopeRoles, hooks.ActionUpdate, role, roleID)
 
 	c.JSON(http.StatusOK, gin.H{"data": role})
 }
 
 func (h *RolesHandler) deleteRole(c *gin.Context) {
-	// Only admins can delete roles
-	if !h.isAdmin(c) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
-		return
-	}
-
 	roleID := c.Param("id")
 
 	// Check if role exists
@@ -435,7 +416,7 @@ func (h *RolesHandler) deleteRole(c *gin.Context) {
 	}
 
 	// Prevent deletion of essential system roles
-	if existingRole.Name == "Administrator" || existingRole.Name == "Public" {
+	if isSystemRole(existingRole) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot delete system roles"})
 		return
 	}
@@ -457,6 +438,11 @@ func (h *RolesHandler) deleteRole(c *gin.Context) {
 		return
 	}
 
+	event := requestEvent(c, scopeRoles, scopeRoles, hooks.ActionDelete, nil, roleID)
+	if !runFilterHooks(c, h.events, &event) {
+		return
+	}
+
 	// Delete the role (permissions will be deleted automatically due to CASCADE)
 	_, err = h.db.Exec("DELETE FROM roles WHERE id = $1", roleID)
 	if err != nil {
@@ -464,12 +450,14 @@ func (h *RolesHandler) deleteRole(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
+	h.invalidateRoleAccess()
 
 	logrus.WithFields(logrus.Fields{
 		"role_id":    roleID,
 		"role_name":  existingRole.Name,
 		"deleted_by": c.GetString("user_id"),
 	}).Info("Role deleted successfully")
+	emitActionHooks(c, h.events, scopeRoles, scopeRoles, hooks.ActionDelete, existingRole, roleID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
 }
@@ -16,6 +16,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+
+	"gorectus/internal/hooks"
 )
 
 // Test suite for role handlers
@@ -76,10 +78,22 @@ func (m *mockRoleServerInterface) AuthMiddleware() gin.HandlerFunc {
 		c.Set("user_id", "550e8400-e29b-41d4-a716-446655440000")
 		c.Set("user_email", "admin@example.com")
 		c.Set("user_role", "Administrator")
+		c.Set("admin_access", true)
+		c.Set("app_access", true)
 		c.Next()
 	}
 }
 
+func (m *mockRoleServerInterface) OptionalAuthMiddleware() gin.HandlerFunc {
+	return m.AuthMiddleware()
+}
+
+func (m *mockRoleServerInterface) InvalidateRoleAccess() {}
+
+func (m *mockRoleServerInterface) Webhooks() *webhookDispatcher { return nil }
+
+func (m *mockRoleServerInterface) Events() *hooks.Bus { return nil }
+
 func (m *mockRoleServerInterface) OptionsHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
@@ -99,6 +113,8 @@ func (suite *RoleHandlersTestSuite) createAuthenticatedRequest(method, url strin
 		c.Set("user_id", userID)
 		c.Set("user_email", "test@example.com")
 		c.Set("user_role", role)
+		c.Set("admin_access", role == "Administrator")
+		c.Set("app_access", true)
 		c.Next()
 	}
 
@@ -277,6 +293,44 @@ func (suite *RoleHandlersTestSuite) TestCreateRole_DuplicateName() {
 	assert.Equal(suite.T(), "Role name already exists", response["error"])
 }
 
+func (suite *RoleHandlersTestSuite) TestUpdateRole_CannotRevokeOwnAdminAccess() {
+	roleID := "admin-role-id"
+
+	existingRows := sqlmock.NewRows([]string{
+		"id", "name", "icon", "description", "ip_access", "enforce_tfa", "admin_access", "app_access",
+		"created_at", "updated_at",
+	}).AddRow(
+		roleID, "Site Owner", "verified_user", nil, pq.Array([]string{}), false, true, true,
+		time.Now(), time.Now(),
+	)
+
+	suite.mock.ExpectQuery("SELECT id, name, icon, description, ip_access, enforce_tfa, admin_access, app_access,.*FROM roles.*WHERE id = \\$1").
+		WithArgs(roleID).
+		WillReturnRows(existingRows)
+
+	router := gin.New()
+	mockServer := &mockRoleServerInterface{
+		db: suite.db,
+		customAuthFunc: func(c *gin.Context) {
+			c.Set("user_id", "admin-id")
+			c.Set("role_id", roleID)
+			c.Set("user_role", "Site Owner")
+			c.Set("admin_access", true)
+			c.Next()
+		},
+	}
+	NewRolesHandler(mockServer).SetupRoutes(router.Group("/api/v1"))
+
+	body, _ := json.Marshal(map[string]interface{}{"admin_access": false})
+	req := httptest.NewRequest("PATCH", "/api/v1/roles/"+roleID, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Cannot remove admin access from your own role")
+}
+
 // Test GetRole endpoint
 func (suite *RoleHandlersTestSuite) TestGetRole_AsAdmin() {
 	roleID := "test-role-id"
@@ -388,7 +442,7 @@ func (suite *RoleHandlersTestSuite) TestDeleteRole_AsNonAdmin() {
 }
 
 func (suite *RoleHandlersTestSuite) TestDeleteRole_SystemRoleProtection() {
-	roleID := "admin-role-id"
+	roleID := administratorRoleID
 
 	// Mock fetching existing Administrator role
 	existingRows := sqlmock.NewRows([]string{
@@ -415,6 +469,35 @@ func (suite *RoleHandlersTestSuite) TestDeleteRole_SystemRoleProtection() {
 	assert.Equal(suite.T(), "Cannot delete system roles", response["error"])
 }
 
+func (suite *RoleHandlersTestSuite) TestDeleteRole_CustomRoleNamedPublic() {
+	roleID := "test-role-id"
+
+	// System roles are identified by ID, so a custom role's name doesn't protect it
+	existingRows := sqlmock.NewRows([]string{
+		"id", "name", "icon", "description", "ip_access", "enforce_tfa", "admin_access", "app_access",
+		"created_at", "updated_at",
+	}).AddRow(
+		roleID, "Public", "public", "Renamed custom role", pq.Array([]string{}), false, false, false,
+		time.Now(), time.Now(),
+	)
+
+	suite.mock.ExpectQuery("SELECT id, name, icon, description, ip_access, enforce_tfa, admin_access, app_access,.*FROM roles.*WHERE id = \\$1").
+		WithArgs(roleID).
+		WillReturnRows(existingRows)
+	suite.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM users WHERE role_id = \\$1").
+		WithArgs(roleID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	suite.mock.ExpectExec("DELETE FROM roles WHERE id = \\$1").
+		WithArgs(roleID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	req, router := suite.createAuthenticatedRequest("DELETE", "/api/v1/roles/"+roleID, nil, "admin-id", "Administrator")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
 func (suite *RoleHandlersTestSuite) TestDeleteRole_RoleInUse() {
 	roleID := "test-role-id"
 
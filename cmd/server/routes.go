@@ -5,6 +5,8 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"gorectus/internal/hooks"
 )
 
 // RouteHandler interface for all route handlers
@@ -16,7 +18,11 @@ type RouteHandler interface {
 type ServerInterface interface {
 	GetDB() *sql.DB
 	AuthMiddleware() gin.HandlerFunc
+	OptionalAuthMiddleware() gin.HandlerFunc
 	OptionsHandler() gin.HandlerFunc
+	InvalidateRoleAccess()
+	Webhooks() *webhookDispatcher
+	Events() *hooks.Bus
 }
 
 // Implement ServerInterface for Server
@@ -28,6 +34,25 @@ func (s *Server) AuthMiddleware() gin.HandlerFunc {
 	return s.authMiddleware()
 }
 
+func (s *Server) OptionalAuthMiddleware() gin.HandlerFunc {
+	return s.optionalAuthMiddleware()
+}
+
+// InvalidateRoleAccess drops cached role access after roles or role assignments change
+func (s *Server) InvalidateRoleAccess() {
+	s.roleCache.clear()
+}
+
+// Webhooks returns the dispatcher that sends item and schema events to webhooks
+func (s *Server) Webhooks() *webhookDispatcher {
+	return s.webhooks
+}
+
+// Events returns the hook bus handlers run filter and action hooks on
+func (s *Server) Events() *hooks.Bus {
+	return s.events
+}
+
 func (s *Server) OptionsHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
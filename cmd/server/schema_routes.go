@@ -0,0 +1,408 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gorectus/internal/schema"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// SchemaHandler handles schema snapshot routes
+type SchemaHandler struct {
+	db             *sql.DB
+	authMiddleware gin.HandlerFunc
+	optionsHandler gin.HandlerFunc
+	fields         *FieldsHandler // column helpers shared with the fields API
+}
+
+// NewSchemaHandler creates a new schema handler
+func NewSchemaHandler(server ServerInterface) *SchemaHandler {
+	return &SchemaHandler{
+		db:             server.GetDB(),
+		authMiddleware: server.AuthMiddleware(),
+		optionsHandler: server.OptionsHandler(),
+		fields:         NewFieldsHandler(server),
+	}
+}
+
+// SchemaApplyResult is the response of a schema apply request
+type SchemaApplyResult struct {
+	DryRun  bool          `json:"dry_run"`
+	Applied bool          `json:"applied"`
+	Steps   []schema.Step `json:"steps"`
+}
+
+// SetupRoutes sets up schema routes
+func (h *SchemaHandler) SetupRoutes(v1 *gin.RouterGroup) {
+	// CORS preflight OPTIONS for schema endpoints
+	v1.OPTIONS("/schema/snapshot", h.optionsHandler)
+	v1.OPTIONS("/schema/diff", h.optionsHandler)
+	v1.OPTIONS("/schema/apply", h.optionsHandler)
+
+	// Schema routes (admin only)
+	schemaGroup := v1.Group("/schema")
+	schemaGroup.Use(h.authMiddleware, requireAdmin())
+	{
+		schemaGroup.GET("/snapshot", h.getSnapshot)
+		schemaGroup.POST("/diff", h.diffSnapshot)
+		schemaGroup.POST("/apply", h.applySnapshot)
+	}
+}
+
+// getSnapshot returns a snapshot of collections, fields and permissions
+//
+//	@Summary		Export schema snapshot
+//	@Description	Serialize all collections, fields (with column details) and permissions into a versioned document. Use export=json or export=yaml to download the raw document.
+//	@Tags			schema
+//	@Produce		json
+//	@Produce		application/x-yaml
+//	@Security		BearerAuth
+//	@Param			export	query		string					false	"Download the raw snapshot as json or yaml"
+//	@Success		200		{object}	map[string]interface{}	"Schema snapshot"
+//	@Failure		400		{object}	ErrorResponse			"Unsupported export format"
+//	@Failure		401		{object}	ErrorResponse			"Unauthorized"
+//	@Failure		403		{object}	ErrorResponse			"Admin access required"
+//	@Failure		500		{object}	ErrorResponse			"Internal server error"
+//	@Router			/schema/snapshot [get]
+func (h *SchemaHandler) getSnapshot(c *gin.Context) {
+	export := c.Query("export")
+	if export != "" && export != schema.FormatJSON && export != schema.FormatYAML {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported export format. Use json or yaml"})
+		return
+	}
+
+	snapshot, err := schema.Load(h.db)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to load schema snapshot")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if export == "" {
+		c.JSON(http.StatusOK, gin.H{"data": snapshot})
+		return
+	}
+
+	document, err := schema.Marshal(snapshot, export)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to encode schema snapshot")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode snapshot"})
+		return
+	}
+
+	contentType := "application/json"
+	if export == schema.FormatYAML {
+		contentType = "application/x-yaml"
+	}
+	c.Header("Content-Disposition", `attachment; filename="snapshot.`+export+`"`)
+	c.Data(http.StatusOK, contentType, document)
+}
+
+// diffSnapshot compares a snapshot with the live schema
+//
+//	@Summary		Diff schema snapshot
+//	@Description	Compare a snapshot document (JSON, or YAML with a yaml Content-Type) with the live schema and return the ordered plan: create collection, add field, alter field, drop field
+//	@Tags			schema
+//	@Accept			json
+//	@Accept			application/x-yaml
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			snapshot	body		object					true	"Snapshot document"
+//	@Success		200			{object}	map[string]interface{}	"Ordered plan"
+//	@Failure		400			{object}	ErrorResponse			"Invalid snapshot"
+//	@Failure		401			{object}	ErrorResponse			"Unauthorized"
+//	@Failure		403			{object}	ErrorResponse			"Admin access required"
+//	@Failure		500			{object}	ErrorResponse			"Internal server error"
+//	@Router			/schema/diff [post]
+func (h *SchemaHandler) diffSnapshot(c *gin.Context) {
+	plan, ok := h.buildPlan(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": plan})
+}
+
+// applySnapshot brings the live schema in line with a snapshot
+//
+//	@Summary		Apply schema snapshot
+//	@Description	Diff a snapshot against the live schema and run the plan in a single transaction. With dry_run=true the plan is executed and rolled back.
+//	@Tags			schema
+//	@Accept			json
+//	@Accept			application/x-yaml
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			dry_run		query		bool				false	"Execute the plan and roll it back"
+//	@Param			snapshot	body		object				true	"Snapshot document"
+//	@Success		200			{object}	SchemaApplyResult	"Executed plan"
+//	@Failure		400			{object}	ErrorResponse		"Invalid snapshot"
+//	@Failure		401			{object}	ErrorResponse		"Unauthorized"
+//	@Failure		403			{object}	ErrorResponse		"Admin access required"
+//	@Failure		500			{object}	ErrorResponse		"Failed to apply schema plan"
+//	@Router			/schema/apply [post]
+func (h *SchemaHandler) applySnapshot(c *gin.Context) {
+	dryRun := c.Query("dry_run") == "true"
+
+	plan, ok := h.buildPlan(c)
+	if !ok {
+		return
+	}
+
+	result := SchemaApplyResult{DryRun: dryRun, Steps: plan}
+	if len(plan) == 0 {
+		c.JSON(http.StatusOK, gin.H{"data": result})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to begin transaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	for _, step := range plan {
+		if err := h.applyStep(tx, step); err != nil {
+			logrus.WithError(err).WithField("step", step.Description).Error("Failed to apply schema step")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to apply schema plan",
+				"step":  step.Description,
+			})
+			return
+		}
+	}
+
+	// A dry run executes every statement but never commits
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{"data": result})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logrus.WithError(err).Error("Failed to commit transaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	result.Applied = true
+	logrus.WithFields(logrus.Fields{
+		"steps":      len(plan),
+		"applied_by": c.GetString("user_id"),
+	}).Info("Schema snapshot applied successfully")
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// buildPlan parses the snapshot in the request body and diffs it against the
+// live schema.
+func (h *SchemaHandler) buildPlan(c *gin.Context) ([]schema.Step, bool) {
+	body, err := c.GetRawData()
+	if err != nil || len(body) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Snapshot document required"})
+		return nil, false
+	}
+
+	format := schema.FormatJSON
+	if strings.Contains(c.ContentType(), "yaml") {
+		format = schema.FormatYAML
+	}
+
+	target, err := schema.Parse(body, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid snapshot: " + err.Error()})
+		return nil, false
+	}
+
+	current, err := schema.Load(h.db)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to load schema snapshot")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+
+	plan := schema.Diff(current, target)
+	for _, step := range plan {
+		if err := validatePlanStep(step); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid snapshot: " + err.Error()})
+			return nil, false
+		}
+	}
+
+	return plan, true
+}
+
+// validatePlanStep rejects identifiers that cannot be used safely in DDL
+func validatePlanStep(step schema.Step) error {
+	if !isValidFieldName(step.Collection) {
+		return fmt.Errorf("invalid collection name %q", step.Collection)
+	}
+	if step.Field != "" && !isValidFieldName(step.Field) {
+		return fmt.Errorf("invalid field name %q", step.Field)
+	}
+	if step.Schema != nil && !isScalarDefault(step.Schema.DefaultValue) {
+		return fmt.Errorf("default value of %s.%s must be a string, number, boolean or null", step.Collection, step.Field)
+	}
+	if step.Schema != nil && step.Action == schema.ActionAddField {
+		if step.Schema.ForeignTable != nil && !isValidFieldName(*step.Schema.ForeignTable) {
+			return fmt.Errorf("invalid foreign table %q", *step.Schema.ForeignTable)
+		}
+		if step.Schema.ForeignColumn != nil && !isValidFieldName(*step.Schema.ForeignColumn) {
+			return fmt.Errorf("invalid foreign column %q", *step.Schema.ForeignColumn)
+		}
+	}
+	return nil
+}
+
+// applyStep executes a single plan step inside tx
+func (h *SchemaHandler) applyStep(tx *sql.Tx, step schema.Step) error {
+	switch step.Action {
+	case schema.ActionCreateCollection:
+		if err := insertCollection(tx, collectionRequestFromSnapshot(step.Definition)); err != nil {
+			return err
+		}
+		return createCollectionTable(tx, step.Collection)
+
+	case schema.ActionAddField:
+		field := fieldFromSnapshot(step.FieldDefinition)
+		if err := insertFieldMetadata(tx, step.Collection, field); err != nil {
+			return err
+		}
+		if step.Schema != nil && !isVirtualField(field.Interface) {
+			return h.fields.createDatabaseColumn(tx, step.Collection, step.Field, fieldSchemaFromColumn(step.Schema))
+		}
+		return nil
+
+	case schema.ActionAlterField:
+		field := fieldFromSnapshot(step.FieldDefinition)
+		if err := updateFieldMetadata(tx, step.Collection, field); err != nil {
+			return err
+		}
+		if step.Schema != nil && !isVirtualField(field.Interface) {
+			columnSchema := fieldSchemaFromColumn(step.Schema)
+			conversion, err := h.fields.planConversion(tx, step.Collection, step.Field, columnSchema)
+			if err != nil {
+				return err
+			}
+			if conversion != nil && conversion.FailingRows > 0 {
+				return fmt.Errorf("%d rows cannot be converted to %s", conversion.FailingRows, conversion.To)
+			}
+			return h.fields.alterDatabaseColumn(tx, step.Collection, step.Field, columnSchema, conversion)
+		}
+		return nil
+
+	case schema.ActionDropField:
+		if _, err := tx.Exec("DELETE FROM fields WHERE collection = $1 AND field = $2", step.Collection, step.Field); err != nil {
+			return err
+		}
+		if step.Schema != nil {
+			return h.fields.dropDatabaseColumn(tx, step.Collection, step.Field)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown plan action %q", step.Action)
+	}
+}
+
+// updateFieldMetadata overwrites the metadata of an existing field
+func updateFieldMetadata(tx *sql.Tx, collectionName string, field *Field) error {
+	width := "full"
+	if field.Width != "" {
+		width = field.Width
+	}
+	special := field.Special
+	if special == nil {
+		special = []string{}
+	}
+
+	_, err := tx.Exec(`
+		UPDATE fields SET
+			special = $1, interface = $2, options = $3, display = $4, display_options = $5,
+			readonly = $6, hidden = $7, sort = $8, width = $9, translations = $10,
+			note = $11, conditions = $12, required = $13, "group" = $14, validation = $15,
+			validation_message = $16, updated_at = CURRENT_TIMESTAMP
+		WHERE collection = $17 AND field = $18
+	`, pq.Array(special), field.Interface, jsonOrNull(field.Options), field.Display,
+		jsonOrNull(field.DisplayOptions), field.Readonly, field.Hidden, field.Sort, width,
+		jsonOrNull(field.Translations), field.Note, jsonOrNull(field.Conditions), field.Required,
+		field.Group, jsonOrNull(field.Validation), field.ValidationMessage,
+		collectionName, field.Field)
+	return err
+}
+
+// collectionRequestFromSnapshot converts snapshot collection metadata into a create request
+func collectionRequestFromSnapshot(collection *schema.Collection) *CreateCollectionRequest {
+	accountability := collection.Accountability
+	collapse := collection.Collapse
+	req := &CreateCollectionRequest{
+		Collection:            collection.Collection,
+		Icon:                  collection.Icon,
+		Note:                  collection.Note,
+		DisplayTemplate:       collection.DisplayTemplate,
+		Hidden:                &collection.Hidden,
+		Singleton:             &collection.Singleton,
+		Translations:          collection.Translations,
+		ArchiveField:          collection.ArchiveField,
+		ArchiveAppFilter:      &collection.ArchiveAppFilter,
+		ArchiveValue:          collection.ArchiveValue,
+		UnarchiveValue:        collection.UnarchiveValue,
+		SortField:             collection.SortField,
+		Color:                 collection.Color,
+		ItemDuplicationFields: collection.ItemDuplicationFields,
+		Sort:                  collection.Sort,
+		Group:                 collection.Group,
+		PreviewURL:            collection.PreviewURL,
+		Versioning:            &collection.Versioning,
+	}
+	if accountability != "" {
+		req.Accountability = &accountability
+	}
+	if collapse != "" {
+		req.Collapse = &collapse
+	}
+	return req
+}
+
+// fieldFromSnapshot converts snapshot field metadata into a Field
+func fieldFromSnapshot(field *schema.Field) *Field {
+	return &Field{
+		Collection:        field.Collection,
+		Field:             field.Field,
+		Special:           field.Special,
+		Interface:         field.Interface,
+		Options:           field.Options,
+		Display:           field.Display,
+		DisplayOptions:    field.DisplayOptions,
+		Readonly:          field.Readonly,
+		Hidden:            field.Hidden,
+		Sort:              field.Sort,
+		Width:             field.Width,
+		Translations:      field.Translations,
+		Note:              field.Note,
+		Conditions:        field.Conditions,
+		Required:          field.Required,
+		Group:             field.Group,
+		Validation:        field.Validation,
+		ValidationMessage: field.ValidationMessage,
+	}
+}
+
+// fieldSchemaFromColumn converts snapshot column details into a FieldSchema
+func fieldSchemaFromColumn(column *schema.Column) *FieldSchema {
+	return &FieldSchema{
+		DataType:      column.DataType,
+		MaxLength:     column.MaxLength,
+		IsNullable:    column.IsNullable,
+		DefaultValue:  column.DefaultValue,
+		IsUnique:      column.IsUnique,
+		IsPrimaryKey:  column.IsPrimaryKey,
+		ForeignTable:  column.ForeignTable,
+		ForeignColumn: column.ForeignColumn,
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gorectus/internal/hooks"
+)
+
+// SchemaHandlersTestSuite is the test suite for schema handlers
+type SchemaHandlersTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	mock   sqlmock.Sqlmock
+	router *gin.Engine
+}
+
+// Mock server interface for testing
+type mockSchemaServerInterface struct {
+	db             *sql.DB
+	customAuthFunc gin.HandlerFunc
+}
+
+func (m *mockSchemaServerInterface) GetDB() *sql.DB {
+	return m.db
+}
+
+func (m *mockSchemaServerInterface) AuthMiddleware() gin.HandlerFunc {
+	if m.customAuthFunc != nil {
+		return m.customAuthFunc
+	}
+	return func(c *gin.Context) {
+		c.Set("user_id", "550e8400-e29b-41d4-a716-446655440000")
+		c.Set("user_role", "Administrator")
+		c.Set("admin_access", true)
+		c.Set("app_access", true)
+		c.Next()
+	}
+}
+
+func (m *mockSchemaServerInterface) OptionalAuthMiddleware() gin.HandlerFunc {
+	return m.AuthMiddleware()
+}
+
+func (m *mockSchemaServerInterface) InvalidateRoleAccess() {}
+
+func (m *mockSchemaServerInterface) Webhooks() *webhookDispatcher { return nil }
+
+func (m *mockSchemaServerInterface) Events() *hooks.Bus { return nil }
+
+func (m *mockSchemaServerInterface) OptionsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	}
+}
+
+// SetupSuite runs once before all tests
+func (suite *SchemaHandlersTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+	logrus.SetLevel(logrus.PanicLevel)
+}
+
+// SetupTest runs before each test
+func (suite *SchemaHandlersTestSuite) SetupTest() {
+	db, mock, err := sqlmock.New()
+	require.NoError(suite.T(), err)
+
+	suite.db = db
+	suite.mock = mock
+	suite.router = gin.New()
+
+	handler := NewSchemaHandler(&mockSchemaServerInterface{db: db})
+	handler.SetupRoutes(suite.router.Group("/api/v1"))
+}
+
+// TearDownTest runs after each test
+func (suite *SchemaHandlersTestSuite) TearDownTest() {
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+	suite.db.Close()
+}
+
+// expectEmptySnapshot mocks the queries of a snapshot without collections
+func (suite *SchemaHandlersTestSuite) expectEmptySnapshot() {
+	suite.mock.ExpectQuery("SELECT collection, icon, note, display_template.*FROM collections").
+		WillReturnRows(sqlmock.NewRows([]string{"collection"}))
+	suite.mock.ExpectQuery("SELECT collection, field, special.*FROM fields").
+		WillReturnRows(sqlmock.NewRows([]string{"collection"}))
+	suite.mock.ExpectQuery("SELECT r.name, p.collection, p.action.*FROM permissions p").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "collection", "action", "permissions", "validation", "presets", "fields"}).
+			AddRow("Public", "articles", "read", []byte(`{"status":{"_eq":"published"}}`), nil, nil, "{title,body}"))
+}
+
+func (suite *SchemaHandlersTestSuite) TestGetSnapshot() {
+	suite.expectEmptySnapshot()
+
+	req := httptest.NewRequest("GET", "/api/v1/schema/snapshot", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response struct {
+		Data struct {
+			Version     int                      `json:"version"`
+			Permissions []map[string]interface{} `json:"permissions"`
+		} `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), 1, response.Data.Version)
+	require.Len(suite.T(), response.Data.Permissions, 1)
+	assert.Equal(suite.T(), "Public", response.Data.Permissions[0]["role"])
+}
+
+func (suite *SchemaHandlersTestSuite) TestGetSnapshot_ExportYAML() {
+	suite.expectEmptySnapshot()
+
+	req := httptest.NewRequest("GET", "/api/v1/schema/snapshot?export=yaml", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "application/x-yaml", w.Header().Get("Content-Type"))
+	assert.Contains(suite.T(), w.Header().Get("Content-Disposition"), "snapshot.yaml")
+	assert.Contains(suite.T(), w.Body.String(), "version: 1")
+	assert.Contains(suite.T(), w.Body.String(), "role: Public")
+}
+
+func (suite *SchemaHandlersTestSuite) TestGetSnapshot_InvalidExport() {
+	req := httptest.NewRequest("GET", "/api/v1/schema/snapshot?export=xml", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *SchemaHandlersTestSuite) TestGetSnapshot_AsNonAdmin() {
+	router := gin.New()
+	handler := NewSchemaHandler(&mockSchemaServerInterface{
+		db: suite.db,
+		customAuthFunc: func(c *gin.Context) {
+			c.Set("user_id", "user-id")
+			c.Set("user_role", "Editor")
+			c.Set("admin_access", false)
+			c.Next()
+		},
+	})
+	handler.SetupRoutes(router.Group("/api/v1"))
+
+	req := httptest.NewRequest("GET", "/api/v1/schema/snapshot", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+// targetSnapshot creates an articles collection with a title column
+const targetSnapshot = `{
+	"version": 1,
+	"collections": [{"collection": "articles", "accountability": "all", "collapse": "open"}],
+	"fields": [
+		{"collection": "articles", "field": "id", "schema": {"data_type": "uuid"}},
+		{"collection": "articles", "field": "title", "interface": "input", "width": "full",
+		 "schema": {"data_type": "string", "max_length": 120, "is_nullable": false}}
+	]
+}`
+
+func (suite *SchemaHandlersTestSuite) TestDiffSnapshot() {
+	suite.expectEmptySnapshot()
+
+	req := httptest.NewRequest("POST", "/api/v1/schema/diff", bytes.NewBufferString(targetSnapshot))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response struct {
+		Data []struct {
+			Action string `json:"action"`
+			Field  string `json:"field"`
+		} `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(suite.T(), response.Data, 3)
+	assert.Equal(suite.T(), "create_collection", response.Data[0].Action)
+	assert.Equal(suite.T(), "add_field", response.Data[1].Action)
+	assert.Equal(suite.T(), "title", response.Data[2].Field)
+}
+
+func (suite *SchemaHandlersTestSuite) TestDiffSnapshot_InvalidDocument() {
+	req := httptest.NewRequest("POST", "/api/v1/schema/diff", bytes.NewBufferString(`{"collections": []}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "version is missing")
+}
+
+func (suite *SchemaHandlersTestSuite) TestDiffSnapshot_InvalidIdentifier() {
+	suite.expectEmptySnapshot()
+
+	document := `{"version": 1, "collections": [{"collection": "bad\"name"}]}`
+	req := httptest.NewRequest("POST", "/api/v1/schema/diff", bytes.NewBufferString(document))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "invalid collection name")
+}
+
+func (suite *SchemaHandlersTestSuite) TestDiffSnapshot_NonScalarDefault() {
+	suite.expectEmptySnapshot()
+
+	document := `{"version": 1, "collections": [{"collection": "articles"}], "fields": [
+		{"collection": "articles", "field": "tags", "schema": {"data_type": "json", "default_value": {"a": 1}}}]}`
+	req := httptest.NewRequest("POST", "/api/v1/schema/diff", bytes.NewBufferString(document))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "default value of articles.tags")
+}
+
+// expectApplyStatements mocks the statements of the targetSnapshot plan
+func (suite *SchemaHandlersTestSuite) expectApplyStatements() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("INSERT INTO collections").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "articles"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectExec("INSERT INTO fields").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec("INSERT INTO fields").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec(`ALTER TABLE "articles" ADD COLUMN "title" VARCHAR\(120\) NOT NULL`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func (suite *SchemaHandlersTestSuite) TestApplySnapshot() {
+	suite.expectEmptySnapshot()
+	suite.expectApplyStatements()
+	suite.mock.ExpectCommit()
+
+	req := httptest.NewRequest("POST", "/api/v1/schema/apply", bytes.NewBufferString(targetSnapshot))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"applied":true`)
+}
+
+func (suite *SchemaHandlersTestSuite) TestApplySnapshot_DryRun() {
+	suite.expectEmptySnapshot()
+	suite.expectApplyStatements()
+	suite.mock.ExpectRollback()
+
+	req := httptest.NewRequest("POST", "/api/v1/schema/apply?dry_run=true", bytes.NewBufferString(targetSnapshot))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"dry_run":true`)
+	assert.Contains(suite.T(), w.Body.String(), `"applied":false`)
+}
+
+func (suite *SchemaHandlersTestSuite) TestApplySnapshot_FailureRollsBack() {
+	suite.expectEmptySnapshot()
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("INSERT INTO collections").WillReturnError(sql.ErrConnDone)
+	suite.mock.ExpectRollback()
+
+	req := httptest.NewRequest("POST", "/api/v1/schema/apply", bytes.NewBufferString(targetSnapshot))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Create collection articles")
+}
+
+func TestSchemaHandlersTestSuite(t *testing.T) {
+	suite.Run(t, new(SchemaHandlersTestSuite))
+}
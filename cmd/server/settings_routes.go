@@ -10,6 +10,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/hooks"
 )
 
 // SettingsHandler handles settings-related routes
@@ -17,6 +19,7 @@ type SettingsHandler struct {
 	db             *sql.DB
 	authMiddleware gin.HandlerFunc
 	optionsHandler gin.HandlerFunc
+	events         *hooks.Bus
 }
 
 // NewSettingsHandler creates a new settings handler
@@ -25,6 +28,7 @@ func NewSettingsHandler(server ServerInterface) *SettingsHandler {
 		db:             server.GetDB(),
 		authMiddleware: server.AuthMiddleware(),
 		optionsHandler: server.OptionsHandler(),
+		events:         server.Events(),
 	}
 }
 
@@ -33,9 +37,9 @@ func (h *SettingsHandler) SetupRoutes(v1 *gin.RouterGroup) {
 	// CORS preflight OPTIONS for settings endpoints
 	v1.OPTIONS("/settings", h.optionsHandler)
 
-	// Settings routes (protected)
+	// Settings routes (admin only)
 	settings := v1.Group("/settings")
-	settings.Use(h.authMiddleware)
+	settings.Use(h.authMiddleware, requireAdmin())
 	{
 		settings.GET("", h.getSettings)
 		settings.PATCH("", h.updateSettings)
@@ -103,12 +107,6 @@ type UpdateSettingsRequest struct {
 	RequireTwoFactor  *bool   `json:"require_two_factor,omitempty"`
 }
 
-// isAdmin checks if the requesting user is an admin
-func (h *SettingsHandler) isAdmin(c *gin.Context) bool {
-	currentUserRole := c.GetString("user_role")
-	return currentUserRole == "Administrator"
-}
-
 // getSettings godoc
 // @Summary Get system settings
 // @Description Retrieve current system settings (Admin only)
@@ -122,12 +120,6 @@ func (h *SettingsHandler) isAdmin(c *gin.Context) bool {
 // @Failure 500 {object} main.ErrorResponse "Internal server error"
 // @Router /settings [get]
 func (h *SettingsHandler) getSettings(c *gin.Context) {
-	// Only admins can access settings
-	if !h.isAdmin(c) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
-		return
-	}
-
 	settings, err := h.getSettingsFromDB()
 	if err != nil {
 		logrus.WithError(err).Error("Error fetching settings")
@@ -135,6 +127,7 @@ func (h *SettingsHandler) getSettings(c *gin.Context) {
 		return
 	}
 
+	emitActionHooks(c, h.events, scopeSettings, scopeSettings, hooks.ActionUpdate, settings)
 	c.JSON(http.StatusOK, SettingsResponse{Data: settings})
 }
 
@@ -153,9 +146,7 @@ func (h *SettingsHandler) getSettings(c *gin.Context) {
 // @Failure 500 {object} main.ErrorResponse "Internal server error"
 // @Router /settings [patch]
 func (h *SettingsHandler) updateSettings(c *gin.Context) {
-	// Only admins can update settings
-	if !h.isAdmin(c) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeSettings, scopeSettings, hooks.ActionUpdate, nil)) {
 		return
 	}
 
@@ -190,12 +181,6 @@ func (h *SettingsHandler) updateSettings(c *gin.Context) {
 // @Failure 500 {object} main.ErrorResponse "Connection failed"
 // @Router /settings/test-connection [post]
 func (h *SettingsHandler) testDatabaseConnection(c *gin.Context) {
-	// Only admins can test connections
-	if !h.isAdmin(c) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
-		return
-	}
-
 	// Test current database connection
 	if err := h.db.Ping(); err != nil {
 		logrus.WithError(err).Error("Database connection test failed")
@@ -219,12 +204,6 @@ func (h *SettingsHandler) testDatabaseConnection(c *gin.Context) {
 // @Failure 500 {object} main.ErrorResponse "Email test failed"
 // @Router /settings/test-email [post]
 func (h *SettingsHandler) testEmailConfiguration(c *gin.Context) {
-	// Only admins can test email
-	if !h.isAdmin(c) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
-		return
-	}
-
 	// Get current email settings
 	settings, err := h.getSettingsFromDB()
 	if err != nil {
@@ -13,6 +13,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+
+	"gorectus/internal/hooks"
 )
 
 // SettingsHandlersTestSuite is the test suite for settings handlers
@@ -69,10 +71,22 @@ func (m *mockSettingsServerInterface) AuthMiddleware() gin.HandlerFunc {
 		c.Set("user_id", "550e8400-e29b-41d4-a716-446655440000")
 		c.Set("user_email", "admin@example.com")
 		c.Set("user_role", "Administrator")
+		c.Set("admin_access", true)
+		c.Set("app_access", true)
 		c.Next()
 	}
 }
 
+func (m *mockSettingsServerInterface) OptionalAuthMiddleware() gin.HandlerFunc {
+	return m.AuthMiddleware()
+}
+
+func (m *mockSettingsServerInterface) InvalidateRoleAccess() {}
+
+func (m *mockSettingsServerInterface) Webhooks() *webhookDispatcher { return nil }
+
+func (m *mockSettingsServerInterface) Events() *hooks.Bus { return nil }
+
 func (m *mockSettingsServerInterface) OptionsHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
@@ -97,6 +111,8 @@ func (suite *SettingsHandlersTestSuite) createAuthenticatedRequest(method, url s
 	router.Use(func(c *gin.Context) {
 		c.Set("user_id", userID)
 		c.Set("user_role", role)
+		c.Set("admin_access", role == "Administrator")
+		c.Set("app_access", true)
 		c.Next()
 	})
 
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// errSingletonExists is returned when a singleton collection already has its item
+var errSingletonExists = errors.New("singleton collection already has an item")
+
+// UpdateSingleton creates or updates the item of a singleton collection
+//
+//	@Summary		Update a singleton
+//	@Description	Update the item of a singleton collection, creating it if it doesn't exist yet
+//	@Tags			items
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			collection	path		string		true	"Collection name"
+//	@Param			item		body		ItemModel	true	"Item data"
+//	@Success		200			{object}	ItemModel	"Updated item"
+//	@Success		201			{object}	ItemModel	"Created item"
+//	@Failure		400			{object}	ErrorResponse	"Invalid request payload or not a singleton"
+//	@Failure		401			{object}	ErrorResponse	"Unauthorized"
+//	@Failure		404			{object}	ErrorResponse	"Collection not found"
+//	@Failure		500			{object}	ErrorResponse	"Internal server error"
+//	@Router			/items/{collection} [patch]
+func (h *ItemsHandler) updateSingleton(c *gin.Context) {
+	// Only admins can update items
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	collectionName := c.Param("collection")
+
+	collection, ok := h.resolveCollection(c, collectionName)
+	if !ok {
+		return
+	}
+
+	if !collection.Singleton {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only singleton collections can be updated without an item ID"})
+		return
+	}
+
+	existing, err := h.getSingletonItem(collection, nil)
+	if err == sql.ErrNoRows {
+		h.insertItem(c, collection)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while fetching singleton item")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	h.patchItem(c, collection, fmt.Sprint(existing["id"]), existing)
+}
+
+// getSingletonItem gets the item of a singleton collection, only matching it
+// if it also satisfies the filter
+func (h *ItemsHandler) getSingletonItem(collection *ItemCollection, filter map[string]interface{}) (Item, error) {
+	whereClause, whereArgs, err := buildFilterSQL(filter, 1)
+	if err != nil {
+		return nil, err
+	}
+	if whereClause != "" {
+		whereClause = " WHERE " + whereClause
+	}
+
+	// Rows created before the collection became a singleton are ignored
+	query := fmt.Sprintf(`SELECT * FROM "%s"%s ORDER BY %s LIMIT 1`,
+		collection.Collection, whereClause, collection.creationOrder())
+
+	rows, err := h.db.Query(query, whereArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, sql.ErrNoRows
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	return scanItemRow(rows, columns)
+}
+
+// insertSingletonRow runs insertQuery unless the singleton already has its item.
+// The table is locked for the check so concurrent writes can't both insert.
+func (h *ItemsHandler) insertSingletonRow(tx *sql.Tx, collectionName, insertQuery string, values []interface{}) (string, error) {
+	if _, err := tx.Exec(fmt.Sprintf(`LOCK TABLE "%s" IN SHARE ROW EXCLUSIVE MODE`, collectionName)); err != nil {
+		return "", err
+	}
+
+	var exists bool
+	if err := tx.QueryRow(fmt.Sprintf(`SELECT EXISTS(SELECT 1 FROM "%s")`, collectionName)).Scan(&exists); err != nil {
+		return "", err
+	}
+	if exists {
+		return "", errSingletonExists
+	}
+
+	var newID string
+	err := tx.QueryRow(insertQuery, values...).Scan(&newID)
+	return newID, err
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// SortItemRequest moves an item before or after another item
+type SortItemRequest struct {
+	Item string `json:"item" binding:"required"`
+	To   string `json:"to" binding:"required"`
+	// Position is "before" (default) or "after"
+	Position string `json:"position"`
+}
+
+// itemOrder returns the ORDER BY clause of item lists. Collections with a
+// sort field are ordered manually, with unsorted items last.
+func (col *ItemCollection) itemOrder() string {
+	newest := "created_at DESC"
+	if !col.HasCreatedAt {
+		newest = "id"
+	}
+	if col.SortField != nil && *col.SortField != "" {
+		return fmt.Sprintf(`"%s" ASC NULLS LAST, %s`, *col.SortField, newest)
+	}
+	return newest
+}
+
+// creationOrder returns the ORDER BY clause listing items oldest first.
+// Tables without created_at are ordered by id.
+func (col *ItemCollection) creationOrder() string {
+	if col.HasCreatedAt {
+		return "created_at ASC"
+	}
+	return "id"
+}
+
+// parseSortQuery turns a ?sort query parameter such as "title,-created_at"
+// into an ORDER BY clause. A leading "-" sorts descending. An empty parameter
+// produces an empty clause.
+func parseSortQuery(raw string, allowed func(string) bool) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	var terms []string
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		direction := "ASC"
+		if strings.HasPrefix(field, "-") {
+			field = field[1:]
+			direction = "DESC"
+		}
+		if !isValidFieldName(field) || !allowed(field) {
+			return "", fmt.Errorf("unknown sort field: %s", field)
+		}
+		terms = append(terms, fmt.Sprintf(`"%s" %s`, field, direction))
+	}
+	return strings.Join(terms, ", "), nil
+}
+
+// planMove works out the new sort value of an item moved from one position to
+// before or after target, and the range [lo, hi] of sort values shifted by
+// delta to make room. It needs unique sort values, gaps are fine.
+func planMove(from, target int64, after bool) (position, lo, hi, delta int64) {
+	if from < target {
+		// Moving down: the items in between move up by one
+		position = target
+		if !after {
+			position = target - 1
+		}
+		return position, from + 1, position, -1
+	}
+
+	// Moving up: the items in between move down by one
+	position = target
+	if after {
+		position = target + 1
+	}
+	return position, position, from - 1, 1
+}
+
+// SortItems moves an item before or after another
+//
+//	@Summary		Sort items
+//	@Description	Move an item before or after another item of a collection with a sort field
+//	@Tags			items
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			collection	path		string			true	"Collection name"
+//	@Param			request		body		SortItemRequest	true	"Item to move and its new neighbour"
+//	@Success		200			{object}	map[string]interface{}	"New sort value of the item"
+//	@Failure		400			{object}	ErrorResponse	"Invalid request payload or no sort field"
+//	@Failure		401			{object}	ErrorResponse	"Unauthorized"
+//	@Failure		404			{object}	ErrorResponse	"Item not found"
+//	@Failure		500			{object}	ErrorResponse	"Internal server error"
+//	@Router			/items/{collection}/sort [post]
+func (h *ItemsHandler) sortItems(c *gin.Context) {
+	// Only admins can update items
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	collectionName := c.Param("collection")
+
+	collection, ok := h.resolveCollection(c, collectionName)
+	if !ok {
+		return
+	}
+
+	if collection.SortField == nil || *collection.SortField == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Collection has no sort field"})
+		return
+	}
+
+	var req SortItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid sort request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if req.Position == "" {
+		req.Position = "before"
+	}
+	if req.Position != "before" && req.Position != "after" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "position must be before or after"})
+		return
+	}
+	if req.Item == req.To {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "An item can't be moved next to itself"})
+		return
+	}
+
+	table := `"` + collectionName + `"`
+	column := `"` + *collection.SortField + `"`
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to start transaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	// Concurrent moves would shift the same ranges
+	if _, err := tx.Exec(`LOCK TABLE ` + table + ` IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		logrus.WithError(err).Error("Failed to lock collection for sorting")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// Shifting needs unique values, so missing and duplicate ones are
+	// renumbered once in the current order
+	var conflicts int
+	err = tx.QueryRow(`SELECT COUNT(*) - COUNT(DISTINCT ` + column + `) FROM ` + table).Scan(&conflicts)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to check sort values")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if conflicts > 0 {
+		_, err = tx.Exec(`
+			UPDATE ` + table + ` SET ` + column + ` = numbered.position
+			FROM (
+				SELECT id, ROW_NUMBER() OVER (ORDER BY ` + collection.itemOrder() + `) AS position
+				FROM ` + table + `
+			) AS numbered
+			WHERE ` + table + `.id = numbered.id
+		`)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to renumber sort values")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+	}
+
+	var from, target int64
+	err = tx.QueryRow(`SELECT `+column+` FROM `+table+` WHERE id = $1`, req.Item).Scan(&from)
+	if err == nil {
+		err = tx.QueryRow(`SELECT `+column+` FROM `+table+` WHERE id = $1`, req.To).Scan(&target)
+	}
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while fetching sort values")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	position, lo, hi, delta := planMove(from, target, req.Position == "after")
+
+	if lo <= hi {
+		_, err = tx.Exec(`UPDATE `+table+` SET `+column+` = `+column+` + $1 WHERE `+column+` BETWEEN $2 AND $3`,
+			delta, lo, hi)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to shift sort values")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE `+table+` SET `+column+` = $1 WHERE id = $2`, position, req.Item); err != nil {
+		logrus.WithError(err).Error("Failed to move item")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		logrus.WithError(err).Error("Failed to commit sort")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"collection": collectionName,
+		"item_id":    req.Item,
+		"sort":       position,
+	}).Info("Item sorted successfully")
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"item": req.Item, *collection.SortField: position}})
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanMove(t *testing.T) {
+	tests := []struct {
+		name                    string
+		from, target            int64
+		after                   bool
+		position, lo, hi, delta int64
+	}{
+		{"Down before", 1, 4, false, 3, 2, 3, -1},
+		{"Down after", 1, 4, true, 4, 2, 4, -1},
+		{"Up before", 5, 2, false, 2, 2, 4, 1},
+		{"Up after", 5, 2, true, 3, 3, 4, 1},
+		{"Already before", 3, 4, false, 3, 4, 3, -1},
+		{"Already after", 4, 3, true, 4, 4, 3, 1},
+		{"Gaps", 10, 30, false, 29, 11, 29, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			position, lo, hi, delta := planMove(tt.from, tt.target, tt.after)
+			assert.Equal(t, tt.position, position)
+			assert.Equal(t, tt.lo, lo)
+			assert.Equal(t, tt.hi, hi)
+			assert.Equal(t, tt.delta, delta)
+		})
+	}
+}
+
+func TestParseSortQuery(t *testing.T) {
+	allowed := func(field string) bool { return field == "title" || field == "created_at" }
+
+	order, err := parseSortQuery("title, -created_at", allowed)
+	require.NoError(t, err)
+	assert.Equal(t, `"title" ASC, "created_at" DESC`, order)
+
+	order, err = parseSortQuery("", allowed)
+	require.NoError(t, err)
+	assert.Empty(t, order)
+
+	for _, invalid := range []string{"password", "-", `title"; DROP TABLE items; --`, "title,"} {
+		_, err := parseSortQuery(invalid, allowed)
+		assert.Error(t, err, invalid)
+	}
+}
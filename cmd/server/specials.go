@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Values of fields.special that the items API acts on
+const (
+	specialUUID        = "uuid"
+	specialDateCreated = "date-created"
+	specialDateUpdated = "date-updated"
+	specialUserCreated = "user-created"
+	specialUserUpdated = "user-updated"
+	specialHash        = "hash"
+	// Translations fields have no column; their values live in a junction collection
+	specialTranslations = "translations"
+	// File fields are a column referencing a file
+	specialFile = "file"
+	// Files fields have no column; the files they hold live in a junction collection
+	specialFiles = "files"
+)
+
+// Item write actions special hooks run for
+const (
+	actionCreate = "create"
+	actionUpdate = "update"
+)
+
+// specialHook fills in or transforms a field's value before an item is written.
+// It returns the new value and whether the field should be written at all.
+type specialHook func(ctx specialContext, value interface{}, present bool) (interface{}, bool, error)
+
+// specialContext carries what hooks need to know about the write
+type specialContext struct {
+	action string
+	userID string
+	now    time.Time
+}
+
+// specialHooks maps each special value to its hook
+var specialHooks = map[string]specialHook{
+	specialUUID: func(ctx specialContext, value interface{}, present bool) (interface{}, bool, error) {
+		if ctx.action != actionCreate || (present && value != nil) {
+			return value, present, nil
+		}
+		id, err := newUUID()
+		return id, err == nil, err
+	},
+	specialDateCreated: func(ctx specialContext, value interface{}, present bool) (interface{}, bool, error) {
+		if ctx.action != actionCreate {
+			return nil, false, nil
+		}
+		return ctx.now, true, nil
+	},
+	specialDateUpdated: func(ctx specialContext, value interface{}, present bool) (interface{}, bool, error) {
+		return ctx.now, true, nil
+	},
+	specialUserCreated: func(ctx specialContext, value interface{}, present bool) (interface{}, bool, error) {
+		if ctx.action != actionCreate {
+			return nil, false, nil
+		}
+		return userValue(ctx.userID), true, nil
+	},
+	specialUserUpdated: func(ctx specialContext, value interface{}, present bool) (interface{}, bool, error) {
+		return userValue(ctx.userID), true, nil
+	},
+	specialHash: func(ctx specialContext, value interface{}, present bool) (interface{}, bool, error) {
+		if !present || value == nil {
+			return value, present, nil
+		}
+		// Every string is hashed, the empty one included, so no plain value
+		// is ever stored
+		text, ok := value.(string)
+		if !ok {
+			return nil, false, fmt.Errorf("cannot hash a %T value", value)
+		}
+		hashed, err := bcrypt.GenerateFromPassword([]byte(text), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, false, err
+		}
+		return string(hashed), true, nil
+	},
+}
+
+// maxHashLength is the number of bytes bcrypt hashes; longer values are rejected
+const maxHashLength = 72
+
+// checkSpecialValue checks a value against the limits of the field's special
+// hooks. It returns a description of the problem, or "" when the value fits.
+func checkSpecialValue(field FieldInfo, value interface{}) string {
+	if value == nil || !hasSpecial(field.Special, specialHash) {
+		return ""
+	}
+	text, ok := value.(string)
+	if !ok {
+		return "must be a string to be hashed"
+	}
+	if len(text) > maxHashLength {
+		return fmt.Sprintf("must be at most %d bytes to be hashed", maxHashLength)
+	}
+	return ""
+}
+
+// applySpecials runs the special hooks of every field on data, which is
+// changed in place. Values sent for fields the hooks manage are replaced.
+func applySpecials(fields []FieldInfo, data Item, action, userID string) error {
+	ctx := specialContext{action: action, userID: userID, now: time.Now().UTC()}
+
+	for _, field := range fields {
+		for _, special := range field.Special {
+			hook, ok := specialHooks[special]
+			if !ok {
+				continue
+			}
+
+			value, present := data[field.Field]
+			value, write, err := hook(ctx, value, present)
+			if err != nil {
+				return fmt.Errorf("%s hook on field %s: %w", special, field.Field, err)
+			}
+			if write {
+				data[field.Field] = value
+			} else {
+				delete(data, field.Field)
+			}
+		}
+	}
+
+	return nil
+}
+
+// specialProvidesValue reports whether a hook fills in the field on create,
+// so it doesn't have to be sent even when it is required
+func specialProvidesValue(field FieldInfo) bool {
+	for _, special := range field.Special {
+		switch special {
+		case specialUUID, specialDateCreated, specialDateUpdated, specialUserCreated, specialUserUpdated:
+			return true
+		}
+	}
+	return false
+}
+
+// redactHashes removes hashed values, which are never returned
+func redactHashes(fields []FieldInfo, item Item) Item {
+	if item == nil {
+		return nil
+	}
+	for _, field := range fields {
+		for _, special := range field.Special {
+			if special == specialHash {
+				delete(item, field.Field)
+			}
+		}
+	}
+	return item
+}
+
+// userValue returns the user ID to store, or nil for requests without a user
+func userValue(userID string) interface{} {
+	if userID == "" {
+		return nil
+	}
+	return userID
+}
+
+// newUUID generates a random (version 4) UUID
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestApplySpecials_Create(t *testing.T) {
+	fields := []FieldInfo{
+		{Field: "key", Special: []string{specialUUID}},
+		{Field: "created_on", Special: []string{specialDateCreated}},
+		{Field: "updated_on", Special: []string{specialDateUpdated}},
+		{Field: "created_by", Special: []string{specialUserCreated}},
+		{Field: "updated_by", Special: []string{specialUserUpdated}},
+		{Field: "password", Special: []string{specialHash}},
+		{Field: "tags", Special: []string{"cast-json"}},
+	}
+
+	data := Item{"created_by": "someone-else", "password": "hunter2", "tags": []interface{}{"a"}}
+	require.NoError(t, applySpecials(fields, data, actionCreate, "user-1"))
+
+	assert.Regexp(t, uuidRegexp, data["key"])
+	assert.IsType(t, time.Time{}, data["created_on"])
+	assert.IsType(t, time.Time{}, data["updated_on"])
+	assert.Equal(t, "user-1", data["created_by"])
+	assert.Equal(t, "user-1", data["updated_by"])
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(data["password"].(string)), []byte("hunter2")))
+	assert.Equal(t, []interface{}{"a"}, data["tags"])
+}
+
+func TestApplySpecials_Update(t *testing.T) {
+	fields := []FieldInfo{
+		{Field: "key", Special: []string{specialUUID}},
+		{Field: "created_on", Special: []string{specialDateCreated}},
+		{Field: "updated_on", Special: []string{specialDateUpdated}},
+		{Field: "created_by", Special: []string{specialUserCreated}},
+		{Field: "updated_by", Special: []string{specialUserUpdated}},
+		{Field: "password", Special: []string{specialHash}},
+	}
+
+	data := Item{"created_by": "user-2", "created_on": "2020-01-01"}
+	require.NoError(t, applySpecials(fields, data, actionUpdate, "user-1"))
+
+	// Creation stamps are kept and nothing is generated or hashed
+	assert.Equal(t, Item{"updated_on": data["updated_on"], "updated_by": "user-1"}, data)
+	assert.IsType(t, time.Time{}, data["updated_on"])
+}
+
+func TestSpecialProvidesValue(t *testing.T) {
+	assert.True(t, specialProvidesValue(FieldInfo{Special: []string{"cast-json", specialUserCreated}}))
+	assert.False(t, specialProvidesValue(FieldInfo{Special: []string{specialHash}}))
+	assert.False(t, specialProvidesValue(FieldInfo{}))
+}
+
+func TestNewUUID(t *testing.T) {
+	first, err := newUUID()
+	require.NoError(t, err)
+	second, err := newUUID()
+	require.NoError(t, err)
+
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, first)
+	assert.NotEqual(t, first, second)
+}
+
+func TestApplySpecials_HashesHashLikeValues(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	// A value shaped like a hash is a secret like any other
+	fields := []FieldInfo{{Field: "password", Special: []string{specialHash}}}
+	data := Item{"password": string(hashed)}
+	require.NoError(t, applySpecials(fields, data, actionCreate, ""))
+
+	assert.NotEqual(t, string(hashed), data["password"])
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(data["password"].(string)), hashed))
+}
+
+func TestValidateItem_HashTooLong(t *testing.T) {
+	fields := []FieldInfo{{Field: "password", Special: []string{specialHash}}}
+
+	assert.Empty(t, validateItem(fields, Item{"password": strings.Repeat("a", 72)}, nil))
+	violations := validateItem(fields, Item{"password": strings.Repeat("a", 73)}, nil)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "Field 'password' must be at most 72 bytes to be hashed", violations[0].Message)
+}
+
+func TestApplySpecials_HashesEmptyString(t *testing.T) {
+	fields := []FieldInfo{{Field: "password", Special: []string{specialHash}}}
+
+	data := Item{"password": ""}
+	require.NoError(t, applySpecials(fields, data, actionCreate, ""))
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(data["password"].(string)), []byte("")))
+
+	// Null clears the field rather than being hashed
+	data = Item{"password": nil}
+	require.NoError(t, applySpecials(fields, data, actionUpdate, ""))
+	assert.Equal(t, Item{"password": nil}, data)
+
+	assert.Error(t, applySpecials(fields, Item{"password": 1234.0}, actionCreate, ""))
+}
+
+func TestValidateItem_HashNotString(t *testing.T) {
+	fields := []FieldInfo{{Field: "password", Special: []string{specialHash}}}
+
+	for _, value := range []interface{}{1234.0, true, map[string]interface{}{"a": "b"}} {
+		violations := validateItem(fields, Item{"password": value}, nil)
+		require.Len(t, violations, 1, value)
+		assert.Equal(t, "Field 'password' must be a string to be hashed", violations[0].Message)
+	}
+	assert.Empty(t, validateItem(fields, Item{"password": nil}, nil))
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/schema"
+)
+
+// translationLanguageField is the junction column holding a translation's language code
+const translationLanguageField = "language"
+
+// junctionCollection returns the junction collection of a field without a
+// column: one row per item and language for translations fields, per item and
+// file for files fields
+func junctionCollection(collectionName, fieldName string) string {
+	return collectionName + "_" + fieldName
+}
+
+// junctionParentField returns the junction column pointing to the item
+func junctionParentField(collectionName string) string {
+	return collectionName + "_id"
+}
+
+// hasJunction reports whether a field keeps its values in a junction collection
+func hasJunction(special []string) bool {
+	return hasSpecial(special, specialTranslations) || hasSpecial(special, specialFiles)
+}
+
+// hasSpecial reports whether a special list contains value
+func hasSpecial(special []string, value string) bool {
+	for _, s := range special {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
+
+// createTranslationsCollection sets up the junction collection of a
+// translations field. Translated fields are then added to it like to any
+// other collection.
+func createTranslationsCollection(tx *sql.Tx, collectionName, fieldName string) error {
+	junction := junctionCollection(collectionName, fieldName)
+	parentField := junctionParentField(collectionName)
+
+	idType, err := createJunctionTable(tx, collectionName, junction)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`ALTER TABLE "` + junction + `"
+		ADD COLUMN "` + parentField + `" ` + idType + ` NOT NULL REFERENCES "` + collectionName + `" (id) ON DELETE CASCADE,
+		ADD COLUMN "` + translationLanguageField + `" VARCHAR(10) NOT NULL,
+		ADD CONSTRAINT "uq_` + junction + `_language" UNIQUE ("` + parentField + `", "` + translationLanguageField + `")`)
+	if err != nil {
+		return err
+	}
+
+	languageInterface := "input"
+	for _, field := range []Field{
+		{Field: parentField, Required: true, Hidden: true},
+		{Field: translationLanguageField, Interface: &languageInterface, Required: true},
+	} {
+		if err := insertFieldMetadata(tx, junction, &field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createJunctionTable adds a hidden collection for the junction of a field of
+// collectionName and returns the type of the items' ID, which the column
+// pointing to them must match
+func createJunctionTable(tx *sql.Tx, collectionName, junction string) (string, error) {
+	var idType string
+	err := tx.QueryRow(`
+		SELECT data_type FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1 AND column_name = 'id'
+	`, collectionName).Scan(&idType)
+	if err != nil {
+		return "", fmt.Errorf("loading id type of %s: %w", collectionName, err)
+	}
+
+	hidden := true
+	if err := insertCollection(tx, &CreateCollectionRequest{Collection: junction, Hidden: &hidden}); err != nil {
+		return "", err
+	}
+	if err := createCollectionTable(tx, junction); err != nil {
+		return "", err
+	}
+	return idType, nil
+}
+
+// dropJunctionCollection removes the junction collection of a field
+func dropJunctionCollection(tx *sql.Tx, collectionName, fieldName string) error {
+	junction := junctionCollection(collectionName, fieldName)
+
+	if _, err := tx.Exec("DELETE FROM fields WHERE collection = $1", junction); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM collections WHERE collection = $1", junction); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`DROP TABLE IF EXISTS "` + junction + `" CASCADE`)
+	return err
+}
+
+// pickTranslation returns the translation in lang, or in the fallback
+// language when there is none
+func pickTranslation(byLanguage map[string]Item, lang, fallback string) Item {
+	if translation, ok := byLanguage[lang]; ok {
+		return translation
+	}
+	if fallback != "" {
+		return byLanguage[fallback]
+	}
+	return nil
+}
+
+// mergeTranslation copies the translated fields of a junction row into item
+func mergeTranslation(item, translation Item, parentField string) {
+	for key, value := range translation {
+		if schema.IsSystemColumn(key) || key == parentField || key == translationLanguageField {
+			continue
+		}
+		item[key] = value
+	}
+}
+
+// applyTranslations merges the translated fields of the language asked for
+// with ?lang into items, falling back to settings.default_language for items
+// without a translation in that language.
+func (h *ItemsHandler) applyTranslations(c *gin.Context, collectionName string, fields []FieldInfo, items []Item) bool {
+	lang := c.Query("lang")
+	if lang == "" || len(items) == 0 {
+		return true
+	}
+
+	var translationFields []string
+	for _, field := range fields {
+		if hasSpecial(field.Special, specialTranslations) {
+			translationFields = append(translationFields, field.Field)
+		}
+	}
+	if len(translationFields) == 0 {
+		return true
+	}
+
+	fallback, err := h.defaultLanguage()
+	if err != nil {
+		logrus.WithError(err).Error("Error loading default language")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+
+	for _, field := range translationFields {
+		if err := h.mergeTranslations(c, collectionName, field, lang, fallback, items); err != nil {
+			logrus.WithError(err).WithField("field", field).Error("Error loading translations")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return false
+		}
+	}
+
+	return true
+}
+
+// mergeTranslations loads the translations of one translations field for
+// items and merges them in. Anonymous requests only get the translations and
+// fields the Public role may read, and none without a read permission on the
+// translations collection.
+func (h *ItemsHandler) mergeTranslations(c *gin.Context, collectionName, fieldName, lang, fallback string, items []Item) error {
+	junction := junctionCollection(collectionName, fieldName)
+	parentField := junctionParentField(collectionName)
+
+	var permission *ItemPermission
+	if c.GetBool("is_public") {
+		var err error
+		permission, err = h.getRolePermission(publicRoleID, junction, "read")
+		if err == sql.ErrNoRows {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	languages := []string{lang}
+	if fallback != "" && fallback != lang {
+		languages = append(languages, fallback)
+	}
+
+	junctionFields, err := h.getFieldsByCollection(junction)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		if item != nil {
+			ids = append(ids, fmt.Sprint(item["id"]))
+		}
+	}
+
+	rows, err := h.db.Query(fmt.Sprintf(`SELECT * FROM "%s" WHERE "%s"::text = ANY($1) AND "%s" = ANY($2)`,
+		junction, parentField, translationLanguageField), pq.Array(ids), pq.Array(languages))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	translations := make(map[string]map[string]Item)
+	for rows.Next() {
+		row, err := scanItemRow(rows, columns)
+		if err != nil {
+			return err
+		}
+		if matches, err := matchesFilter(permission.filter(), row); err != nil || !matches {
+			continue
+		}
+
+		parentID := fmt.Sprint(row[parentField])
+		if translations[parentID] == nil {
+			translations[parentID] = make(map[string]Item)
+		}
+		translations[parentID][fmt.Sprint(row[translationLanguageField])] = row
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		translation := pickTranslation(translations[fmt.Sprint(item["id"])], lang, fallback)
+		if translation != nil {
+			mergeTranslation(item, permission.applyFields(outputItem(c, junctionFields, translation)), parentField)
+		}
+	}
+
+	return nil
+}
+
+// defaultLanguage returns settings.default_language, or "" when it isn't set
+func (h *ItemsHandler) defaultLanguage() (string, error) {
+	var language sql.NullString
+	err := h.db.QueryRow(`SELECT default_language FROM settings LIMIT 1`).Scan(&language)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return language.String, err
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickTranslation(t *testing.T) {
+	byLanguage := map[string]Item{
+		"de":    {"title": "Hallo"},
+		"en-US": {"title": "Hello"},
+	}
+
+	assert.Equal(t, Item{"title": "Hallo"}, pickTranslation(byLanguage, "de", "en-US"))
+	assert.Equal(t, Item{"title": "Hello"}, pickTranslation(byLanguage, "fr", "en-US"))
+	assert.Nil(t, pickTranslation(byLanguage, "fr", ""))
+	assert.Nil(t, pickTranslation(nil, "de", "en-US"))
+}
+
+func TestMergeTranslation(t *testing.T) {
+	item := Item{"id": "a1", "title": "Hello", "status": "published"}
+	translation := Item{"id": "t1", "articles_id": "a1", "language": "de", "title": "Hallo", "created_at": "2024-01-01"}
+
+	mergeTranslation(item, translation, "articles_id")
+
+	assert.Equal(t, Item{"id": "a1", "title": "Hallo", "status": "published"}, item)
+}
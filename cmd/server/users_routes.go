@@ -9,21 +9,27 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
+
+	"gorectus/internal/hooks"
 )
 
 // UsersHandler handles user-related routes
 type UsersHandler struct {
-	db             *sql.DB
-	authMiddleware gin.HandlerFunc
-	optionsHandler gin.HandlerFunc
+	db                   *sql.DB
+	authMiddleware       gin.HandlerFunc
+	optionsHandler       gin.HandlerFunc
+	invalidateRoleAccess func()
+	events               *hooks.Bus
 }
 
 // NewUsersHandler creates a new users handler
 func NewUsersHandler(server ServerInterface) *UsersHandler {
 	return &UsersHandler{
-		db:             server.GetDB(),
-		authMiddleware: server.AuthMiddleware(),
-		optionsHandler: server.OptionsHandler(),
+		db:                   server.GetDB(),
+		authMiddleware:       server.AuthMiddleware(),
+		optionsHandler:       server.OptionsHandler(),
+		invalidateRoleAccess: server.InvalidateRoleAccess,
+		events:               server.Events(),
 	}
 }
 
@@ -94,34 +100,10 @@ type UpdateUserRequest struct {
 	EmailNotifications *bool   `json:"email_notifications"`
 }
 
-// isAdminOrSelf checks if the requesting user is an admin or the user themselves
-func (h *UsersHandler) isAdminOrSelf(c *gin.Context, targetUserID string) bool {
-	currentUserID := c.GetString("user_id")
-	currentUserRole := c.GetString("user_role")
-
-	// Allow if user is admin
-	if currentUserRole == "Administrator" {
-		return true
-	}
-
-	// Allow if user is accessing their own data
-	if currentUserID == targetUserID {
-		return true
-	}
-
-	return false
-}
-
-// isAdmin checks if the requesting user is an admin
-func (h *UsersHandler) isAdmin(c *gin.Context) bool {
-	currentUserRole := c.GetString("user_role")
-	return currentUserRole == "Administrator"
-}
-
 // Users handlers implementations
 func (h *UsersHandler) getUsers(c *gin.Context) {
 	// Only admins can list all users
-	if !h.isAdmin(c) {
+	if !isAdmin(c) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
 		return
 	}
@@ -200,11 +182,15 @@ func (h *UsersHandler) getUsers(c *gin.Context) {
 
 func (h *UsersHandler) createUser(c *gin.Context) {
 	// Only admins can create users
-	if !h.isAdmin(c) {
+	if !isAdmin(c) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
 		return
 	}
 
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeUsers, scopeUsers, hooks.ActionCreate, nil)) {
+		return
+	}
+
 	var req CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logrus.WithError(err).Error("Invalid create user request payload")
@@ -276,6 +262,7 @@ func (h *UsersHandler) createUser(c *gin.Context) {
 		"email":      req.Email,
 		"created_by": c.GetString("user_id"),
 	}).Info("User created successfully")
+	emitActionHooks(c, h.events, scopeUsers, scopeUsers, hooks.ActionCreate, user, userID)
 
 	c.JSON(http.StatusCreated, gin.H{"data": user})
 }
@@ -284,7 +271,7 @@ func (h *UsersHandler) getUser(c *gin.Context) {
 	userID := c.Param("id")
 
 	// Check authorization
-	if !h.isAdminOrSelf(c, userID) {
+	if !isAdminOrSelf(c, userID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
@@ -306,11 +293,15 @@ func (h *UsersHandler) updateUser(c *gin.Context) {
 	userID := c.Param("id")
 
 	// Check authorization
-	if !h.isAdminOrSelf(c, userID) {
+	if !isAdminOrSelf(c, userID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
 
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeUsers, scopeUsers, hooks.ActionUpdate, nil, userID)) {
+		return
+	}
+
 	var req UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logrus.WithError(err).Error("Invalid update user request payload")
@@ -332,19 +323,14 @@ func (h *UsersHandler) updateUser(c *gin.Context) {
 	}
 
 	// Non-admin users can only update certain fields and only their own data
-	currentUserRole := c.GetString("user_role")
-	currentUserID := c.GetString("user_id")
-	isAdmin := currentUserRole == "Administrator"
-	isSelf := currentUserID == userID
-
-	// If not admin and trying to update someone else's data, deny
-	if !isAdmin && !isSelf {
+	if !isAdminOrSelf(c, userID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
 
 	// Non-admin users cannot change certain fields
-	if !isAdmin {
+	callerIsAdmin := isAdmin(c)
+	if !callerIsAdmin {
 		if req.Status != nil || req.RoleID != nil {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions to update these fields"})
 			return
@@ -411,12 +397,12 @@ func (h *UsersHandler) updateUser(c *gin.Context) {
 		args = append(args, *req.Theme)
 		argIndex++
 	}
-	if req.Status != nil && isAdmin {
+	if req.Status != nil && callerIsAdmin {
 		updateFields = append(updateFields, "status = $"+strconv.Itoa(argIndex))
 		args = append(args, *req.Status)
 		argIndex++
 	}
-	if req.RoleID != nil && isAdmin {
+	if req.RoleID != nil && callerIsAdmin {
 		updateFields = append(updateFields, "role_id = $"+strconv.Itoa(argIndex))
 		args = append(args, *req.RoleID)
 		argIndex++
@@ -451,6 +437,9 @@ func (h *UsersHandler) updateUser(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
+	if req.RoleID != nil {
+		h.invalidateRoleAccess()
+	}
 
 	// Fetch updated user
 	user, err := h.getUserByID(userID)
@@ -464,6 +453,7 @@ func (h *UsersHandler) updateUser(c *gin.Context) {
 		"user_id":    userID,
 		"updated_by": c.GetString("user_id"),
 	}).Info("User updated successfully")
+	emitActionHooks(c, h.events, scopeUsers, scopeUsers, hooks.ActionUpdate, user, userID)
 
 	c.JSON(http.StatusOK, gin.H{"data": user})
 }
@@ -472,7 +462,7 @@ func (h *UsersHandler) deleteUser(c *gin.Context) {
 	userID := c.Param("id")
 
 	// Only admins can delete users
-	if !h.isAdmin(c) {
+	if !isAdmin(c) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
 		return
 	}
@@ -497,6 +487,11 @@ func (h *UsersHandler) deleteUser(c *gin.Context) {
 		return
 	}
 
+	event := requestEvent(c, scopeUsers, scopeUsers, hooks.ActionDelete, nil, userID)
+	if !runFilterHooks(c, h.events, &event) {
+		return
+	}
+
 	// Delete user
 	_, err = h.db.Exec("DELETE FROM users WHERE id = $1", userID)
 	if err != nil {
@@ -504,11 +499,13 @@ func (h *UsersHandler) deleteUser(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
+	h.invalidateRoleAccess()
 
 	logrus.WithFields(logrus.Fields{
 		"user_id":    userID,
 		"deleted_by": currentUserID,
 	}).Info("User deleted successfully")
+	emitActionHooks(c, h.events, scopeUsers, scopeUsers, hooks.ActionDelete, nil, userID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
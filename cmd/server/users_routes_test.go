@@ -15,6 +15,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+
+	"gorectus/internal/hooks"
 )
 
 // Test suite for user handlers
@@ -75,10 +77,22 @@ func (m *mockServerInterface) AuthMiddleware() gin.HandlerFunc {
 		c.Set("user_id", "550e8400-e29b-41d4-a716-446655440000")
 		c.Set("user_email", "admin@example.com")
 		c.Set("user_role", "Administrator")
+		c.Set("admin_access", true)
+		c.Set("app_access", true)
 		c.Next()
 	}
 }
 
+func (m *mockServerInterface) OptionalAuthMiddleware() gin.HandlerFunc {
+	return m.AuthMiddleware()
+}
+
+func (m *mockServerInterface) InvalidateRoleAccess() {}
+
+func (m *mockServerInterface) Webhooks() *webhookDispatcher { return nil }
+
+func (m *mockServerInterface) Events() *hooks.Bus { return nil }
+
 func (m *mockServerInterface) OptionsHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
@@ -98,6 +112,8 @@ func (suite *UserHandlersTestSuite) createAuthenticatedRequest(method, url strin
 		c.Set("user_id", userID)
 		c.Set("user_email", "test@example.com")
 		c.Set("user_role", role)
+		c.Set("admin_access", role == "Administrator")
+		c.Set("app_access", true)
 		c.Next()
 	}
 
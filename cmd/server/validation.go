@@ -0,0 +1,325 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Keys of fields.validation that hold rules for the field's own value. Any
+// other key is part of a filter the whole item must match, using the same
+// syntax as permission filters: {"_or": [{"status": "draft"}, {"slug": {"_nnull": true}}]}.
+var validationRuleKeys = map[string]bool{
+	"regex":      true,
+	"min":        true,
+	"max":        true,
+	"min_length": true,
+	"max_length": true,
+	"enum":       true,
+	"format":     true,
+}
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Layouts accepted for temporal columns
+var (
+	dateLayouts      = []string{"2006-01-02"}
+	timeLayouts      = []string{"15:04", "15:04:05", "15:04:05.999999"}
+	timestampLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+)
+
+// ValidationError is a single field violation returned to the client
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// itemWriteError is a write of an item that fails validation. Violations
+// lists the fields at fault, when the error is about field values.
+type itemWriteError struct {
+	Message    string
+	Violations []ValidationError
+}
+
+func (e *itemWriteError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+	if len(messages) == 0 {
+		return e.Message
+	}
+	return e.Message + ": " + strings.Join(messages, "; ")
+}
+
+// response is the body of the 400 response to the write
+func (e *itemWriteError) response() gin.H {
+	if len(e.Violations) == 0 {
+		return gin.H{"error": e.Message}
+	}
+	return gin.H{"error": e.Message, "errors": e.Violations}
+}
+
+// checkItemWrite runs the checks a write of data to an item must pass:
+// readonly and required fields, with conditions resolved against the item
+// as it will be after the write, then column types and validation rules.
+// existing is the stored item on update and nil on create. It returns the
+// fields with their conditions resolved.
+func checkItemWrite(fields []FieldInfo, data, existing Item) ([]FieldInfo, *itemWriteError) {
+	merged := make(Item, len(existing)+len(data))
+	for key, value := range existing {
+		merged[key] = value
+	}
+	for key, value := range data {
+		merged[key] = value
+	}
+
+	// Only the fields being written are checked against readonly
+	resolved := resolveFieldConditions(fields, merged)
+	if violations := readonlyViolations(resolved, data); len(violations) > 0 {
+		return nil, &itemWriteError{Message: "Validation failed", Violations: violations}
+	}
+
+	for i, field := range resolved {
+		if !field.Required || merged[field.Field] != nil {
+			continue
+		}
+		if existing == nil {
+			// Fields filled in by a special hook don't have to be sent
+			if specialProvidesValue(field) {
+				continue
+			}
+		} else if _, written := data[field.Field]; !written && fields[i].Required {
+			// A required field can't be cleared, and a condition that starts
+			// to apply can require a field the update doesn't touch. Items
+			// that were stored without a required value can still be updated
+			// otherwise.
+			continue
+		}
+		return nil, &itemWriteError{Message: fmt.Sprintf("Required field '%s' is missing", field.Field)}
+	}
+
+	if violations := validateItem(resolved, data, merged); len(violations) > 0 {
+		return nil, &itemWriteError{Message: "Validation failed", Violations: violations}
+	}
+	return resolved, nil
+}
+
+// validateItem checks the fields present in data against their column type
+// and validation rules. item is the full item after the write (equal to data
+// on create) and is what filter rules are evaluated against. A field's
+// validation_message replaces the default message of any of its violations.
+func validateItem(fields []FieldInfo, data Item, item Item) []ValidationError {
+	var violations []ValidationError
+
+	for _, field := range fields {
+		value, present := data[field.Field]
+		if !present {
+			continue
+		}
+
+		message := checkDataType(field.Schema, value)
+		if message == "" {
+			message = checkSpecialValue(field, value)
+		}
+		if message == "" && value != nil {
+			message = checkValidationRules(field.Validation, value)
+		}
+		if message == "" {
+			if filter := validationFilter(field.Validation); len(filter) > 0 {
+				matches, err := matchesFilter(filter, item)
+				if err != nil {
+					message = "has an invalid validation rule: " + err.Error()
+				} else if !matches {
+					message = "does not satisfy the validation rule"
+				}
+			}
+		}
+		if message == "" {
+			continue
+		}
+
+		if field.ValidationMessage != nil && *field.ValidationMessage != "" {
+			message = *field.ValidationMessage
+		} else {
+			message = fmt.Sprintf("Field '%s' %s", field.Field, message)
+		}
+		violations = append(violations, ValidationError{Field: field.Field, Message: message})
+	}
+
+	return violations
+}
+
+// checkDataType checks that a JSON value can be stored in the field's column.
+// It returns a description of the problem, or "" when the value fits.
+func checkDataType(schema *FieldSchema, value interface{}) string {
+	if schema == nil || schema.DataType == "" {
+		return ""
+	}
+
+	if value == nil {
+		if schema.IsNullable != nil && !*schema.IsNullable {
+			return "must not be null"
+		}
+		return ""
+	}
+
+	switch schema.DataType {
+	case "string", "text":
+		text, ok := value.(string)
+		if !ok {
+			return "must be a string"
+		}
+		if schema.DataType == "string" && schema.MaxLength != nil &&
+			len([]rune(text)) > *schema.MaxLength {
+			return fmt.Sprintf("must be at most %d characters", *schema.MaxLength)
+		}
+	case "integer", "bigint":
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			return "must be an integer"
+		}
+		if schema.DataType == "integer" && (number < math.MinInt32 || number > math.MaxInt32) {
+			return "is out of range for an integer"
+		}
+	case "decimal":
+		if _, ok := value.(float64); !ok {
+			return "must be a number"
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return "must be a boolean"
+		}
+	case "uuid":
+		text, ok := value.(string)
+		if !ok || !uuidRegexp.MatchString(text) {
+			return "must be a UUID"
+		}
+	case "date":
+		if !parsesAs(value, dateLayouts) {
+			return "must be a date (YYYY-MM-DD)"
+		}
+	case "time":
+		if !parsesAs(value, timeLayouts) {
+			return "must be a time (HH:MM[:SS])"
+		}
+	case "timestamp":
+		if !parsesAs(value, timestampLayouts) {
+			return "must be a timestamp (RFC 3339)"
+		}
+	}
+
+	return ""
+}
+
+// checkValidationRules evaluates the rules that apply to the field's own value
+func checkValidationRules(validation map[string]interface{}, value interface{}) string {
+	if len(validation) == 0 {
+		return ""
+	}
+
+	if pattern, ok := validation["regex"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "has an invalid regex rule"
+		}
+		if !re.MatchString(fmt.Sprint(value)) {
+			return "does not match the required pattern"
+		}
+	}
+
+	if number, ok := value.(float64); ok {
+		if min, ok := validation["min"].(float64); ok && number < min {
+			return fmt.Sprintf("must be at least %v", min)
+		}
+		if max, ok := validation["max"].(float64); ok && number > max {
+			return fmt.Sprintf("must be at most %v", max)
+		}
+	}
+
+	if length, unit, ok := valueLength(value); ok {
+		if min, ok := validation["min_length"].(float64); ok && float64(length) < min {
+			return fmt.Sprintf("must have at least %v %s", min, unit)
+		}
+		if max, ok := validation["max_length"].(float64); ok && float64(length) > max {
+			return fmt.Sprintf("must have at most %v %s", max, unit)
+		}
+	}
+
+	if allowed, ok := validation["enum"].([]interface{}); ok {
+		found := false
+		for _, option := range allowed {
+			if valuesEqual(option, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "must be one of the allowed values"
+		}
+	}
+
+	if format, ok := validation["format"].(string); ok {
+		text, _ := value.(string)
+		switch format {
+		case "email":
+			address, err := mail.ParseAddress(text)
+			if err != nil || address.Address != text {
+				return "must be a valid email address"
+			}
+		case "url":
+			parsed, err := url.ParseRequestURI(text)
+			if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+				return "must be a valid URL"
+			}
+		}
+	}
+
+	return ""
+}
+
+// validationFilter returns the filter part of a field's validation
+func validationFilter(validation map[string]interface{}) map[string]interface{} {
+	var filter map[string]interface{}
+	for key, value := range validation {
+		if validationRuleKeys[key] {
+			continue
+		}
+		if filter == nil {
+			filter = make(map[string]interface{})
+		}
+		filter[key] = value
+	}
+	return filter
+}
+
+// valueLength returns the length of strings (in characters) and arrays (in items)
+func valueLength(value interface{}) (int, string, bool) {
+	switch v := value.(type) {
+	case string:
+		return len([]rune(v)), "characters", true
+	case []interface{}:
+		return len(v), "items", true
+	default:
+		return 0, "", false
+	}
+}
+
+func parsesAs(value interface{}, layouts []string) bool {
+	text, ok := value.(string)
+	if !ok {
+		return false
+	}
+	for _, layout := range layouts {
+		if _, err := time.Parse(layout, text); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckDataType(t *testing.T) {
+	notNull := false
+	length := 5
+
+	tests := []struct {
+		name     string
+		schema   *FieldSchema
+		value    interface{}
+		expected string
+	}{
+		{"No column", nil, "anything", ""},
+		{"String", &FieldSchema{DataType: "string"}, "abc", ""},
+		{"String too long", &FieldSchema{DataType: "string", MaxLength: &length}, "abcdef", "must be at most 5 characters"},
+		{"Number for string", &FieldSchema{DataType: "text"}, float64(1), "must be a string"},
+		{"Integer", &FieldSchema{DataType: "integer"}, float64(42), ""},
+		{"Fraction for integer", &FieldSchema{DataType: "integer"}, 4.2, "must be an integer"},
+		{"Integer overflow", &FieldSchema{DataType: "integer"}, float64(1 << 40), "is out of range for an integer"},
+		{"Bigint", &FieldSchema{DataType: "bigint"}, float64(1 << 40), ""},
+		{"Boolean", &FieldSchema{DataType: "boolean"}, "true", "must be a boolean"},
+		{"UUID", &FieldSchema{DataType: "uuid"}, "550e8400-e29b-41d4-a716-446655440000", ""},
+		{"Invalid UUID", &FieldSchema{DataType: "uuid"}, "550e8400", "must be a UUID"},
+		{"Date", &FieldSchema{DataType: "date"}, "2024-02-29", ""},
+		{"Invalid date", &FieldSchema{DataType: "date"}, "2024-02-30", "must be a date (YYYY-MM-DD)"},
+		{"Timestamp", &FieldSchema{DataType: "timestamp"}, "2024-01-01T10:00:00Z", ""},
+		{"JSON accepts anything", &FieldSchema{DataType: "json"}, map[string]interface{}{"a": 1}, ""},
+		{"Null in nullable column", &FieldSchema{DataType: "string"}, nil, ""},
+		{"Null in not null column", &FieldSchema{DataType: "string", IsNullable: &notNull}, nil, "must not be null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, checkDataType(tt.schema, tt.value))
+		})
+	}
+}
+
+func TestCheckValidationRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		validation map[string]interface{}
+		value      interface{}
+		expected   string
+	}{
+		{"No rules", nil, "x", ""},
+		{"Regex", map[string]interface{}{"regex": "^[A-Z]{3}$"}, "ABC", ""},
+		{"Regex mismatch", map[string]interface{}{"regex": "^[A-Z]{3}$"}, "abc", "does not match the required pattern"},
+		{"Min", map[string]interface{}{"min": float64(1)}, float64(0), "must be at least 1"},
+		{"Max", map[string]interface{}{"max": float64(10)}, float64(10), ""},
+		{"Min length", map[string]interface{}{"min_length": float64(2)}, "é", "must have at least 2 characters"},
+		{"Max length of array", map[string]interface{}{"max_length": float64(1)}, []interface{}{"a", "b"}, "must have at most 1 items"},
+		{"Enum", map[string]interface{}{"enum": []interface{}{"draft", "published"}}, "draft", ""},
+		{"Enum mismatch", map[string]interface{}{"enum": []interface{}{"draft", "published"}}, "archived", "must be one of the allowed values"},
+		{"Email", map[string]interface{}{"format": "email"}, "jane@example.com", ""},
+		{"Email with display name", map[string]interface{}{"format": "email"}, "Jane <jane@example.com>", "must be a valid email address"},
+		{"URL", map[string]interface{}{"format": "url"}, "https://example.com/a", ""},
+		{"Relative URL", map[string]interface{}{"format": "url"}, "/a", "must be a valid URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, checkValidationRules(tt.validation, tt.value))
+		})
+	}
+}
+
+func TestValidateItem_SkipsAbsentFields(t *testing.T) {
+	fields := []FieldInfo{
+		{Field: "title", Validation: map[string]interface{}{"min_length": float64(3)}},
+		{Field: "slug", Schema: &FieldSchema{DataType: "string"}},
+	}
+
+	assert.Empty(t, validateItem(fields, Item{"slug": "ok"}, Item{"slug": "ok"}))
+
+	violations := validateItem(fields, Item{"title": "ab"}, Item{"title": "ab"})
+	assert.Equal(t, []ValidationError{{Field: "title", Message: "Field 'title' must have at least 3 characters"}}, violations)
+}
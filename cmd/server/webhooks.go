@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/hooks"
+)
+
+// Actions a webhook can subscribe to
+var webhookActions = []string{hooks.ActionCreate, hooks.ActionUpdate, hooks.ActionDelete}
+
+// Methods webhook requests can be sent with
+var webhookMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch}
+
+// Delivery statuses
+const (
+	deliveryStatusPending = "pending"
+	deliveryStatusSuccess = "success"
+	deliveryStatusFailed  = "failed"
+)
+
+// Webhook delivery defaults, overridable with WEBHOOK_MAX_ATTEMPTS and
+// WEBHOOK_RETRY_DELAY
+const (
+	defaultWebhookMaxAttempts = 5
+	defaultWebhookRetryDelay  = 30 * time.Second
+	// webhookMaxRetryDelay caps the exponential backoff between attempts
+	webhookMaxRetryDelay = 6 * time.Hour
+	// webhookPollInterval is how often due retries are looked for when no
+	// new event wakes the dispatcher
+	webhookPollInterval = 10 * time.Second
+	// webhookLease is how long a claimed delivery is hidden from other
+	// dispatchers, so it is picked up again if the process dies mid-request
+	webhookLease = time.Minute
+	// webhookBatchSize is the number of deliveries claimed at once
+	webhookBatchSize = 20
+	// webhookResponseLimit is the number of response body bytes logged
+	webhookResponseLimit = 4096
+)
+
+// Headers added to every webhook request
+const (
+	webhookEventHeader     = "X-GoRectus-Event"
+	webhookDeliveryHeader  = "X-GoRectus-Delivery"
+	webhookSignatureHeader = "X-GoRectus-Signature"
+)
+
+// webhookEvent is the JSON body of a webhook request
+type webhookEvent struct {
+	Event      string                 `json:"event"`
+	Collection string                 `json:"collection"`
+	Action     string                 `json:"action"`
+	Keys       []string               `json:"keys"`
+	Payload    map[string]interface{} `json:"payload,omitempty"`
+	User       string                 `json:"user,omitempty"`
+	Timestamp  time.Time              `json:"timestamp"`
+}
+
+// webhookDispatcher records a delivery for every webhook matching an event
+// and sends them in the background, retrying failures with exponential
+// backoff. Deliveries live in the database, so pending retries survive
+// restarts and are shared between server instances.
+type webhookDispatcher struct {
+	db          *sql.DB
+	client      *http.Client
+	maxAttempts int
+	retryDelay  time.Duration
+	wake        chan struct{}
+}
+
+// newWebhookDispatcher creates a dispatcher; run starts delivering
+func newWebhookDispatcher(db *sql.DB, maxAttempts int, retryDelay time.Duration) *webhookDispatcher {
+	return &webhookDispatcher{
+		db:          db,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		maxAttempts: maxAttempts,
+		retryDelay:  retryDelay,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// webhookDispatcherFromEnv creates a dispatcher configured by
+// WEBHOOK_MAX_ATTEMPTS and WEBHOOK_RETRY_DELAY
+func webhookDispatcherFromEnv(db *sql.DB) (*webhookDispatcher, error) {
+	maxAttempts := defaultWebhookMaxAttempts
+	if value := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS %q", value)
+		}
+		maxAttempts = n
+	}
+	retryDelay := defaultWebhookRetryDelay
+	if value := os.Getenv("WEBHOOK_RETRY_DELAY"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid WEBHOOK_RETRY_DELAY %q", value)
+		}
+		retryDelay = d
+	}
+	return newWebhookDispatcher(db, maxAttempts, retryDelay), nil
+}
+
+// register subscribes the dispatcher to every event of the bus. Webhooks
+// match events by their collection and action.
+func (d *webhookDispatcher) register(events *hooks.Bus) {
+	events.Action("*", func(ctx context.Context, event hooks.Event) {
+		if err := d.enqueue(event); err != nil {
+			logrus.WithError(err).WithField("event", event.Name).Error("Failed to queue webhook deliveries")
+		}
+	})
+}
+
+// enqueue records a pending delivery for every active webhook subscribed to
+// the event and wakes the dispatcher
+func (d *webhookDispatcher) enqueue(event hooks.Event) error {
+	body, err := json.Marshal(webhookEvent{
+		Event:      event.Name,
+		Collection: event.Collection,
+		Action:     event.Action,
+		Keys:       event.Keys,
+		Payload:    event.Payload,
+		User:       event.Accountability.User,
+		Timestamp:  event.Timestamp,
+	})
+	if err != nil {
+		return err
+	}
+	result, err := d.db.Exec(`
+		INSERT INTO webhook_deliveries (webhook, event, payload)
+		SELECT id, $1, $2 FROM webhooks
+		WHERE status = 'active' AND $3 = ANY(collections) AND $4 = ANY(actions)`,
+		event.Name, string(body), event.Collection, event.Action)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n > 0 {
+		d.notify()
+	}
+	return nil
+}
+
+// notify wakes the dispatcher to send pending deliveries now
+func (d *webhookDispatcher) notify() {
+	if d == nil {
+		return
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run sends due deliveries whenever woken and on every poll interval until
+// ctx is done
+func (d *webhookDispatcher) run(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+	for {
+		d.deliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// dueDelivery is a claimed delivery with the webhook it is sent to
+type dueDelivery struct {
+	ID       string
+	Event    string
+	Payload  []byte
+	Attempts int
+	URL      string
+	Method   string
+	Secret   sql.NullString
+	Headers  []byte
+}
+
+// deliverDue claims and sends pending deliveries until none are due
+func (d *webhookDispatcher) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		deliveries, err := d.claimDue()
+		if err != nil {
+			logrus.WithError(err).Error("Failed to claim webhook deliveries")
+			return
+		}
+		for _, delivery := range deliveries {
+			if ctx.Err() != nil {
+				// Deliveries claimed but not sent are retried once their lease ends
+				return
+			}
+			d.send(ctx, delivery)
+		}
+		if len(deliveries) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// claimDue leases a batch of due deliveries of active webhooks. SKIP LOCKED
+// keeps several server instances from claiming the same deliveries.
+func (d *webhookDispatcher) claimDue() ([]dueDelivery, error) {
+	rows, err := d.db.Query(`
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		FROM webhooks w
+		WHERE w.id = d.webhook AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+				AND webhook IN (SELECT id FROM webhooks WHERE status = 'active')
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.id, d.event, d.payload, d.attempts, w.url, w.method, w.secret, w.headers`,
+		webhookBatchSize, int(webhookLease.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []dueDelivery
+	for rows.Next() {
+		var delivery dueDelivery
+		if err := rows.Scan(&delivery.ID, &delivery.Event, &delivery.Payload, &delivery.Attempts,
+			&delivery.URL, &delivery.Method, &delivery.Secret, &delivery.Headers); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// send makes one attempt at a delivery and records its outcome
+func (d *webhookDispatcher) send(ctx context.Context, delivery dueDelivery) {
+	responseStatus, responseBody, sendErr := d.request(ctx, delivery)
+	if sendErr != nil && ctx.Err() != nil {
+		// Attempts cut short by shutdown don't count; the lease ends and the
+		// delivery is sent again
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	status := deliveryStatusSuccess
+	// The retry delay in seconds; the time is computed by the database, whose
+	// clock next_attempt_at is compared with
+	var retryDelay interface{}
+	var errMessage interface{}
+	if sendErr != nil {
+		errMessage = sendErr.Error()
+		status = deliveryStatusFailed
+		if attempts < d.maxAttempts {
+			status = deliveryStatusPending
+			retryDelay = d.backoff(attempts).Seconds()
+		}
+	}
+
+	_, err := d.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, response_status = $4, response_body = $5, error = $6,
+			next_attempt_at = NOW() + $7 * INTERVAL '1 second', last_attempt_at = NOW()
+		WHERE id = $1`,
+		delivery.ID, status, attempts, responseStatus, responseBody, errMessage, retryDelay)
+	if err != nil {
+		logrus.WithError(err).WithField("delivery_id", delivery.ID).Error("Failed to record webhook delivery")
+	}
+
+	entry := logrus.WithFields(logrus.Fields{
+		"delivery_id": delivery.ID,
+		"event":       delivery.Event,
+		"url":         delivery.URL,
+		"attempt":     attempts,
+	})
+	switch status {
+	case deliveryStatusSuccess:
+		entry.Debug("Webhook delivered")
+	case deliveryStatusPending:
+		entry.WithError(sendErr).Warn("Webhook delivery failed, will retry")
+	default:
+		entry.WithError(sendErr).Error("Webhook delivery failed")
+	}
+}
+
+// backoff returns the delay after a failed attempt, doubling with every
+// attempt
+func (d *webhookDispatcher) backoff(attempts int) time.Duration {
+	delay := d.retryDelay
+	for i := 1; i < attempts && delay < webhookMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookMaxRetryDelay)
+}
+
+// request sends a delivery. Responses other than 2xx are errors; the status
+// and the start of the body are returned either way.
+func (d *webhookDispatcher) request(ctx context.Context, delivery dueDelivery) (interface{}, interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, delivery.Method, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var headers map[string]string
+	if len(delivery.Headers) > 0 {
+		if err := json.Unmarshal(delivery.Headers, &headers); err != nil {
+			return nil, nil, fmt.Errorf("invalid webhook headers: %w", err)
+		}
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GoRectus-Webhook/1.0")
+	req.Header.Set(webhookEventHeader, delivery.Event)
+	req.Header.Set(webhookDeliveryHeader, delivery.ID)
+	if delivery.Secret.Valid && delivery.Secret.String != "" {
+		req.Header.Set(webhookSignatureHeader, signWebhookPayload(delivery.Secret.String, delivery.Payload))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(responseBody), fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(responseBody), nil
+}
+
+// signWebhookPayload returns the signature header value of a payload: the
+// hex HMAC-SHA256 of the body keyed with the webhook's secret
+func signWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,733 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"gorectus/internal/hooks"
+)
+
+// WebhooksHandler handles webhook routes
+type WebhooksHandler struct {
+	db             *sql.DB
+	webhooks       *webhookDispatcher
+	authMiddleware gin.HandlerFunc
+	optionsHandler gin.HandlerFunc
+	events         *hooks.Bus
+}
+
+// NewWebhooksHandler creates a new webhooks handler
+func NewWebhooksHandler(server ServerInterface) *WebhooksHandler {
+	return &WebhooksHandler{
+		db:             server.GetDB(),
+		webhooks:       server.Webhooks(),
+		authMiddleware: server.AuthMiddleware(),
+		optionsHandler: server.OptionsHandler(),
+		events:         server.Events(),
+	}
+}
+
+// SetupRoutes sets up webhook routes
+func (h *WebhooksHandler) SetupRoutes(v1 *gin.RouterGroup) {
+	// CORS preflight OPTIONS for webhooks endpoints
+	v1.OPTIONS("/webhooks", h.optionsHandler)
+	v1.OPTIONS("/webhooks/:id", h.optionsHandler)
+	v1.OPTIONS("/webhooks/:id/deliveries", h.optionsHandler)
+	v1.OPTIONS("/webhooks/:id/deliveries/:delivery/redeliver", h.optionsHandler)
+
+	// Webhooks routes (admin only)
+	webhooks := v1.Group("/webhooks")
+	webhooks.Use(h.authMiddleware, requireAdmin())
+	{
+		webhooks.GET("", h.getWebhooks)
+		webhooks.POST("", h.createWebhook)
+		webhooks.GET("/:id", h.getWebhook)
+		webhooks.PATCH("/:id", h.updateWebhook)
+		webhooks.DELETE("/:id", h.deleteWebhook)
+		webhooks.GET("/:id/deliveries", h.getDeliveries)
+		webhooks.POST("/:id/deliveries/:delivery/redeliver", h.redeliver)
+	}
+}
+
+// Webhook represents a webhook. The secret is never returned; HasSecret
+// tells whether requests are signed.
+type Webhook struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	URL         string            `json:"url"`
+	Method      string            `json:"method"`
+	Status      string            `json:"status"`
+	Collections []string          `json:"collections"`
+	Actions     []string          `json:"actions"`
+	HasSecret   bool              `json:"has_secret"`
+	Headers     map[string]string `json:"headers"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// WebhookDelivery represents one event sent to a webhook
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	Webhook        string          `json:"webhook"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"response_status"`
+	ResponseBody   *string         `json:"response_body"`
+	Error          *string         `json:"error"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// CreateWebhookRequest represents the request body for creating a webhook
+type CreateWebhookRequest struct {
+	Name        string            `json:"name" binding:"required"`
+	URL         string            `json:"url" binding:"required"`
+	Method      string            `json:"method"`
+	Status      string            `json:"status"`
+	Collections []string          `json:"collections" binding:"required"`
+	Actions     []string          `json:"actions" binding:"required"`
+	Secret      string            `json:"secret"`
+	Headers     map[string]string `json:"headers"`
+}
+
+// UpdateWebhookRequest represents the request body for updating a webhook
+type UpdateWebhookRequest struct {
+	Name        *string   `json:"name"`
+	URL         *string   `json:"url"`
+	Method      *string   `json:"method"`
+	Status      *string   `json:"status"`
+	Collections *[]string `json:"collections"`
+	Actions     *[]string `json:"actions"`
+	// Secret replaces the signing secret; an empty string stops signing
+	Secret  *string            `json:"secret"`
+	Headers *map[string]string `json:"headers"`
+}
+
+const webhookColumns = `id, name, url, method, status, collections, actions, secret IS NOT NULL AND secret <> '', headers, created_at, updated_at`
+
+const deliveryColumns = `id, webhook, event, payload, status, attempts, response_status, response_body, error, next_attempt_at, last_attempt_at, created_at`
+
+// scanWebhook scans a row of webhookColumns
+func scanWebhook(scanner interface{ Scan(...interface{}) error }) (*Webhook, error) {
+	var webhook Webhook
+	var headers []byte
+	if err := scanner.Scan(&webhook.ID, &webhook.Name, &webhook.URL, &webhook.Method, &webhook.Status,
+		pq.Array(&webhook.Collections), pq.Array(&webhook.Actions), &webhook.HasSecret, &headers,
+		&webhook.CreatedAt, &webhook.UpdatedAt); err != nil {
+		return nil, err
+	}
+	webhook.Headers = map[string]string{}
+	if len(headers) > 0 {
+		if err := json.Unmarshal(headers, &webhook.Headers); err != nil {
+			return nil, err
+		}
+	}
+	if webhook.Collections == nil {
+		webhook.Collections = []string{}
+	}
+	if webhook.Actions == nil {
+		webhook.Actions = []string{}
+	}
+	return &webhook, nil
+}
+
+// scanDelivery scans a row of deliveryColumns
+func scanDelivery(scanner interface{ Scan(...interface{}) error }) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	var payload []byte
+	var responseStatus sql.NullInt64
+	var responseBody, errMessage sql.NullString
+	var nextAttemptAt, lastAttemptAt sql.NullTime
+	if err := scanner.Scan(&delivery.ID, &delivery.Webhook, &delivery.Event, &payload, &delivery.Status,
+		&delivery.Attempts, &responseStatus, &responseBody, &errMessage, &nextAttemptAt, &lastAttemptAt,
+		&delivery.CreatedAt); err != nil {
+		return nil, err
+	}
+	delivery.Payload = json.RawMessage(payload)
+	if responseStatus.Valid {
+		status := int(responseStatus.Int64)
+		delivery.ResponseStatus = &status
+	}
+	if responseBody.Valid {
+		delivery.ResponseBody = &responseBody.String
+	}
+	if errMessage.Valid {
+		delivery.Error = &errMessage.String
+	}
+	// Only pending deliveries have a next attempt
+	if nextAttemptAt.Valid && delivery.Status == deliveryStatusPending {
+		delivery.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if lastAttemptAt.Valid {
+		delivery.LastAttemptAt = &lastAttemptAt.Time
+	}
+	return &delivery, nil
+}
+
+// validateWebhookURL reports whether a URL is an absolute http(s) URL
+func validateWebhookURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// normalizeWebhookMethod uppercases a method, defaulting to POST. The second
+// result is false when the method isn't supported.
+func normalizeWebhookMethod(method string) (string, bool) {
+	if method == "" {
+		return http.MethodPost, true
+	}
+	method = strings.ToUpper(method)
+	return method, slices.Contains(webhookMethods, method)
+}
+
+// validateWebhookHeaders reports the first header that can't be set on a
+// webhook request: invalid names and the headers the dispatcher sets itself
+func validateWebhookHeaders(headers map[string]string) (string, bool) {
+	reserved := []string{"content-type", "user-agent", strings.ToLower(webhookEventHeader),
+		strings.ToLower(webhookDeliveryHeader), strings.ToLower(webhookSignatureHeader)}
+	for name, value := range headers {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") || strings.ContainsAny(value, "\r\n") {
+			return name, false
+		}
+		if slices.Contains(reserved, strings.ToLower(name)) {
+			return name, false
+		}
+	}
+	return "", true
+}
+
+// validateWebhookSubscription checks collections and actions, writing the
+// error response when they are invalid
+func validateWebhookSubscription(c *gin.Context, collections, actions []string) bool {
+	if len(collections) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one collection is required"})
+		return false
+	}
+	for _, collection := range collections {
+		if strings.TrimSpace(collection) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection name"})
+			return false
+		}
+	}
+	if len(actions) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one action is required"})
+		return false
+	}
+	for _, action := range actions {
+		if !slices.Contains(webhookActions, action) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action: " + action})
+			return false
+		}
+	}
+	return true
+}
+
+// GetWebhooks lists webhooks
+//
+//	@Summary		List webhooks
+//	@Description	Get all webhooks ordered by name
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{array}		WebhookModel	"Webhooks"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Admin access required"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/webhooks [get]
+func (h *WebhooksHandler) getWebhooks(c *gin.Context) {
+	rows, err := h.db.Query(`SELECT ` + webhookColumns + ` FROM webhooks ORDER BY name, id`)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while fetching webhooks")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	webhooks := []Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			logrus.WithError(err).Error("Error scanning webhook row")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": webhooks})
+}
+
+// CreateWebhook creates a webhook
+//
+//	@Summary		Create a webhook
+//	@Description	Create a webhook sent for the given actions on items of the given collections. Subscribe to the "collections" and "fields" collections for schema changes
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			webhook	body		CreateWebhookRequest	true	"Webhook"
+//	@Success		201		{object}	WebhookModel	"Created webhook"
+//	@Failure		400		{object}	ErrorResponse	"Invalid request payload"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	ErrorResponse	"Admin access required"
+//	@Failure		500		{object}	ErrorResponse	"Internal server error"
+//	@Router			/webhooks [post]
+func (h *WebhooksHandler) createWebhook(c *gin.Context) {
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeWebhooks, scopeWebhooks, hooks.ActionCreate, nil)) {
+		return
+	}
+
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid create webhook request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Webhook name is required"})
+		return
+	}
+	if !validateWebhookURL(req.URL) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Webhook URL must be an absolute http or https URL"})
+		return
+	}
+	method, ok := normalizeWebhookMethod(req.Method)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid method: " + req.Method})
+		return
+	}
+	status := req.Status
+	if status == "" {
+		status = "active"
+	} else if status != "active" && status != "inactive" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status: " + status})
+		return
+	}
+	if !validateWebhookSubscription(c, req.Collections, req.Actions) {
+		return
+	}
+	if req.Headers == nil {
+		req.Headers = map[string]string{}
+	}
+	if header, ok := validateWebhookHeaders(req.Headers); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid header: " + header})
+		return
+	}
+	headers, err := json.Marshal(req.Headers)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	var webhookID string
+	err = h.db.QueryRow(`
+		INSERT INTO webhooks (name, url, method, status, collections, actions, secret, headers)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		name, req.URL, method, status, pq.Array(req.Collections), pq.Array(req.Actions),
+		nullableString(req.Secret), string(headers)).Scan(&webhookID)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while creating webhook")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	webhook, err := h.getWebhookByID(webhookID)
+	if err != nil {
+		logrus.WithError(err).Error("Error fetching created webhook")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"webhook_id": webhookID,
+		"created_by": c.GetString("user_id"),
+	}).Info("Webhook created successfully")
+	emitActionHooks(c, h.events, scopeWebhooks, scopeWebhooks, hooks.ActionCreate, webhookEventPayload(webhook), webhookID)
+	c.JSON(http.StatusCreated, gin.H{"data": webhook})
+}
+
+// GetWebhook returns a webhook
+//
+//	@Summary		Get a webhook
+//	@Description	Get a webhook by ID
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Webhook ID"
+//	@Success		200	{object}	WebhookModel	"Webhook"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Admin access required"
+//	@Failure		404	{object}	ErrorResponse	"Webhook not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/webhooks/{id} [get]
+func (h *WebhooksHandler) getWebhook(c *gin.Context) {
+	webhook, ok := h.resolveWebhook(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": webhook})
+}
+
+// UpdateWebhook updates a webhook
+//
+//	@Summary		Update a webhook
+//	@Description	Update a webhook. Deliveries already queued keep their payload but are sent to the updated URL
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string					true	"Webhook ID"
+//	@Param			webhook	body		UpdateWebhookRequest	true	"Webhook changes"
+//	@Success		200		{object}	WebhookModel	"Updated webhook"
+//	@Failure		400		{object}	ErrorResponse	"Invalid request payload"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	ErrorResponse	"Admin access required"
+//	@Failure		404		{object}	ErrorResponse	"Webhook not found"
+//	@Failure		500		{object}	ErrorResponse	"Internal server error"
+//	@Router			/webhooks/{id} [patch]
+func (h *WebhooksHandler) updateWebhook(c *gin.Context) {
+	webhook, ok := h.resolveWebhook(c)
+	if !ok {
+		return
+	}
+	if !filterRequestBody(c, h.events, requestEvent(c, scopeWebhooks, scopeWebhooks, hooks.ActionUpdate, nil, webhook.ID)) {
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid update webhook request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	var updates []string
+	var args []interface{}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		updates = append(updates, column+" = $"+strconv.Itoa(len(args)))
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Webhook name is required"})
+			return
+		}
+		set("name", name)
+	}
+	if req.URL != nil {
+		if !validateWebhookURL(*req.URL) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Webhook URL must be an absolute http or https URL"})
+			return
+		}
+		set("url", *req.URL)
+	}
+	if req.Method != nil {
+		method, ok := normalizeWebhookMethod(*req.Method)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid method: " + *req.Method})
+			return
+		}
+		set("method", method)
+	}
+	if req.Status != nil {
+		if *req.Status != "active" && *req.Status != "inactive" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status: " + *req.Status})
+			return
+		}
+		set("status", *req.Status)
+	}
+	if req.Collections != nil || req.Actions != nil {
+		collections, actions := webhook.Collections, webhook.Actions
+		if req.Collections != nil {
+			collections = *req.Collections
+		}
+		if req.Actions != nil {
+			actions = *req.Actions
+		}
+		if !validateWebhookSubscription(c, collections, actions) {
+			return
+		}
+		if req.Collections != nil {
+			set("collections", pq.Array(collections))
+		}
+		if req.Actions != nil {
+			set("actions", pq.Array(actions))
+		}
+	}
+	if req.Secret != nil {
+		set("secret", nullableString(*req.Secret))
+	}
+	if req.Headers != nil {
+		headers := *req.Headers
+		if headers == nil {
+			headers = map[string]string{}
+		}
+		if header, ok := validateWebhookHeaders(headers); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid header: " + header})
+			return
+		}
+		encoded, err := json.Marshal(headers)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+		set("headers", string(encoded))
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	args = append(args, webhook.ID)
+	query := `UPDATE webhooks SET ` + strings.Join(updates, ", ") + ` WHERE id = $` + strconv.Itoa(len(args))
+	if _, err := h.db.Exec(query, args...); err != nil {
+		logrus.WithError(err).Error("Database error while updating webhook")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	updated, err := h.getWebhookByID(webhook.ID)
+	if err != nil {
+		logrus.WithError(err).Error("Error fetching updated webhook")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithField("webhook_id", webhook.ID).Info("Webhook updated successfully")
+	emitActionHooks(c, h.events, scopeWebhooks, scopeWebhooks, hooks.ActionUpdate, webhookEventPayload(updated), webhook.ID)
+	c.JSON(http.StatusOK, gin.H{"data": updated})
+}
+
+// DeleteWebhook deletes a webhook
+//
+//	@Summary		Delete a webhook
+//	@Description	Delete a webhook along with its delivery log
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Webhook ID"
+//	@Success		200	{object}	SuccessMessage	"Success message"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Admin access required"
+//	@Failure		404	{object}	ErrorResponse	"Webhook not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/webhooks/{id} [delete]
+func (h *WebhooksHandler) deleteWebhook(c *gin.Context) {
+	webhook, ok := h.resolveWebhook(c)
+	if !ok {
+		return
+	}
+	event := requestEvent(c, scopeWebhooks, scopeWebhooks, hooks.ActionDelete, nil, webhook.ID)
+	if !runFilterHooks(c, h.events, &event) {
+		return
+	}
+
+	if _, err := h.db.Exec(`DELETE FROM webhooks WHERE id = $1`, webhook.ID); err != nil {
+		logrus.WithError(err).Error("Database error while deleting webhook")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	logrus.WithField("webhook_id", webhook.ID).Info("Webhook deleted successfully")
+	emitActionHooks(c, h.events, scopeWebhooks, scopeWebhooks, hooks.ActionDelete, webhookEventPayload(webhook), webhook.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetDeliveries lists the deliveries of a webhook
+//
+//	@Summary		List webhook deliveries
+//	@Description	Get a paginated log of the requests sent for a webhook, newest first
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"Webhook ID"
+//	@Param			status	query		string	false	"Only deliveries with this status (pending, success or failed)"
+//	@Param			page	query		int		false	"Page number"
+//	@Param			limit	query		int		false	"Limit the number of results"
+//	@Success		200		{object}	map[string]interface{}	"Deliveries with pagination metadata"
+//	@Failure		400		{object}	ErrorResponse	"Invalid status"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	ErrorResponse	"Admin access required"
+//	@Failure		404		{object}	ErrorResponse	"Webhook not found"
+//	@Failure		500		{object}	ErrorResponse	"Internal server error"
+//	@Router			/webhooks/{id}/deliveries [get]
+func (h *WebhooksHandler) getDeliveries(c *gin.Context) {
+	webhook, ok := h.resolveWebhook(c)
+	if !ok {
+		return
+	}
+
+	page := 1
+	limit := 50
+	if pageStr := c.Query("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+	offset := (page - 1) * limit
+
+	where := `webhook = $1`
+	args := []interface{}{webhook.ID}
+	if status := c.Query("status"); status != "" {
+		if status != deliveryStatusPending && status != deliveryStatusSuccess && status != deliveryStatusFailed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status: " + status})
+			return
+		}
+		where += ` AND status = $2`
+		args = append(args, status)
+	}
+
+	var total int
+	if err := h.db.QueryRow(`SELECT COUNT(*) FROM webhook_deliveries WHERE `+where, args...).Scan(&total); err != nil {
+		logrus.WithError(err).Error("Database error while counting webhook deliveries")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE ` + where +
+		` ORDER BY created_at DESC, id LIMIT $` + strconv.Itoa(len(args)+1) + ` OFFSET $` + strconv.Itoa(len(args)+2)
+	rows, err := h.db.Query(query, append(args, limit, offset)...)
+	if err != nil {
+		logrus.WithError(err).Error("Database error while fetching webhook deliveries")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			logrus.WithError(err).Error("Error scanning webhook delivery row")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": deliveries,
+		"meta": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// Redeliver queues a delivery again
+//
+//	@Summary		Redeliver a webhook delivery
+//	@Description	Queue a new delivery with the payload of an earlier one. It is sent in the background to the webhook's current URL, even if the webhook is inactive
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string	true	"Webhook ID"
+//	@Param			delivery	path		string	true	"Delivery ID"
+//	@Success		202			{object}	WebhookDeliveryModel	"Queued delivery"
+//	@Failure		401			{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403			{object}	ErrorResponse	"Admin access required"
+//	@Failure		404			{object}	ErrorResponse	"Webhook or delivery not found"
+//	@Failure		500			{object}	ErrorResponse	"Internal server error"
+//	@Router			/webhooks/{id}/deliveries/{delivery}/redeliver [post]
+func (h *WebhooksHandler) redeliver(c *gin.Context) {
+	webhook, ok := h.resolveWebhook(c)
+	if !ok {
+		return
+	}
+
+	deliveryID := c.Param("delivery")
+	if !uuidRegexp.MatchString(deliveryID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+
+	var newID string
+	err := h.db.QueryRow(`
+		INSERT INTO webhook_deliveries (webhook, event, payload)
+		SELECT webhook, event, payload FROM webhook_deliveries WHERE id = $1 AND webhook = $2
+		RETURNING id`, deliveryID, webhook.ID).Scan(&newID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while queueing webhook redelivery")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	delivery, err := scanDelivery(h.db.QueryRow(`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = $1`, newID))
+	if err != nil {
+		logrus.WithError(err).Error("Error fetching queued webhook delivery")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	h.webhooks.notify()
+
+	logrus.WithFields(logrus.Fields{
+		"webhook_id":  webhook.ID,
+		"delivery_id": deliveryID,
+		"redelivery":  newID,
+	}).Info("Webhook delivery queued again")
+	c.JSON(http.StatusAccepted, gin.H{"data": delivery})
+}
+
+// webhookEventPayload converts a webhook to an event payload. Headers often
+// hold credentials and are left out, as events are delivered to webhooks.
+func webhookEventPayload(webhook *Webhook) map[string]interface{} {
+	payload := eventPayload(webhook)
+	delete(payload, "headers")
+	return payload
+}
+
+// resolveWebhook loads the webhook of the :id parameter, writing a 404 when
+// it doesn't exist
+func (h *WebhooksHandler) resolveWebhook(c *gin.Context) (*Webhook, bool) {
+	webhookID := c.Param("id")
+	if !uuidRegexp.MatchString(webhookID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return nil, false
+	}
+
+	webhook, err := h.getWebhookByID(webhookID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return nil, false
+	} else if err != nil {
+		logrus.WithError(err).Error("Database error while fetching webhook")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	return webhook, true
+}
+
+// getWebhookByID loads a webhook
+func (h *WebhooksHandler) getWebhookByID(id string) (*Webhook, error) {
+	return scanWebhook(h.db.QueryRow(`SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id))
+}
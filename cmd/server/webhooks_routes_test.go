@@ -0,0 +1,249 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	testWebhookID  = "3e9c1d5b-4f6a-4b8c-9d2e-3f4a5b6c7d8e"
+	testDeliveryID = "4f0d2e6c-5a7b-4c9d-8e3f-4a5b6c7d8e9f"
+)
+
+// Test suite for webhook handlers
+type WebhookHandlersTestSuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+}
+
+func (suite *WebhookHandlersTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+	logrus.SetLevel(logrus.PanicLevel)
+}
+
+func (suite *WebhookHandlersTestSuite) SetupTest() {
+	db, mock, err := sqlmock.New()
+	require.NoError(suite.T(), err)
+	suite.db = db
+	suite.mock = mock
+}
+
+func (suite *WebhookHandlersTestSuite) TearDownTest() {
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+	suite.db.Close()
+}
+
+// request serves a request to the webhook routes as the given user
+func (suite *WebhookHandlersTestSuite) request(method, url, body string, admin bool) *httptest.ResponseRecorder {
+	router := gin.New()
+	mockServer := &mockItemServerInterface{
+		db: suite.db,
+		customAuthFunc: func(c *gin.Context) {
+			c.Set("user_id", "user-1")
+			c.Set("admin_access", admin)
+			c.Set("app_access", true)
+			c.Next()
+		},
+	}
+	NewWebhooksHandler(mockServer).SetupRoutes(router.Group("/api/v1"))
+
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func webhookRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "name", "url", "method", "status", "collections", "actions",
+		"has_secret", "headers", "created_at", "updated_at"})
+}
+
+func deliveryRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "webhook", "event", "payload", "status", "attempts", "response_status",
+		"response_body", "error", "next_attempt_at", "last_attempt_at", "created_at"})
+}
+
+func (suite *WebhookHandlersTestSuite) expectWebhook(status string, hasSecret bool) {
+	now := time.Now()
+	suite.mock.ExpectQuery(`FROM webhooks WHERE id = \$1`).WithArgs(testWebhookID).
+		WillReturnRows(webhookRows().AddRow(testWebhookID, "Indexer", "https://hooks.example.com/in", "POST", status,
+			"{articles,fields}", "{create,update}", hasSecret, []byte(`{"X-Token":"abc"}`), now, now))
+}
+
+func (suite *WebhookHandlersTestSuite) TestGetWebhooks() {
+	now := time.Now()
+	suite.mock.ExpectQuery(`FROM webhooks ORDER BY name, id`).
+		WillReturnRows(webhookRows().AddRow(testWebhookID, "Indexer", "https://hooks.example.com/in", "POST", "active",
+			"{articles}", "{create}", false, []byte(`{}`), now, now))
+
+	w := suite.request("GET", "/api/v1/webhooks", "", true)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Data []Webhook `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(suite.T(), response.Data, 1)
+	assert.Equal(suite.T(), []string{"articles"}, response.Data[0].Collections)
+	assert.Equal(suite.T(), []string{"create"}, response.Data[0].Actions)
+}
+
+func (suite *WebhookHandlersTestSuite) TestGetWebhooks_NonAdmin() {
+	w := suite.request("GET", "/api/v1/webhooks", "", false)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *WebhookHandlersTestSuite) TestCreateWebhook() {
+	suite.mock.ExpectQuery(`INSERT INTO webhooks`).
+		WithArgs("Indexer", "https://hooks.example.com/in", "PUT", "active", pq.Array([]string{"articles", "fields"}),
+			pq.Array([]string{"create", "update"}), "s3cret", `{"X-Token":"abc"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testWebhookID))
+	suite.expectWebhook("active", true)
+
+	w := suite.request("POST", "/api/v1/webhooks", `{"name":" Indexer ","url":"https://hooks.example.com/in",
+		"method":"put","collections":["articles","fields"],"actions":["create","update"],
+		"secret":"s3cret","headers":{"X-Token":"abc"}}`, true)
+
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Body.String(), `"has_secret":true`)
+	assert.NotContains(suite.T(), w.Body.String(), "s3cret")
+}
+
+func (suite *WebhookHandlersTestSuite) TestCreateWebhook_Invalid() {
+	tests := map[string]string{
+		"url":        `{"name":"a","url":"ftp://example.com","collections":["articles"],"actions":["create"]}`,
+		"method":     `{"name":"a","url":"https://example.com","method":"GET","collections":["articles"],"actions":["create"]}`,
+		"action":     `{"name":"a","url":"https://example.com","collections":["articles"],"actions":["read"]}`,
+		"collection": `{"name":"a","url":"https://example.com","collections":[],"actions":["create"]}`,
+		"status":     `{"name":"a","url":"https://example.com","status":"paused","collections":["articles"],"actions":["create"]}`,
+		"header":     `{"name":"a","url":"https://example.com","collections":["articles"],"actions":["create"],"headers":{"X-GoRectus-Signature":"x"}}`,
+	}
+	for name, body := range tests {
+		suite.Run(name, func() {
+			w := suite.request("POST", "/api/v1/webhooks", body, true)
+
+			assert.Equal(suite.T(), http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+}
+
+func (suite *WebhookHandlersTestSuite) TestUpdateWebhook() {
+	suite.expectWebhook("active", true)
+	suite.mock.ExpectExec(`UPDATE webhooks SET status = \$1, actions = \$2, secret = \$3 WHERE id = \$4`).
+		WithArgs("inactive", pq.Array([]string{"delete"}), nil, testWebhookID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.expectWebhook("inactive", false)
+
+	w := suite.request("PATCH", "/api/v1/webhooks/"+testWebhookID,
+		`{"status":"inactive","actions":["delete"],"secret":""}`, true)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Body.String(), `"has_secret":false`)
+}
+
+func (suite *WebhookHandlersTestSuite) TestUpdateWebhook_EmptyActions() {
+	suite.expectWebhook("active", false)
+
+	w := suite.request("PATCH", "/api/v1/webhooks/"+testWebhookID, `{"actions":[]}`, true)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *WebhookHandlersTestSuite) TestDeleteWebhook() {
+	suite.expectWebhook("active", false)
+	suite.mock.ExpectExec(`DELETE FROM webhooks WHERE id = \$1`).WithArgs(testWebhookID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := suite.request("DELETE", "/api/v1/webhooks/"+testWebhookID, "", true)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *WebhookHandlersTestSuite) TestDeleteWebhook_NotFound() {
+	suite.mock.ExpectQuery(`FROM webhooks WHERE id = \$1`).WithArgs(testWebhookID).
+		WillReturnError(sql.ErrNoRows)
+
+	w := suite.request("DELETE", "/api/v1/webhooks/"+testWebhookID, "", true)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *WebhookHandlersTestSuite) TestGetDeliveries() {
+	now := time.Now()
+	suite.expectWebhook("active", false)
+	suite.mock.ExpectQuery(`SELECT COUNT\(\*\) FROM webhook_deliveries WHERE webhook = \$1 AND status = \$2`).
+		WithArgs(testWebhookID, "pending").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	suite.mock.ExpectQuery(`FROM webhook_deliveries WHERE webhook = \$1 AND status = \$2 ORDER BY created_at DESC, id LIMIT \$3 OFFSET \$4`).
+		WithArgs(testWebhookID, "pending", 10, 10).
+		WillReturnRows(deliveryRows().AddRow(testDeliveryID, testWebhookID, "items.create", []byte(`{"event":"items.create"}`),
+			"pending", 2, 503, "busy", "unexpected response status 503", now, now, now))
+
+	w := suite.request("GET", "/api/v1/webhooks/"+testWebhookID+"/deliveries?status=pending&page=2&limit=10", "", true)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Data []WebhookDelivery `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(suite.T(), response.Data, 1)
+	assert.Equal(suite.T(), 503, *response.Data[0].ResponseStatus)
+	assert.NotNil(suite.T(), response.Data[0].NextAttemptAt)
+	assert.JSONEq(suite.T(), `{"event":"items.create"}`, string(response.Data[0].Payload))
+}
+
+func (suite *WebhookHandlersTestSuite) TestGetDeliveries_InvalidStatus() {
+	suite.expectWebhook("active", false)
+
+	w := suite.request("GET", "/api/v1/webhooks/"+testWebhookID+"/deliveries?status=sent", "", true)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *WebhookHandlersTestSuite) TestRedeliver() {
+	newID := "5a1e3f7d-6b8c-4d0e-9f4a-5b6c7d8e9f0a"
+	suite.expectWebhook("inactive", false)
+	suite.mock.ExpectQuery(`INSERT INTO webhook_deliveries \(webhook, event, payload\)\s+SELECT webhook, event, payload FROM webhook_deliveries`).
+		WithArgs(testDeliveryID, testWebhookID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newID))
+	suite.mock.ExpectQuery(`FROM webhook_deliveries WHERE id = \$1`).WithArgs(newID).
+		WillReturnRows(deliveryRows().AddRow(newID, testWebhookID, "items.create", []byte(`{}`),
+			"pending", 0, nil, nil, nil, time.Now(), nil, time.Now()))
+
+	w := suite.request("POST", "/api/v1/webhooks/"+testWebhookID+"/deliveries/"+testDeliveryID+"/redeliver", "", true)
+
+	require.Equal(suite.T(), http.StatusAccepted, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Body.String(), newID)
+}
+
+func (suite *WebhookHandlersTestSuite) TestRedeliver_NotFound() {
+	suite.expectWebhook("active", false)
+	suite.mock.ExpectQuery(`INSERT INTO webhook_deliveries`).WithArgs(testDeliveryID, testWebhookID).
+		WillReturnError(sql.ErrNoRows)
+
+	w := suite.request("POST", "/api/v1/webhooks/"+testWebhookID+"/deliveries/"+testDeliveryID+"/redeliver", "", true)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func TestWebhookHandlersTestSuite(t *testing.T) {
+	suite.Run(t, new(WebhookHandlersTestSuite))
+}
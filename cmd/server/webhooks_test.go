@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gorectus/internal/hooks"
+)
+
+func newTestDispatcher(t *testing.T) (*webhookDispatcher, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, mock.ExpectationsWereMet())
+		db.Close()
+	})
+	return newWebhookDispatcher(db, 3, time.Second), mock
+}
+
+func testDueDelivery(url string) dueDelivery {
+	return dueDelivery{
+		ID:      testDeliveryID,
+		Event:   "items.create",
+		Payload: []byte(`{"event":"items.create","keys":["1"]}`),
+		URL:     url,
+		Method:  http.MethodPost,
+		Secret:  sql.NullString{String: "s3cret", Valid: true},
+		Headers: []byte(`{"X-Token":"abc"}`),
+	}
+}
+
+func TestWebhookDispatcher_Enqueue(t *testing.T) {
+	d, mock := newTestDispatcher(t)
+	mock.ExpectExec(`INSERT INTO webhook_deliveries \(webhook, event, payload\)\s+SELECT id, \$1, \$2 FROM webhooks`).
+		WithArgs("items.update", sqlmock.AnyArg(), "articles", "update").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// Webhooks are sent for the action hooks of every event
+	events := hooks.New()
+	d.register(events)
+	event := hooks.NewEvent(scopeItems, "articles", hooks.ActionUpdate, map[string]interface{}{"title": "Hi"}, "1")
+	event.Accountability.User = "user-1"
+	events.Emit(event)
+	events.Wait()
+
+	// Queued deliveries wake the dispatcher
+	select {
+	case <-d.wake:
+	default:
+		t.Fatal("dispatcher was not woken")
+	}
+}
+
+// payloadArg captures the payload of a queued delivery
+type payloadArg struct{ payload *string }
+
+func (a payloadArg) Match(value driver.Value) bool {
+	*a.payload, _ = value.(string)
+	return true
+}
+
+func TestWebhookDispatcher_EnqueueRedactsItems(t *testing.T) {
+	d, mock := newTestDispatcher(t)
+	events := hooks.New()
+	d.register(events)
+
+	mock.ExpectQuery("FROM collections WHERE collection").
+		WillReturnRows(collectionRows().AddRow(false, nil, nil, nil, true, nil, nil, nil, true, true))
+	mock.ExpectQuery("SELECT f.field, f.required").WillReturnRows(fieldInfoRows().
+		AddRow("title", true, nil, nil, false, false, nil, nil, "character varying", 255, "NO").
+		AddRow("password", false, nil, nil, false, false, nil, "{hash}", "character varying", 255, "YES").
+		AddRow("notes", false, nil, nil, false, true, nil, nil, "text", nil, "YES"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "accounts"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("item-1"))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT \* FROM "accounts"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "title", "password", "notes"}).
+			AddRow("item-1", "Shop", "$2a$10$hashedvalue", "Internal"))
+	var payload string
+	mock.ExpectExec(`INSERT INTO webhook_deliveries`).
+		WithArgs("items.create", payloadArg{&payload}, "accounts", "create").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := serveWithHooks(t, d.db, events, "POST", "/api/v1/items/accounts",
+		`{"title":"Shop","password":"hunter2","notes":"Internal"}`)
+	events.Wait()
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	// Hashes and hidden fields never leave the server
+	assert.Contains(t, payload, `"title":"Shop"`)
+	assert.NotContains(t, payload, "password")
+	assert.NotContains(t, payload, "hashedvalue")
+	assert.NotContains(t, payload, "Internal")
+}
+
+func TestWebhookDispatcher_Send(t *testing.T) {
+	payload := `{"event":"items.create","keys":["1"]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, payload, string(body))
+		assert.Equal(t, "abc", r.Header.Get("X-Token"))
+		assert.Equal(t, "items.create", r.Header.Get(webhookEventHeader))
+		assert.Equal(t, testDeliveryID, r.Header.Get(webhookDeliveryHeader))
+		assert.Equal(t, signWebhookPayload("s3cret", []byte(payload)), r.Header.Get(webhookSignatureHeader))
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	d, mock := newTestDispatcher(t)
+	mock.ExpectExec(`UPDATE webhook_deliveries`).
+		WithArgs(testDeliveryID, deliveryStatusSuccess, 1, 200, "ok", nil, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	d.send(context.Background(), testDueDelivery(server.URL))
+}
+
+func TestWebhookDispatcher_SendRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	d, mock := newTestDispatcher(t)
+	mock.ExpectExec(`UPDATE webhook_deliveries`).
+		WithArgs(testDeliveryID, deliveryStatusPending, 2, 503, "", "unexpected response status 503", float64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	delivery := testDueDelivery(server.URL)
+	delivery.Attempts = 1
+	d.send(context.Background(), delivery)
+}
+
+func TestWebhookDispatcher_SendGivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	d, mock := newTestDispatcher(t)
+	mock.ExpectExec(`UPDATE webhook_deliveries`).
+		WithArgs(testDeliveryID, deliveryStatusFailed, 3, 500, "", "unexpected response status 500", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	delivery := testDueDelivery(server.URL)
+	delivery.Attempts = 2
+	d.send(context.Background(), delivery)
+}
+
+// An attempt cut short by shutdown is left for the lease to run out
+func TestWebhookDispatcher_SendShutdown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// No attempt is recorded
+	d, _ := newTestDispatcher(t)
+	d.send(ctx, testDueDelivery(server.URL))
+}
+
+func TestWebhookDispatcher_ClaimDue(t *testing.T) {
+	d, mock := newTestDispatcher(t)
+	// Deliveries of inactive webhooks stay queued
+	mock.ExpectQuery(`UPDATE webhook_deliveries d\s+SET next_attempt_at = NOW\(\) \+ \$2 \* INTERVAL '1 second'.*`+
+		`AND webhook IN \(SELECT id FROM webhooks WHERE status = 'active'\)`).
+		WithArgs(webhookBatchSize, int(webhookLease.Seconds())).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event", "payload", "attempts", "url", "method", "secret", "headers"}))
+
+	deliveries, err := d.claimDue()
+
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+}
+
+func TestWebhookDispatcher_Backoff(t *testing.T) {
+	d := newWebhookDispatcher(nil, 5, 30*time.Second)
+
+	assert.Equal(t, 30*time.Second, d.backoff(1))
+	assert.Equal(t, time.Minute, d.backoff(2))
+	assert.Equal(t, 4*time.Minute, d.backoff(4))
+	assert.Equal(t, webhookMaxRetryDelay, d.backoff(50))
+}
+
+func TestWebhookDispatcherFromEnv(t *testing.T) {
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "8")
+	t.Setenv("WEBHOOK_RETRY_DELAY", "1m")
+	d, err := webhookDispatcherFromEnv(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 8, d.maxAttempts)
+	assert.Equal(t, time.Minute, d.retryDelay)
+
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "0")
+	_, err = webhookDispatcherFromEnv(nil)
+	assert.Error(t, err)
+}
+
+func TestWebhookDispatcher_NilNotify(t *testing.T) {
+	var d *webhookDispatcher
+	assert.NotPanics(t, d.notify)
+}
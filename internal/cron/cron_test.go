@@ -0,0 +1,77 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func at(value string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *",
+		"* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *", "@every"} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		expr    string
+		time    string
+		matches bool
+	}{
+		{"* * * * *", "2024-03-10 12:34", true},
+		{"*/15 * * * *", "2024-03-10 12:45", true},
+		{"*/15 * * * *", "2024-03-10 12:46", false},
+		{"0 9-17 * * 1-5", "2024-03-11 09:00", true},  // Monday
+		{"0 9-17 * * 1-5", "2024-03-10 09:00", false}, // Sunday
+		{"30 2 1,15 * *", "2024-03-15 02:30", true},
+		{"0 0 * * 7", "2024-03-10 00:00", true}, // 7 is Sunday
+		{"5/20 * * * *", "2024-03-10 00:45", true},
+		{"@daily", "2024-03-10 00:00", true},
+		{"@hourly", "2024-03-10 00:01", false},
+		// Restricted day of month and day of week match either one
+		{"0 0 13 * 5", "2024-03-13 00:00", true},
+		{"0 0 13 * 5", "2024-03-15 00:00", true},
+		{"0 0 13 * 5", "2024-03-14 00:00", false},
+	}
+	for _, test := range tests {
+		schedule, err := Parse(test.expr)
+		require.NoError(t, err, test.expr)
+		assert.Equal(t, test.matches, schedule.Matches(at(test.time)), "%s at %s", test.expr, test.time)
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		expr string
+		from string
+		next string
+	}{
+		{"* * * * *", "2024-03-10 12:34", "2024-03-10 12:35"},
+		{"0 * * * *", "2024-03-10 12:00", "2024-03-10 13:00"},
+		{"30 2 * * *", "2024-03-10 12:34", "2024-03-11 02:30"},
+		{"0 0 1 * *", "2024-12-15 08:00", "2025-01-01 00:00"},
+		{"0 12 * * 1", "2024-03-10 12:34", "2024-03-11 12:00"},
+		{"0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+	}
+	for _, test := range tests {
+		schedule, err := Parse(test.expr)
+		require.NoError(t, err, test.expr)
+		assert.Equal(t, at(test.next), schedule.Next(at(test.from)), "%s after %s", test.expr, test.from)
+	}
+
+	never, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, never.Next(at("2024-01-01 00:00")).IsZero())
+}
@@ -0,0 +1,233 @@
+// Package hooks lets Go code extend the API server by hooking into the
+// mutations its handlers make. Filter hooks run before a change is written
+// and may change or reject its payload; action hooks run after it has been
+// committed.
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Actions of the mutations that emit events
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Accountability identifies who made a change
+type Accountability struct {
+	User  string `json:"user,omitempty"`
+	Role  string `json:"role,omitempty"`
+	Admin bool   `json:"admin"`
+}
+
+// Event describes a mutation. Name is "<scope>.<action>", e.g.
+// "items.create" or "fields.delete".
+type Event struct {
+	Name   string
+	Scope  string
+	Action string
+	// Collection is the collection of the changed items. For scopes other
+	// than items it is the scope itself, e.g. "users".
+	Collection string
+	// Keys identify the changed records
+	Keys []string
+	// Payload is the data written. Filters see the request data and may
+	// change it; actions see the stored record, if there is one.
+	Payload        map[string]interface{}
+	Accountability Accountability
+	Timestamp      time.Time
+	// Origin names the automation that made the change, e.g. "flow:<id>",
+	// and is empty for changes made by clients
+	Origin string
+	// Depth counts the automations that led to the change: 0 for changes
+	// made by clients, one more than the triggering event's otherwise.
+	// Hooks that make changes use it to end chains of changes.
+	Depth int
+}
+
+// NewEvent creates the event of an action on a scope
+func NewEvent(scope, collection, action string, payload map[string]interface{}, keys ...string) Event {
+	return Event{
+		Name:       scope + "." + action,
+		Scope:      scope,
+		Action:     action,
+		Collection: collection,
+		Keys:       keys,
+		Payload:    payload,
+		Timestamp:  time.Now().UTC(),
+	}
+}
+
+// FilterFunc runs before a change is written. It may change event.Payload;
+// returning an error rejects the change.
+type FilterFunc func(ctx context.Context, event *Event) error
+
+// ActionFunc runs after a change has been committed
+type ActionFunc func(ctx context.Context, event Event)
+
+// RejectError rejects a change with a message for the client. Filters
+// return it through Reject; other errors are reported as internal errors.
+type RejectError struct {
+	Message string
+}
+
+func (e *RejectError) Error() string {
+	return e.Message
+}
+
+// Reject returns an error rejecting a change with message
+func Reject(format string, args ...interface{}) error {
+	return &RejectError{Message: fmt.Sprintf(format, args...)}
+}
+
+// IsReject reports whether err rejects a change, returning the rejection
+func IsReject(err error) (*RejectError, bool) {
+	var reject *RejectError
+	ok := errors.As(err, &reject)
+	return reject, ok
+}
+
+// Bus holds the registered hooks. Hooks are registered for an event name or
+// a pattern where either part is "*", e.g. "items.*" or "*.delete"; "*"
+// alone matches every event. A nil Bus runs no hooks.
+type Bus struct {
+	mu      sync.RWMutex
+	filters []filterHook
+	actions []actionHook
+	// OnActionError is called with the error of an action hook that
+	// panicked; actions have no caller to return errors to
+	OnActionError func(event Event, err error)
+	running       sync.WaitGroup
+}
+
+type filterHook struct {
+	pattern string
+	fn      FilterFunc
+}
+
+type actionHook struct {
+	pattern string
+	fn      ActionFunc
+}
+
+// New creates an empty bus
+func New() *Bus {
+	return &Bus{}
+}
+
+// Filter registers a filter hook. Filters run in registration order.
+func (b *Bus) Filter(pattern string, fn FilterFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.filters = append(b.filters, filterHook{pattern: pattern, fn: fn})
+}
+
+// Action registers an action hook
+func (b *Bus) Action(pattern string, fn ActionFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.actions = append(b.actions, actionHook{pattern: pattern, fn: fn})
+}
+
+// HasFilters reports whether any filter matches an event name, so callers
+// can skip preparing a payload nobody looks at
+func (b *Bus) HasFilters(name string) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, hook := range b.filters {
+		if matches(hook.pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunFilters runs the filters matching an event in registration order, each
+// seeing the payload left by the previous one. It stops at the first error,
+// which rejects the change; a panicking filter rejects it too.
+func (b *Bus) RunFilters(ctx context.Context, event *Event) (err error) {
+	if b == nil {
+		return nil
+	}
+	b.mu.RLock()
+	filters := make([]filterHook, 0, len(b.filters))
+	for _, hook := range b.filters {
+		if matches(hook.pattern, event.Name) {
+			filters = append(filters, hook)
+		}
+	}
+	b.mu.RUnlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("hooks: filter for %s panicked: %v", event.Name, r)
+		}
+	}()
+	for _, hook := range filters {
+		if err := hook.fn(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Emit runs the actions matching an event in the background, so they don't
+// delay the response. Actions share the event's payload and must not
+// modify it.
+func (b *Bus) Emit(event Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	var actions []actionHook
+	for _, hook := range b.actions {
+		if matches(hook.pattern, event.Name) {
+			actions = append(actions, hook)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, hook := range actions {
+		b.running.Add(1)
+		go func(fn ActionFunc, event Event) {
+			defer b.running.Done()
+			defer func() {
+				if r := recover(); r != nil && b.OnActionError != nil {
+					b.OnActionError(event, fmt.Errorf("hooks: action for %s panicked: %v", event.Name, r))
+				}
+			}()
+			fn(context.Background(), event)
+		}(hook.fn, event)
+	}
+}
+
+// Wait blocks until the actions emitted so far have returned
+func (b *Bus) Wait() {
+	if b == nil {
+		return
+	}
+	b.running.Wait()
+}
+
+// matches reports whether an event name matches a hook pattern
+func matches(pattern, name string) bool {
+	if pattern == "*" || pattern == name {
+		return true
+	}
+	patternScope, patternAction, ok := strings.Cut(pattern, ".")
+	if !ok {
+		return false
+	}
+	scope, action, _ := strings.Cut(name, ".")
+	return (patternScope == "*" || patternScope == scope) && (patternAction == "*" || patternAction == action)
+}
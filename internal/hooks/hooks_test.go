@@ -0,0 +1,118 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatches(t *testing.T) {
+	assert.True(t, matches("*", "items.create"))
+	assert.True(t, matches("items.create", "items.create"))
+	assert.True(t, matches("items.*", "items.delete"))
+	assert.True(t, matches("*.delete", "users.delete"))
+	assert.False(t, matches("items.create", "items.update"))
+	assert.False(t, matches("items.*", "fields.create"))
+	assert.False(t, matches("items", "items.create"))
+}
+
+func TestRunFilters(t *testing.T) {
+	bus := New()
+	bus.Filter("items.create", func(ctx context.Context, event *Event) error {
+		event.Payload["status"] = "draft"
+		return nil
+	})
+	bus.Filter("items.*", func(ctx context.Context, event *Event) error {
+		// Filters see the payload left by earlier filters
+		event.Payload["seen"] = event.Payload["status"]
+		return nil
+	})
+	bus.Filter("fields.create", func(ctx context.Context, event *Event) error {
+		t.Fatal("filter of another event ran")
+		return nil
+	})
+
+	assert.True(t, bus.HasFilters("items.update"))
+	assert.False(t, bus.HasFilters("users.create"))
+
+	event := NewEvent("items", "articles", ActionCreate, map[string]interface{}{"title": "Hi"})
+	require.NoError(t, bus.RunFilters(context.Background(), &event))
+	assert.Equal(t, map[string]interface{}{"title": "Hi", "status": "draft", "seen": "draft"}, event.Payload)
+	assert.Equal(t, "items.create", event.Name)
+}
+
+func TestRunFilters_Reject(t *testing.T) {
+	bus := New()
+	bus.Filter("*.delete", func(ctx context.Context, event *Event) error {
+		return Reject("%s can't be deleted", event.Collection)
+	})
+	bus.Filter("*", func(ctx context.Context, event *Event) error {
+		t.Fatal("filter ran after a rejection")
+		return nil
+	})
+
+	event := NewEvent("items", "articles", ActionDelete, nil, "1")
+	err := bus.RunFilters(context.Background(), &event)
+	reject, ok := IsReject(err)
+	require.True(t, ok)
+	assert.Equal(t, "articles can't be deleted", reject.Message)
+}
+
+func TestRunFilters_ErrorsAndPanics(t *testing.T) {
+	bus := New()
+	bus.Filter("items.create", func(ctx context.Context, event *Event) error {
+		return errors.New("lookup failed")
+	})
+	bus.Filter("items.update", func(ctx context.Context, event *Event) error {
+		panic("boom")
+	})
+
+	event := NewEvent("items", "articles", ActionCreate, nil)
+	err := bus.RunFilters(context.Background(), &event)
+	_, ok := IsReject(err)
+	assert.False(t, ok)
+	assert.EqualError(t, err, "lookup failed")
+
+	event = NewEvent("items", "articles", ActionUpdate, nil)
+	assert.ErrorContains(t, bus.RunFilters(context.Background(), &event), "panicked")
+}
+
+func TestEmit(t *testing.T) {
+	bus := New()
+	var mu sync.Mutex
+	var seen []string
+	record := func(label string) ActionFunc {
+		return func(ctx context.Context, event Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			seen = append(seen, label+":"+event.Name)
+		}
+	}
+	bus.Action("items.create", record("exact"))
+	bus.Action("*", record("all"))
+	bus.Action("users.*", record("users"))
+	var panicked error
+	bus.OnActionError = func(event Event, err error) { panicked = err }
+	bus.Action("items.create", func(ctx context.Context, event Event) { panic("boom") })
+
+	bus.Emit(NewEvent("items", "articles", ActionCreate, nil, "1"))
+	bus.Wait()
+
+	assert.ElementsMatch(t, []string{"exact:items.create", "all:items.create"}, seen)
+	assert.ErrorContains(t, panicked, "panicked")
+}
+
+func TestNilBus(t *testing.T) {
+	var bus *Bus
+	event := NewEvent("items", "articles", ActionCreate, nil)
+	assert.NoError(t, bus.RunFilters(context.Background(), &event))
+	assert.False(t, bus.HasFilters(event.Name))
+	assert.NotPanics(t, func() {
+		bus.Emit(event)
+		bus.Wait()
+	})
+}
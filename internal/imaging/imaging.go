@@ -0,0 +1,199 @@
+// Package imaging resizes, crops and re-encodes images for asset
+// transformations.
+package imaging
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	// Registered for image.Decode
+	_ "image/gif"
+
+	_ "golang.org/x/image/webp"
+)
+
+// Fit modes, following the sharp/Directus naming
+const (
+	// FitCover crops the image to fill the box exactly
+	FitCover = "cover"
+	// FitContain fits the image into the box and pads the rest transparently
+	FitContain = "contain"
+	// FitInside fits the image into the box, keeping its aspect ratio
+	FitInside = "inside"
+	// FitOutside covers the box, keeping its aspect ratio
+	FitOutside = "outside"
+)
+
+// Output formats
+const (
+	FormatJPEG = "jpg"
+	FormatPNG  = "png"
+)
+
+// DefaultQuality is the JPEG quality used when none is requested
+const DefaultQuality = 80
+
+// ErrTooLarge is returned for images with more pixels than allowed
+var ErrTooLarge = errors.New("imaging: image too large")
+
+// Options describes a transformation. A zero Width or Height is derived from
+// the other one, keeping the aspect ratio.
+type Options struct {
+	Width              int
+	Height             int
+	Fit                string
+	Format             string
+	Quality            int
+	WithoutEnlargement bool
+}
+
+// IsFit reports whether fit is a known fit mode
+func IsFit(fit string) bool {
+	switch fit {
+	case FitCover, FitContain, FitInside, FitOutside:
+		return true
+	}
+	return false
+}
+
+// FormatOf returns the output format for a format name or MIME type, and
+// whether it is supported. GIF and WebP sources are written as PNG.
+func FormatOf(name string) (string, bool) {
+	switch name {
+	case "jpg", "jpeg", "image/jpeg":
+		return FormatJPEG, true
+	case "png", "image/png", "image/gif", "image/webp":
+		return FormatPNG, true
+	}
+	return "", false
+}
+
+// ContentType returns the MIME type of an output format
+func ContentType(format string) string {
+	switch format {
+	case FormatJPEG:
+		return "image/jpeg"
+	default:
+		return "image/png"
+	}
+}
+
+// Decode decodes a JPEG, PNG, GIF or WebP image, refusing images with more than
+// maxPixels pixels before decoding them
+func Decode(r io.ReadSeeker, maxPixels int) (image.Image, error) {
+	config, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(r)
+	return img, err
+}
+
+// Transform resizes and crops img as described by opts
+func Transform(img image.Image, opts Options) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if opts.Width == 0 && opts.Height == 0 || srcW == 0 || srcH == 0 {
+		return img
+	}
+
+	fit := opts.Fit
+	if fit == "" {
+		fit = FitCover
+	}
+
+	// With a single dimension every fit scales proportionally
+	boxW, boxH := opts.Width, opts.Height
+	if boxW == 0 {
+		boxW = max(1, srcW*boxH/srcH)
+		fit = FitInside
+	} else if boxH == 0 {
+		boxH = max(1, srcH*boxW/srcW)
+		fit = FitInside
+	}
+	if opts.WithoutEnlargement && boxW >= srcW && boxH >= srcH {
+		return img
+	}
+
+	src := toRGBA(img)
+	switch fit {
+	case FitCover:
+		// Crop the source to the box's aspect ratio, centered
+		crop := image.Rect(0, 0, srcW, srcH)
+		if srcW*boxH > boxW*srcH {
+			w := srcH * boxW / boxH
+			crop = image.Rect((srcW-w)/2, 0, (srcW-w)/2+w, srcH)
+		} else {
+			h := srcW * boxH / boxW
+			crop = image.Rect(0, (srcH-h)/2, srcW, (srcH-h)/2+h)
+		}
+		if opts.WithoutEnlargement && (boxW > crop.Dx() || boxH > crop.Dy()) {
+			boxW, boxH = crop.Dx(), crop.Dy()
+		}
+		return resize(src.SubImage(crop.Add(src.Rect.Min)).(*image.RGBA), boxW, boxH)
+	case FitContain:
+		w, h := scaledSize(srcW, srcH, boxW, boxH, false, opts.WithoutEnlargement)
+		canvas := image.NewRGBA(image.Rect(0, 0, boxW, boxH))
+		offset := image.Pt((boxW-w)/2, (boxH-h)/2)
+		draw.Draw(canvas, image.Rectangle{offset, offset.Add(image.Pt(w, h))}, resize(src, w, h), image.Point{}, draw.Src)
+		return canvas
+	case FitOutside:
+		w, h := scaledSize(srcW, srcH, boxW, boxH, true, opts.WithoutEnlargement)
+		return resize(src, w, h)
+	default:
+		w, h := scaledSize(srcW, srcH, boxW, boxH, false, opts.WithoutEnlargement)
+		return resize(src, w, h)
+	}
+}
+
+// scaledSize scales srcW x srcH to fit into (or, with cover, to cover) the box
+func scaledSize(srcW, srcH, boxW, boxH int, cover, withoutEnlargement bool) (int, int) {
+	scaleW := float64(boxW) / float64(srcW)
+	scaleH := float64(boxH) / float64(srcH)
+	scale := min(scaleW, scaleH)
+	if cover {
+		scale = max(scaleW, scaleH)
+	}
+	if withoutEnlargement && scale > 1 {
+		scale = 1
+	}
+	return max(1, int(float64(srcW)*scale+0.5)), max(1, int(float64(srcH)*scale+0.5))
+}
+
+// toRGBA converts an image to premultiplied RGBA with its origin at zero
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	if rgba, ok := img.(*image.RGBA); ok && bounds.Min == (image.Point{}) {
+		return rgba
+	}
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// Encode writes img in format. Quality applies to JPEG only.
+func Encode(w io.Writer, img image.Image, format string, quality int) error {
+	switch format {
+	case FormatJPEG:
+		if quality <= 0 {
+			quality = DefaultQuality
+		}
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case FormatPNG:
+		return png.Encode(w, img)
+	default:
+		return fmt.Errorf("imaging: unsupported format %q", format)
+	}
+}
@@ -0,0 +1,114 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testImage is a w x h image, red on the left half and blue on the right
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestTransformSizes(t *testing.T) {
+	src := testImage(400, 200)
+
+	tests := []struct {
+		name       string
+		opts       Options
+		wantWidth  int
+		wantHeight int
+	}{
+		{"width only", Options{Width: 100}, 100, 50},
+		{"height only", Options{Height: 100}, 200, 100},
+		{"cover", Options{Width: 100, Height: 100, Fit: FitCover}, 100, 100},
+		{"default fit is cover", Options{Width: 100, Height: 100}, 100, 100},
+		{"contain", Options{Width: 100, Height: 100, Fit: FitContain}, 100, 100},
+		{"inside", Options{Width: 100, Height: 100, Fit: FitInside}, 100, 50},
+		{"outside", Options{Width: 100, Height: 100, Fit: FitOutside}, 200, 100},
+		{"enlarge", Options{Width: 800}, 800, 400},
+		{"without enlargement", Options{Width: 800, WithoutEnlargement: true}, 400, 200},
+		{"cover without enlargement", Options{Width: 300, Height: 300, WithoutEnlargement: true}, 200, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bounds := Transform(src, tt.opts).Bounds()
+			assert.Equal(t, tt.wantWidth, bounds.Dx())
+			assert.Equal(t, tt.wantHeight, bounds.Dy())
+		})
+	}
+}
+
+func TestTransformPixels(t *testing.T) {
+	src := testImage(400, 200)
+
+	// Cover crops the middle of the image, which is half red, half blue
+	cover := Transform(src, Options{Width: 10, Height: 10, Fit: FitCover})
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, cover.At(0, 5))
+	assert.Equal(t, color.RGBA{0, 0, 255, 255}, cover.At(9, 5))
+
+	// Contain pads above and below with transparency
+	contain := Transform(src, Options{Width: 100, Height: 100, Fit: FitContain})
+	assert.Equal(t, color.RGBA{}, contain.At(50, 0))
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, contain.At(10, 50))
+}
+
+func TestEncode(t *testing.T) {
+	img := testImage(8, 4)
+
+	var jpg, pngData bytes.Buffer
+	require.NoError(t, Encode(&jpg, img, FormatJPEG, 50))
+	require.NoError(t, Encode(&pngData, img, FormatPNG, 0))
+
+	decoded, err := png.Decode(&pngData)
+	require.NoError(t, err)
+	assert.Equal(t, img.Bounds(), decoded.Bounds())
+
+	config, format, err := image.DecodeConfig(&jpg)
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, 8, config.Width)
+
+	assert.Error(t, Encode(&bytes.Buffer{}, img, "tiff", 0))
+}
+
+func TestDecodeRejectsLargeImages(t *testing.T) {
+	var data bytes.Buffer
+	require.NoError(t, png.Encode(&data, testImage(100, 100)))
+
+	_, err := Decode(bytes.NewReader(data.Bytes()), 100*99)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	img, err := Decode(bytes.NewReader(data.Bytes()), 100*100)
+	require.NoError(t, err)
+	assert.Equal(t, 100, img.Bounds().Dx())
+}
+
+func TestFormatOf(t *testing.T) {
+	for name, want := range map[string]string{"jpeg": FormatJPEG, "image/jpeg": FormatJPEG, "image/gif": FormatPNG, "image/webp": FormatPNG} {
+		format, ok := FormatOf(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, want, format, name)
+	}
+	for _, name := range []string{"image/svg+xml", "webp"} {
+		_, ok := FormatOf(name)
+		assert.False(t, ok, name)
+	}
+}
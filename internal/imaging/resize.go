@@ -0,0 +1,107 @@
+package imaging
+
+import (
+	"image"
+	"math"
+)
+
+// weights holds the source pixels and their weights for one output pixel
+type weights struct {
+	start  int
+	values []float32
+}
+
+// linearWeights computes triangle filter weights for scaling srcSize pixels
+// to dstSize. When shrinking, the filter widens so every source pixel counts.
+func linearWeights(srcSize, dstSize int) []weights {
+	scale := float64(srcSize) / float64(dstSize)
+	support := math.Max(scale, 1)
+
+	result := make([]weights, dstSize)
+	for i := range result {
+		center := (float64(i)+0.5)*scale - 0.5
+		first := int(math.Ceil(center - support))
+		last := int(math.Floor(center + support))
+
+		values := make([]float32, 0, last-first+1)
+		var sum float32
+		for j := first; j <= last; j++ {
+			w := float32(1 - math.Abs(float64(j)-center)/support)
+			if w < 0 {
+				w = 0
+			}
+			values = append(values, w)
+			sum += w
+		}
+		for j := range values {
+			values[j] /= sum
+		}
+		result[i] = weights{start: first, values: values}
+	}
+	return result
+}
+
+// resize scales src to w x h with a separable linear filter. Edge pixels are
+// repeated for filter taps outside the image.
+func resize(src *image.RGBA, w, h int) *image.RGBA {
+	srcW, srcH := src.Rect.Dx(), src.Rect.Dy()
+	if srcW == w && srcH == h {
+		return src
+	}
+
+	// Horizontal pass into a float buffer of w x srcH
+	tmp := make([]float32, w*srcH*4)
+	for x, wt := range linearWeights(srcW, w) {
+		for y := 0; y < srcH; y++ {
+			row := src.Pix[y*src.Stride:]
+			var r, g, b, a float32
+			for k, weight := range wt.values {
+				sx := clamp(wt.start+k, srcW) * 4
+				r += float32(row[sx]) * weight
+				g += float32(row[sx+1]) * weight
+				b += float32(row[sx+2]) * weight
+				a += float32(row[sx+3]) * weight
+			}
+			i := (y*w + x) * 4
+			tmp[i], tmp[i+1], tmp[i+2], tmp[i+3] = r, g, b, a
+		}
+	}
+
+	// Vertical pass into the result
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y, wt := range linearWeights(srcH, h) {
+		for x := 0; x < w; x++ {
+			var r, g, b, a float32
+			for k, weight := range wt.values {
+				i := (clamp(wt.start+k, srcH)*w + x) * 4
+				r += tmp[i] * weight
+				g += tmp[i+1] * weight
+				b += tmp[i+2] * weight
+				a += tmp[i+3] * weight
+			}
+			o := y*dst.Stride + x*4
+			dst.Pix[o], dst.Pix[o+1], dst.Pix[o+2], dst.Pix[o+3] = toByte(r), toByte(g), toByte(b), toByte(a)
+		}
+	}
+	return dst
+}
+
+func clamp(i, size int) int {
+	if i < 0 {
+		return 0
+	}
+	if i >= size {
+		return size - 1
+	}
+	return i
+}
+
+func toByte(v float32) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
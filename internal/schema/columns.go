@@ -0,0 +1,317 @@
+package schema
+
+import (
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// TableColumn is a column of a collection table, in table order
+type TableColumn struct {
+	Name string
+	// HasDefault is set for any column default, including expressions that
+	// have no literal DefaultValue such as gen_random_uuid()
+	HasDefault bool
+	Column
+}
+
+// literalDefault matches Postgres literal defaults such as 'draft'::character varying
+var literalDefault = regexp.MustCompile(`^'((?:[^']|'')*)'(?:::[a-z ]+(?:\(\d+\))?)?$`)
+
+// LoadColumns introspects the given tables and returns their columns keyed by
+// table name, including nullability, literal defaults, primary keys, single
+// column unique indexes and foreign keys.
+func LoadColumns(db *sql.DB, tables []string) (map[string][]TableColumn, error) {
+	result := make(map[string][]TableColumn)
+	if len(tables) == 0 {
+		return result, nil
+	}
+
+	rows, err := db.Query(`
+		SELECT table_name, column_name, data_type, character_maximum_length, is_nullable, column_default
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ANY($1)
+		ORDER BY table_name ASC, ordinal_position ASC
+	`, pq.Array(tables))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, name, dataType, nullable string
+		var maxLength sql.NullInt64
+		var columnDefault sql.NullString
+
+		if err := rows.Scan(&table, &name, &dataType, &maxLength, &nullable, &columnDefault); err != nil {
+			return nil, err
+		}
+
+		column := Column{DataType: NormalizeDataType(dataType)}
+		if maxLength.Valid && column.DataType == "string" {
+			length := int(maxLength.Int64)
+			column.MaxLength = &length
+		}
+		isNullable := nullable == "YES"
+		column.IsNullable = &isNullable
+		if columnDefault.Valid {
+			column.DefaultValue = ParseColumnDefault(columnDefault.String)
+		}
+
+		result[table] = append(result[table], TableColumn{Name: name, HasDefault: columnDefault.Valid, Column: column})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadKeys(db, tables, result); err != nil {
+		return nil, err
+	}
+	if err := loadUniqueIndexes(db, tables, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// loadKeys marks primary key columns and records foreign key targets
+func loadKeys(db *sql.DB, tables []string, columns map[string][]TableColumn) error {
+	rows, err := db.Query(`
+		SELECT tc.table_name, kcu.column_name, tc.constraint_type, ccu.table_name, ccu.column_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+		  ON tc.constraint_name = kcu.constraint_name AND tc.table_schema = kcu.table_schema
+		LEFT JOIN information_schema.constraint_column_usage ccu
+		  ON tc.constraint_type = 'FOREIGN KEY'
+		 AND tc.constraint_name = ccu.constraint_name AND tc.table_schema = ccu.table_schema
+		WHERE tc.table_schema = current_schema() AND tc.table_name = ANY($1)
+		  AND tc.constraint_type IN ('PRIMARY KEY', 'FOREIGN KEY')
+	`, pq.Array(tables))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, name, constraintType string
+		var foreignTable, foreignColumn sql.NullString
+
+		if err := rows.Scan(&table, &name, &constraintType, &foreignTable, &foreignColumn); err != nil {
+			return err
+		}
+
+		column := findColumn(columns, table, name)
+		if column == nil {
+			continue
+		}
+
+		switch constraintType {
+		case "PRIMARY KEY":
+			isPrimaryKey := true
+			column.IsPrimaryKey = &isPrimaryKey
+		case "FOREIGN KEY":
+			if foreignTable.Valid && foreignColumn.Valid {
+				column.ForeignTable = &foreignTable.String
+				column.ForeignColumn = &foreignColumn.String
+			}
+		}
+	}
+
+	return rows.Err()
+}
+
+// loadUniqueIndexes marks columns covered by a single column unique index or constraint
+func loadUniqueIndexes(db *sql.DB, tables []string, columns map[string][]TableColumn) error {
+	unique, err := UniqueColumns(db, tables)
+	if err != nil {
+		return err
+	}
+
+	for table, names := range unique {
+		for _, name := range names {
+			if column := findColumn(columns, table, name); column != nil {
+				isUnique := true
+				column.IsUnique = &isUnique
+			}
+		}
+	}
+
+	return nil
+}
+
+// UniqueColumns returns the columns of the given tables covered by a single
+// column unique index or constraint, keyed by table name. Primary keys aren't
+// included.
+func UniqueColumns(db *sql.DB, tables []string) (map[string][]string, error) {
+	rows, err := db.Query(`
+		SELECT t.relname, a.attname
+		FROM pg_index i
+		JOIN pg_class t ON t.oid = i.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = i.indkey[0]
+		WHERE i.indisunique AND NOT i.indisprimary AND i.indnatts = 1
+		  AND n.nspname = current_schema() AND t.relname = ANY($1)
+	`, pq.Array(tables))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	unique := make(map[string][]string)
+	for rows.Next() {
+		var table, name string
+		if err := rows.Scan(&table, &name); err != nil {
+			return nil, err
+		}
+		unique[table] = append(unique[table], name)
+	}
+
+	return unique, rows.Err()
+}
+
+// ForeignKey is the column a foreign key column references
+type ForeignKey struct {
+	Table  string
+	Column string
+	// Type is the Postgres type name of the referenced column, such as uuid
+	// or int4, for comparing values in the column's own type
+	Type string
+}
+
+// ForeignKeys returns the foreign keys of a table keyed by column name, such
+// as the related collection of a many-to-one field
+func ForeignKeys(db *sql.DB, table string) (map[string]ForeignKey, error) {
+	rows, err := db.Query(`
+		SELECT kcu.column_name, ccu.table_name, ccu.column_name, c.udt_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+		  ON tc.constraint_name = kcu.constraint_name AND tc.table_schema = kcu.table_schema
+		JOIN information_schema.constraint_column_usage ccu
+		  ON tc.constraint_name = ccu.constraint_name AND tc.table_schema = ccu.table_schema
+		JOIN information_schema.columns c
+		  ON c.table_schema = ccu.table_schema AND c.table_name = ccu.table_name AND c.column_name = ccu.column_name
+		WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = current_schema()
+		  AND tc.table_name = $1
+	`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make(map[string]ForeignKey)
+	for rows.Next() {
+		var column string
+		var key ForeignKey
+		if err := rows.Scan(&column, &key.Table, &key.Column, &key.Type); err != nil {
+			return nil, err
+		}
+		keys[column] = key
+	}
+
+	return keys, rows.Err()
+}
+
+// ReferencingColumns returns the columns of table with a foreign key to
+// referencedTable, such as the parent column of a one-to-many relation
+func ReferencingColumns(db *sql.DB, table, referencedTable string) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT DISTINCT kcu.column_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+		  ON tc.constraint_name = kcu.constraint_name AND tc.table_schema = kcu.table_schema
+		JOIN information_schema.constraint_column_usage ccu
+		  ON tc.constraint_name = ccu.constraint_name AND tc.table_schema = ccu.table_schema
+		WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = current_schema()
+		  AND tc.table_name = $1 AND ccu.table_name = $2
+		ORDER BY kcu.column_name ASC
+	`, table, referencedTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+
+	return columns, rows.Err()
+}
+
+func findColumn(columns map[string][]TableColumn, table, name string) *Column {
+	for i := range columns[table] {
+		if columns[table][i].Name == name {
+			return &columns[table][i].Column
+		}
+	}
+	return nil
+}
+
+// NormalizeDataType maps an information_schema data type to the data type
+// names accepted by the fields API. Unknown types are returned unchanged.
+func NormalizeDataType(dataType string) string {
+	switch strings.ToLower(dataType) {
+	case "character varying", "character":
+		return "string"
+	case "text":
+		return "text"
+	case "integer", "smallint":
+		return "integer"
+	case "bigint":
+		return "bigint"
+	case "numeric", "real", "double precision":
+		return "decimal"
+	case "boolean":
+		return "boolean"
+	case "date":
+		return "date"
+	case "time without time zone", "time with time zone":
+		return "time"
+	case "timestamp without time zone", "timestamp with time zone":
+		return "timestamp"
+	case "uuid":
+		return "uuid"
+	case "json", "jsonb":
+		return "json"
+	default:
+		return dataType
+	}
+}
+
+// ParseColumnDefault converts a Postgres column default expression into a
+// literal value. Expressions such as gen_random_uuid() or CURRENT_TIMESTAMP
+// have no literal form and yield nil.
+func ParseColumnDefault(expression string) interface{} {
+	expression = strings.TrimSpace(expression)
+
+	if match := literalDefault.FindStringSubmatch(expression); match != nil {
+		literal := strings.ReplaceAll(match[1], "''", "'")
+		if strings.Contains(expression, "::numeric") || strings.Contains(expression, "::integer") ||
+			strings.Contains(expression, "::bigint") {
+			if number, err := strconv.ParseFloat(literal, 64); err == nil {
+				return number
+			}
+		}
+		return literal
+	}
+
+	switch expression {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	if number, err := strconv.ParseFloat(strings.Trim(expression, "()"), 64); err == nil {
+		return number
+	}
+
+	return nil
+}
@@ -0,0 +1,297 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Plan step actions, in the order they are executed
+const (
+	ActionCreateCollection = "create_collection"
+	ActionAddField         = "add_field"
+	ActionAlterField       = "alter_field"
+	ActionDropField        = "drop_field"
+)
+
+// SystemColumns are created with every collection table and never added or dropped by a plan
+var SystemColumns = []string{"id", "created_at", "updated_at"}
+
+// Step is a single change needed to bring the live schema in line with a snapshot.
+//
+// For create_collection, Definition holds the collection metadata. For field
+// steps, FieldDefinition holds the target field metadata (the current one for
+// drop_field) and Schema describes the column: the column to create for
+// add_field, only the changed column attributes for alter_field, and the column
+// being removed for drop_field. A nil Schema means no column is touched.
+type Step struct {
+	Action          string      `json:"action" yaml:"action"`
+	Collection      string      `json:"collection" yaml:"collection"`
+	Field           string      `json:"field,omitempty" yaml:"field,omitempty"`
+	Description     string      `json:"description" yaml:"description"`
+	Definition      *Collection `json:"definition,omitempty" yaml:"definition,omitempty"`
+	FieldDefinition *Field      `json:"field_definition,omitempty" yaml:"field_definition,omitempty"`
+	Schema          *Column     `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+// Parse decodes a JSON or YAML snapshot document and checks its version.
+// YAML is normalized through JSON so both formats yield the same value types.
+func Parse(data []byte, format string) (*Snapshot, error) {
+	switch format {
+	case FormatJSON:
+	case FormatYAML:
+		var raw interface{}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("invalid YAML snapshot: %w", err)
+		}
+		converted, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML snapshot: %w", err)
+		}
+		data = converted
+	default:
+		return nil, fmt.Errorf("unsupported snapshot format %q", format)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("invalid JSON snapshot: %w", err)
+	}
+
+	if snapshot.Version == 0 {
+		return nil, fmt.Errorf("snapshot version is missing")
+	}
+	if snapshot.Version > Version {
+		return nil, fmt.Errorf("snapshot version %d is not supported (max %d)", snapshot.Version, Version)
+	}
+
+	return &snapshot, nil
+}
+
+// Diff compares the live schema with a target snapshot and returns the ordered
+// plan: created collections first, then added fields, altered fields and
+// finally dropped fields. Collections missing from the target are left alone;
+// fields are only dropped from collections present in both.
+func Diff(current, target *Snapshot) []Step {
+	currentCollections := make(map[string]bool)
+	for _, collection := range current.Collections {
+		currentCollections[collection.Collection] = true
+	}
+	targetCollections := make(map[string]bool)
+	for _, collection := range target.Collections {
+		targetCollections[collection.Collection] = true
+	}
+
+	currentFields := indexFields(current.Fields)
+	targetFields := indexFields(target.Fields)
+
+	var creates, adds, alters, drops []Step
+
+	for i := range target.Collections {
+		collection := target.Collections[i]
+		if currentCollections[collection.Collection] {
+			continue
+		}
+		creates = append(creates, Step{
+			Action:      ActionCreateCollection,
+			Collection:  collection.Collection,
+			Description: "Create collection " + collection.Collection,
+			Definition:  &collection,
+		})
+	}
+
+	for i := range target.Fields {
+		field := target.Fields[i]
+		if !targetCollections[field.Collection] {
+			continue
+		}
+
+		existing, ok := currentFields[fieldKey(field.Collection, field.Field)]
+		if !ok {
+			step := Step{
+				Action:          ActionAddField,
+				Collection:      field.Collection,
+				Field:           field.Field,
+				Description:     "Add field " + field.Collection + "." + field.Field,
+				FieldDefinition: &field,
+			}
+			// System columns already exist on every collection table
+			if field.Schema != nil && !IsSystemColumn(field.Field) {
+				step.Schema = field.Schema
+				step.Description += " (" + describeColumn(field.Schema) + ")"
+			}
+			adds = append(adds, step)
+			continue
+		}
+
+		columnChanges := diffColumn(existing.Schema, field.Schema)
+		metadataChanged := !sameMetadata(existing, &field)
+		if columnChanges == nil && !metadataChanged {
+			continue
+		}
+
+		step := Step{
+			Action:          ActionAlterField,
+			Collection:      field.Collection,
+			Field:           field.Field,
+			Description:     "Alter field " + field.Collection + "." + field.Field,
+			FieldDefinition: &field,
+			Schema:          columnChanges,
+		}
+		if columnChanges != nil {
+			step.Description += " (" + describeColumnChanges(existing.Schema, columnChanges) + ")"
+		} else {
+			step.Description += " (metadata only)"
+		}
+		alters = append(alters, step)
+	}
+
+	for i := range current.Fields {
+		field := current.Fields[i]
+		if !targetCollections[field.Collection] || IsSystemColumn(field.Field) {
+			continue
+		}
+		if _, ok := targetFields[fieldKey(field.Collection, field.Field)]; ok {
+			continue
+		}
+		drops = append(drops, Step{
+			Action:          ActionDropField,
+			Collection:      field.Collection,
+			Field:           field.Field,
+			Description:     "Drop field " + field.Collection + "." + field.Field,
+			FieldDefinition: &field,
+			Schema:          field.Schema,
+		})
+	}
+
+	plan := make([]Step, 0, len(creates)+len(adds)+len(alters)+len(drops))
+	plan = append(plan, creates...)
+	plan = append(plan, adds...)
+	plan = append(plan, alters...)
+	plan = append(plan, drops...)
+	return plan
+}
+
+// IsSystemColumn reports whether a column is one of the default collection columns
+func IsSystemColumn(name string) bool {
+	for _, column := range SystemColumns {
+		if name == column {
+			return true
+		}
+	}
+	return false
+}
+
+// CanonicalDataType maps data type aliases accepted by the fields API to a single name
+func CanonicalDataType(dataType string) string {
+	switch strings.ToLower(dataType) {
+	case "string", "varchar":
+		return "string"
+	case "integer", "int":
+		return "integer"
+	case "float", "decimal":
+		return "decimal"
+	case "boolean", "bool":
+		return "boolean"
+	case "datetime", "timestamp":
+		return "timestamp"
+	case "json", "jsonb":
+		return "json"
+	default:
+		return strings.ToLower(dataType)
+	}
+}
+
+// diffColumn returns the column attributes that must change to turn current
+// into target, or nil when nothing changes. Unique and foreign key changes are
+// not altered in place and are ignored.
+func diffColumn(current, target *Column) *Column {
+	if current == nil || target == nil {
+		return nil
+	}
+
+	var changes Column
+	changed := false
+
+	currentType := CanonicalDataType(current.DataType)
+	targetType := CanonicalDataType(target.DataType)
+	if target.DataType != "" &&
+		(currentType != targetType || (targetType == "string" && stringLength(current) != stringLength(target))) {
+		changes.DataType = target.DataType
+		changes.MaxLength = target.MaxLength
+		changed = true
+	}
+
+	if target.IsNullable != nil && (current.IsNullable == nil || *current.IsNullable != *target.IsNullable) {
+		changes.IsNullable = target.IsNullable
+		changed = true
+	}
+
+	if target.DefaultValue != nil && !reflect.DeepEqual(current.DefaultValue, target.DefaultValue) {
+		changes.DefaultValue = target.DefaultValue
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	return &changes
+}
+
+// stringLength returns the effective VARCHAR length, which defaults to 255
+func stringLength(column *Column) int {
+	if column.MaxLength != nil && *column.MaxLength > 0 {
+		return *column.MaxLength
+	}
+	return 255
+}
+
+// sameMetadata compares two fields ignoring their column details
+func sameMetadata(a, b *Field) bool {
+	left, right := *a, *b
+	left.Schema, right.Schema = nil, nil
+	leftJSON, _ := json.Marshal(left)
+	rightJSON, _ := json.Marshal(right)
+	return string(leftJSON) == string(rightJSON)
+}
+
+func describeColumn(column *Column) string {
+	description := column.DataType
+	if CanonicalDataType(column.DataType) == "string" {
+		description += fmt.Sprintf("(%d)", stringLength(column))
+	}
+	return description
+}
+
+func describeColumnChanges(current, changes *Column) string {
+	var parts []string
+	if changes.DataType != "" {
+		parts = append(parts, "type "+describeColumn(current)+" -> "+describeColumn(changes))
+	}
+	if changes.IsNullable != nil {
+		if *changes.IsNullable {
+			parts = append(parts, "nullable")
+		} else {
+			parts = append(parts, "not null")
+		}
+	}
+	if changes.DefaultValue != nil {
+		parts = append(parts, fmt.Sprintf("default %v", changes.DefaultValue))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func indexFields(fields []Field) map[string]*Field {
+	index := make(map[string]*Field, len(fields))
+	for i := range fields {
+		index[fieldKey(fields[i].Collection, fields[i].Field)] = &fields[i]
+	}
+	return index
+}
+
+func fieldKey(collection, field string) string {
+	return collection + "." + field
+}
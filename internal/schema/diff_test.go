@@ -0,0 +1,112 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stringPtr(value string) *string { return &value }
+func intPtr(value int) *int          { return &value }
+func boolPtr(value bool) *bool       { return &value }
+
+func TestParse(t *testing.T) {
+	snapshot, err := Parse([]byte(`{"version": 1, "collections": [{"collection": "articles"}]}`), FormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, "articles", snapshot.Collections[0].Collection)
+
+	yamlDocument := []byte(`
+version: 1
+fields:
+  - collection: articles
+    field: views
+    schema:
+      data_type: integer
+      default_value: 0
+`)
+	snapshot, err = Parse(yamlDocument, FormatYAML)
+	require.NoError(t, err)
+	// YAML numbers are normalized to the JSON representation
+	assert.Equal(t, float64(0), snapshot.Fields[0].Schema.DefaultValue)
+
+	_, err = Parse([]byte(`{"collections": []}`), FormatJSON)
+	assert.ErrorContains(t, err, "version is missing")
+
+	_, err = Parse([]byte(`{"version": 99}`), FormatJSON)
+	assert.ErrorContains(t, err, "not supported")
+
+	_, err = Parse([]byte(`{`), FormatJSON)
+	assert.Error(t, err)
+}
+
+func TestDiff(t *testing.T) {
+	current := &Snapshot{
+		Collections: []Collection{{Collection: "articles"}},
+		Fields: []Field{
+			{Collection: "articles", Field: "id", Schema: &Column{DataType: "uuid", IsNullable: boolPtr(false)}},
+			{Collection: "articles", Field: "title", Width: "full", Schema: &Column{DataType: "string", MaxLength: intPtr(100), IsNullable: boolPtr(true)}},
+			{Collection: "articles", Field: "views", Width: "full", Schema: &Column{DataType: "string", IsNullable: boolPtr(true)}},
+			{Collection: "articles", Field: "legacy", Width: "full", Schema: &Column{DataType: "text", IsNullable: boolPtr(true)}},
+			{Collection: "articles", Field: "note", Width: "full", Note: stringPtr("old"), Schema: &Column{DataType: "text", IsNullable: boolPtr(true)}},
+		},
+	}
+	target := &Snapshot{
+		Collections: []Collection{{Collection: "articles"}, {Collection: "authors"}},
+		Fields: []Field{
+			{Collection: "articles", Field: "id", Schema: &Column{DataType: "uuid", IsNullable: boolPtr(false)}},
+			{Collection: "articles", Field: "title", Width: "full", Schema: &Column{DataType: "varchar", MaxLength: intPtr(100), IsNullable: boolPtr(true)}},
+			{Collection: "articles", Field: "views", Width: "full", Schema: &Column{DataType: "integer", IsNullable: boolPtr(false)}},
+			{Collection: "articles", Field: "note", Width: "full", Note: stringPtr("new"), Schema: &Column{DataType: "text", IsNullable: boolPtr(true)}},
+			{Collection: "authors", Field: "id", Schema: &Column{DataType: "uuid"}},
+			{Collection: "authors", Field: "name", Width: "full", Schema: &Column{DataType: "string"}},
+		},
+	}
+
+	plan := Diff(current, target)
+
+	var actions []string
+	for _, step := range plan {
+		actions = append(actions, step.Action+" "+step.Collection+"."+step.Field)
+	}
+	assert.Equal(t, []string{
+		"create_collection authors.",
+		"add_field authors.id",
+		"add_field authors.name",
+		"alter_field articles.views",
+		"alter_field articles.note",
+		"drop_field articles.legacy",
+	}, actions)
+
+	// System columns are registered without creating a column
+	assert.Nil(t, plan[1].Schema)
+	assert.NotNil(t, plan[2].Schema)
+
+	views := plan[3].Schema
+	require.NotNil(t, views)
+	assert.Equal(t, "integer", views.DataType)
+	assert.False(t, *views.IsNullable)
+	assert.Contains(t, plan[3].Description, "type string(255) -> integer")
+
+	assert.Nil(t, plan[4].Schema, "metadata-only change")
+	assert.Contains(t, plan[4].Description, "metadata only")
+
+	assert.NotNil(t, plan[5].Schema, "the dropped column is removed")
+}
+
+func TestDiffNoChanges(t *testing.T) {
+	snapshot := &Snapshot{
+		Collections: []Collection{{Collection: "articles"}},
+		Fields: []Field{
+			{Collection: "articles", Field: "title", Special: []string{}, Schema: &Column{DataType: "string", IsNullable: boolPtr(true)}},
+		},
+	}
+	target := &Snapshot{
+		Collections: []Collection{{Collection: "articles"}},
+		Fields: []Field{
+			{Collection: "articles", Field: "title", Schema: &Column{DataType: "varchar", MaxLength: intPtr(255)}},
+		},
+	}
+
+	assert.Empty(t, Diff(snapshot, target))
+}
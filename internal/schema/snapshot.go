@@ -0,0 +1,301 @@
+// Package schema serializes the GoRectus data model (collections, fields,
+// their database columns and permissions) into a portable snapshot document.
+// It is shared by the API server and the migration tool.
+package schema
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the snapshot format version written by this package
+const Version = 1
+
+// Supported snapshot encodings
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Snapshot is a versioned description of the whole data model
+type Snapshot struct {
+	Version     int          `json:"version" yaml:"version"`
+	GeneratedAt time.Time    `json:"generated_at" yaml:"generated_at"`
+	Collections []Collection `json:"collections" yaml:"collections"`
+	Fields      []Field      `json:"fields" yaml:"fields"`
+	Permissions []Permission `json:"permissions" yaml:"permissions"`
+}
+
+// Collection is the metadata of a collection as stored in the collections table
+type Collection struct {
+	Collection            string      `json:"collection" yaml:"collection"`
+	Icon                  *string     `json:"icon,omitempty" yaml:"icon,omitempty"`
+	Note                  *string     `json:"note,omitempty" yaml:"note,omitempty"`
+	DisplayTemplate       *string     `json:"display_template,omitempty" yaml:"display_template,omitempty"`
+	Hidden                bool        `json:"hidden" yaml:"hidden"`
+	Singleton             bool        `json:"singleton" yaml:"singleton"`
+	Translations          interface{} `json:"translations,omitempty" yaml:"translations,omitempty"`
+	ArchiveField          *string     `json:"archive_field,omitempty" yaml:"archive_field,omitempty"`
+	ArchiveAppFilter      bool        `json:"archive_app_filter" yaml:"archive_app_filter"`
+	ArchiveValue          *string     `json:"archive_value,omitempty" yaml:"archive_value,omitempty"`
+	UnarchiveValue        *string     `json:"unarchive_value,omitempty" yaml:"unarchive_value,omitempty"`
+	SortField             *string     `json:"sort_field,omitempty" yaml:"sort_field,omitempty"`
+	Accountability        string      `json:"accountability" yaml:"accountability"`
+	Color                 *string     `json:"color,omitempty" yaml:"color,omitempty"`
+	ItemDuplicationFields interface{} `json:"item_duplication_fields,omitempty" yaml:"item_duplication_fields,omitempty"`
+	Sort                  *int        `json:"sort,omitempty" yaml:"sort,omitempty"`
+	Group                 *string     `json:"group,omitempty" yaml:"group,omitempty"`
+	Collapse              string      `json:"collapse" yaml:"collapse"`
+	PreviewURL            *string     `json:"preview_url,omitempty" yaml:"preview_url,omitempty"`
+	Versioning            bool        `json:"versioning" yaml:"versioning"`
+}
+
+// Field is the metadata of a field plus the details of its database column
+type Field struct {
+	Collection        string      `json:"collection" yaml:"collection"`
+	Field             string      `json:"field" yaml:"field"`
+	Special           []string    `json:"special,omitempty" yaml:"special,omitempty"`
+	Interface         *string     `json:"interface,omitempty" yaml:"interface,omitempty"`
+	Options           interface{} `json:"options,omitempty" yaml:"options,omitempty"`
+	Display           *string     `json:"display,omitempty" yaml:"display,omitempty"`
+	DisplayOptions    interface{} `json:"display_options,omitempty" yaml:"display_options,omitempty"`
+	Readonly          bool        `json:"readonly" yaml:"readonly"`
+	Hidden            bool        `json:"hidden" yaml:"hidden"`
+	Sort              *int        `json:"sort,omitempty" yaml:"sort,omitempty"`
+	Width             string      `json:"width" yaml:"width"`
+	Translations      interface{} `json:"translations,omitempty" yaml:"translations,omitempty"`
+	Note              *string     `json:"note,omitempty" yaml:"note,omitempty"`
+	Conditions        interface{} `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+	Required          bool        `json:"required" yaml:"required"`
+	Group             *string     `json:"group,omitempty" yaml:"group,omitempty"`
+	Validation        interface{} `json:"validation,omitempty" yaml:"validation,omitempty"`
+	ValidationMessage *string     `json:"validation_message,omitempty" yaml:"validation_message,omitempty"`
+	// Schema is nil for virtual fields that have no database column
+	Schema *Column `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+// Column describes a database column using the same vocabulary as the
+// fields API (FieldSchema), so it can be fed back into column creation.
+type Column struct {
+	DataType      string      `json:"data_type" yaml:"data_type"`
+	MaxLength     *int        `json:"max_length,omitempty" yaml:"max_length,omitempty"`
+	IsNullable    *bool       `json:"is_nullable,omitempty" yaml:"is_nullable,omitempty"`
+	DefaultValue  interface{} `json:"default_value,omitempty" yaml:"default_value,omitempty"`
+	IsUnique      *bool       `json:"is_unique,omitempty" yaml:"is_unique,omitempty"`
+	IsPrimaryKey  *bool       `json:"is_primary_key,omitempty" yaml:"is_primary_key,omitempty"`
+	ForeignTable  *string     `json:"foreign_table,omitempty" yaml:"foreign_table,omitempty"`
+	ForeignColumn *string     `json:"foreign_column,omitempty" yaml:"foreign_column,omitempty"`
+}
+
+// Permission is a role permission. Roles are referenced by name so that a
+// snapshot can be applied to an instance where role IDs differ.
+type Permission struct {
+	Role        *string     `json:"role" yaml:"role"`
+	Collection  string      `json:"collection" yaml:"collection"`
+	Action      string      `json:"action" yaml:"action"`
+	Permissions interface{} `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+	Validation  interface{} `json:"validation,omitempty" yaml:"validation,omitempty"`
+	Presets     interface{} `json:"presets,omitempty" yaml:"presets,omitempty"`
+	Fields      []string    `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// Load reads the current data model from the database
+func Load(db *sql.DB) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		Version:     Version,
+		GeneratedAt: time.Now().UTC(),
+		Collections: []Collection{},
+		Fields:      []Field{},
+		Permissions: []Permission{},
+	}
+
+	collections, err := loadCollections(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load collections: %w", err)
+	}
+	snapshot.Collections = collections
+
+	tables := make([]string, 0, len(collections))
+	for _, collection := range collections {
+		tables = append(tables, collection.Collection)
+	}
+
+	columns, err := LoadColumns(db, tables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load columns: %w", err)
+	}
+
+	fields, err := loadFields(db, columns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fields: %w", err)
+	}
+	snapshot.Fields = fields
+
+	permissions, err := loadPermissions(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load permissions: %w", err)
+	}
+	snapshot.Permissions = permissions
+
+	return snapshot, nil
+}
+
+// Marshal encodes a snapshot as JSON or YAML
+func Marshal(snapshot *Snapshot, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(snapshot, "", "  ")
+	case FormatYAML:
+		return yaml.Marshal(snapshot)
+	default:
+		return nil, fmt.Errorf("unsupported snapshot format %q", format)
+	}
+}
+
+func loadCollections(db *sql.DB) ([]Collection, error) {
+	rows, err := db.Query(`
+		SELECT collection, icon, note, display_template,
+		       COALESCE(hidden, false), COALESCE(singleton, false),
+		       translations, archive_field, COALESCE(archive_app_filter, true), archive_value,
+		       unarchive_value, sort_field, COALESCE(accountability, 'all'), color,
+		       item_duplication_fields, sort, "group", COALESCE(collapse, 'open'), preview_url,
+		       COALESCE(versioning, false)
+		FROM collections
+		ORDER BY sort ASC, collection ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []Collection{}
+	for rows.Next() {
+		var collection Collection
+		var translations, itemDuplicationFields []byte
+
+		err := rows.Scan(
+			&collection.Collection, &collection.Icon, &collection.Note,
+			&collection.DisplayTemplate, &collection.Hidden, &collection.Singleton,
+			&translations, &collection.ArchiveField, &collection.ArchiveAppFilter,
+			&collection.ArchiveValue, &collection.UnarchiveValue, &collection.SortField,
+			&collection.Accountability, &collection.Color, &itemDuplicationFields,
+			&collection.Sort, &collection.Group, &collection.Collapse,
+			&collection.PreviewURL, &collection.Versioning,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		collection.Translations = decodeJSON(translations)
+		collection.ItemDuplicationFields = decodeJSON(itemDuplicationFields)
+		collections = append(collections, collection)
+	}
+
+	return collections, rows.Err()
+}
+
+func loadFields(db *sql.DB, columns map[string][]TableColumn) ([]Field, error) {
+	rows, err := db.Query(`
+		SELECT collection, field, special, interface, options, display,
+		       display_options, COALESCE(readonly, false), COALESCE(hidden, false), sort,
+		       COALESCE(width, 'full'), translations, note, conditions,
+		       COALESCE(required, false), "group", validation, validation_message
+		FROM fields
+		ORDER BY collection ASC, sort ASC, field ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fields := []Field{}
+	for rows.Next() {
+		var field Field
+		var special pq.StringArray
+		var options, displayOptions, translations, conditions, validation []byte
+
+		err := rows.Scan(
+			&field.Collection, &field.Field, &special, &field.Interface,
+			&options, &field.Display, &displayOptions, &field.Readonly,
+			&field.Hidden, &field.Sort, &field.Width, &translations, &field.Note,
+			&conditions, &field.Required, &field.Group, &validation,
+			&field.ValidationMessage,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		field.Special = []string(special)
+		field.Options = decodeJSON(options)
+		field.DisplayOptions = decodeJSON(displayOptions)
+		field.Translations = decodeJSON(translations)
+		field.Conditions = decodeJSON(conditions)
+		field.Validation = decodeJSON(validation)
+
+		for _, column := range columns[field.Collection] {
+			if column.Name == field.Field {
+				schema := column.Column
+				field.Schema = &schema
+				break
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, rows.Err()
+}
+
+func loadPermissions(db *sql.DB) ([]Permission, error) {
+	rows, err := db.Query(`
+		SELECT r.name, p.collection, p.action, p.permissions, p.validation, p.presets, p.fields
+		FROM permissions p
+		LEFT JOIN roles r ON r.id = p.role_id
+		ORDER BY p.collection ASC, r.name ASC, p.action ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := []Permission{}
+	for rows.Next() {
+		var permission Permission
+		var rules, validation, presets []byte
+		var fields pq.StringArray
+
+		err := rows.Scan(
+			&permission.Role, &permission.Collection, &permission.Action,
+			&rules, &validation, &presets, &fields,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		permission.Permissions = decodeJSON(rules)
+		permission.Validation = decodeJSON(validation)
+		permission.Presets = decodeJSON(presets)
+		permission.Fields = []string(fields)
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, rows.Err()
+}
+
+// decodeJSON unmarshals a nullable JSONB column, returning nil for NULL or invalid data
+func decodeJSON(data []byte) interface{} {
+	if data == nil {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil
+	}
+	return value
+}
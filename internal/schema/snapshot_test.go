@@ -0,0 +1,150 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeDataType(t *testing.T) {
+	tests := map[string]string{
+		"character varying":           "string",
+		"text":                        "text",
+		"integer":                     "integer",
+		"bigint":                      "bigint",
+		"numeric":                     "decimal",
+		"boolean":                     "boolean",
+		"timestamp without time zone": "timestamp",
+		"uuid":                        "uuid",
+		"jsonb":                       "json",
+		"inet":                        "inet",
+	}
+
+	for input, expected := range tests {
+		assert.Equal(t, expected, NormalizeDataType(input), input)
+	}
+}
+
+func TestParseColumnDefault(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   interface{}
+	}{
+		{"'draft'::character varying", "draft"},
+		{"'it''s'::text", "it's"},
+		{"true", true},
+		{"false", false},
+		{"0", float64(0)},
+		{"(-1)", float64(-1)},
+		{"'1.5'::numeric", 1.5},
+		{"gen_random_uuid()", nil},
+		{"CURRENT_TIMESTAMP", nil},
+		{"NULL::character varying", nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, ParseColumnDefault(tt.expression), tt.expression)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Nullable flags are read with their column defaults
+	mock.ExpectQuery(`SELECT collection, icon, note.*COALESCE\(hidden, false\).*COALESCE\(collapse, 'open'\).*FROM collections`).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"collection", "icon", "note", "display_template", "hidden", "singleton",
+			"translations", "archive_field", "archive_app_filter", "archive_value",
+			"unarchive_value", "sort_field", "accountability", "color",
+			"item_duplication_fields", "sort", "group", "collapse", "preview_url", "versioning",
+		}).AddRow(
+			"articles", "article", nil, nil, false, false,
+			nil, "status", true, "archived",
+			"draft", nil, "all", nil,
+			[]byte(`["title"]`), 1, nil, "open", nil, false,
+		))
+
+	mock.ExpectQuery("SELECT table_name, column_name, data_type.*FROM information_schema.columns").
+		WithArgs(`{"articles"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "data_type", "character_maximum_length", "is_nullable", "column_default"}).
+			AddRow("articles", "id", "uuid", nil, "NO", "gen_random_uuid()").
+			AddRow("articles", "title", "character varying", 120, "NO", nil).
+			AddRow("articles", "author", "uuid", nil, "YES", nil).
+			AddRow("articles", "status", "character varying", 20, "YES", "'draft'::character varying"))
+
+	mock.ExpectQuery("SELECT tc.table_name, kcu.column_name, tc.constraint_type.*FROM information_schema.table_constraints").
+		WithArgs(`{"articles"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "constraint_type", "foreign_table", "foreign_column"}).
+			AddRow("articles", "id", "PRIMARY KEY", nil, nil).
+			AddRow("articles", "author", "FOREIGN KEY", "users", "id"))
+
+	mock.ExpectQuery("SELECT t.relname, a.attname.*FROM pg_index").
+		WithArgs(`{"articles"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"relname", "attname"}).AddRow("articles", "title"))
+
+	mock.ExpectQuery(`SELECT collection, field, special.*COALESCE\(hidden, false\).*COALESCE\(width, 'full'\).*FROM fields`).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"collection", "field", "special", "interface", "options", "display",
+			"display_options", "readonly", "hidden", "sort", "width", "translations",
+			"note", "conditions", "required", "group", "validation", "validation_message",
+		}).
+			AddRow("articles", "title", "{}", "input", nil, nil, nil, false, false, 1, "full", nil, nil, nil, true, nil, nil, nil).
+			AddRow("articles", "author", "{}", "select-dropdown-m2o", nil, nil, nil, false, false, 2, "half", nil, nil, nil, false, nil, nil, nil).
+			AddRow("articles", "divider", "{}", "presentation-divider", nil, nil, nil, false, false, 3, "full", nil, nil, nil, false, nil, nil, nil))
+
+	mock.ExpectQuery("SELECT r.name, p.collection, p.action.*FROM permissions p").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "collection", "action", "permissions", "validation", "presets", "fields"}).
+			AddRow("Public", "articles", "read", nil, nil, nil, "{*}"))
+
+	snapshot, err := Load(db)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, Version, snapshot.Version)
+	require.Len(t, snapshot.Collections, 1)
+	assert.Equal(t, []interface{}{"title"}, snapshot.Collections[0].ItemDuplicationFields)
+
+	require.Len(t, snapshot.Fields, 3)
+	title := snapshot.Fields[0].Schema
+	require.NotNil(t, title)
+	assert.Equal(t, "string", title.DataType)
+	assert.Equal(t, 120, *title.MaxLength)
+	assert.False(t, *title.IsNullable)
+	assert.True(t, *title.IsUnique)
+
+	author := snapshot.Fields[1].Schema
+	require.NotNil(t, author)
+	assert.Equal(t, "users", *author.ForeignTable)
+	assert.Equal(t, "id", *author.ForeignColumn)
+
+	assert.Nil(t, snapshot.Fields[2].Schema, "virtual fields have no column")
+
+	require.Len(t, snapshot.Permissions, 1)
+	assert.Equal(t, "Public", *snapshot.Permissions[0].Role)
+	assert.Equal(t, []string{"*"}, snapshot.Permissions[0].Fields)
+}
+
+func TestMarshal(t *testing.T) {
+	snapshot := &Snapshot{
+		Version:     Version,
+		Collections: []Collection{{Collection: "articles", Accountability: "all", Collapse: "open"}},
+		Fields:      []Field{},
+		Permissions: []Permission{},
+	}
+
+	document, err := Marshal(snapshot, FormatYAML)
+	require.NoError(t, err)
+	assert.Contains(t, string(document), "version: 1")
+	assert.Contains(t, string(document), "- collection: articles")
+
+	document, err = Marshal(snapshot, FormatJSON)
+	require.NoError(t, err)
+	assert.Contains(t, string(document), `"collection": "articles"`)
+
+	_, err = Marshal(snapshot, "xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	driver, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, driver.Put(ctx, "a/file.txt", strings.NewReader("Hello, world")))
+
+	read := func(offset, length int64) string {
+		r, err := driver.Open(ctx, "a/file.txt", offset, length)
+		require.NoError(t, err)
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		return string(data)
+	}
+	assert.Equal(t, "Hello, world", read(0, -1))
+	assert.Equal(t, "world", read(7, -1))
+	assert.Equal(t, "llo", read(2, 3))
+
+	require.NoError(t, driver.Delete(ctx, "a/file.txt"))
+	_, err = driver.Open(ctx, "a/file.txt", 0, -1)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, driver.Delete(ctx, "a/file.txt"))
+}
+
+func TestLocalRejectsKeysOutsideRoot(t *testing.T) {
+	driver, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../escape.txt", "a/../../escape.txt"} {
+		assert.Error(t, driver.Put(context.Background(), key, strings.NewReader("x")), key)
+	}
+}
@@ -0,0 +1,338 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Multipart uploads need parts of at least 5 MiB, except for the last one
+const (
+	minPartSize     = 5 << 20
+	defaultPartSize = 8 << 20
+)
+
+// S3Config configures an S3-compatible driver
+type S3Config struct {
+	// Endpoint is the service URL, such as http://localhost:9000 for MinIO.
+	// It defaults to AWS S3 in Region.
+	Endpoint string
+	Region   string
+	Bucket   string
+	// Prefix is prepended to every key, so a bucket can be shared
+	Prefix    string
+	AccessKey string
+	SecretKey string
+	// ForcePathStyle addresses the bucket in the path instead of the host
+	// name, which most S3-compatible stores need
+	ForcePathStyle bool
+	// PartSize is the size of multipart upload parts; larger files are
+	// uploaded in parts
+	PartSize int64
+	// Client sends the requests; http.DefaultClient is used when nil
+	Client *http.Client
+}
+
+// S3 stores files in an S3-compatible bucket
+type S3 struct {
+	config   S3Config
+	endpoint *url.URL
+	signer   *signer
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3 creates an S3-compatible driver
+func NewS3(config S3Config) (*S3, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("s3: bucket is required")
+	}
+	if config.AccessKey == "" || config.SecretKey == "" {
+		return nil, errors.New("s3: access key and secret are required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+	if config.PartSize == 0 {
+		config.PartSize = defaultPartSize
+	}
+	if config.PartSize < minPartSize {
+		return nil, fmt.Errorf("s3: part size must be at least %d bytes", minPartSize)
+	}
+	config.Prefix = strings.Trim(config.Prefix, "/")
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("s3: invalid endpoint %q", config.Endpoint)
+	}
+
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &S3{
+		config:   config,
+		endpoint: endpoint,
+		signer:   &signer{accessKey: config.AccessKey, secretKey: config.SecretKey, region: config.Region},
+		client:   client,
+		now:      time.Now,
+	}, nil
+}
+
+// objectURL returns the URL of an object, with optional query parameters
+func (s *S3) objectURL(key string, query url.Values) *url.URL {
+	if s.config.Prefix != "" {
+		key = s.config.Prefix + "/" + key
+	}
+
+	u := *s.endpoint
+	if s.config.ForcePathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.config.Bucket + "/" + key
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	}
+	u.RawPath = ""
+	if query != nil {
+		u.RawQuery = canonicalQuery(query)
+	}
+	return &u
+}
+
+// do sends a signed request and returns the response of a successful one.
+// Error responses are closed and returned as errors.
+func (s *S3) do(ctx context.Context, method string, u *url.URL, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	payloadHash := emptyPayloadHash
+	if len(body) > 0 {
+		payloadHash = hashHex(body)
+	}
+	s.signer.sign(req, payloadHash, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+	return resp, nil
+}
+
+// s3Error is the XML error document S3 responds with
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// responseError converts an error response into an error
+func responseError(resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	var body s3Error
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if xml.Unmarshal(data, &body) == nil && body.Code != "" {
+		if body.Code == "NoSuchKey" {
+			return ErrNotFound
+		}
+		return fmt.Errorf("s3: %s: %s", body.Code, body.Message)
+	}
+	return fmt.Errorf("s3: unexpected status %s", resp.Status)
+}
+
+// Put uploads small files in one request and larger ones as a multipart
+// upload, holding at most one part in memory
+func (s *S3) Put(ctx context.Context, key string, r io.Reader) error {
+	first, err := readPart(r, s.config.PartSize)
+	if err != nil {
+		return err
+	}
+	if int64(len(first)) < s.config.PartSize {
+		resp, err := s.do(ctx, http.MethodPut, s.objectURL(key, nil), first, nil)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	return s.putMultipart(ctx, key, first, r)
+}
+
+// readPart reads up to size bytes
+func readPart(r io.Reader, size int64) ([]byte, error) {
+	var part bytes.Buffer
+	_, err := io.CopyN(&part, r, size)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return part.Bytes(), nil
+}
+
+// completedPart is a part of a multipart upload
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// putMultipart uploads first and the rest of r as parts of a multipart upload
+func (s *S3) putMultipart(ctx context.Context, key string, first []byte, r io.Reader) error {
+	uploadID, err := s.createMultipartUpload(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	parts, err := s.uploadParts(ctx, key, uploadID, first, r)
+	if err == nil {
+		err = s.completeMultipartUpload(ctx, key, uploadID, parts)
+	}
+	if err != nil {
+		// Uploaded parts are stored (and billed) until the upload is aborted
+		abortURL := s.objectURL(key, url.Values{"uploadId": {uploadID}})
+		if resp, abortErr := s.do(context.Background(), http.MethodDelete, abortURL, nil, nil); abortErr == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *S3) createMultipartUpload(ctx context.Context, key string) (string, error) {
+	resp, err := s.do(ctx, http.MethodPost, s.objectURL(key, url.Values{"uploads": {""}}), nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("s3: decoding multipart upload: %w", err)
+	}
+	if result.UploadID == "" {
+		return "", errors.New("s3: no upload ID in response")
+	}
+	return result.UploadID, nil
+}
+
+func (s *S3) uploadParts(ctx context.Context, key, uploadID string, part []byte, r io.Reader) ([]completedPart, error) {
+	var parts []completedPart
+	for number := 1; len(part) > 0; number++ {
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+		resp, err := s.do(ctx, http.MethodPut, s.objectURL(key, query), part, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		parts = append(parts, completedPart{PartNumber: number, ETag: resp.Header.Get("ETag")})
+
+		if int64(len(part)) < s.config.PartSize {
+			break
+		}
+		if part, err = readPart(r, s.config.PartSize); err != nil {
+			return nil, err
+		}
+	}
+	return parts, nil
+}
+
+func (s *S3) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []completedPart) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, http.MethodPost, s.objectURL(key, url.Values{"uploadId": {uploadID}}), body, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Completing can fail after the 200 status has been sent
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var failure s3Error
+	if xml.Unmarshal(data, &failure) == nil && failure.Code != "" {
+		return fmt.Errorf("s3: %s: %s", failure.Code, failure.Message)
+	}
+	return nil
+}
+
+// Open downloads an object, or a byte range of it
+func (s *S3) Open(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	if offset > 0 || length >= 0 {
+		rangeHeader := fmt.Sprintf("bytes=%d-", offset)
+		if length >= 0 {
+			if length == 0 {
+				return io.NopCloser(bytes.NewReader(nil)), nil
+			}
+			rangeHeader += strconv.FormatInt(offset+length-1, 10)
+		}
+		header.Set("Range", rangeHeader)
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, s.objectURL(key, nil), nil, header)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes an object; S3 doesn't report missing objects either
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, s.objectURL(key, nil), nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// PresignGet returns a URL downloading an object as filename until it expires
+func (s *S3) PresignGet(key, filename string, expires time.Duration) (string, error) {
+	if expires < time.Second || expires > 7*24*time.Hour {
+		return "", errors.New("s3: presigned URLs expire after one second to seven days")
+	}
+
+	var query url.Values
+	if filename != "" {
+		query = url.Values{"response-content-disposition": {
+			mime.FormatMediaType("attachment", map[string]string{"filename": filename}),
+		}}
+	}
+
+	u := s.objectURL(key, query)
+	s.signer.presign(u, http.MethodGet, expires, s.now())
+	return u.String(), nil
+}
@@ -0,0 +1,139 @@
+// Package storage stores the contents of uploaded files behind a driver
+// interface, so the API server doesn't depend on where files are kept.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned for keys that have no stored object
+var ErrNotFound = errors.New("storage: object not found")
+
+// Driver stores file contents by key
+type Driver interface {
+	// Put stores the contents of r under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open reads length bytes of an object starting at offset. A negative
+	// length reads to the end.
+	Open(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Presigner is implemented by drivers that can hand out temporary URLs, so
+// clients download objects without going through the API server
+type Presigner interface {
+	// PresignGet returns a URL downloading key as filename until it expires
+	PresignGet(key, filename string, expires time.Duration) (string, error)
+}
+
+// Locations holds the configured storage locations by name. Files record the
+// location they were stored in, so locations can be added without moving
+// existing files.
+type Locations struct {
+	names   []string
+	drivers map[string]Driver
+}
+
+// NewLocations creates locations from drivers keyed by name. The first name
+// is the default location for new files.
+func NewLocations(names []string, drivers map[string]Driver) *Locations {
+	return &Locations{names: names, drivers: drivers}
+}
+
+// Default returns the name and driver of the location new files are stored in
+func (l *Locations) Default() (string, Driver) {
+	return l.names[0], l.drivers[l.names[0]]
+}
+
+// Get returns the driver of a location
+func (l *Locations) Get(name string) (Driver, bool) {
+	driver, ok := l.drivers[name]
+	return driver, ok
+}
+
+// LocationsFromEnv creates the locations listed in STORAGE_LOCATIONS
+// ("local" by default). Each location is configured with variables prefixed
+// STORAGE_<NAME>_, starting with STORAGE_<NAME>_DRIVER (local or s3).
+func LocationsFromEnv() (*Locations, error) {
+	list := os.Getenv("STORAGE_LOCATIONS")
+	if list == "" {
+		list = "local"
+	}
+
+	var names []string
+	drivers := make(map[string]Driver)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := drivers[name]; ok {
+			return nil, fmt.Errorf("storage location %q is listed twice", name)
+		}
+
+		driver, err := driverFromEnv(name, func(key string) string {
+			return os.Getenv("STORAGE_" + strings.ToUpper(name) + "_" + key)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("storage location %q: %w", name, err)
+		}
+		names = append(names, name)
+		drivers[name] = driver
+	}
+	if len(names) == 0 {
+		return nil, errors.New("STORAGE_LOCATIONS lists no locations")
+	}
+
+	return NewLocations(names, drivers), nil
+}
+
+// driverFromEnv creates the driver of one location from its variables
+func driverFromEnv(name string, env func(string) string) (Driver, error) {
+	driver := env("DRIVER")
+	if driver == "" && name == "local" {
+		driver = "local"
+	}
+
+	switch driver {
+	case "local":
+		root := env("ROOT")
+		if root == "" {
+			root = "./uploads"
+		}
+		return NewLocal(root)
+	case "s3":
+		config := S3Config{
+			Endpoint:       env("ENDPOINT"),
+			Region:         env("REGION"),
+			Bucket:         env("BUCKET"),
+			Prefix:         env("PREFIX"),
+			AccessKey:      env("KEY"),
+			SecretKey:      env("SECRET"),
+			ForcePathStyle: env("FORCE_PATH_STYLE") == "true",
+		}
+		if partSize := env("PART_SIZE"); partSize != "" {
+			size, err := strconv.ParseInt(partSize, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PART_SIZE: %w", err)
+			}
+			config.PartSize = size
+		}
+		s3, err := NewS3(config)
+		if err != nil {
+			return nil, err
+		}
+		return s3, nil
+	case "":
+		return nil, errors.New("no driver configured")
+	default:
+		return nil, fmt.Errorf("unknown driver %q", driver)
+	}
+}
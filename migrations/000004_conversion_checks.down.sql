@@ -0,0 +1,2 @@
+-- Remove the type conversion helper
+DROP FUNCTION IF EXISTS can_cast_to(TEXT, REGTYPE);
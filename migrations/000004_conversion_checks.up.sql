@@ -0,0 +1,9 @@
+-- Create a helper reporting whether a text value can be cast to a type, used
+-- to count the rows a field type conversion would fail on. Postgres 16 has
+-- pg_input_is_valid for this.
+CREATE OR REPLACE FUNCTION can_cast_to(value TEXT, target REGTYPE) RETURNS BOOLEAN AS $$ BEGIN EXECUTE format('SELECT %L::%s', value, target);
+RETURN true;
+EXCEPTION
+WHEN data_exception THEN RETURN false;
+END;
+$$ language 'plpgsql' VOLATILE;
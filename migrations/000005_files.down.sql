@@ -0,0 +1,7 @@
+-- Remove files table and the settings references to it, keeping the settings
+-- values
+ALTER TABLE settings DROP CONSTRAINT IF EXISTS fk_settings_project_logo;
+ALTER TABLE settings DROP CONSTRAINT IF EXISTS fk_settings_public_foreground;
+ALTER TABLE settings DROP CONSTRAINT IF EXISTS fk_settings_public_background;
+DROP TRIGGER IF EXISTS update_files_updated_at ON files;
+DROP TABLE IF EXISTS files;
@@ -0,0 +1,26 @@
+-- Create files table for uploaded assets
+CREATE TABLE IF NOT EXISTS files (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    storage VARCHAR(50) NOT NULL,
+    filename_disk VARCHAR(255) NOT NULL,
+    filename_download VARCHAR(255) NOT NULL,
+    title VARCHAR(255),
+    description TEXT,
+    type VARCHAR(255),
+    filesize BIGINT NOT NULL DEFAULT 0,
+    checksum VARCHAR(64),
+    uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_files_uploaded_by ON files(uploaded_by);
+CREATE TRIGGER update_files_updated_at BEFORE
+UPDATE ON files FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+-- Settings images now reference files. The constraints are NOT VALID, so
+-- values set before files existed are kept; only new values are checked.
+ALTER TABLE settings
+ADD CONSTRAINT fk_settings_project_logo FOREIGN KEY (project_logo) REFERENCES files(id) ON DELETE SET NULL NOT VALID;
+ALTER TABLE settings
+ADD CONSTRAINT fk_settings_public_foreground FOREIGN KEY (public_foreground) REFERENCES files(id) ON DELETE SET NULL NOT VALID;
+ALTER TABLE settings
+ADD CONSTRAINT fk_settings_public_background FOREIGN KEY (public_background) REFERENCES files(id) ON DELETE SET NULL NOT VALID;
@@ -0,0 +1,6 @@
+-- Remove folders table and the references to it, keeping the settings value
+ALTER TABLE settings DROP CONSTRAINT IF EXISTS fk_settings_storage_default_folder;
+DROP INDEX IF EXISTS idx_files_folder;
+ALTER TABLE files DROP COLUMN IF EXISTS folder;
+DROP TRIGGER IF EXISTS update_folders_updated_at ON folders;
+DROP TABLE IF EXISTS folders;
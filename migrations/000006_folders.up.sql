@@ -0,0 +1,20 @@
+-- Create folders table for organizing files
+CREATE TABLE IF NOT EXISTS folders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    parent UUID REFERENCES folders(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_folders_parent ON folders(parent);
+CREATE TRIGGER update_folders_updated_at BEFORE
+UPDATE ON folders FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+-- Non-empty folders can't be deleted; the API moves or deletes their contents first
+ALTER TABLE files
+ADD COLUMN folder UUID REFERENCES folders(id);
+CREATE INDEX IF NOT EXISTS idx_files_folder ON files(folder);
+-- Uploads without a folder go to the default folder. The constraint is NOT
+-- VALID, so a value set before folders existed is kept; only new values are
+-- checked.
+ALTER TABLE settings
+ADD CONSTRAINT fk_settings_storage_default_folder FOREIGN KEY (storage_default_folder) REFERENCES folders(id) ON DELETE SET NULL NOT VALID;
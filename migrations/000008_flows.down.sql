@@ -0,0 +1,7 @@
+-- Remove flows, their operations and run log
+DROP TABLE IF EXISTS flow_runs;
+ALTER TABLE IF EXISTS flows DROP CONSTRAINT IF EXISTS flows_operation_fkey;
+DROP TRIGGER IF EXISTS update_operations_updated_at ON operations;
+DROP TABLE IF EXISTS operations;
+DROP TRIGGER IF EXISTS update_flows_updated_at ON flows;
+DROP TABLE IF EXISTS flows;
@@ -0,0 +1,51 @@
+-- Create flows table for automations started by a trigger
+CREATE TABLE IF NOT EXISTS flows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'inactive')),
+    trigger VARCHAR(20) NOT NULL CHECK (trigger IN ('event', 'schedule', 'manual', 'webhook')),
+    options JSONB NOT NULL DEFAULT '{}',
+    -- First operation run when the flow is triggered
+    operation UUID,
+    -- Last minute a scheduled flow was started in, claimed by one server
+    last_scheduled_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TRIGGER update_flows_updated_at BEFORE
+UPDATE ON flows FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+-- Operations are the steps of a flow. Each one continues with its resolve
+-- operation when it succeeds and its reject operation when it fails.
+CREATE TABLE IF NOT EXISTS operations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    flow UUID NOT NULL REFERENCES flows(id) ON DELETE CASCADE,
+    key VARCHAR(64) NOT NULL,
+    name VARCHAR(255),
+    type VARCHAR(20) NOT NULL CHECK (
+        type IN ('condition', 'item-create', 'item-update', 'mail', 'request', 'transform')
+    ),
+    options JSONB NOT NULL DEFAULT '{}',
+    resolve UUID REFERENCES operations(id) ON DELETE SET NULL,
+    reject UUID REFERENCES operations(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (flow, key)
+);
+CREATE TRIGGER update_operations_updated_at BEFORE
+UPDATE ON operations FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+ALTER TABLE flows
+ADD CONSTRAINT flows_operation_fkey FOREIGN KEY (operation) REFERENCES operations(id) ON DELETE SET NULL;
+-- Log of every flow run with the result of each operation
+CREATE TABLE IF NOT EXISTS flow_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    flow UUID NOT NULL REFERENCES flows(id) ON DELETE CASCADE,
+    trigger VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'success', 'failed')),
+    data JSONB,
+    steps JSONB NOT NULL DEFAULT '[]',
+    error TEXT,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_flow_runs_flow ON flow_runs(flow, started_at);
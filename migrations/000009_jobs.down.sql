@@ -0,0 +1,5 @@
+-- Remove jobs and their run history, restoring the claim of scheduled flows
+ALTER TABLE flows ADD COLUMN IF NOT EXISTS last_scheduled_at TIMESTAMP;
+DROP TABLE IF EXISTS job_runs;
+DROP TRIGGER IF EXISTS update_jobs_updated_at ON jobs;
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,35 @@
+-- Create jobs table for the background jobs run on a cron schedule. Rows are
+-- created for the jobs the server registers; admins change their schedule
+-- and options or pause them.
+CREATE TABLE IF NOT EXISTS jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT,
+    schedule VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'paused')),
+    options JSONB NOT NULL DEFAULT '{}',
+    next_run_at TIMESTAMPTZ,
+    last_run_at TIMESTAMP,
+    last_status VARCHAR(20),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TRIGGER update_jobs_updated_at BEFORE
+UPDATE ON jobs FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs(next_run_at)
+WHERE status = 'active';
+-- Run history of every job
+CREATE TABLE IF NOT EXISTS job_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    trigger VARCHAR(20) NOT NULL CHECK (trigger IN ('schedule', 'manual')),
+    status VARCHAR(20) NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'success', 'failed')),
+    result JSONB,
+    error TEXT,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_job_runs_job ON job_runs(job, started_at);
+-- Scheduled flows are started by the run-scheduled-flows job, whose lock
+-- replaces the per-flow claim
+ALTER TABLE flows DROP COLUMN IF EXISTS last_scheduled_at;